@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/neurosnap/lists.sh/internal/admin"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/db/postgres"
+	"github.com/neurosnap/lists.sh/internal/flags"
+)
+
+const usage = `usage: lists-admin <command>
+
+commands:
+  users                                   list users with their usage
+  user <name>                             show usage and keys for a user
+  suspend <name> [reason]                 block a user from ssh and hide their blog
+  ban <name> [reason]                     permanently block a user
+  unsuspend <name> [reason]               restore a suspended or banned user
+  delist <name> [reason]                  keep a user's posts out of discovery
+  relist <name> [reason]                  let a delisted user back into discovery
+  reset-keys <name> [reason]              remove every public key from a user
+  erase <name> [reason]                   permanently delete a user and all their data
+  takedown <name> <filename> [reason]     hide a post
+  reinstate <name> <filename> [reason]    make a hidden post public again
+  flagged                                 list posts held back by the spam check
+  approve <name> <filename> [reason]      clear the spam flag on a post
+  reports [status]                        list abuse reports (open, hidden, dismissed, all)
+  report-hide <id> [reason]               hide a reported post pending review
+  report-dismiss <id> [reason]            close a report without action
+  invite [count]                          create registration codes for invite mode
+  audit [limit]                           show recent moderation actions
+  stats [days]                            show daily signups, publishers, posts and sessions
+  jobs                                    list background jobs that failed every attempt
+  job-retry <id> [reason]                 run a dead job again
+  flags [flag]                            list feature flags, or who one is set for
+  flag <flag> <name> <state> [reason]     turn a flag on, off or back to default for a user
+`
+
+func actor() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "admin"
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func args(n int) []string {
+	if len(os.Args) < n+2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	return os.Args[2:]
+}
+
+// reason joins everything after the required arguments into a note.
+func reason(rest []string, n int) string {
+	if len(rest) <= n {
+		return ""
+	}
+	return strings.Join(rest[n:], " ")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	if _, err := config.Init(); err != nil {
+		fail(err)
+	}
+	dbpool := postgres.NewDB()
+	defer dbpool.Close()
+	adm := admin.New(dbpool, actor())
+
+	var err error
+	switch os.Args[1] {
+	case "users":
+		var stats []*db.UserStats
+		stats, err = adm.Users()
+		if err == nil {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSTATUS\tPOSTS\tBYTES\tKEYS\tCREATED")
+			for _, st := range stats {
+				fmt.Fprintf(
+					w, "%s\t%s\t%d\t%d\t%d\t%s\n",
+					st.User.Name, st.User.Status, st.Posts, st.Bytes, st.Keys,
+					st.User.CreatedAt.Format("2006-01-02"),
+				)
+			}
+			w.Flush()
+		}
+	case "user":
+		a := args(1)
+		var st *db.UserStats
+		var keys []*db.PublicKey
+		st, keys, err = adm.User(a[0])
+		if err == nil {
+			fmt.Printf("name:     %s\nstatus:   %s\ndelisted: %t\nposts:    %d\nbytes:    %d\ncreated:  %s\nkeys:\n",
+				st.User.Name, st.User.Status, st.User.Delisted, st.Posts, st.Bytes, st.User.CreatedAt.Format("2006-01-02"))
+			for _, pk := range keys {
+				fmt.Printf("  %s\n", pk.Key)
+			}
+		}
+	case "suspend":
+		a := args(1)
+		err = adm.SetStatus(a[0], db.UserStatusSuspended, reason(a, 1))
+	case "ban":
+		a := args(1)
+		err = adm.SetStatus(a[0], db.UserStatusBanned, reason(a, 1))
+	case "unsuspend":
+		a := args(1)
+		err = adm.SetStatus(a[0], db.UserStatusActive, reason(a, 1))
+	case "delist":
+		a := args(1)
+		err = adm.SetDelisted(a[0], true, reason(a, 1))
+	case "relist":
+		a := args(1)
+		err = adm.SetDelisted(a[0], false, reason(a, 1))
+	case "reset-keys":
+		a := args(1)
+		err = adm.ResetKeys(a[0], reason(a, 1))
+	case "erase":
+		a := args(1)
+		err = adm.Erase(a[0], reason(a, 1))
+	case "takedown":
+		a := args(2)
+		err = adm.Takedown(a[0], a[1], reason(a, 2))
+	case "reinstate":
+		a := args(2)
+		err = adm.Reinstate(a[0], a[1], reason(a, 2))
+	case "flagged":
+		var posts []*db.Post
+		posts, err = adm.Flagged()
+		if err == nil {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "POST\tHIDDEN\tREASON")
+			for _, post := range posts {
+				fmt.Fprintf(w, "%s/%s\t%t\t%s\n", post.Username, post.Filename, post.HiddenAt != nil, post.FlaggedReason)
+			}
+			w.Flush()
+		}
+	case "approve":
+		a := args(2)
+		err = adm.Approve(a[0], a[1], reason(a, 2))
+	case "reports":
+		status := db.ReportStatusOpen
+		if len(os.Args) > 2 {
+			status = os.Args[2]
+		}
+		if status == "all" {
+			status = ""
+		}
+		var reports []*db.Report
+		reports, err = adm.Reports(status)
+		if err == nil {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID	WHEN	STATUS	POST	NOTE")
+			for _, rp := range reports {
+				fmt.Fprintf(
+					w, "%s\t%s\t%s\t%s/%s\t%s\n",
+					rp.ID, rp.CreatedAt.Format("2006-01-02 15:04"), rp.Status,
+					rp.Username, rp.Filename, strings.ReplaceAll(rp.Note, "\n", " "),
+				)
+			}
+			w.Flush()
+		}
+	case "report-hide":
+		a := args(1)
+		err = adm.HideReported(a[0], reason(a, 1))
+	case "report-dismiss":
+		a := args(1)
+		err = adm.DismissReport(a[0], reason(a, 1))
+	case "invite":
+		count := 1
+		if len(os.Args) > 2 {
+			count, err = strconv.Atoi(os.Args[2])
+			if err != nil || count < 1 {
+				fail(fmt.Errorf("count must be a positive number"))
+			}
+		}
+		var invites []*db.Invite
+		invites, err = adm.Invite(count)
+		for _, invite := range invites {
+			fmt.Println(invite.Code)
+		}
+	case "audit":
+		limit := 50
+		if len(os.Args) > 2 {
+			limit, err = strconv.Atoi(os.Args[2])
+			if err != nil {
+				fail(fmt.Errorf("limit must be a number"))
+			}
+		}
+		var entries []*db.AuditLog
+		entries, err = adm.AuditLog(limit)
+		if err == nil {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "WHEN\tACTOR\tACTION\tTARGET\tNOTE")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.CreatedAt.Format("2006-01-02 15:04:05"), e.Actor, e.Action, e.Target, e.Note)
+			}
+			w.Flush()
+		}
+	case "stats":
+		days := 30
+		if len(os.Args) > 2 {
+			days, err = strconv.Atoi(os.Args[2])
+			if err != nil || days < 1 {
+				fail(fmt.Errorf("days must be a positive number"))
+			}
+		}
+		var stats []*db.SiteStats
+		stats, err = adm.Stats(days)
+		if err == nil {
+			total := &db.SiteStats{}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "DAY\tSIGNUPS\tPUBLISHERS\tPOSTS\tSESSIONS")
+			for _, st := range stats {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", st.Day.Format("2006-01-02"), st.Signups, st.Publishers, st.Posts, st.Sessions)
+				total.Signups += st.Signups
+				total.Posts += st.Posts
+				total.Sessions += st.Sessions
+			}
+			// Publishers aren't summed, the same person posts on many days.
+			fmt.Fprintf(w, "total\t%d\t-\t%d\t%d\n", total.Signups, total.Posts, total.Sessions)
+			w.Flush()
+		}
+	case "jobs":
+		var jobs []*db.Job
+		jobs, err = adm.DeadJobs()
+		if err == nil {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKIND\tCREATED\tATTEMPTS\tERROR")
+			for _, job := range jobs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", job.ID, job.Kind, job.CreatedAt.Format("2006-01-02 15:04"), job.Attempts, strings.ReplaceAll(job.LastError, "\n", " "))
+			}
+			w.Flush()
+		}
+	case "job-retry":
+		a := args(1)
+		err = adm.RetryJob(a[0], reason(a, 1))
+	case "flags":
+		cfg := config.Current().Flags
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		if len(os.Args) < 3 {
+			fmt.Fprintln(w, "FLAG\tROLLOUT")
+			for _, flag := range flags.Known() {
+				fmt.Fprintf(w, "%s\t%s\n", flag, flags.Rollout(cfg, flag))
+			}
+			w.Flush()
+			break
+		}
+		var users []*db.UserFlag
+		users, err = adm.FlagUsers(os.Args[2])
+		if err == nil {
+			fmt.Fprintf(w, "rollout: %s\n\nUSER\tSTATE\tSET\n", flags.Rollout(cfg, os.Args[2]))
+			for _, u := range users {
+				state := "off"
+				if u.Enabled {
+					state = "on"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", u.Username, state, u.CreatedAt.Format("2006-01-02 15:04"))
+			}
+			w.Flush()
+		}
+	case "flag":
+		a := args(3)
+		err = adm.SetFlag(a[0], a[1], a[2], reason(a, 3))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
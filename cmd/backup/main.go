@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/backup"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db/postgres"
+)
+
+const usage = `usage: backup <command>
+
+commands:
+  run              take a backup every LISTS_BACKUP_INTERVAL (default)
+  once             take a single backup and exit
+  list             list stored backups, newest first
+  restore [key]    restore a backup (defaults to the latest)
+
+restore merges the backup into the live database: rows in the backup are
+written back as they were, rows created since are kept.  Restore into an
+empty database to get exactly what was backed up.
+`
+
+func main() {
+	settings, err := config.Init()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger := internal.CreateLogger()
+
+	cfg, err := backup.NewConfig(settings.Backup)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	cmd := "run"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	dbpool := postgres.NewDB()
+	defer dbpool.Close()
+
+	switch cmd {
+	case "run":
+		done := make(chan struct{})
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-sig
+			close(done)
+		}()
+
+		logger.Infof("Starting backups every %s (keeping %d)", cfg.Interval, cfg.Retention)
+		backup.Run(dbpool, cfg, logger, done)
+		logger.Info("Stopping backups")
+	case "once":
+		key, err := backup.Backup(dbpool, cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		fmt.Println(key)
+	case "list":
+		objects, err := backup.List(cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		for _, obj := range objects {
+			fmt.Printf("%s\t%d\t%s\n", obj.Key, obj.Size, obj.LastModified.Format("2006-01-02 15:04:05"))
+		}
+	case "restore":
+		key := ""
+		if len(os.Args) > 2 {
+			key = os.Args[2]
+		}
+		snapshot, err := backup.Restore(dbpool, cfg, key)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		fmt.Printf(
+			"restored %d users, %d keys, %d posts from %s\n",
+			len(snapshot.Users),
+			len(snapshot.PublicKeys),
+			len(snapshot.Posts),
+			snapshot.CreatedAt.Format("2006-01-02 15:04:05"),
+		)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db/postgres"
+	"github.com/neurosnap/lists.sh/internal/scp"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const usage = `usage: lists-loadtest [flags]
+
+Simulates publishers uploading posts over scp at the same time and reports
+how long the uploads took and why any failed.  Each publisher gets a new
+account, created straight in the database DATABASE_URL points at, which is
+erased again at the end unless -keep is set.  Point it at a staging server:
+the accounts look like duplicates to the spam check, turn it off there.
+
+flags:
+`
+
+// publisher is an account uploading posts.
+type publisher struct {
+	name   string
+	userID string
+	signer gossh.Signer
+}
+
+// result is how one upload went.
+type result struct {
+	took time.Duration
+	err  string // empty when the upload was saved
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:2222", "ssh server to upload to")
+	publishers := flag.Int("publishers", 20, "publishers uploading at the same time")
+	posts := flag.Int("posts", 10, "posts each publisher uploads, one after the other")
+	size := flag.Int("size", 2, "size of each post in KB")
+	keep := flag.Bool("keep", false, "keep the accounts and posts afterwards")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *publishers < 1 || *posts < 1 || *size < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if _, err := config.Init(); err != nil {
+		fail(err)
+	}
+	dbpool := postgres.NewDB()
+	defer dbpool.Close()
+
+	run := make([]byte, 3)
+	if _, err := rand.Read(run); err != nil {
+		fail(err)
+	}
+	prefix := "loadtest-" + hex.EncodeToString(run)
+
+	pubs := make([]*publisher, *publishers)
+	for i := range pubs {
+		p, err := register(dbpool, fmt.Sprintf("%s-%d", prefix, i+1))
+		if err != nil {
+			fail(err)
+		}
+		pubs[i] = p
+	}
+	if !*keep {
+		defer func() {
+			for _, p := range pubs {
+				if err := dbpool.EraseUser(p.userID, p.name); err != nil {
+					fmt.Fprintf(os.Stderr, "erasing %s: %v\n", p.name, err)
+				}
+			}
+		}()
+	}
+
+	fmt.Printf("%d publishers uploading %d posts of %dKB each to %s\n", *publishers, *posts, *size, *addr)
+	body := post(*size * 1024)
+	results := make(chan result, *publishers**posts)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, p := range pubs {
+		wg.Add(1)
+		go func(p *publisher) {
+			defer wg.Done()
+			for i := 0; i < *posts; i++ {
+				results <- p.upload(*addr, fmt.Sprintf("post-%d.txt", i+1), body)
+			}
+		}(p)
+	}
+	wg.Wait()
+	close(results)
+
+	report(results, time.Since(start))
+}
+
+// register creates an account with a new key, the way the TUI does.
+func register(dbpool *postgres.PsqlDB, name string) (*publisher, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	pub := signer.PublicKey()
+	keyText := fmt.Sprintf("%s %s", pub.Type(), base64.StdEncoding.EncodeToString(pub.Marshal()))
+
+	userID, err := dbpool.AddUser()
+	if err != nil {
+		return nil, err
+	}
+	if err := dbpool.LinkUserKey(userID, keyText); err != nil {
+		return nil, err
+	}
+	if err := dbpool.SetUserName(userID, name); err != nil {
+		return nil, err
+	}
+	return &publisher{name: name, userID: userID, signer: signer}, nil
+}
+
+// post is a list of about n bytes.
+func post(n int) string {
+	var b strings.Builder
+	b.WriteString("=: title Load test\n")
+	for i := 1; b.Len() < n; i++ {
+		fmt.Fprintf(&b, "- item number %d on a list uploaded by the load test\n", i)
+	}
+	return b.String()
+}
+
+// upload copies a file the way `scp file lists.sh:` does, including the
+// time it takes to connect.
+func (p *publisher) upload(addr string, name string, text string) result {
+	start := time.Now()
+	failed := func(err error) result {
+		return result{took: time.Since(start), err: err.Error()}
+	}
+
+	conn, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            p.name,
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(p.signer)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return failed(err)
+	}
+	defer conn.Close()
+	s, err := conn.NewSession()
+	if err != nil {
+		return failed(err)
+	}
+	defer s.Close()
+
+	var stderr strings.Builder
+	s.Stdout = ioutil.Discard
+	s.Stderr = &stderr
+	stdin, err := s.StdinPipe()
+	if err != nil {
+		return failed(err)
+	}
+	if err := s.Start("scp -t /"); err != nil {
+		return failed(err)
+	}
+	entry := &scp.FileEntry{
+		Name:   name,
+		Mode:   0644,
+		Size:   int64(len(text)),
+		Reader: strings.NewReader(text),
+	}
+	if err := entry.Write(stdin); err != nil {
+		return failed(err)
+	}
+	stdin.Close()
+	if err := s.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return failed(fmt.Errorf("%s", msg))
+		}
+		return failed(err)
+	}
+	return result{took: time.Since(start)}
+}
+
+// report prints how many uploads were saved, how fast, and why the others
+// weren't.
+func report(results <-chan result, elapsed time.Duration) {
+	var took []time.Duration
+	errs := map[string]int{}
+	total := 0
+	for r := range results {
+		total++
+		if r.err != "" {
+			errs[r.err]++
+			continue
+		}
+		took = append(took, r.took)
+	}
+	sort.Slice(took, func(i, j int) bool { return took[i] < took[j] })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "uploads\t%d\n", total)
+	fmt.Fprintf(w, "saved\t%d\n", len(took))
+	fmt.Fprintf(w, "failed\t%d\n", total-len(took))
+	fmt.Fprintf(w, "elapsed\t%s\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "uploads/s\t%.1f\n", float64(len(took))/elapsed.Seconds())
+	if len(took) > 0 {
+		for _, q := range []float64{0.5, 0.9, 0.99} {
+			fmt.Fprintf(w, "p%.0f\t%s\n", q*100, percentile(took, q).Round(time.Millisecond))
+		}
+		fmt.Fprintf(w, "max\t%s\n", took[len(took)-1].Round(time.Millisecond))
+	}
+	w.Flush()
+
+	if len(errs) > 0 {
+		fmt.Println("\nfailures:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for msg, n := range errs {
+			fmt.Fprintf(w, "%d\t%s\n", n, msg)
+		}
+		w.Flush()
+	}
+}
+
+// percentile picks from durations sorted shortest first.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -10,17 +10,50 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Users pick their timezone in the TUI, don't depend on the host having
+	// a zoneinfo database.
+	_ "time/tzdata"
 
 	"github.com/charmbracelet/wish"
 	bm "github.com/charmbracelet/wish/bubbletea"
-	lm "github.com/charmbracelet/wish/logging"
 	"github.com/gliderlabs/ssh"
 	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/api"
 	"github.com/neurosnap/lists.sh/internal/cms"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db/postgres"
+	"github.com/neurosnap/lists.sh/internal/export"
+	"github.com/neurosnap/lists.sh/internal/importer"
+	"github.com/neurosnap/lists.sh/internal/limit"
+	"github.com/neurosnap/lists.sh/internal/login"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+	"github.com/neurosnap/lists.sh/internal/org"
+	"github.com/neurosnap/lists.sh/internal/publish"
+	"github.com/neurosnap/lists.sh/internal/remote"
 	"github.com/neurosnap/lists.sh/internal/scp"
+	"github.com/neurosnap/lists.sh/internal/share"
 )
 
+var (
+	sessionsActive = metrics.NewGauge(
+		"lists_ssh_sessions_active",
+		"Open ssh sessions by kind (tui, scp, import, export, edit, org, publish, login, share).",
+		"kind",
+	)
+	sessionsTotal = metrics.NewCounter(
+		"lists_ssh_sessions_total",
+		"ssh sessions started by kind (tui, scp, import, export, edit, org, publish, login, share).",
+		"kind",
+	)
+)
+
+// trackSession counts a session for its whole lifetime.
+func trackSession(kind string) func() {
+	sessionsTotal.Inc(kind)
+	sessionsActive.Inc(kind)
+	return func() { sessionsActive.Dec(kind) }
+}
+
 type SSHServer struct{}
 
 func (me *SSHServer) authHandler(ctx ssh.Context, key ssh.PublicKey) bool {
@@ -35,21 +68,55 @@ func withMiddleware(mw ...wish.Middleware) ssh.Handler {
 	return h
 }
 
-func proxyMiddleware() wish.Middleware {
+// busy turns a session away that waited too long for a slot.
+func busy(s ssh.Session, err error) {
+	_, _ = fmt.Fprintln(s.Stderr(), err)
+	_ = s.Exit(1)
+}
+
+// proxyMiddleware runs the session's command once there's a slot for it,
+// scp uploads and imports also need a slot from transfers.
+func proxyMiddleware(sessions *limit.Limiter, transfers *limit.Limiter) wish.Middleware {
 	return func(sh ssh.Handler) ssh.Handler {
 		return func(s ssh.Session) {
 			cmd := s.Command()
 
+			logger := internal.WithSessionLogger(s)
+			start := time.Now()
+			logger.Infow("session started", "command", cmd)
+			defer func() {
+				logger.Infow("session ended", "duration", time.Since(start).String())
+			}()
+
+			release, err := sessions.Acquire(s.Context())
+			if err != nil {
+				logger.Warnw("session turned away", "error", err, "waited", time.Since(start).String())
+				busy(s, err)
+				return
+			}
+			defer release()
+
+			if len(cmd) > 0 && (cmd[0] == "scp" || cmd[0] == "import") {
+				release, err := transfers.Acquire(s.Context())
+				if err != nil {
+					logger.Warnw("transfer turned away", "error", err, "waited", time.Since(start).String())
+					busy(s, err)
+					return
+				}
+				defer release()
+			}
+
 			if len(cmd) == 0 {
+				defer trackSession("tui")()
 				fn := withMiddleware(
 					bm.Middleware(cms.Handler),
-					lm.Middleware(),
 				)
 				fn(s)
 				return
 			}
 
 			if cmd[0] == "scp" {
+				defer trackSession("scp")()
 				handler := &scp.DbHandler{}
 				dbh := postgres.NewDB()
 				defer dbh.Close()
@@ -57,40 +124,128 @@ func proxyMiddleware() wish.Middleware {
 				fn(s)
 				return
 			}
+
+			if cmd[0] == "import" {
+				defer trackSession("import")()
+				handler := &scp.DbHandler{}
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(importer.Middleware(handler, dbh))
+				fn(s)
+				return
+			}
+
+			if cmd[0] == "export" {
+				defer trackSession("export")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(export.Middleware(dbh, api.StaticSite))
+				fn(s)
+				return
+			}
+
+			if remote.IsCommand(cmd) {
+				defer trackSession("edit")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(remote.Middleware(dbh))
+				fn(s)
+				return
+			}
+
+			if org.IsCommand(cmd) {
+				defer trackSession("org")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(org.Middleware(dbh))
+				fn(s)
+				return
+			}
+
+			if publish.IsCommand(cmd) {
+				defer trackSession("publish")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(publish.Middleware(dbh))
+				fn(s)
+				return
+			}
+
+			if login.IsCommand(cmd) {
+				defer trackSession("login")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(login.Middleware(dbh))
+				fn(s)
+				return
+			}
+
+			if share.IsCommand(cmd) {
+				defer trackSession("share")()
+				dbh := postgres.NewDB()
+				defer dbh.Close()
+				fn := withMiddleware(share.Middleware(dbh))
+				fn(s)
+				return
+			}
 		}
 	}
 }
 
-func main() {
-	logger := internal.CreateLogger()
-	host := internal.GetEnv("LISTS_HOST", "0.0.0.0")
-	port := internal.GetEnv("LISTS_SSH_PORT", "2222")
+// newServer serves the TUI and the ssh commands on addr, the end to end
+// tests start one too.
+func newServer(addr string, hostKeyPath string) (*ssh.Server, error) {
+	cfg := config.Current().SSH
+	sessions := limit.New("sessions", cfg.MaxSessions, cfg.QueueTimeout)
+	transfers := limit.New("transfers", cfg.MaxTransfers, cfg.QueueTimeout)
 
 	sshServer := &SSHServer{}
-	s, err := wish.NewServer(
-		wish.WithAddress(fmt.Sprintf("%s:%s", host, port)),
-		wish.WithHostKeyPath("ssh_data/term_info_ed25519"),
+	return wish.NewServer(
+		wish.WithAddress(addr),
+		wish.WithHostKeyPath(hostKeyPath),
 		wish.WithPublicKeyAuth(sshServer.authHandler),
-		wish.WithMiddleware(proxyMiddleware()),
+		wish.WithMiddleware(proxyMiddleware(sessions, transfers)),
 	)
+}
+
+func main() {
+	cfg, err := config.Init()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger := internal.CreateLogger()
+	host := cfg.Host
+	port := cfg.SSH.Port
+	metricsPort := cfg.SSH.MetricsPort
+
+	s, err := newServer(fmt.Sprintf("%s:%d", host, port), "ssh_data/term_info_ed25519")
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	logger.Infof("Starting SSH server on %s:%s", host, port)
+	logger.Infof("Starting SSH server on %s:%d", host, port)
 	go func() {
-		if err = s.ListenAndServe(); err != nil {
+		if err = s.ListenAndServe(); err != nil && err != ssh.ErrServerClosed {
 			logger.Fatal(err)
 		}
 	}()
 
+	logger.Infof("Starting metrics server on %s:%d", cfg.MetricsHost, metricsPort)
+	go func() {
+		if err := metrics.ListenAndServe(fmt.Sprintf("%s:%d", cfg.MetricsHost, metricsPort)); err != nil {
+			logger.Error(err)
+		}
+	}()
+
 	<-done
-	logger.Info("Stopping SSH server")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	logger.Info("Stopping SSH server, draining open sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer func() { cancel() }()
 	if err := s.Shutdown(ctx); err != nil {
-		logger.Fatal(err)
+		logger.Errorf("sessions did not drain in time: %v", err)
+		_ = s.Close()
 	}
 }
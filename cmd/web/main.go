@@ -1,7 +1,17 @@
 package main
 
-import "github.com/neurosnap/lists.sh/internal/api"
+import (
+	"fmt"
+	"os"
+
+	"github.com/neurosnap/lists.sh/internal/api"
+	"github.com/neurosnap/lists.sh/internal/config"
+)
 
 func main() {
+	if _, err := config.Init(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	api.StartServer()
 }
@@ -0,0 +1,14 @@
+ALTER TABLE app_users ADD COLUMN status character varying(20) NOT NULL DEFAULT 'active';
+ALTER TABLE posts ADD COLUMN hidden_at timestamp without time zone;
+ALTER TABLE posts ADD COLUMN hidden_reason character varying(255) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  actor character varying(255) NOT NULL,
+  action character varying(50) NOT NULL,
+  target character varying(255) NOT NULL DEFAULT '',
+  note text NOT NULL DEFAULT '',
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+CREATE INDEX audit_log_created_at ON audit_log USING btree(created_at);
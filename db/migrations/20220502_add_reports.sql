@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS reports (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  post_id uuid NOT NULL,
+  note text NOT NULL DEFAULT '',
+  status character varying(20) NOT NULL DEFAULT 'open',
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  resolved_at timestamp without time zone,
+  CONSTRAINT reports_pkey PRIMARY KEY (id),
+  CONSTRAINT fk_reports_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+CREATE INDEX reports_status ON reports USING btree(status);
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN flagged_reason character varying(255) NOT NULL DEFAULT '';
+CREATE INDEX posts_text_md5 ON posts USING btree(md5(text));
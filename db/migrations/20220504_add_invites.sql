@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS invites (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  code character varying(50) NOT NULL,
+  created_by uuid,
+  used_by uuid,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  used_at timestamp without time zone,
+  CONSTRAINT invites_pkey PRIMARY KEY (id),
+  CONSTRAINT unique_invite_code UNIQUE (code),
+  CONSTRAINT fk_invites_created_by
+    FOREIGN KEY(created_by)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_invites_used_by
+    FOREIGN KEY(used_by)
+  REFERENCES app_users(id)
+  ON DELETE SET NULL
+  ON UPDATE CASCADE
+);
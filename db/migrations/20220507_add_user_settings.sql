@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+  user_id uuid NOT NULL,
+  post_sort character varying(16) NOT NULL DEFAULT 'date',
+  updated_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT user_settings_pkey PRIMARY KEY (user_id),
+  CONSTRAINT fk_user_settings_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN deleted_at timestamp without time zone;
@@ -0,0 +1,3 @@
+ALTER TABLE user_settings ADD COLUMN timezone character varying(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE user_settings ADD COLUMN per_page integer NOT NULL DEFAULT 4;
+ALTER TABLE user_settings ADD COLUMN theme character varying(16) NOT NULL DEFAULT 'auto';
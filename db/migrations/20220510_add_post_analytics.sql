@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS post_views_daily (
+  post_id uuid NOT NULL,
+  day date NOT NULL,
+  views integer NOT NULL DEFAULT 0,
+  CONSTRAINT post_views_daily_pkey PRIMARY KEY (post_id, day),
+  CONSTRAINT fk_post_views_daily_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS post_referrers (
+  post_id uuid NOT NULL,
+  host character varying(255) NOT NULL,
+  views integer NOT NULL DEFAULT 0,
+  CONSTRAINT post_referrers_pkey PRIMARY KEY (post_id, host),
+  CONSTRAINT fk_post_referrers_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS feed_subscribers (
+  user_id uuid NOT NULL,
+  fetcher character varying(255) NOT NULL,
+  subscribers integer NOT NULL DEFAULT 0,
+  updated_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT feed_subscribers_pkey PRIMARY KEY (user_id, fetcher),
+  CONSTRAINT fk_feed_subscribers_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
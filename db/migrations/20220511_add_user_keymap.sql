@@ -0,0 +1 @@
+ALTER TABLE user_settings ADD COLUMN keymap character varying(16) NOT NULL DEFAULT 'default';
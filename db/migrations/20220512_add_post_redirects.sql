@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS post_redirects (
+  user_id uuid NOT NULL,
+  from_filename character varying(255) NOT NULL,
+  to_filename character varying(255) NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT post_redirects_pkey PRIMARY KEY (user_id, from_filename),
+  CONSTRAINT fk_post_redirects_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
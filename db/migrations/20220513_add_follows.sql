@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS follows (
+  user_id uuid NOT NULL,
+  author_id uuid NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT follows_pkey PRIMARY KEY (user_id, author_id),
+  CONSTRAINT fk_follows_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_follows_authors
+    FOREIGN KEY(author_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS follows_author_id_idx ON follows (author_id);
+
+CREATE TABLE IF NOT EXISTS post_reads (
+  user_id uuid NOT NULL,
+  post_id uuid NOT NULL,
+  read_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT post_reads_pkey PRIMARY KEY (user_id, post_id),
+  CONSTRAINT fk_post_reads_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_post_reads_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE public_keys ADD COLUMN IF NOT EXISTS last_used_at timestamp without time zone;
@@ -0,0 +1,2 @@
+ALTER TABLE app_users ADD COLUMN IF NOT EXISTS display_name text NOT NULL DEFAULT '';
+ALTER TABLE app_users ADD COLUMN IF NOT EXISTS bio text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS layout character varying(16) NOT NULL DEFAULT 'list';
@@ -0,0 +1,10 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS posts_tags_idx ON posts USING gin (tags);
+
+-- Posts saved before the column existed get their tags from the `=: tags`
+-- line, the same way the parser reads it.
+UPDATE posts SET tags = ARRAY(
+    SELECT DISTINCT lower(trim(tag))
+    FROM regexp_split_to_table(substring(text from '(?n)^\s*=:\s*tags\s+(.*)$'), ',') AS tag
+    WHERE trim(tag) <> ''
+) WHERE text ~ '(?n)^\s*=:\s*tags\s';
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at timestamp without time zone;
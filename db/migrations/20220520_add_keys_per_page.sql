@@ -0,0 +1,2 @@
+-- 0 fits as many keys as the terminal has room for.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS keys_per_page integer NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- What to do with an upload that matches another post word for word, "warn"
+-- or "skip".
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS duplicates character varying(16) NOT NULL DEFAULT 'warn';
@@ -0,0 +1,2 @@
+-- Where readers' "reply by email" links go, empty leaves the link off.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS reply_email character varying(254) NOT NULL DEFAULT '';
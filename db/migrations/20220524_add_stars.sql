@@ -0,0 +1,23 @@
+-- Stars come from signed in readers in the TUI, one per reader and post,
+-- likes from anyone on the web. Both are only shown to the author.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS stars integer NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS likes integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS post_stars (
+  user_id uuid NOT NULL,
+  post_id uuid NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT post_stars_pkey PRIMARY KEY (user_id, post_id),
+  CONSTRAINT fk_post_stars_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_post_stars_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS post_stars_post_id_idx ON post_stars (post_id);
@@ -0,0 +1,19 @@
+-- Ranks posts for the trending sort on the discovery page by their views
+-- and stars from the last week, each day counting half as much as the day
+-- after it. The web server refreshes it every few minutes so reading the
+-- page stays cheap.
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_posts AS
+  SELECT post_id, sum(score) AS score FROM (
+    SELECT post_id, views * power(0.5, current_date - day) AS score
+    FROM post_views_daily
+    WHERE day > current_date - 7
+    UNION ALL
+    SELECT post_id, 5 * power(0.5, current_date - created_at::date) AS score
+    FROM post_stars
+    WHERE created_at > current_date - 7
+  ) AS recent
+  GROUP BY post_id
+WITH DATA;
+
+-- REFRESH ... CONCURRENTLY needs a unique index.
+CREATE UNIQUE INDEX IF NOT EXISTS trending_posts_post_id_idx ON trending_posts (post_id);
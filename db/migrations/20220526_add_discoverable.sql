@@ -0,0 +1,4 @@
+-- Blogs can be left out of the discovery feed, trending and topic pages,
+-- either by their owner or by an admin, while staying up at their own URLs.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS discoverable boolean NOT NULL DEFAULT true;
+ALTER TABLE app_users ADD COLUMN IF NOT EXISTS delisted boolean NOT NULL DEFAULT false;
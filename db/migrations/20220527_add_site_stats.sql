@@ -0,0 +1,20 @@
+-- Daily totals for operators, lists-admin stats reads them.  Only counts are
+-- kept, last_published_on is what lets a publisher be counted once a day.
+CREATE TABLE IF NOT EXISTS site_stats_daily (
+  day date NOT NULL,
+  signups integer NOT NULL DEFAULT 0,
+  publishers integer NOT NULL DEFAULT 0,
+  posts integer NOT NULL DEFAULT 0,
+  sessions integer NOT NULL DEFAULT 0,
+  CONSTRAINT site_stats_daily_pkey PRIMARY KEY (day)
+);
+
+ALTER TABLE app_users ADD COLUMN IF NOT EXISTS last_published_on date;
+
+-- Signups and posts can be backfilled from what's already there.
+INSERT INTO site_stats_daily (day, signups)
+  SELECT created_at::date, count(id) FROM app_users GROUP BY 1
+  ON CONFLICT (day) DO NOTHING;
+INSERT INTO site_stats_daily (day, posts)
+  SELECT created_at::date, count(id) FROM posts GROUP BY 1
+  ON CONFLICT (day) DO UPDATE SET posts = EXCLUDED.posts;
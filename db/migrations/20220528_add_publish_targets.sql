@@ -0,0 +1,23 @@
+-- Where a user's blog is pushed as a static site after every change, either
+-- their own S3 bucket or a Netlify site.  queued_at is set by each change
+-- and cleared by the web server once the push is done.
+CREATE TABLE IF NOT EXISTS publish_targets (
+  user_id uuid NOT NULL,
+  kind character varying(16) NOT NULL,
+  s3_endpoint character varying(255) NOT NULL DEFAULT '',
+  s3_region character varying(64) NOT NULL DEFAULT '',
+  s3_bucket character varying(255) NOT NULL DEFAULT '',
+  s3_access_key character varying(255) NOT NULL DEFAULT '',
+  s3_secret_key character varying(255) NOT NULL DEFAULT '',
+  netlify_site_id character varying(255) NOT NULL DEFAULT '',
+  netlify_token character varying(255) NOT NULL DEFAULT '',
+  queued_at timestamp without time zone,
+  published_at timestamp without time zone,
+  last_error text NOT NULL DEFAULT '',
+  CONSTRAINT publish_targets_pkey PRIMARY KEY (user_id),
+  CONSTRAINT fk_publish_targets_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
@@ -0,0 +1,23 @@
+-- Extra blogs an account publishes under names of their own.  Each blog is
+-- an app_users row without keys so it gets its own posts, profile and URL,
+-- owner_id is the account whose keys manage it.
+CREATE TABLE IF NOT EXISTS blogs (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  owner_id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT blogs_pkey PRIMARY KEY (id),
+  CONSTRAINT unique_blog_user UNIQUE (user_id),
+  CONSTRAINT fk_blogs_owner
+    FOREIGN KEY(owner_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_blogs_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blogs_owner_id_idx ON blogs (owner_id);
@@ -0,0 +1,36 @@
+-- Blogs shared by several accounts.  Owners invite other users and manage
+-- members, editors publish.  An invite is a row without joined_at until the
+-- invited user accepts it.  Whoever started a blog is its first owner.
+CREATE TABLE IF NOT EXISTS blog_members (
+  blog_id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  role character varying(16) NOT NULL DEFAULT 'editor',
+  invited_by uuid,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  joined_at timestamp without time zone,
+  CONSTRAINT blog_members_pkey PRIMARY KEY (blog_id, user_id),
+  CONSTRAINT fk_blog_members_blogs
+    FOREIGN KEY(blog_id)
+  REFERENCES blogs(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_blog_members_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE,
+  CONSTRAINT fk_blog_members_invited_by
+    FOREIGN KEY(invited_by)
+  REFERENCES app_users(id)
+  ON DELETE SET NULL
+  ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blog_members_user_id_idx ON blog_members (user_id);
+
+INSERT INTO blog_members (blog_id, user_id, role, created_at, joined_at)
+  SELECT id, owner_id, 'owner', created_at, created_at FROM blogs
+  ON CONFLICT DO NOTHING;
+
+-- Who wrote a post on a shared blog, NULL when it was the blog itself.
+ALTER TABLE posts ADD COLUMN author_id uuid REFERENCES app_users(id) ON DELETE SET NULL;
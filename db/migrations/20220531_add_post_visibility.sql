@@ -0,0 +1,24 @@
+-- Who can read a post, from its `=: visibility` variable: public, followers
+-- of the blog or the readers named in readers.
+ALTER TABLE posts ADD COLUMN visibility character varying(16) NOT NULL DEFAULT 'public';
+ALTER TABLE posts ADD COLUMN readers text[] NOT NULL DEFAULT '{}';
+
+-- Readers signed in on the web.  `ssh lists.sh login` makes a row with a
+-- short lived login token, opening its link swaps that for the session
+-- token kept in a cookie.  Only hashes of the tokens are stored.
+CREATE TABLE IF NOT EXISTS reader_sessions (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  user_id uuid NOT NULL,
+  login_token character varying(64) UNIQUE,
+  session_token character varying(64) UNIQUE,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  expires_at timestamp without time zone NOT NULL,
+  CONSTRAINT reader_sessions_pkey PRIMARY KEY (id),
+  CONSTRAINT fk_reader_sessions_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS reader_sessions_user_id_idx ON reader_sessions (user_id);
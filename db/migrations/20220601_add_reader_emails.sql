@@ -0,0 +1,8 @@
+-- Readers without an account sign in with a link sent to an address a post
+-- was shared with, their sessions have an email instead of a user.
+ALTER TABLE reader_sessions ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE reader_sessions ADD COLUMN email character varying(254);
+ALTER TABLE reader_sessions ADD CONSTRAINT reader_sessions_reader CHECK (user_id IS NOT NULL OR email IS NOT NULL);
+
+-- Finds whether any post is shared with an address before mailing it.
+CREATE INDEX IF NOT EXISTS posts_readers_idx ON posts USING GIN (readers);
@@ -0,0 +1,10 @@
+-- Text of posts longer than bodies.inline_max_kb can live in a body store on
+-- disk or in s3 under body_key, the text column is empty then.  text_bytes
+-- and text_md5 stand in for the text in usage, stats and duplicate checks
+-- wherever it's kept.
+ALTER TABLE posts ADD COLUMN body_key character varying(64) NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN text_bytes integer NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN text_md5 character(32) NOT NULL DEFAULT '';
+UPDATE posts SET text_bytes = octet_length(text), text_md5 = md5(text);
+
+CREATE INDEX IF NOT EXISTS posts_text_md5_idx ON posts (text_md5);
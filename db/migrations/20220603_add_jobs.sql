@@ -0,0 +1,17 @@
+-- Background work queued by any of the servers, see internal/jobs.  Rows
+-- are deleted once a job runs, dead ones stay until an admin requeues them.
+CREATE TABLE IF NOT EXISTS jobs (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  kind character varying(32) NOT NULL,
+  payload text NOT NULL DEFAULT '',
+  status character varying(16) NOT NULL DEFAULT 'queued',
+  attempts integer NOT NULL DEFAULT 0,
+  max_attempts integer NOT NULL DEFAULT 5,
+  run_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  locked_at timestamp without time zone,
+  last_error text NOT NULL DEFAULT '',
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT jobs_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS jobs_due_idx ON jobs (status, run_at);
@@ -0,0 +1,11 @@
+-- Post changes recorded by the statement that makes them, see
+-- internal/events.  There's no foreign key so deleting a post keeps its
+-- event; rows are deleted once they're handed to the jobs table.
+CREATE TABLE IF NOT EXISTS post_events (
+  id bigserial NOT NULL,
+  post_id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  kind character varying(16) NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT post_events_pkey PRIMARY KEY (id)
+);
@@ -0,0 +1,14 @@
+-- Features turned on or off for single users ahead of the rest, see
+-- internal/flags.  A missing row leaves the user to the configured rollout.
+CREATE TABLE IF NOT EXISTS user_flags (
+  user_id uuid NOT NULL,
+  flag character varying(32) NOT NULL,
+  enabled boolean NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT user_flags_pkey PRIMARY KEY (user_id, flag),
+  CONSTRAINT fk_user_flags_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
@@ -0,0 +1,19 @@
+-- Pages elsewhere that users list on their blog with rel="me", see
+-- internal/relme.  verified_at is when the page was last seen linking back
+-- to the blog, NULL when it didn't or hasn't been checked yet.
+CREATE TABLE IF NOT EXISTS profile_links (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  user_id uuid NOT NULL,
+  url character varying(256) NOT NULL,
+  position integer NOT NULL DEFAULT 0,
+  verified_at timestamp without time zone,
+  checked_at timestamp without time zone,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT profile_links_pkey PRIMARY KEY (id),
+  CONSTRAINT profile_links_unique_url UNIQUE (user_id, url),
+  CONSTRAINT fk_profile_links_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
@@ -0,0 +1,27 @@
+-- IndieAuth sign ins waiting on, or given, the user's go ahead in the TUI,
+-- see internal/indieauth.  handle is the hash of the token the waiting
+-- browser holds and code the hash of the authorization code it's handed
+-- once the user approves, so a leaked table can't finish a sign in.
+CREATE TABLE IF NOT EXISTS auth_requests (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  user_id uuid NOT NULL,
+  handle character varying(64) NOT NULL,
+  challenge character varying(16) NOT NULL,
+  client_id character varying(512) NOT NULL,
+  redirect_uri character varying(512) NOT NULL,
+  state character varying(512) NOT NULL DEFAULT '',
+  code_challenge character varying(128) NOT NULL,
+  scope character varying(256) NOT NULL DEFAULT '',
+  status character varying(16) NOT NULL DEFAULT 'pending',
+  code character varying(64),
+  expires_at timestamp without time zone NOT NULL,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT auth_requests_pkey PRIMARY KEY (id),
+  CONSTRAINT auth_requests_unique_handle UNIQUE (handle),
+  CONSTRAINT auth_requests_unique_code UNIQUE (code),
+  CONSTRAINT fk_auth_requests_app_users
+    FOREIGN KEY(user_id)
+  REFERENCES app_users(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
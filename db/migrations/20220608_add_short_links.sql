@@ -0,0 +1,16 @@
+-- Short links like lists.sh/-/Ab3xZ that redirect to a post, made the
+-- first time the post's page or details are shown.  clicks counts the
+-- redirects.
+CREATE TABLE IF NOT EXISTS short_links (
+  slug character varying(16) NOT NULL,
+  post_id uuid NOT NULL,
+  clicks integer NOT NULL DEFAULT 0,
+  created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT short_links_pkey PRIMARY KEY (slug),
+  CONSTRAINT short_links_unique_post UNIQUE (post_id),
+  CONSTRAINT fk_short_links_posts
+    FOREIGN KEY(post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE
+);
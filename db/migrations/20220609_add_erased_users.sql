@@ -0,0 +1,8 @@
+-- The ids of erased accounts, and of the blogs erased with them, so
+-- restoring a backup taken before the erasure leaves them out.  Nothing else
+-- about the account is kept.
+CREATE TABLE IF NOT EXISTS erased_users (
+  user_id uuid NOT NULL,
+  erased_at timestamp without time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT erased_users_pkey PRIMARY KEY (user_id)
+);
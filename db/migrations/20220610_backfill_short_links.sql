@@ -0,0 +1,21 @@
+-- Every post has a short link from when it's saved, give the posts from
+-- before that one too.  Slugs that collide are tried again, any post still
+-- left over gets its link the first time its page is shown.
+DO $$
+BEGIN
+  FOR attempt IN 1..5 LOOP
+    INSERT INTO short_links (slug, post_id)
+    SELECT (
+      SELECT string_agg(substr('ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789', floor(random() * 62)::int + 1, 1), '')
+      FROM generate_series(1, 5)
+      WHERE posts.id IS NOT NULL
+    ), posts.id
+    FROM posts
+    WHERE NOT EXISTS (SELECT 1 FROM short_links WHERE short_links.post_id = posts.id)
+    ON CONFLICT DO NOTHING;
+    EXIT WHEN NOT EXISTS (
+      SELECT 1 FROM posts
+      WHERE NOT EXISTS (SELECT 1 FROM short_links WHERE short_links.post_id = posts.id)
+    );
+  END LOOP;
+END $$;
@@ -13,6 +13,7 @@ require (
 	github.com/matryer/is v1.4.0
 	github.com/muesli/reflow v0.3.0
 	go.uber.org/zap v1.21.0
+	golang.org/x/crypto v0.0.0-20211209193657-4570a0811e8b
 	golang.org/x/exp v0.0.0-20220414153411-bcd21879b8fd
 )
 
@@ -32,7 +33,6 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
-	golang.org/x/crypto v0.0.0-20211209193657-4570a0811e8b // indirect
 	golang.org/x/sys v0.0.0-20211210111614-af8b64212486 // indirect
 	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed // indirect
 )
@@ -0,0 +1,10 @@
+// Package html holds the web server's templates, built into the binary so
+// it runs without them on disk.
+package html
+
+import "embed"
+
+// FS is every template in this directory.
+//
+//go:embed *.tmpl
+var FS embed.FS
@@ -0,0 +1,333 @@
+// Package admin implements the moderation actions behind lists-admin.  Every
+// change is recorded in the audit log along with the operator who made it.
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+)
+
+type Admin struct {
+	dbpool db.DB
+	actor  string
+}
+
+// New returns an Admin that attributes its actions to actor.
+func New(dbpool db.DB, actor string) *Admin {
+	return &Admin{dbpool: dbpool, actor: actor}
+}
+
+func (a *Admin) audit(action string, target string, note string) error {
+	return a.dbpool.InsertAuditLog(&db.AuditLog{
+		Actor:  a.actor,
+		Action: action,
+		Target: target,
+		Note:   note,
+	})
+}
+
+func (a *Admin) user(name string) (*db.User, error) {
+	user, err := a.dbpool.UserForName(name)
+	if err != nil {
+		return nil, fmt.Errorf("user %q not found", name)
+	}
+	return user, nil
+}
+
+func (a *Admin) post(name string, filename string) (*db.Post, error) {
+	user, err := a.user(name)
+	if err != nil {
+		return nil, err
+	}
+	post, err := a.dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("post %s/%s not found", name, filename)
+	}
+	return post, nil
+}
+
+// Users lists every account with its usage.
+func (a *Admin) Users() ([]*db.UserStats, error) {
+	return a.dbpool.UserStats()
+}
+
+// User returns usage and keys for a single account.
+func (a *Admin) User(name string) (*db.UserStats, []*db.PublicKey, error) {
+	user, err := a.user(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats, err := a.dbpool.UserStats()
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := a.dbpool.ListKeysForUser(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, st := range stats {
+		if st.User.ID == user.ID {
+			return st, keys, nil
+		}
+	}
+	return &db.UserStats{User: user}, keys, nil
+}
+
+// SetStatus suspends, bans or reinstates an account.
+func (a *Admin) SetStatus(name string, status string, reason string) error {
+	user, err := a.user(name)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.SetUserStatus(user.ID, status)
+	if err != nil {
+		return err
+	}
+	return a.audit("user:"+status, name, reason)
+}
+
+// SetDelisted keeps an account's posts out of the discovery feed, trending
+// and topic pages without suspending it, or lets them back in.
+func (a *Admin) SetDelisted(name string, delisted bool, reason string) error {
+	user, err := a.user(name)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.SetUserDelisted(user.ID, delisted)
+	if err != nil {
+		return err
+	}
+	if delisted {
+		return a.audit("user:delist", name, reason)
+	}
+	return a.audit("user:relist", name, reason)
+}
+
+// ResetKeys removes every public key from an account so the owner has to
+// link a new one.
+func (a *Admin) ResetKeys(name string, reason string) error {
+	user, err := a.user(name)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.RemoveKeysForUser(user.ID)
+	if err != nil {
+		return err
+	}
+	return a.audit("user:reset-keys", name, reason)
+}
+
+// Takedown hides a post from the blog, feeds and discovery page.
+func (a *Admin) Takedown(name string, filename string, reason string) error {
+	post, err := a.post(name, filename)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.HidePost(post.ID, reason)
+	if err != nil {
+		return err
+	}
+	return a.audit("post:takedown", name+"/"+filename, reason)
+}
+
+// Reinstate makes a hidden post public again.
+func (a *Admin) Reinstate(name string, filename string, reason string) error {
+	post, err := a.post(name, filename)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.UnhidePost(post.ID)
+	if err != nil {
+		return err
+	}
+	return a.audit("post:reinstate", name+"/"+filename, reason)
+}
+
+// Flagged lists posts the spam check is holding back.
+func (a *Admin) Flagged() ([]*db.Post, error) {
+	return a.dbpool.FindFlaggedPosts()
+}
+
+// Approve clears the spam flag on a post and lifts an automatic quarantine.
+// Takedowns made by an admin are left alone.
+func (a *Admin) Approve(name string, filename string, reason string) error {
+	post, err := a.post(name, filename)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.FlagPost(post.ID, "")
+	if err != nil {
+		return err
+	}
+	if post.HiddenAt != nil && strings.HasPrefix(post.HiddenReason, "quarantined:") {
+		err = a.dbpool.UnhidePost(post.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return a.audit("post:approve", name+"/"+filename, reason)
+}
+
+// DeadJobs lists background jobs that failed every attempt.
+func (a *Admin) DeadJobs() ([]*db.Job, error) {
+	return a.dbpool.FindDeadJobs()
+}
+
+// RetryJob queues a dead job to run again.
+func (a *Admin) RetryJob(jobID string, reason string) error {
+	err := a.dbpool.RequeueJob(jobID)
+	if err != nil {
+		return fmt.Errorf("dead job %q not found", jobID)
+	}
+	return a.audit("job:retry", jobID, reason)
+}
+
+// FlagUsers lists the users a feature is turned on or off for, whatever its
+// rollout says.
+func (a *Admin) FlagUsers(flag string) ([]*db.UserFlag, error) {
+	if !flags.IsKnown(flag) {
+		return nil, fmt.Errorf("no flag called %q, there's %s", flag, strings.Join(flags.Known(), ", "))
+	}
+	return a.dbpool.FindFlagUsers(flag)
+}
+
+// SetFlag turns a feature on or off for one user ahead of its rollout, or
+// leaves them to the rollout again with "default".
+func (a *Admin) SetFlag(flag string, name string, state string, reason string) error {
+	if !flags.IsKnown(flag) {
+		return fmt.Errorf("no flag called %q, there's %s", flag, strings.Join(flags.Known(), ", "))
+	}
+	user, err := a.user(name)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case "on", "off":
+		err = a.dbpool.SetUserFlag(user.ID, flag, state == "on")
+	case "default":
+		err = a.dbpool.RemoveUserFlag(user.ID, flag)
+	default:
+		return fmt.Errorf("a flag can be on, off or default, not %q", state)
+	}
+	if err != nil {
+		return err
+	}
+	return a.audit("flag:"+state, name, strings.TrimSpace(flag+" "+reason))
+}
+
+// Reports lists abuse reports with the given status, or all reports when
+// status is empty.
+func (a *Admin) Reports(status string) ([]*db.Report, error) {
+	return a.dbpool.FindReports(status)
+}
+
+func (a *Admin) report(reportID string) (*db.Report, error) {
+	report, err := a.dbpool.FindReport(reportID)
+	if err != nil {
+		return nil, fmt.Errorf("report %q not found", reportID)
+	}
+	return report, nil
+}
+
+// HideReported takes the reported post down while the report is reviewed.
+func (a *Admin) HideReported(reportID string, reason string) error {
+	report, err := a.report(reportID)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = "pending review of report " + report.ID
+	}
+	err = a.dbpool.HidePost(report.PostID, reason)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.SetReportStatus(report.ID, db.ReportStatusHidden)
+	if err != nil {
+		return err
+	}
+	return a.audit("report:hide", report.Username+"/"+report.Filename, reason)
+}
+
+// DismissReport closes a report without touching the post.
+func (a *Admin) DismissReport(reportID string, reason string) error {
+	report, err := a.report(reportID)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.SetReportStatus(report.ID, db.ReportStatusDismissed)
+	if err != nil {
+		return err
+	}
+	return a.audit("report:dismiss", report.Username+"/"+report.Filename, reason)
+}
+
+// Erase permanently deletes a user and all of their data, for requests that
+// arrive by email.  The audit entry only keeps the account id.
+func (a *Admin) Erase(name string, reason string) error {
+	user, err := a.user(name)
+	if err != nil {
+		return err
+	}
+	err = a.dbpool.EraseUser(user.ID, user.Name)
+	if err != nil {
+		return err
+	}
+	return a.audit("user:erase", user.ID, reason)
+}
+
+// Invite mints count registration codes that aren't tied to any user.
+func (a *Admin) Invite(count int) ([]*db.Invite, error) {
+	var invites []*db.Invite
+	for i := 0; i < count; i++ {
+		invite, err := a.dbpool.InsertInvite("", internal.NewInviteCode())
+		if err != nil {
+			return invites, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, a.audit("invite:create", strconv.Itoa(count), "")
+}
+
+// AuditLog returns the most recent moderation actions.
+func (a *Admin) AuditLog(limit int) ([]*db.AuditLog, error) {
+	return a.dbpool.FindAuditLog(limit)
+}
+
+// Stats returns the site's daily totals for the last days days, oldest
+// first, with a zero row for days nothing happened on.
+func (a *Admin) Stats(days int) ([]*db.SiteStats, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-days)
+	stats, err := a.dbpool.FindSiteStats(since)
+	if err != nil {
+		return nil, err
+	}
+	return fillDays(stats, since, today), nil
+}
+
+// fillDays lines stats up one row per day from since to until.
+func fillDays(stats []*db.SiteStats, since time.Time, until time.Time) []*db.SiteStats {
+	byDay := map[string]*db.SiteStats{}
+	for _, st := range stats {
+		byDay[st.Day.Format("2006-01-02")] = st
+	}
+
+	var filled []*db.SiteStats
+	for day := since; !day.After(until); day = day.AddDate(0, 0, 1) {
+		st, ok := byDay[day.Format("2006-01-02")]
+		if !ok {
+			st = &db.SiteStats{Day: day}
+		}
+		filled = append(filled, st)
+	}
+	return filled
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestFillDays(t *testing.T) {
+	is := is.New(t)
+	day := func(d int) time.Time { return time.Date(2022, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	stats := fillDays([]*db.SiteStats{
+		{Day: day(2), Signups: 3},
+		{Day: day(4), Posts: 5, Publishers: 2},
+	}, day(1), day(5))
+
+	is.Equal(len(stats), 5)
+	for i, st := range stats {
+		is.Equal(st.Day, day(i+1))
+	}
+	is.Equal(stats[0].Signups, 0) // nothing happened, still a row
+	is.Equal(stats[1].Signups, 3)
+	is.Equal(stats[3].Posts, 5)
+	is.Equal(stats[3].Publishers, 2)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// subscribersRe matches the subscriber count feed readers like Feedly and
+// Inoreader put in their user agent, e.g. "Feedly/1.0 (...; 16 subscribers)".
+var subscribersRe = regexp.MustCompile(`(\d+) (?:subscribers|readers)`)
+
+// referrerHost returns the host of the referring page, or "" when there isn't
+// one or it's part of lists.sh itself.
+func referrerHost(referer string, domain string) string {
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	domain = strings.ToLower(domain)
+	if i := strings.Index(domain, ":"); i >= 0 {
+		domain = domain[:i]
+	}
+	if host == domain || strings.HasSuffix(host, "."+domain) {
+		return ""
+	}
+	return host
+}
+
+// feedSubscribers reads the subscriber count a feed reader reports in its
+// user agent along with the reader's name.
+func feedSubscribers(userAgent string) (string, int, bool) {
+	match := subscribersRe.FindStringSubmatch(userAgent)
+	if match == nil {
+		return "", 0, false
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return "", 0, false
+	}
+
+	fetcher := strings.FieldsFunc(userAgent, func(r rune) bool {
+		return r == '/' || r == ' ' || r == ';' || r == '('
+	})
+	if len(fetcher) == 0 {
+		return "", 0, false
+	}
+	return fetcher[0], count, true
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestReferrerHost(t *testing.T) {
+	t.Run("other site", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(referrerHost("https://www.news.ycombinator.com/item?id=1", "lists.sh"), "news.ycombinator.com")
+	})
+
+	t.Run("own pages are ignored", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(referrerHost("https://erock.lists.sh/", "lists.sh"), "")
+		is.Equal(referrerHost("http://localhost:3000/read", "localhost:3000"), "")
+	})
+
+	t.Run("missing or broken", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(referrerHost("", "lists.sh"), "")
+		is.Equal(referrerHost("not a url", "lists.sh"), "")
+	})
+}
+
+func TestFeedSubscribers(t *testing.T) {
+	t.Run("feedly", func(t *testing.T) {
+		is := is.New(t)
+		fetcher, count, ok := feedSubscribers("Feedly/1.0 (+http://www.feedly.com/fetcher.html; 16 subscribers; )")
+		is.True(ok)
+		is.Equal(fetcher, "Feedly")
+		is.Equal(count, 16)
+	})
+
+	t.Run("newsblur", func(t *testing.T) {
+		is := is.New(t)
+		fetcher, count, ok := feedSubscribers("NewsBlur Feed Fetcher - 5 subscribers - http://www.newsblur.com/site/1/example")
+		is.True(ok)
+		is.Equal(fetcher, "NewsBlur")
+		is.Equal(count, 5)
+	})
+
+	t.Run("plain reader", func(t *testing.T) {
+		is := is.New(t)
+		_, _, ok := feedSubscribers("Mozilla/5.0")
+		is.True(!ok)
+	})
+}
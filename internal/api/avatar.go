@@ -0,0 +1,36 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/neurosnap/lists.sh/internal/avatar"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// avatarHandler serves the user's identicon.  It's drawn from the user's
+// id, so it only changes when the name goes to another account.
+func avatarHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		logger.Infof("avatar not found: %s", username)
+		http.Error(w, "avatar not found", http.StatusNotFound)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := avatar.WritePNG(&b, user.ID); err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	_, _ = w.Write(b.Bytes())
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/ical"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// calendarURL is where the post's dated items can be subscribed to.
+func calendarURL(username string, filename string) string {
+	return config.Current().URL(username, filename, "calendar.ics")
+}
+
+// calendarHandler serves the post's dated items, like
+// "2024-07-01 — conference talk", as an iCalendar feed.  A post without any
+// still gets an empty calendar so subscriptions outlive a list of past
+// events being cleared out.
+func calendarHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderError(w, r, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	text, _ := expandPost(dbpool, post)
+	cal := &ical.Calendar{
+		ID:      post.ID,
+		Name:    internal.FilenameToTitle(post.Filename, post.Title),
+		URL:     config.Current().URL(post.Username, post.Filename),
+		Domain:  config.Current().Domain,
+		Updated: updatedAt(post),
+		Events:  pkg.Events(pkg.ParseText(text).Items),
+	}
+
+	var b bytes.Buffer
+	if err := ical.Write(&b, cal); err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", post.Filename+".ics"))
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(b.Bytes())
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// notFoundFilename is the post a user can upload to replace the 404 page on
+// their blog.
+const notFoundFilename = "_404"
+
+type ErrorPageData struct {
+	PageTitle string
+	Status    int
+	Message   string
+	Username  string     // set when the page is on someone's blog
+	Custom    *ReadmeTxt // the user's _404 post, shown instead of the message
+}
+
+// errorMessages are shown when a handler doesn't have anything more useful
+// to say.
+var errorMessages = map[int]string{
+	http.StatusNotFound:            "There's nothing here, the page may have moved or never existed.",
+	http.StatusInternalServerError: "Something went wrong on our end, try again in a bit.",
+}
+
+// renderError writes the sitewide error page.  message is shown to the
+// reader, so it should never carry internal details.
+func renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if message == "" {
+		message = errorMessages[status]
+	}
+	message = i18n.T(requestLocale(r), message)
+	renderErrorPage(w, r, &ErrorPageData{
+		PageTitle: fmt.Sprintf("%d %s -- lists.sh", status, http.StatusText(status)),
+		Status:    status,
+		Message:   message,
+		Custom:    &ReadmeTxt{},
+	})
+}
+
+// renderNotFound writes the 404 page for a path on user's blog, using their
+// _404 post when they have one.
+func renderNotFound(w http.ResponseWriter, r *http.Request, user *db.User, message string) {
+	locale := requestLocale(r)
+	message = i18n.T(locale, message)
+	data := &ErrorPageData{
+		PageTitle: fmt.Sprintf("%s -- %s", message, i18n.Tf(locale, "%s's blog", user.Name)),
+		Status:    http.StatusNotFound,
+		Message:   message,
+		Username:  user.Name,
+		Custom:    &ReadmeTxt{},
+	}
+
+	post, err := routeHelper.GetDB(r).FindPostWithFilename(notFoundFilename, user.ID)
+	if err == nil && post.IsPublic() {
+		parsedText := pkg.ParseText(post.Text)
+		data.Custom.ListType = parsedText.MetaData.ListType
+		data.Custom.Items = parsedText.Items
+		data.Custom.HasItems = len(parsedText.Items) > 0
+	}
+	renderErrorPage(w, r, data)
+}
+
+// notFoundHandler catches paths no route matches.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	renderError(w, r, http.StatusNotFound, "")
+}
+
+func renderErrorPage(w http.ResponseWriter, r *http.Request, data *ErrorPageData) {
+	logger := routeHelper.GetLogger(r)
+
+	// Render up front, a broken template still gets the right status.
+	var page bytes.Buffer
+	ts, err := renderTemplate(requestLocale(r), []string{
+		"error.page.tmpl",
+		"list.partial.tmpl",
+	})
+	if err == nil {
+		err = ts.Execute(&page, data)
+	}
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, data.Message, data.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(data.Status)
+	_, _ = page.WriteTo(w)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// expandPost fills in the placeholders of a post's text for showing it.
+// Includes can pull in any of the author's posts, drafts too so snippets
+// don't have to be published on their own, but not hidden or deleted ones.
+// The filenames it tried to include come back with the post found for each,
+// nil when there wasn't one, and each is only looked up once.
+func expandPost(dbpool db.DB, post *db.Post) (string, map[string]*db.Post) {
+	includes := map[string]*db.Post{}
+	text := pkg.Expand(post.Text, expandVars(post), func(filename string) (string, bool) {
+		if filename == post.Filename {
+			return "", false
+		}
+		if included, ok := includes[filename]; ok {
+			if included == nil {
+				return "", false
+			}
+			return included.Text, true
+		}
+		included, err := dbpool.FindPostWithFilename(filename, post.UserID)
+		if err != nil || included.HiddenAt != nil || included.DeletedAt != nil {
+			includes[filename] = nil
+			return "", false
+		}
+		includes[filename] = included
+		return included.Text, true
+	})
+	return text, includes
+}
+
+// expandVars are the values of the placeholders in the post's text.
+func expandVars(post *db.Post) map[string]string {
+	cfg := config.Current()
+	return map[string]string{
+		"site.url":  strings.TrimSuffix(cfg.URL(), "/"),
+		"user.name": post.Username,
+		"user.url":  cfg.URL(post.Username),
+	}
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/indieauth"
+	"github.com/neurosnap/lists.sh/internal/login"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// IndieAuthPageData asks for the blog to sign in with, or waits on the user
+// to approve the sign in from the TUI.
+type IndieAuthPageData struct {
+	Client    string
+	Me        string
+	Error     string
+	Params    url.Values // the site's request, carried through the form
+	Challenge string
+	Minutes   int
+}
+
+// maxAuthRequestsPerHour limits the sign ins a client can start in an hour,
+// on top of indieauth.MaxPending for each blog.
+const maxAuthRequestsPerHour = 20
+
+var authRequests = newLoginLimiter(maxAuthRequestsPerHour)
+
+// indieAuthError is how the authorization endpoint turns down a site.
+type indieAuthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// indieAuthMetadataHandler describes the server to sites signing people in.
+func indieAuthMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	writeJSON(w, r, http.StatusOK, indieauth.NewMetadata(config.Current()))
+}
+
+// indieAuthHandler is the authorization endpoint.  A GET is a browser sent
+// over by a site, a POST is the site swapping the code it got back.
+func indieAuthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		redeemAuthCode(w, r)
+		return
+	}
+	startAuthRequest(w, r)
+}
+
+func startAuthRequest(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+	cfg := config.Current()
+	q := r.URL.Query()
+
+	if rt := q.Get("response_type"); rt != "" && rt != "code" {
+		renderError(w, r, http.StatusBadRequest, "Only response_type=code is supported.")
+		return
+	}
+	clientID := q.Get("client_id")
+	redirectURI := q.Get("redirect_uri")
+	if err := indieauth.ValidateClient(clientID, redirectURI); err != nil {
+		renderError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		renderError(w, r, http.StatusBadRequest, "Sign in requests need a code_challenge made with S256.")
+		return
+	}
+
+	data := IndieAuthPageData{
+		Client: indieauth.ClientName(clientID),
+		Me:     q.Get("me"),
+		Params: url.Values{},
+	}
+	for k, v := range q {
+		if k != "me" {
+			data.Params[k] = v
+		}
+	}
+	user, err := authUser(dbpool, data.Me, cfg)
+	if err != nil {
+		// No blog given or one we don't know, ask which.
+		if data.Me != "" {
+			data.Error = err.Error()
+		}
+		renderIndieAuthPage(w, r, data)
+		return
+	}
+
+	if !authRequests.allow(time.Now(), "client "+clientIP(r)) {
+		renderError(w, r, http.StatusTooManyRequests, "That's a lot of sign ins, try again in a bit.")
+		return
+	}
+	pending, err := dbpool.FindPendingAuthRequests(user.ID)
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	if len(pending) >= indieauth.MaxPending {
+		renderError(w, r, http.StatusTooManyRequests, "There are already sign ins waiting on this blog, try again in a few minutes.")
+		return
+	}
+
+	challenge, err := indieauth.NewChallenge()
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	handle, err := login.NewToken()
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	req := &db.AuthRequest{
+		UserID:        user.ID,
+		Challenge:     challenge,
+		ClientID:      clientID,
+		RedirectURI:   redirectURI,
+		State:         q.Get("state"),
+		CodeChallenge: q.Get("code_challenge"),
+		Scope:         indieauth.Scope(q.Get("scope")),
+		ExpiresAt:     time.Now().Add(indieauth.RequestTTL),
+	}
+	if err := dbpool.InsertAuthRequest(req, handle); err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, indieauth.RequestsPath+"/"+handle, http.StatusSeeOther)
+}
+
+// authUser finds the active blog me points at.
+func authUser(dbpool db.DB, me string, cfg *config.Config) (*db.User, error) {
+	name, err := indieauth.Username(me, cfg)
+	if err != nil {
+		return nil, err
+	}
+	user, err := dbpool.UserForName(name)
+	if err != nil || !user.IsActive() {
+		return nil, errors.New("there's no blog at " + cfg.URL(name))
+	}
+	return user, nil
+}
+
+// indieAuthRequestHandler is the page a browser waits on until the user
+// answers the sign in from the TUI, then sends it back to the site.
+func indieAuthRequestHandler(w http.ResponseWriter, r *http.Request) {
+	handle := routeHelper.GetField(r, 0)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+	cfg := config.Current()
+
+	w.Header().Set("Cache-Control", "no-store")
+	req, err := dbpool.FindAuthRequest(handle)
+	if err != nil || time.Now().After(req.ExpiresAt) {
+		renderError(w, r, http.StatusNotFound, "This sign in has expired, start again from the site you were signing in to.")
+		return
+	}
+	back := url.Values{"iss": {indieauth.Issuer(cfg)}}
+	if req.State != "" {
+		back.Set("state", req.State)
+	}
+
+	switch req.Status {
+	case db.AuthRequestPending:
+		user, err := dbpool.User(req.UserID)
+		if err != nil {
+			logger.Error(err)
+			renderError(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		renderIndieAuthPage(w, r, IndieAuthPageData{
+			Client:    indieauth.ClientName(req.ClientID),
+			Me:        cfg.URL(user.Name),
+			Challenge: req.Challenge,
+			Minutes:   int(time.Until(req.ExpiresAt).Minutes()) + 1,
+		})
+	case db.AuthRequestApproved:
+		code, err := login.NewToken()
+		if err != nil {
+			logger.Error(err)
+			renderError(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		err = dbpool.IssueAuthCode(handle, code, time.Now().Add(indieauth.CodeTTL))
+		if err != nil {
+			renderError(w, r, http.StatusNotFound, "This sign in has expired, start again from the site you were signing in to.")
+			return
+		}
+		back.Set("code", code)
+		http.Redirect(w, r, indieauth.RedirectURL(req.RedirectURI, back), http.StatusFound)
+	case db.AuthRequestDenied:
+		back.Set("error", "access_denied")
+		http.Redirect(w, r, indieauth.RedirectURL(req.RedirectURI, back), http.StatusFound)
+	default:
+		renderError(w, r, http.StatusNotFound, "This sign in is already finished, start again from the site you were signing in to.")
+	}
+}
+
+// redeemAuthCode swaps a code for the blog it signs in as, once.
+func redeemAuthCode(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, r, http.StatusBadRequest, indieAuthError{Error: "invalid_request", Description: err.Error()})
+		return
+	}
+	if gt := r.PostFormValue("grant_type"); gt != "" && gt != "authorization_code" {
+		writeJSON(w, r, http.StatusBadRequest, indieAuthError{Error: "unsupported_grant_type"})
+		return
+	}
+
+	req, err := dbpool.RedeemAuthCode(r.PostFormValue("code"))
+	if err != nil {
+		if !errors.Is(err, db.ErrAuthRequestInvalid) {
+			logger.Error(err)
+		}
+		writeJSON(w, r, http.StatusBadRequest, indieAuthError{Error: "invalid_grant", Description: "the code has expired or was already used"})
+		return
+	}
+	user, err := dbpool.User(req.UserID)
+	if err != nil || !user.IsActive() {
+		writeJSON(w, r, http.StatusBadRequest, indieAuthError{Error: "invalid_grant", Description: "the blog is gone"})
+		return
+	}
+	res, err := indieauth.Redeem(
+		req,
+		user,
+		r.PostFormValue("client_id"),
+		r.PostFormValue("redirect_uri"),
+		r.PostFormValue("code_verifier"),
+		config.Current(),
+	)
+	if err != nil {
+		writeJSON(w, r, http.StatusBadRequest, indieAuthError{Error: "invalid_grant", Description: err.Error()})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, res)
+}
+
+func renderIndieAuthPage(w http.ResponseWriter, r *http.Request, data IndieAuthPageData) {
+	logger := routeHelper.GetLogger(r)
+
+	ts, err := renderTemplate(i18n.English, []string{"indieauth.page.tmpl"})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	err = ts.Execute(w, data)
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		routeHelper.GetLogger(r).Error(err)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// Limits on anonymous likes, a reader counts once per post a day and can't
+// like more than maxLikesPerHour posts in an hour.
+const (
+	likeWindow      = 24 * time.Hour
+	maxLikesPerHour = 20
+)
+
+// likeLimiter remembers recent likes in memory, a restart forgets them which
+// is fine for a count nobody but the author sees.
+type likeLimiter struct {
+	mu     sync.Mutex
+	liked  map[string]time.Time // client and post ID to when it was liked
+	hourly map[string][]time.Time
+	pruned time.Time
+}
+
+func newLikeLimiter() *likeLimiter {
+	return &likeLimiter{
+		liked:  map[string]time.Time{},
+		hourly: map[string][]time.Time{},
+	}
+}
+
+var likes = newLikeLimiter()
+
+// allow reports whether the client's like of the post should count and
+// whether the client is over its hourly limit.
+func (l *likeLimiter) allow(client string, postID string, now time.Time) (count bool, limited bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) > time.Hour {
+		l.prune(now)
+	}
+
+	recent := l.hourly[client][:0]
+	for _, at := range l.hourly[client] {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= maxLikesPerHour {
+		l.hourly[client] = recent
+		return false, true
+	}
+
+	key := client + " " + postID
+	if at, ok := l.liked[key]; ok && now.Sub(at) < likeWindow {
+		return false, false
+	}
+	l.liked[key] = now
+	l.hourly[client] = append(recent, now)
+	return true, false
+}
+
+// prune drops likes too old to matter.
+func (l *likeLimiter) prune(now time.Time) {
+	for key, at := range l.liked {
+		if now.Sub(at) >= likeWindow {
+			delete(l.liked, key)
+		}
+	}
+	for client, times := range l.hourly {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= time.Hour {
+			delete(l.hourly, client)
+		}
+	}
+	l.pruned = now
+}
+
+// clientIP is the reader's address. Requests relayed by a proxy on the
+// local network, like Caddy in production, use the address it forwarded.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if ip != nil && (ip.IsLoopback() || ip.IsPrivate()) && forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		return strings.TrimSpace(hops[len(hops)-1])
+	}
+	return host
+}
+
+// likeHandler counts a like from the form on a post and sends the reader
+// back to the post.
+func likeHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderNotFound(w, r, user, "Post not found")
+		return
+	}
+
+	count, limited := likes.allow(clientIP(r), post.ID, time.Now())
+	if limited {
+		renderError(w, r, http.StatusTooManyRequests, "That's a lot of likes, try again in a bit.")
+		return
+	}
+	if count {
+		err = dbpool.LikePost(post.ID)
+		if err != nil {
+			logger.Error(err)
+			renderError(w, r, http.StatusInternalServerError, "")
+			return
+		}
+	}
+
+	http.Redirect(w, r, config.Current().URL(username, post.Filename)+"?liked", http.StatusSeeOther)
+}
@@ -1,20 +1,42 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"html/template"
-	"io/ioutil"
+	"io/fs"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/feeds"
 	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/bodies"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
 	"github.com/neurosnap/lists.sh/internal/db/postgres"
+	"github.com/neurosnap/lists.sh/internal/events"
+	"github.com/neurosnap/lists.sh/internal/export"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/indieauth"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"github.com/neurosnap/lists.sh/internal/mailer"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+	"github.com/neurosnap/lists.sh/internal/publish"
+	"github.com/neurosnap/lists.sh/internal/relme"
 	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/internal/spellcheck"
 	"github.com/neurosnap/lists.sh/pkg"
+	"github.com/neurosnap/lists.sh/public"
+	"go.uber.org/zap"
 )
 
 type PostItemData struct {
@@ -24,6 +46,23 @@ type PostItemData struct {
 	Description  string
 	PublishAtISO string
 	PublishAt    string
+	Tags         []string
+	Stats        string // like "12 items · 3 min read"
+}
+
+// ChangelogItem is an update entry at the bottom of a post in changelog
+// mode.
+type ChangelogItem struct {
+	Date    string
+	DateISO string
+	Text    string
+}
+
+// TagGroup is a heading in the tags layout, posts without tags are grouped
+// under an empty tag.
+type TagGroup struct {
+	Tag   string
+	Posts []PostItemData
 }
 
 type BlogPageData struct {
@@ -32,52 +71,124 @@ type BlogPageData struct {
 	Username  string
 	Readme    *ReadmeTxt
 	Header    *HeaderTxt
+	Footer    *FooterTxt
 	Posts     []PostItemData
+	TagGroups []TagGroup
+	// IndieAuth is the server metadata sites find the authorization
+	// endpoint from, see internal/indieauth.
+	IndieAuth    string
+	AuthEndpoint string
 }
 
 type ReadPageData struct {
 	NextPage string
 	PrevPage string
+	Sort     string
+	Sorts    []ReadSortLink
 	Posts    []PostItemData
 }
 
+// ReadSortLink switches the discovery page to another sort.
+type ReadSortLink struct {
+	Name     string
+	URL      string
+	Selected bool
+}
+
 type PostPageData struct {
 	PageTitle    string
 	URL          string
+	OEmbedURL    string // where oEmbed consumers can discover the post
 	Title        string
 	Description  string
 	Username     string
-	ListType     string
-	Items        []*pkg.ListItem
+	Authors      []string      // who wrote it, on shared or co-written posts
+	Restricted   bool          // only some readers may see it, see canRead
+	List         template.HTML // the post's items, see renderPost
 	PublishAtISO string
 	PublishAt    string
+	Filename     string
+	Stats        string
+	Updated      string // like "updated 3 days ago", empty unless edited after publishing
+	UpdatedISO   string
+	Changelog    []ChangelogItem
+	Footer       *FooterTxt
+	Older        *PostItemData // the post published before this one
+	Newer        *PostItemData // the post published after this one
+	Related      []PostItemData
+	ReplyURL     string // a mailto link to the author, empty unless they set an address
+	Liked        bool   // the reader just liked the post
+	ShortURL     string // like https://lists.sh/-/Ab3xZ, empty in static exports
+	CalendarURL  string // the feed of the post's dated items, empty without any
 }
 
-func renderTemplate(templates []string) (*template.Template, error) {
+type ReportPageData struct {
+	Title     string
+	Username  string
+	Filename  string
+	Note      string
+	Error     string
+	MaxNote   int
+	Submitted bool
+}
+
+// maxReportNote bounds the size of an abuse report.
+const maxReportNote = 2000
+
+// requestLocale is the language the reader's browser asks for.
+func requestLocale(r *http.Request) string {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// localeFuncs lets templates translate with {{t "older"}} or
+// {{t "%s's blog" .Username}} and fill in <html lang="{{lang}}">.
+func localeFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(msg string, args ...interface{}) string {
+			if len(args) == 0 {
+				return i18n.T(locale, msg)
+			}
+			return i18n.Tf(locale, msg, args...)
+		},
+		"lang": func() string { return locale },
+	}
+}
+
+// renderTemplate parses the page with the shared partials. Pages that are
+// only written in English, like help, pass i18n.English. Each combination is
+// only parsed once unless the config asks for templates to be reloaded.
+func renderTemplate(locale string, templates []string) (*template.Template, error) {
 	files := make([]string, len(templates))
 	copy(files, templates)
 	files = append(
 		files,
-		"./html/footer.partial.tmpl",
-		"./html/marketing-footer.partial.tmpl",
-		"./html/base.layout.tmpl",
+		"footer.partial.tmpl",
+		"marketing-footer.partial.tmpl",
+		"base.layout.tmpl",
 	)
 
-	ts, err := template.ParseFiles(files...)
+	key := locale + ":" + strings.Join(files, ",")
+	reload := config.Current().Web.ReloadTemplates
+	if ct, ok := parsedTemplates.get(key); ok && !reload {
+		return ct.ts, nil
+	}
+
+	ts, err := template.New(files[0]).Funcs(localeFuncs(locale)).ParseFS(templateFS(), files...)
 	if err != nil {
 		return nil, err
 	}
+	parsedTemplates.set(key, cachedTemplate{ts: ts})
 	return ts, nil
 }
 
 func createPageHandler(fname string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger := routeHelper.GetLogger(r)
-		ts, err := renderTemplate([]string{fname})
+		ts, err := renderTemplate(i18n.English, []string{fname})
 
 		if err != nil {
 			logger.Error(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			renderError(w, r, http.StatusInternalServerError, "")
 			return
 		}
 
@@ -94,6 +205,25 @@ type HeaderTxt struct {
 	Bio      string
 	Nav      []*pkg.ListItem
 	HasItems bool
+	// Links are the user's pages elsewhere from Settings, see
+	// internal/relme.
+	Links []ProfileLinkData
+}
+
+// ProfileLinkData is a link to one of the user's pages elsewhere.
+type ProfileLinkData struct {
+	URL      string
+	Host     string
+	Verified bool // the page links back to the blog
+}
+
+// profileLinks lists the user's links by their label.
+func profileLinks(links []*db.ProfileLink) []ProfileLinkData {
+	data := make([]ProfileLinkData, 0, len(links))
+	for _, link := range links {
+		data = append(data, ProfileLinkData{URL: link.URL, Host: relme.Label(link.URL), Verified: link.VerifiedAt != nil})
+	}
+	return data
 }
 
 type ReadmeTxt struct {
@@ -102,40 +232,153 @@ type ReadmeTxt struct {
 	Items    []*pkg.ListItem
 }
 
+// footerFilename is the post shown at the bottom of a user's blog and posts.
+const footerFilename = "_footer"
+
+type FooterTxt struct {
+	HasItems bool
+	ListType string
+	Items    []*pkg.ListItem
+}
+
+// parseFooter renders the user's _footer post, which is empty when there
+// isn't one or it isn't public.
+func parseFooter(post *db.Post) *FooterTxt {
+	footer := &FooterTxt{}
+	if post == nil || !post.IsPublic() {
+		return footer
+	}
+	parsedText := pkg.ParseText(post.Text)
+	footer.ListType = parsedText.MetaData.ListType
+	footer.Items = parsedText.Items
+	footer.HasItems = len(footer.Items) > 0
+	return footer
+}
+
+// applyProfile puts the display name and bio from the user's profile over
+// the defaults and whatever an older _header post says.
+func applyProfile(header *HeaderTxt, user *db.User) {
+	if user.DisplayName != "" {
+		header.Title = user.DisplayName
+	}
+	if user.Bio != "" {
+		header.Bio = user.Bio
+	}
+}
+
+// layoutTemplates are the ways a blog index can list its posts, each defines
+// the "posts" template.
+var layoutTemplates = map[string]string{
+	db.LayoutList:   "layout-list.partial.tmpl",
+	db.LayoutDigest: "layout-digest.partial.tmpl",
+	db.LayoutTags:   "layout-tags.partial.tmpl",
+}
+
+// groupByTag lists the posts under each of their tags, tags sorted by name
+// and posts kept in order.  Untagged posts come last.
+func groupByTag(posts []PostItemData) []TagGroup {
+	byTag := map[string][]PostItemData{}
+	tags := []string{}
+	var untagged []PostItemData
+	for _, post := range posts {
+		if len(post.Tags) == 0 {
+			untagged = append(untagged, post)
+			continue
+		}
+		for _, tag := range post.Tags {
+			if _, ok := byTag[tag]; !ok {
+				tags = append(tags, tag)
+			}
+			byTag[tag] = append(byTag[tag], post)
+		}
+	}
+
+	sort.Strings(tags)
+	groups := make([]TagGroup, 0, len(tags)+1)
+	for _, tag := range tags {
+		groups = append(groups, TagGroup{Tag: tag, Posts: byTag[tag]})
+	}
+	if len(untagged) > 0 {
+		groups = append(groups, TagGroup{Posts: untagged})
+	}
+	return groups
+}
+
+// publicPosts drops posts that were taken down by an admin.
+func publicPosts(posts []*db.Post) []*db.Post {
+	public := make([]*db.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.IsPublic() {
+			public = append(public, post)
+		}
+	}
+	return public
+}
+
 func blogHandler(w http.ResponseWriter, r *http.Request) {
 	username := routeHelper.GetField(r, 0)
 	dbpool := routeHelper.GetDB(r)
 	logger := routeHelper.GetLogger(r)
 
 	user, err := dbpool.UserForName(username)
-	if err != nil {
+	if err != nil || !user.IsActive() {
 		logger.Infof("blog not found: %s", username)
-		http.Error(w, "blog not found", http.StatusNotFound)
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
 		return
 	}
 	posts, err := dbpool.PostsForUser(user.ID)
 	if err != nil {
 		logger.Error(err)
-		http.Error(w, "could not fetch posts for blog", http.StatusInternalServerError)
+		renderError(w, r, http.StatusInternalServerError, "")
 		return
 	}
+	posts, restricted := readablePosts(r, posts)
+	if restricted {
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
 
-	ts, err := renderTemplate([]string{
-		"./html/blog.page.tmpl",
-		"./html/list.partial.tmpl",
-	})
+	ts, data, err := blogPage(dbpool, logger, user, posts, requestLocale(r))
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
 
+	err = ts.Execute(w, data)
 	if err != nil {
 		logger.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	}
+}
+
+// blogPage builds the front page of a blog from its public posts.
+func blogPage(dbpool db.DB, logger *zap.SugaredLogger, user *db.User, posts []*db.Post, locale string) (*template.Template, *BlogPageData, error) {
+	settings, err := dbpool.FindUserSettings(user.ID)
+	if err != nil {
+		logger.Error(err)
+		settings = db.DefaultUserSettings()
+	}
+	layout, ok := layoutTemplates[settings.Layout]
+	if !ok {
+		layout = layoutTemplates[db.LayoutList]
+	}
+
+	ts, err := renderTemplate(locale, []string{
+		"blog.page.tmpl",
+		"list.partial.tmpl",
+		"user-footer.partial.tmpl",
+		layout,
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	headerTxt := &HeaderTxt{
-		Title: fmt.Sprintf("%s's blog", username),
+		Title: fmt.Sprintf("%s's blog", user.Name),
 		Bio:   "",
 	}
 	readmeTxt := &ReadmeTxt{}
+	footerTxt := &FooterTxt{}
 
 	postCollection := make([]PostItemData, 0, len(posts))
 	for _, post := range posts {
@@ -160,31 +403,118 @@ func blogHandler(w http.ResponseWriter, r *http.Request) {
 			if len(readmeTxt.Items) > 0 {
 				readmeTxt.HasItems = true
 			}
+		} else if post.Filename == footerFilename {
+			footerTxt = parseFooter(post)
+		} else if post.Filename == spellcheck.DictionaryFilename || post.Filename == notFoundFilename {
+			continue
 		} else {
 			p := PostItemData{
 				URL:          fmt.Sprintf("/%s/%s", post.Username, post.Filename),
 				Title:        internal.FilenameToTitle(post.Filename, post.Title),
+				Description:  post.Description,
 				PublishAt:    post.PublishAt.Format("02 Jan, 2006"),
 				PublishAtISO: post.PublishAt.Format(time.RFC3339),
+				Tags:         pkg.ParseText(post.Text).MetaData.Tags,
+				Stats:        readingStats(post, locale),
 			}
 			postCollection = append(postCollection, p)
 		}
 	}
 
-	data := BlogPageData{
-		PageTitle: headerTxt.Title,
-		URL:       fmt.Sprintf("https://lists.sh/%s", username),
-		Readme:    readmeTxt,
-		Header:    headerTxt,
-		Username:  username,
-		Posts:     postCollection,
-	}
-
-	err = ts.Execute(w, data)
+	applyProfile(headerTxt, user)
+	links, err := dbpool.FindProfileLinks(user.ID)
 	if err != nil {
 		logger.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
+	headerTxt.Links = profileLinks(links)
+
+	data := &BlogPageData{
+		PageTitle:    headerTxt.Title,
+		URL:          config.Current().URL(user.Name),
+		Readme:       readmeTxt,
+		Header:       headerTxt,
+		Footer:       footerTxt,
+		Username:     user.Name,
+		Posts:        postCollection,
+		IndieAuth:    indieauth.MetadataURL(config.Current()),
+		AuthEndpoint: indieauth.AuthURL(config.Current()),
+	}
+	if settings.Layout == db.LayoutTags {
+		data.TagGroups = groupByTag(postCollection)
+	}
+	return ts, data, nil
+}
+
+// navItem links to a neighbouring post, it's nil when there isn't one.
+func navItem(post *db.Post) *PostItemData {
+	if post == nil {
+		return nil
+	}
+	return &PostItemData{
+		URL:   fmt.Sprintf("/%s/%s", post.Username, post.Filename),
+		Title: internal.FilenameToTitle(post.Filename, post.Title),
+	}
+}
+
+// wordsPerMinute is the reading speed the estimate assumes.
+const wordsPerMinute = 200
+
+// readingStats describes how long a post is, like "12 items · 3 min read".
+// Posts saved before the counts were stored are counted on the fly.
+func readingStats(post *db.Post, locale string) string {
+	items, words := post.ItemCount, post.WordCount
+	if items == 0 && words == 0 {
+		parsed := pkg.ParseText(post.Text)
+		items, words = parsed.ItemCount, parsed.WordCount
+	}
+
+	minutes := (words + wordsPerMinute - 1) / wordsPerMinute
+	if minutes < 1 {
+		minutes = 1
+	}
+	count := "%d items"
+	if items == 1 {
+		count = "%d item"
+	}
+	return i18n.Tf(locale, count, items) + " · " + i18n.Tf(locale, "%d min read", minutes)
+}
+
+// updatedAgo says how long ago the post's text last changed, or nothing
+// when it hasn't changed since it was published.
+func updatedAgo(post *db.Post, now time.Time, locale string) string {
+	if post.EditedAt == nil || post.PublishAt == nil || !post.EditedAt.After(*post.PublishAt) {
+		return ""
+	}
+
+	days := int(now.Sub(*post.EditedAt).Hours() / 24)
+	switch {
+	case days < 1:
+		return i18n.T(locale, "updated today")
+	case days == 1:
+		return i18n.T(locale, "updated 1 day ago")
+	default:
+		return i18n.Tf(locale, "updated %d days ago", days)
+	}
+}
+
+func updatedISO(post *db.Post) string {
+	if post.EditedAt == nil {
+		return ""
+	}
+	return post.EditedAt.Format(time.RFC3339)
+}
+
+func changelogItems(entries []*pkg.ChangelogEntry) []ChangelogItem {
+	items := make([]ChangelogItem, 0, len(entries))
+	for _, entry := range entries {
+		item := ChangelogItem{Text: entry.Text}
+		if entry.Date != nil {
+			item.Date = entry.Date.Format("02 Jan, 2006")
+			item.DateISO = entry.Date.Format(time.RFC3339)
+		}
+		items = append(items, item)
+	}
+	return items
 }
 
 func getPostTitle(post *db.Post) string {
@@ -198,41 +528,292 @@ func postHandler(w http.ResponseWriter, r *http.Request) {
 	logger := routeHelper.GetLogger(r)
 
 	user, err := dbpool.UserForName(username)
-	if err != nil {
+	if err != nil || !user.IsActive() {
 		logger.Infof("blog not found: %s", username)
-		http.Error(w, "blog not found", http.StatusNotFound)
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
 		return
 	}
 
 	post, err := dbpool.FindPostWithFilename(filename, user.ID)
 	if err != nil {
+		// The post may have been renamed since the link was shared.
+		if to, err := dbpool.FindPostRedirect(user.ID, filename); err == nil {
+			http.Redirect(w, r, config.Current().URL(username, to), http.StatusMovedPermanently)
+			return
+		}
+	}
+	shared := err == nil && !post.IsPublic() && hasShareLink(r, dbpool, post)
+	if err != nil || !(post.IsPublished() || shared) {
 		logger.Infof("post not found %s/%s", username, filename)
-		http.Error(w, "post not found", http.StatusNotFound)
+		renderNotFound(w, r, user, "Post not found")
+		return
+	}
+	if shared {
+		// Links in the post mustn't pass the token on to other sites.
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+	} else if post.IsRestricted() {
+		reader := currentReader(r)
+		if reader == nil && mailer.Enabled(config.Current().Mail) {
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, "/login?next="+url.QueryEscape("/"+username+"/"+filename), http.StatusSeeOther)
+			return
+		}
+		if reader == nil {
+			renderError(w, r, http.StatusForbidden, i18n.Tf(requestLocale(r), "This post isn't public. If it was shared with you, run ssh lists.sh login %s and open the link it prints.", config.Current().URL(username, filename)))
+			return
+		}
+		if !canRead(dbpool, logger, reader, post) {
+			logger.Infof("post not shared with %s: %s/%s", reader.Name(), username, filename)
+			renderNotFound(w, r, user, "Post not found")
+			return
+		}
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	// Tools can ask for the source instead of scraping the page.
+	w.Header().Add("Vary", "Accept")
+	if mediaType := sourceType(r.Header.Get("Accept")); mediaType != "" {
+		w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+		fmt.Fprint(w, export.SourceText(post))
 		return
 	}
 
-	parsedText := pkg.ParseText(post.Text)
+	err = dbpool.RecordPostView(post.ID, referrerHost(r.Referer(), config.Current().Domain))
+	if err != nil {
+		logger.Error(err)
+	}
 
-	data := PostPageData{
+	ts, data, err := postPage(dbpool, logger, user, post, requestLocale(r))
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	data.Liked = r.URL.Query().Has("liked")
+	data.Restricted = data.Restricted || shared
+	if link, err := dbpool.ShortLinkForPost(post.ID); err == nil {
+		data.ShortURL = config.Current().URL(internal.ShortLinkPath, link.Slug)
+	} else {
+		logger.Error(err)
+	}
+
+	err = ts.Execute(w, data)
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// postPage builds the page for one of the user's public posts.
+func postPage(dbpool db.DB, logger *zap.SugaredLogger, user *db.User, post *db.Post, locale string) (*template.Template, *PostPageData, error) {
+	rp, err := renderPost(dbpool, post)
+	if err != nil {
+		return nil, nil, err
+	}
+	footer, _ := dbpool.FindPostWithFilename(footerFilename, user.ID)
+	older, newer, err := dbpool.FindAdjacentPosts(post, time.Now())
+	if err != nil {
+		logger.Error(err)
+	}
+	relatedPosts, err := findRelated(dbpool, post, rp.Tags, time.Now())
+	if err != nil {
+		logger.Error(err)
+	}
+	settings, err := dbpool.FindUserSettings(user.ID)
+	if err != nil {
+		logger.Error(err)
+		settings = db.DefaultUserSettings()
+	}
+
+	ts, err := renderTemplate(locale, []string{
+		"post.page.tmpl",
+		"list.partial.tmpl",
+		"user-footer.partial.tmpl",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := &PostPageData{
 		PageTitle:    getPostTitle(post),
-		URL:          fmt.Sprintf("https://lists.sh/%s/%s", post.Username, post.Filename),
+		URL:          config.Current().URL(post.Username, post.Filename),
+		OEmbedURL:    oembedURL(config.Current().URL(post.Username, post.Filename)),
 		Description:  post.Description,
-		ListType:     parsedText.MetaData.ListType,
 		Title:        internal.FilenameToTitle(post.Filename, post.Title),
 		PublishAt:    post.PublishAt.Format("Mon January 2, 2006"),
 		PublishAtISO: post.PublishAt.Format(time.RFC3339),
-		Username:     username,
-		Items:        parsedText.Items,
+		Username:     user.Name,
+		Authors:      postAuthors(post, rp.Authors),
+		Restricted:   post.IsRestricted(),
+		List:         rp.List,
+		Filename:     post.Filename,
+		Stats:        readingStats(post, locale),
+		Updated:      updatedAgo(post, time.Now(), locale),
+		UpdatedISO:   updatedISO(post),
+		Changelog:    rp.Changelog,
+		Footer:       parseFooter(footer),
+		Older:        navItem(older),
+		Newer:        navItem(newer),
+		Related:      relatedPosts,
+		ReplyURL:     replyURL(settings.ReplyEmail, internal.FilenameToTitle(post.Filename, post.Title)),
+	}
+	if rp.HasEvents && !post.IsRestricted() {
+		data.CalendarURL = calendarURL(post.Username, post.Filename)
+	}
+	return ts, data, nil
+}
+
+// postAuthors is who wrote the post: the member who published it on a shared
+// blog, or the blog itself, followed by the people credited in `authors`.
+// Posts nobody else worked on have none, the blog's name is already shown.
+func postAuthors(post *db.Post, credited []string) []string {
+	first := post.AuthorName
+	if first == "" && len(credited) > 0 {
+		first = post.Username
+	}
+	if first == "" {
+		return nil
+	}
+
+	authors := []string{first}
+	for _, name := range credited {
+		if name != first {
+			authors = append(authors, name)
+		}
+	}
+	return authors
+}
+
+// feedAuthor credits the post's authors on its feed entry.
+func feedAuthor(post *db.Post, rp *renderedPost) *feeds.Author {
+	authors := postAuthors(post, rp.Authors)
+	if len(authors) == 0 {
+		return nil
+	}
+	return &feeds.Author{Name: strings.Join(authors, ", ")}
+}
+
+// embedHandler renders a post as a bare page meant for an <iframe> on
+// another site, it carries its own styles and always shows the latest text.
+func embedHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderError(w, r, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	err = dbpool.RecordPostView(post.ID, referrerHost(r.Referer(), config.Current().Domain))
+	if err != nil {
+		logger.Error(err)
+	}
+
+	rp, err := renderPost(dbpool, post)
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	data := PostPageData{
+		URL:      config.Current().URL(post.Username, post.Filename),
+		Title:    internal.FilenameToTitle(post.Filename, post.Title),
+		Username: username,
+		List:     rp.List,
+		Filename: post.Filename,
 	}
 
-	ts, err := renderTemplate([]string{
-		"./html/post.page.tmpl",
-		"./html/list.partial.tmpl",
+	ts, err := renderTemplate(requestLocale(r), []string{
+		"embed.page.tmpl",
 	})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
 
+	w.Header().Set("Content-Security-Policy", "frame-ancestors *")
+	w.Header().Set("Cache-Control", "no-cache")
+	err = ts.Execute(w, data)
 	if err != nil {
+		logger.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
+}
+
+// replyURL is a mailto link with the post's title as the subject, so the
+// author's mail client threads replies to the same post together.
+func replyURL(email string, title string) string {
+	if email == "" {
+		return ""
+	}
+	subject := strings.ReplaceAll(url.QueryEscape("Re: "+title), "+", "%20")
+	return "mailto:" + email + "?subject=" + subject
+}
+
+// reportHandler shows and submits the abuse report form for a post.
+func reportHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderNotFound(w, r, user, "Post not found")
+		return
+	}
+
+	data := ReportPageData{
+		Title:    internal.FilenameToTitle(post.Filename, post.Title),
+		Username: username,
+		Filename: post.Filename,
+		MaxNote:  maxReportNote,
+	}
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxReportNote*4)
+		note := strings.TrimSpace(r.FormValue("note"))
+		data.Note = note
+		switch {
+		case note == "":
+			data.Error = "Please describe the problem."
+		case utf8.RuneCountInString(note) > maxReportNote:
+			data.Error = fmt.Sprintf("Reports are limited to %d characters.", maxReportNote)
+		default:
+			err = dbpool.InsertReport(post.ID, note)
+			if err != nil {
+				logger.Error(err)
+				renderError(w, r, http.StatusInternalServerError, "")
+				return
+			}
+			logger.Infof("report filed for %s/%s", username, post.Filename)
+			data.Submitted = true
+		}
+	}
+
+	ts, err := renderTemplate(i18n.English, []string{"report.page.tmpl"})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
 
 	err = ts.Execute(w, data)
 	if err != nil {
@@ -248,19 +829,16 @@ func transparencyHandler(w http.ResponseWriter, r *http.Request) {
 	analytics, err := dbpool.SiteAnalytics()
 	if err != nil {
 		logger.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, r, http.StatusInternalServerError, "")
 		return
 	}
 
-	ts, err := template.ParseFiles(
-		"./html/transparency.page.tmpl",
-		"./html/footer.partial.tmpl",
-		"./html/marketing-footer.partial.tmpl",
-		"./html/base.layout.tmpl",
-	)
+	ts, err := renderTemplate(i18n.English, []string{"transparency.page.tmpl"})
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
 	}
 
 	err = ts.Execute(w, analytics)
@@ -275,46 +853,43 @@ func readHandler(w http.ResponseWriter, r *http.Request) {
 	logger := routeHelper.GetLogger(r)
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pager, err := dbpool.FindAllPosts(&db.Pager{Limit: 20, Offset: page})
+	sort := readSort(r.URL.Query().Get("sort"))
+	pager, err := findReadPosts(dbpool, sort, &db.Pager{Limit: 20, Offset: page})
 	if err != nil {
 		logger.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, r, http.StatusInternalServerError, "")
 		return
 	}
 
-	ts, err := renderTemplate([]string{
-		"./html/read.page.tmpl",
+	ts, err := renderTemplate(requestLocale(r), []string{
+		"read.page.tmpl",
 	})
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
 	}
 
 	nextPage := ""
 	if page < pager.Total-1 {
-		nextPage = fmt.Sprintf("/read?page=%d", page+1)
+		nextPage = readURL(sort, page+1)
 	}
 
 	prevPage := ""
 	if page > 0 {
-		prevPage = fmt.Sprintf("/read?page=%d", page-1)
+		prevPage = readURL(sort, page-1)
 	}
 
 	data := ReadPageData{
 		NextPage: nextPage,
 		PrevPage: prevPage,
+		Sort:     sort,
 	}
-	for _, post := range pager.Data {
-		item := PostItemData{
-			URL:          fmt.Sprintf("/%s/%s", post.Username, post.Filename),
-			Title:        internal.FilenameToTitle(post.Filename, post.Title),
-			Description:  post.Description,
-			Username:     post.Username,
-			PublishAt:    post.PublishAt.Format("02 Jan, 2006"),
-			PublishAtISO: post.PublishAt.Format(time.RFC3339),
-		}
-		data.Posts = append(data.Posts, item)
+	for _, name := range readSorts {
+		data.Sorts = append(data.Sorts, ReadSortLink{Name: name, URL: readURL(name, 0), Selected: name == sort})
 	}
+	data.Posts = discoverItems(pager.Data)
 
 	err = ts.Execute(w, data)
 	if err != nil {
@@ -329,27 +904,41 @@ func rssBlogHandler(w http.ResponseWriter, r *http.Request) {
 	logger := routeHelper.GetLogger(r)
 
 	user, err := dbpool.UserForName(username)
-	if err != nil {
+	if err != nil || !user.IsActive() {
 		logger.Infof("rss feed not found: %s", username)
 		http.Error(w, "rss feed not found", http.StatusNotFound)
 		return
 	}
+	if fetcher, count, ok := feedSubscribers(r.UserAgent()); ok {
+		err = dbpool.RecordFeedSubscribers(user.ID, fetcher, count)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
 	posts, err := dbpool.PostsForUser(user.ID)
 	if err != nil {
 		logger.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	posts = publicPosts(posts)
+
+	feed := blogFeed(dbpool, logger, user, posts)
 
-	ts, err := template.ParseFiles("./html/rss.page.tmpl", "./html/list.partial.tmpl")
+	rss, err := feed.ToAtom()
 	if err != nil {
-		logger.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		logger.Fatal(err)
+		http.Error(w, "Could not generate atom rss feed", http.StatusInternalServerError)
 	}
 
+	w.Header().Add("Content-Type", "application/atom+xml")
+	fmt.Fprintf(w, rss)
+}
+
+// blogFeed is the atom feed of a blog's public posts.
+func blogFeed(dbpool db.DB, logger *zap.SugaredLogger, user *db.User, posts []*db.Post) *feeds.Feed {
 	headerTxt := &HeaderTxt{
-		Title: fmt.Sprintf("%s's blog", username),
+		Title: fmt.Sprintf("%s's blog", user.Name),
 	}
 
 	for _, post := range posts {
@@ -366,45 +955,38 @@ func rssBlogHandler(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 	}
+	applyProfile(headerTxt, user)
 
 	feed := &feeds.Feed{
 		Title:       headerTxt.Title,
-		Link:        &feeds.Link{Href: fmt.Sprintf("https://lists.sh/%s/rss", username)},
+		Link:        &feeds.Link{Href: config.Current().URL(user.Name, "rss")},
 		Description: headerTxt.Bio,
-		Author:      &feeds.Author{Name: username},
+		Author:      &feeds.Author{Name: user.Name},
 		Created:     time.Now(),
 	}
 
 	var feedItems []*feeds.Item
 	for _, post := range posts {
-		parsed := pkg.ParseText(post.Text)
-		var tpl bytes.Buffer
-		data := &PostPageData{
-			ListType: parsed.MetaData.ListType,
-			Items:    parsed.Items,
+		if post.Filename == footerFilename || post.Filename == notFoundFilename {
+			continue
 		}
-		if err := ts.Execute(&tpl, data); err != nil {
+		rp, err := renderPost(dbpool, post)
+		if err != nil {
+			logger.Error(err)
 			continue
 		}
 		feedItems = append(feedItems, &feeds.Item{
 			Id:          post.ID,
 			Title:       post.Title,
-			Link:        &feeds.Link{Href: fmt.Sprintf("https://lists.sh/%s/%s", username, post.Title)},
+			Link:        &feeds.Link{Href: config.Current().URL(user.Name, post.Filename)},
 			Description: post.Description,
-			Content:     tpl.String(),
+			Author:      feedAuthor(post, rp),
+			Content:     string(rp.List),
 			Created:     *post.PublishAt,
 		})
 	}
 	feed.Items = feedItems
-
-	rss, err := feed.ToAtom()
-	if err != nil {
-		logger.Fatal(err)
-		http.Error(w, "Could not generate atom rss feed", http.StatusInternalServerError)
-	}
-
-	w.Header().Add("Content-Type", "application/atom+xml")
-	fmt.Fprintf(w, rss)
+	return feed
 }
 
 func rssHandler(w http.ResponseWriter, r *http.Request) {
@@ -418,38 +1000,36 @@ func rssHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ts, err := template.ParseFiles("./html/rss.page.tmpl", "./html/list.partial.tmpl")
-	if err != nil {
-		logger.Error(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	feed := &feeds.Feed{
 		Title:       "lists.sh discovery feed",
-		Link:        &feeds.Link{Href: "https://lists.sh/rss"},
+		Link:        &feeds.Link{Href: config.Current().URL("rss")},
 		Description: "lists.sh latest posts",
 		Author:      &feeds.Author{Name: "lists.sh"},
 		Created:     time.Now(),
 	}
+	writePostsFeed(w, r, feed, pager.Data)
+}
+
+// writePostsFeed fills the feed with posts from across the site and writes
+// it as atom.
+func writePostsFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed, posts []*db.Post) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
 
 	var feedItems []*feeds.Item
-	for _, post := range pager.Data {
-		parsed := pkg.ParseText(post.Text)
-		var tpl bytes.Buffer
-		data := &PostPageData{
-			ListType: parsed.MetaData.ListType,
-			Items:    parsed.Items,
-		}
-		if err := ts.Execute(&tpl, data); err != nil {
+	for _, post := range posts {
+		rp, err := renderPost(dbpool, post)
+		if err != nil {
+			logger.Error(err)
 			continue
 		}
 		feedItems = append(feedItems, &feeds.Item{
 			Id:          post.ID,
 			Title:       post.Title,
-			Link:        &feeds.Link{Href: fmt.Sprintf("https://lists.sh/%s/%s", post.Username, post.Title)},
+			Link:        &feeds.Link{Href: config.Current().URL(post.Username, post.Filename)},
 			Description: post.Description,
-			Content:     tpl.String(),
+			Author:      feedAuthor(post, rp),
+			Content:     string(rp.List),
 			Created:     *post.PublishAt,
 		})
 	}
@@ -465,14 +1045,41 @@ func rssHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, rss)
 }
 
+// draining is set once shutdown starts so /readyz takes us out of rotation
+// while in-flight requests finish.
+var draining int32
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	if atomic.LoadInt32(&draining) == 1 {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	err := routeHelper.GetDB(r).Ping()
+	if err != nil {
+		logger := routeHelper.GetLogger(r)
+		logger.Errorf("readyz: %v", err)
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
 func serveFile(file string, contentType string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger := routeHelper.GetLogger(r)
 
-		contents, err := ioutil.ReadFile(fmt.Sprintf("./public/%s", file))
+		contents, err := fs.ReadFile(public.FS, file)
 		if err != nil {
 			logger.Error(err)
 			http.Error(w, "file not found", 404)
+			return
 		}
 		w.Header().Add("Content-Type", contentType)
 		w.Write(contents)
@@ -480,11 +1087,11 @@ func serveFile(file string, contentType string) http.HandlerFunc {
 }
 
 var routes = []routeHelper.Route{
-	routeHelper.NewRoute("GET", "/", createPageHandler("./html/marketing.page.tmpl")),
-	routeHelper.NewRoute("GET", "/spec", createPageHandler("./html/spec.page.tmpl")),
-	routeHelper.NewRoute("GET", "/ops", createPageHandler("./html/ops.page.tmpl")),
-	routeHelper.NewRoute("GET", "/privacy", createPageHandler("./html/privacy.page.tmpl")),
-	routeHelper.NewRoute("GET", "/help", createPageHandler("./html/help.page.tmpl")),
+	routeHelper.NewRoute("GET", "/", createPageHandler("marketing.page.tmpl")),
+	routeHelper.NewRoute("GET", "/spec", createPageHandler("spec.page.tmpl")),
+	routeHelper.NewRoute("GET", "/ops", createPageHandler("ops.page.tmpl")),
+	routeHelper.NewRoute("GET", "/privacy", createPageHandler("privacy.page.tmpl")),
+	routeHelper.NewRoute("GET", "/help", createPageHandler("help.page.tmpl")),
 	routeHelper.NewRoute("GET", "/main.css", serveFile("main.css", "text/css")),
 	routeHelper.NewRoute("GET", "/card.png", serveFile("card.png", "image/png")),
 	routeHelper.NewRoute("GET", "/favicon-16x16.png", serveFile("favicon-16x16.png", "image/png")),
@@ -492,27 +1099,107 @@ var routes = []routeHelper.Route{
 	routeHelper.NewRoute("GET", "/apple-touch-icon.png", serveFile("apple-touch-icon.png", "image/png")),
 	routeHelper.NewRoute("GET", "/favicon.ico", serveFile("favicon.ico", "image/x-icon")),
 	routeHelper.NewRoute("GET", "/robots.txt", serveFile("robots.txt", "text/plain")),
+	routeHelper.NewRoute("GET", "/healthz", healthzHandler),
+	routeHelper.NewRoute("GET", "/readyz", readyzHandler),
 	routeHelper.NewRoute("GET", "/transparency", transparencyHandler),
 	routeHelper.NewRoute("GET", "/read", readHandler),
+	routeHelper.NewRoute("GET", "/oembed", oembedHandler),
 	routeHelper.NewRoute("GET", "/rss", rssHandler),
 	routeHelper.NewRoute("GET", "/rss.xml", rssHandler),
 	routeHelper.NewRoute("GET", "/atom.xml", rssHandler),
 	routeHelper.NewRoute("GET", "/feed.xml", rssHandler),
+	routeHelper.NewRoute("GET", "/topics/([^/]+)", topicHandler),
+	routeHelper.NewRoute("GET", "/topics/([^/]+)/rss", rssTopicHandler),
+	routeHelper.NewRoute("GET", "/login", emailLoginHandler),
+	routeHelper.NewRoute("POST", "/login", emailLoginHandler),
+	routeHelper.NewRoute("GET", "/login/([^/]+)", loginHandler),
+	routeHelper.NewRoute("GET", "/logout", logoutHandler),
+	routeHelper.NewRoute("GET", "/"+internal.ShortLinkPath+"/([^/]+)", shortLinkHandler),
+	routeHelper.NewRoute("GET", indieauth.MetadataPath, indieAuthMetadataHandler),
+	routeHelper.NewRoute("GET", indieauth.AuthPath, indieAuthHandler),
+	routeHelper.NewRoute("POST", indieauth.AuthPath, indieAuthHandler),
+	routeHelper.NewRoute("GET", indieauth.RequestsPath+"/([^/]+)", indieAuthRequestHandler),
 	routeHelper.NewRoute("GET", "/([^/]+)", blogHandler),
 	routeHelper.NewRoute("GET", "/([^/]+)/rss", rssBlogHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/avatar.png", avatarHandler),
+	routeHelper.NewRoute("GET", `/([^/]+)/([^/]+\.json)`, listJSONHandler),
+	routeHelper.NewRoute("GET", `/([^/]+)/([^/]+\.csv)`, listCSVHandler),
 	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)", postHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)/embed", embedHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)/qr.png", qrHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)/pdf", pdfHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)/calendar.ics", calendarHandler),
+	routeHelper.NewRoute("POST", "/([^/]+)/([^/]+)/like", likeHandler),
+	routeHelper.NewRoute("GET", "/([^/]+)/([^/]+)/report", reportHandler),
+	routeHelper.NewRoute("POST", "/([^/]+)/([^/]+)/report", reportHandler),
+}
+
+// Handler serves the site's pages, feeds and forms from dbpool.
+func Handler(dbpool db.DB, logger *zap.SugaredLogger) http.Handler {
+	handler := routeHelper.CORS(config.Current().Web.CORSOrigins, routeHelper.CreateServe(routes, notFoundHandler, dbpool, logger))
+	return http.HandlerFunc(handler)
 }
 
 func StartServer() {
+	cfg := config.Current()
 	db := postgres.NewDB()
 	defer db.Close()
 	logger := internal.CreateLogger()
 
-	handler := routeHelper.CreateServe(routes, db, logger)
-	router := http.HandlerFunc(handler)
+	stopTrending := make(chan struct{})
+	defer close(stopTrending)
+	go refreshTrending(db, logger, stopTrending)
+
+	stopPublish := make(chan struct{})
+	defer close(stopPublish)
+	go publish.Run(db, logger, StaticSite, stopPublish)
 
-	port := internal.GetEnv("LISTS_WEB_PORT", "3000")
-	portStr := fmt.Sprintf(":%s", port)
-	logger.Infof("Starting server on port %s", port)
-	logger.Fatal(http.ListenAndServe(portStr, router))
+	store, err := bodies.New(cfg.Bodies)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	queue := jobs.NewQueue(db, logger, cfg.Jobs.Workers)
+	queue.Handle(bodies.DeleteJob, bodies.DeleteHandler(store))
+	queue.Handle(relme.VerifyJob, relme.VerifyHandler(db))
+	queue.Handle(emailLoginJob, emailLoginJobHandler(db))
+	queue.Handle(purgeTrashJob, purgeTrashJobHandler(db))
+	dispatcher := events.NewDispatcher(db, logger, queue)
+	dispatcher.Subscribe("publish", publish.OnPostEvent(db))
+	dispatcher.Subscribe("render-cache", forgetRendered)
+	stopJobs := make(chan struct{})
+	defer close(stopJobs)
+	go queue.Run(stopJobs)
+	go dispatcher.Run(stopJobs)
+	go queuePurges(db, logger, stopJobs)
+
+	router := Handler(db, logger)
+
+	port := cfg.Web.Port
+	portStr := fmt.Sprintf(":%d", port)
+	srv := &http.Server{Addr: portStr, Handler: router}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	logger.Infof("Starting server on port %d", port)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+
+	logger.Infof("Starting metrics server on %s:%d", cfg.MetricsHost, cfg.Web.MetricsPort)
+	go func() {
+		if err := metrics.ListenAndServe(fmt.Sprintf("%s:%d", cfg.MetricsHost, cfg.Web.MetricsPort)); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	<-done
+	logger.Info("Stopping server, draining in-flight requests")
+	atomic.StoreInt32(&draining, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error(err)
+	}
 }
@@ -0,0 +1,448 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+func TestApplyProfile(t *testing.T) {
+	t.Run("profile wins over the header post", func(t *testing.T) {
+		is := is.New(t)
+		header := &HeaderTxt{Title: "From _header", Bio: "old bio"}
+		applyProfile(header, &db.User{DisplayName: "Eric's lists", Bio: "new bio"})
+		is.Equal(header.Title, "Eric's lists")
+		is.Equal(header.Bio, "new bio")
+	})
+
+	t.Run("empty profile keeps what's there", func(t *testing.T) {
+		is := is.New(t)
+		header := &HeaderTxt{Title: "erock's blog"}
+		applyProfile(header, &db.User{})
+		is.Equal(header.Title, "erock's blog")
+		is.Equal(header.Bio, "")
+	})
+}
+
+func TestParseFooter(t *testing.T) {
+	t.Run("links and text", func(t *testing.T) {
+		is := is.New(t)
+		footer := parseFooter(&db.Post{Text: "=> https://erock.io my site\nCC BY 4.0"})
+		is.True(footer.HasItems)
+		is.Equal(len(footer.Items), 2)
+		is.True(footer.Items[0].IsURL)
+	})
+
+	t.Run("missing or not public", func(t *testing.T) {
+		is := is.New(t)
+		is.True(!parseFooter(nil).HasItems)
+		now := time.Now()
+		is.True(!parseFooter(&db.Post{Text: "hi", DeletedAt: &now}).HasItems)
+	})
+}
+
+func TestGroupByTag(t *testing.T) {
+	is := is.New(t)
+	posts := []PostItemData{
+		{Title: "tacos", Tags: []string{"recipes", "til"}},
+		{Title: "notes"},
+		{Title: "pasta", Tags: []string{"recipes"}},
+	}
+	groups := groupByTag(posts)
+	is.Equal(len(groups), 3)
+	is.Equal(groups[0].Tag, "recipes")
+	is.Equal(len(groups[0].Posts), 2)
+	is.Equal(groups[0].Posts[0].Title, "tacos") // posts keep their order
+	is.Equal(groups[1].Tag, "til")
+	is.Equal(groups[2].Tag, "") // untagged last
+	is.Equal(groups[2].Posts[0].Title, "notes")
+}
+
+func TestNavItem(t *testing.T) {
+	is := is.New(t)
+	is.True(navItem(nil) == nil)
+	item := navItem(&db.Post{Username: "erock", Filename: "tacos", Title: "Tacos"})
+	is.Equal(item.URL, "/erock/tacos")
+	is.Equal(item.Title, "Tacos")
+}
+
+func TestReadingStats(t *testing.T) {
+	t.Run("stored counts", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(readingStats(&db.Post{ItemCount: 12, WordCount: 450}, i18n.English), "12 items · 3 min read")
+	})
+
+	t.Run("counted from the text", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{Text: "=: title Tacos\n# Fillings\ncarnitas\n\nal pastor\n"}
+		is.Equal(readingStats(post, i18n.English), "2 items · 1 min read")
+	})
+
+	t.Run("single item", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(readingStats(&db.Post{ItemCount: 1, WordCount: 3}, i18n.English), "1 item · 1 min read")
+	})
+
+	t.Run("translated", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(readingStats(&db.Post{ItemCount: 12, WordCount: 450}, i18n.Spanish), "12 elementos · 3 min de lectura")
+	})
+}
+
+func TestUpdatedAgo(t *testing.T) {
+	now := time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC)
+	published := now.AddDate(0, 0, -10)
+
+	t.Run("never edited", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(updatedAgo(&db.Post{PublishAt: &published}, now, i18n.English), "")
+	})
+
+	t.Run("edited before publishing", func(t *testing.T) {
+		is := is.New(t)
+		edited := published.Add(-time.Hour)
+		is.Equal(updatedAgo(&db.Post{PublishAt: &published, EditedAt: &edited}, now, i18n.English), "")
+	})
+
+	t.Run("edited since", func(t *testing.T) {
+		is := is.New(t)
+		edited := now.AddDate(0, 0, -3)
+		is.Equal(updatedAgo(&db.Post{PublishAt: &published, EditedAt: &edited}, now, i18n.English), "updated 3 days ago")
+		edited = now.Add(-time.Hour)
+		is.Equal(updatedAgo(&db.Post{PublishAt: &published, EditedAt: &edited}, now, i18n.English), "updated today")
+	})
+}
+
+func TestChangelogItems(t *testing.T) {
+	t.Run("changelog mode", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("=: changelog true\ntacos\nburritos\n\n# Changelog\n2022-05-01 added tacos\nfixed a typo\n2022-05-18 added burritos\n")
+		is.Equal(len(parsed.Items), 2)
+		items := changelogItems(parsed.Changelog)
+		is.Equal(len(items), 3)
+		is.Equal(items[0], ChangelogItem{Date: "18 May, 2022", DateISO: "2022-05-18T00:00:00Z", Text: "added burritos"})
+		is.Equal(items[1].Text, "added tacos")
+		is.Equal(items[2], ChangelogItem{Text: "fixed a typo"})
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("tacos\n# Changelog\n2022-05-01 added tacos\n")
+		is.Equal(len(parsed.Items), 3)
+		is.Equal(len(parsed.Changelog), 0)
+	})
+}
+
+// includeDB has the author's posts and counts the lookups.
+type includeDB struct {
+	db.DB
+	posts   map[string]*db.Post
+	lookups int
+}
+
+func (d *includeDB) FindPostWithFilename(filename string, userID string) (*db.Post, error) {
+	d.lookups++
+	if post, ok := d.posts[filename]; ok {
+		return post, nil
+	}
+	return nil, errors.New("post not found")
+}
+
+func TestExpandPost(t *testing.T) {
+	is := is.New(t)
+	dbpool := &includeDB{posts: map[string]*db.Post{
+		"snippet": {Filename: "snippet", Text: "- snippet"},
+	}}
+	post := &db.Post{
+		Filename: "tacos",
+		Username: "erock",
+		Text:     strings.Repeat("{{include snippet}}\n{{include missing}}\n", 5),
+	}
+
+	text, includes := expandPost(dbpool, post)
+	is.Equal(dbpool.lookups, 2) // once per filename
+	is.Equal(strings.Count(text, "- snippet"), 5)
+	is.Equal(strings.Count(text, "{{include missing}}"), 5)
+	is.Equal(len(includes), 2)
+	is.Equal(includes["missing"], nil)
+}
+
+func TestSourceType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"*/*", ""},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", ""},
+		{"text/markdown", "text/markdown"},
+		{"text/plain", "text/plain"},
+		{"text/plain, */*;q=0.1", "text/plain"},
+		{"text/markdown;q=0.5, text/plain;q=0.9", "text/plain"},
+		{"text/html, text/plain", ""},
+		{"text/plain;q=0", ""},
+		{"application/json", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(sourceType(tt.accept), tt.want)
+		})
+	}
+}
+
+func TestParsePostURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		username string
+		filename string
+		ok       bool
+	}{
+		{"https://lists.sh/erock/tacos", "erock", "tacos", true},
+		{"http://LISTS.sh/erock/tacos/", "erock", "tacos", true},
+		{"https://lists.sh/erock/tacos/embed", "erock", "tacos", true},
+		{"https://lists.sh/erock/tacos?ref=x#top", "erock", "tacos", true},
+		{"https://lists.sh/erock", "", "", false},
+		{"https://lists.sh/erock/tacos/report", "", "", false},
+		{"https://example.com/erock/tacos", "", "", false},
+		{"ftp://lists.sh/erock/tacos", "", "", false},
+		{"lists.sh/erock/tacos", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			is := is.New(t)
+			username, filename, ok := parsePostURL(tt.url, "lists.sh")
+			is.Equal(ok, tt.ok)
+			is.Equal(username, tt.username)
+			is.Equal(filename, tt.filename)
+		})
+	}
+}
+
+func TestOEmbedSize(t *testing.T) {
+	is := is.New(t)
+	w, h := oembedSize("", "")
+	is.Equal(w, oembedWidth)
+	is.Equal(h, oembedHeight)
+
+	w, h = oembedSize("300", "2000")
+	is.Equal(w, 300)
+	is.Equal(h, oembedHeight)
+
+	w, h = oembedSize("-1", "tall")
+	is.Equal(w, oembedWidth)
+	is.Equal(h, oembedHeight)
+}
+
+func TestReplyURL(t *testing.T) {
+	is := is.New(t)
+	is.Equal(replyURL("", "Tacos"), "")
+	is.Equal(replyURL("erock@example.com", "Tacos & burritos"), "mailto:erock@example.com?subject=Re%3A%20Tacos%20%26%20burritos")
+}
+
+func TestLikeLimiter(t *testing.T) {
+	now := time.Date(2022, 5, 24, 12, 0, 0, 0, time.UTC)
+
+	t.Run("a reader counts once per post a day", func(t *testing.T) {
+		is := is.New(t)
+		l := newLikeLimiter()
+		count, limited := l.allow("1.2.3.4", "post", now)
+		is.True(count)
+		is.True(!limited)
+
+		count, limited = l.allow("1.2.3.4", "post", now.Add(time.Hour))
+		is.True(!count)
+		is.True(!limited)
+
+		count, _ = l.allow("5.6.7.8", "post", now.Add(time.Hour))
+		is.True(count)
+
+		count, _ = l.allow("1.2.3.4", "post", now.Add(likeWindow))
+		is.True(count)
+	})
+
+	t.Run("too many likes in an hour are refused", func(t *testing.T) {
+		is := is.New(t)
+		l := newLikeLimiter()
+		for i := 0; i < maxLikesPerHour; i++ {
+			count, _ := l.allow("1.2.3.4", strconv.Itoa(i), now)
+			is.True(count)
+		}
+		_, limited := l.allow("1.2.3.4", "one more", now.Add(time.Minute))
+		is.True(limited)
+
+		count, limited := l.allow("1.2.3.4", "one more", now.Add(time.Hour))
+		is.True(count)
+		is.True(!limited)
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"direct", "203.0.113.9:5000", "", "203.0.113.9"},
+		{"direct can't claim another address", "203.0.113.9:5000", "198.51.100.1", "203.0.113.9"},
+		{"behind the proxy", "172.18.0.3:5000", "10.0.0.1, 198.51.100.1", "198.51.100.1"},
+		{"proxy without the header", "127.0.0.1:5000", "", "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			r := httptest.NewRequest("POST", "/erock/tacos/like", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			is.Equal(clientIP(r), tt.want)
+		})
+	}
+}
+
+func TestReadURL(t *testing.T) {
+	is := is.New(t)
+	is.Equal(readSort("trending"), sortTrending)
+	is.Equal(readSort("Trending"), sortNewest)
+	is.Equal(readSort(""), sortNewest)
+
+	is.Equal(readURL(sortNewest, 0), "/read")
+	is.Equal(readURL(sortNewest, 2), "/read?page=2")
+	is.Equal(readURL(sortTrending, 0), "/read?sort=trending")
+	is.Equal(readURL(sortPopular, 1), "/read?sort=popular&page=1")
+}
+
+func TestTopicTag(t *testing.T) {
+	is := is.New(t)
+	tag, ok := topicTag(" Recipes ")
+	is.True(ok)
+	is.Equal(tag, "recipes")
+
+	_, ok = topicTag("")
+	is.True(!ok)
+	_, ok = topicTag("tacos,burritos")
+	is.True(!ok)
+	_, ok = topicTag(strings.Repeat("a", maxTopicLength+1))
+	is.True(!ok)
+
+	is.Equal(topicURL("reading lists", 0), "/topics/reading%20lists")
+	is.Equal(topicURL("til", 2), "/topics/til?page=2")
+}
+
+func TestPostAuthors(t *testing.T) {
+	t.Run("parsed from the post", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("=: authors Mia, @erock, mia,\n- tacos")
+		is.Equal(parsed.MetaData.Authors, []string{"mia", "erock"})
+	})
+
+	t.Run("the blog comes first when nobody else published it", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{Username: "erock"}
+		is.Equal(postAuthors(post, nil), nil)
+		is.Equal(postAuthors(post, []string{"mia", "erock"}), []string{"erock", "mia"})
+	})
+
+	t.Run("the member who published it on a shared blog", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{Username: "team", AuthorName: "mia"}
+		is.Equal(postAuthors(post, nil), []string{"mia"})
+		is.Equal(postAuthors(post, []string{"erock"}), []string{"mia", "erock"})
+	})
+
+	t.Run("feed entries name them all", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{Username: "erock"}
+		is.True(feedAuthor(post, &renderedPost{}) == nil)
+		is.Equal(feedAuthor(post, &renderedPost{Authors: []string{"mia"}}).Name, "erock, mia")
+	})
+}
+
+func TestVisibility(t *testing.T) {
+	is := is.New(t)
+	meta := pkg.ParseText("- tacos").MetaData
+	is.Equal(meta.Visibility, pkg.VisibilityPublic)
+
+	meta = pkg.ParseText("=: visibility Followers\n- tacos").MetaData
+	is.Equal(meta.Visibility, pkg.VisibilityFollowers)
+	is.Equal(len(meta.Readers), 0)
+
+	meta = pkg.ParseText("=: visibility mia, @bo\n- tacos").MetaData
+	is.Equal(meta.Visibility, pkg.VisibilityReaders)
+	is.Equal(meta.Readers, []string{"mia", "bo"})
+
+	post := &db.Post{Visibility: meta.Visibility}
+	is.True(post.IsRestricted())
+	is.True(!(&db.Post{}).IsRestricted()) // posts saved before visibility existed
+}
+
+func TestListPDF(t *testing.T) {
+	is := is.New(t)
+	parsed := pkg.ParseText("=: list_type decimal\n# Camping\ntent\nstove\n[ ] matches\n[x] ~~map~~\n=> https://example.com/trail trail map\n---\n> leave no trace\n")
+	var b bytes.Buffer
+	_, err := listPDF("Camping", "Mon June 6, 2022 on erock's blog", "", parsed).WriteTo(&b)
+	is.NoErr(err)
+	out := b.String()
+	is.True(strings.Contains(out, "(1.) Tj"))
+	is.True(strings.Contains(out, "(3.) Tj")) // the link is numbered too
+	is.True(!strings.Contains(out, "(4.) Tj"))
+	is.True(strings.Contains(out, "(https://example.com/trail) Tj"))
+	is.True(strings.Contains(out, "(leave no trace) Tj"))
+	is.True(strings.Contains(out, " re S\n")) // checkboxes
+
+	text, struck := spansText(pkg.ParseSpans("~~map~~"))
+	is.Equal(text, "map")
+	is.True(struck)
+	_, struck = spansText(pkg.ParseSpans("half ~~done~~"))
+	is.True(!struck)
+}
+
+func TestListItems(t *testing.T) {
+	is := is.New(t)
+	parsed := pkg.ParseText("# Groceries\n## Produce\ntomatillos ~~and limes~~\n\n[ ] tortillas\n[x] salsa\n1. warm\nsalsa verde: spicy\n=> https://example.com the store\n=< https://example.com/map.png map\n> bring bags\n---\n")
+	items := listItems(parsed.Items)
+
+	types := []string{}
+	for _, item := range items {
+		types = append(types, item.Type)
+	}
+	is.Equal(types, []string{"header", "subheader", "text", "todo", "todo", "numbered", "definition", "link", "image", "quote", "divider"})
+
+	is.Equal(items[2].Text, "tomatillos and limes")
+	is.Equal(*items[3].Checked, false)
+	is.Equal(*items[4].Checked, true)
+	is.Equal(items[2].Checked, nil) // only todos are checked or not
+	is.Equal(items[6].Text, "salsa verde: spicy")
+	is.Equal(items[7].Text, "the store")
+	is.Equal(items[7].URL, "https://example.com")
+	is.Equal(items[8].Text, "map")
+	is.Equal(items[8].URL, "https://example.com/map.png")
+}
+
+// TestRootRoutesAreReserved keeps blogs and usernames, which live at the root
+// of the site too, from being shadowed by the site's own routes.
+func TestRootRoutesAreReserved(t *testing.T) {
+	for _, route := range routes {
+		first := strings.SplitN(strings.TrimPrefix(route.Pattern(), "/"), "/", 2)[0]
+		if first == "" || strings.Contains(first, "(") {
+			continue // the home page and the blogs themselves
+		}
+		t.Run(first, func(t *testing.T) {
+			is := is.New(t)
+			is.True(internal.IsReservedName(first))
+		})
+	}
+}
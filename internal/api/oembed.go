@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// The size of the embed when the consumer doesn't ask for one.
+const (
+	oembedWidth  = 480
+	oembedHeight = 360
+)
+
+// oembedResponse is a "rich" oEmbed reply, https://oembed.com
+type oembedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	AuthorURL    string `json:"author_url"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	CacheAge     int    `json:"cache_age"`
+}
+
+// parsePostURL pulls the username and filename out of a post URL on domain,
+// ok is false for anything else like a blog or a page on another site.
+func parsePostURL(raw string, domain string) (string, string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", "", false
+	}
+	if !strings.EqualFold(u.Host, domain) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) == 3 && parts[2] == "embed" {
+		parts = parts[:2]
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// oembedURL is the endpoint that describes the post at postURL.
+func oembedURL(postURL string) string {
+	return config.Current().URL("oembed") + "?url=" + url.QueryEscape(postURL) + "&format=json"
+}
+
+// oembedSize fits the default embed size within the maxwidth and maxheight
+// the consumer sent, either may be missing.
+func oembedSize(maxWidth, maxHeight string) (int, int) {
+	width, height := oembedWidth, oembedHeight
+	if w, err := strconv.Atoi(maxWidth); err == nil && w > 0 && w < width {
+		width = w
+	}
+	if h, err := strconv.Atoi(maxHeight); err == nil && h > 0 && h < height {
+		height = h
+	}
+	return width, height
+}
+
+// oembedHandler describes a post for sites that turn links into previews,
+// the preview itself is the post's /embed page in an iframe.
+func oembedHandler(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+	query := r.URL.Query()
+
+	if format := query.Get("format"); format != "" && format != "json" {
+		http.Error(w, "only the json format is supported", http.StatusNotImplemented)
+		return
+	}
+
+	cfg := config.Current()
+	username, filename, ok := parsePostURL(query.Get("url"), cfg.Domain)
+	if !ok {
+		http.Error(w, "not a post url", http.StatusNotFound)
+		return
+	}
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		http.Error(w, "blog not found", http.StatusNotFound)
+		return
+	}
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		http.Error(w, "post not found", http.StatusNotFound)
+		return
+	}
+
+	title := internal.FilenameToTitle(post.Filename, post.Title)
+	width, height := oembedSize(query.Get("maxwidth"), query.Get("maxheight"))
+	data := oembedResponse{
+		Version:      "1.0",
+		Type:         "rich",
+		Title:        title,
+		AuthorName:   username,
+		AuthorURL:    cfg.URL(username),
+		ProviderName: "lists.sh",
+		ProviderURL:  cfg.URL(),
+		HTML: fmt.Sprintf(
+			`<iframe src="%s" width="%d" height="%d" title="%s" frameborder="0"></iframe>`,
+			html.EscapeString(cfg.URL(username, post.Filename, "embed")),
+			width,
+			height,
+			html.EscapeString(title),
+		),
+		Width:    width,
+		Height:   height,
+		CacheAge: 3600,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		logger.Error(err)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/pdf"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// pdfIndent is how far list items sit in from the margin, leaving room for
+// their bullets and checkboxes.
+const pdfIndent = 20.0
+
+// pdfHandler serves the post as a PDF, for printing a recipe or attaching a
+// packing list to an email.
+func pdfHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+	locale := requestLocale(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderError(w, r, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	text, _ := expandPost(dbpool, post)
+	parsed := pkg.ParseText(text)
+	title := internal.FilenameToTitle(post.Filename, post.Title)
+	byline := fmt.Sprintf("%s %s %s",
+		post.PublishAt.Format("Mon January 2, 2006"),
+		i18n.T(locale, "on"),
+		i18n.Tf(locale, "%s's blog", post.Username),
+	)
+	doc := listPDF(title, byline, post.Description, parsed)
+	doc.Footer = config.Current().URL(post.Username, post.Filename)
+
+	var b bytes.Buffer
+	if _, err := doc.WriteTo(&b); err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", post.Filename+".pdf"))
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(b.Bytes())
+}
+
+// listPDF lays out a post the way its page shows it, minus everything that
+// only makes sense in a browser.
+func listPDF(title string, byline string, description string, parsed *pkg.ParsedText) *pdf.Document {
+	doc := pdf.New(title)
+	doc.Add(pdf.Paragraph{Text: title, Font: pdf.Bold, Size: 20})
+	doc.Add(pdf.Paragraph{Text: byline, Size: 9, Grey: true})
+	if description != "" {
+		doc.Space(6)
+		doc.Add(pdf.Paragraph{Text: description, Font: pdf.Italic})
+	}
+	doc.Space(12)
+
+	numbered := parsed.MetaData.ListType == "decimal"
+	bullet := "•"
+	if parsed.MetaData.ListType == "none" {
+		bullet = ""
+	}
+	n := 0
+	for _, item := range parsed.Items {
+		text, struck := spansText(item.Spans)
+		p := pdf.Paragraph{Text: text, Indent: pdfIndent, Struck: struck}
+		switch {
+		case item.IsHeaderOne:
+			doc.Space(10)
+			p = pdf.Paragraph{Text: text, Font: pdf.Bold, Size: 16}
+		case item.IsHeaderTwo:
+			doc.Space(8)
+			p = pdf.Paragraph{Text: text, Font: pdf.Bold, Size: 13}
+		case item.IsDivider:
+			doc.Rule()
+			continue
+		case item.IsDone:
+			p.Box, p.Struck, p.Grey = pdf.Checked, true, true
+		case item.IsTodo:
+			p.Box = pdf.Unchecked
+		case item.IsNumbered:
+			p.Marker = fmt.Sprintf("%d.", item.Number)
+		case item.IsDefinition:
+			doc.Add(pdf.Paragraph{Text: item.Term, Font: pdf.Bold, Indent: pdfIndent})
+			p.Indent += 12
+		case item.IsBlock:
+			p.Font, p.Bar = pdf.Italic, true
+		case item.IsImg:
+			p.Text, p.Grey = item.URL, true
+			if item.Value != item.URL {
+				p.Text = item.Value + " (" + item.URL + ")"
+			}
+			p.Marker = bullet
+		case item.IsText && item.Value == "":
+			doc.Space(8)
+			continue
+		default:
+			n++
+			p.Marker = bullet
+			if numbered {
+				p.Marker = fmt.Sprintf("%d.", n)
+			}
+		}
+		doc.Add(p)
+		// Links print their address, there's nothing to click on paper.
+		if item.IsURL && item.URL != text {
+			doc.Add(pdf.Paragraph{Text: item.URL, Size: 8, Indent: pdfIndent, Grey: true})
+		}
+	}
+	return doc
+}
+
+// spansText joins the spans of an item, it's struck out when all of it is.
+func spansText(spans []pkg.Span) (string, bool) {
+	var b strings.Builder
+	struck := len(spans) > 0
+	for _, span := range spans {
+		b.WriteString(span.Text)
+		struck = struck && span.Struck
+	}
+	return b.String(), struck
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/qr"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// qrScale is how many pixels wide each module of a served QR code is, big
+// enough to print without blurring.
+const qrScale = 10
+
+// qrHandler serves a QR code for the post, pointing at its short link so the
+// code stays small and easy to scan.
+func qrHandler(w http.ResponseWriter, r *http.Request) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return
+	}
+
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || !post.IsPublic() {
+		renderError(w, r, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	link := config.Current().URL(post.Username, post.Filename)
+	if short, err := dbpool.ShortLinkForPost(post.ID); err == nil {
+		link = config.Current().URL(internal.ShortLinkPath, short.Slug)
+	} else {
+		logger.Error(err)
+	}
+
+	var b bytes.Buffer
+	if err := qr.WritePNG(&b, link, qrScale); err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	_, _ = w.Write(b.Bytes())
+}
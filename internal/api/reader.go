@@ -0,0 +1,335 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"github.com/neurosnap/lists.sh/internal/login"
+	"github.com/neurosnap/lists.sh/internal/mailer"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/pkg"
+	"go.uber.org/zap"
+)
+
+// currentReader is who signed in with `ssh lists.sh login` or a link sent
+// to their email, nil when nobody is.
+func currentReader(r *http.Request) *db.Reader {
+	cookie, err := r.Cookie(login.CookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	reader, err := routeHelper.GetDB(r).ReaderForSession(cookie.Value)
+	if err != nil || (reader.User != nil && !reader.User.IsActive()) {
+		return nil
+	}
+	return reader
+}
+
+// canRead reports whether the reader may see a restricted post.  The blog
+// and its members always can, otherwise it's up to the post's visibility.
+// Readers signed in by email only see posts naming their address.
+func canRead(dbpool db.DB, logger *zap.SugaredLogger, signedIn *db.Reader, post *db.Post) bool {
+	if signedIn == nil {
+		return false
+	}
+	if signedIn.User == nil {
+		for _, email := range post.Readers {
+			if post.Visibility == pkg.VisibilityReaders && email == signedIn.Email {
+				return true
+			}
+		}
+		return false
+	}
+
+	reader := signedIn.User
+	if reader.ID == post.UserID {
+		return true
+	}
+
+	switch post.Visibility {
+	case pkg.VisibilityFollowers:
+		following, err := dbpool.FindFollowing(reader.ID)
+		if err != nil {
+			logger.Error(err)
+		}
+		for _, authorID := range following {
+			if authorID == post.UserID {
+				return true
+			}
+		}
+	case pkg.VisibilityReaders:
+		for _, name := range post.Readers {
+			if name == reader.Name {
+				return true
+			}
+		}
+	}
+
+	blogs, err := dbpool.FindBlogs(reader.ID)
+	if err != nil {
+		logger.Error(err)
+	}
+	for _, blog := range blogs {
+		if blog.UserID == post.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// readablePosts drops the posts the request's reader can't see: ones taken
+// down or not out yet, and restricted ones they weren't given.  It reports
+// whether any restricted post was kept, the page is then only theirs.
+func readablePosts(r *http.Request, posts []*db.Post) ([]*db.Post, bool) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	var reader *db.Reader
+	looked := false
+	restricted := false
+	readable := make([]*db.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.IsPublic() {
+			readable = append(readable, post)
+			continue
+		}
+		if !post.IsPublished() || !post.IsRestricted() {
+			continue
+		}
+		if !looked {
+			reader, looked = currentReader(r), true
+		}
+		if canRead(dbpool, logger, reader, post) {
+			readable = append(readable, post)
+			restricted = true
+		}
+	}
+	return readable, restricted
+}
+
+// LoginPageData is the form readers without an account sign in with.
+type LoginPageData struct {
+	Email string
+	Next  string
+	Error string
+	Sent  bool
+}
+
+// maxEmailLoginsPerHour limits the sign in emails a client can ask for, and
+// that an address can be sent, in an hour.
+const maxEmailLoginsPerHour = 5
+
+// loginLimiter remembers recent sign in emails, or other sign in attempts,
+// in memory, like likeLimiter.
+type loginLimiter struct {
+	mu     sync.Mutex
+	max    int                    // per key an hour
+	sent   map[string][]time.Time // client or address to when it asked
+	pruned time.Time
+}
+
+func newLoginLimiter(max int) *loginLimiter {
+	return &loginLimiter{max: max, sent: map[string][]time.Time{}}
+}
+
+var emailLogins = newLoginLimiter(maxEmailLoginsPerHour)
+
+// allow reports whether every key is under the hourly limit, and counts
+// another attempt against them when they are.
+func (l *loginLimiter) allow(now time.Time, keys ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) > time.Hour {
+		for key, times := range l.sent {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= time.Hour {
+				delete(l.sent, key)
+			}
+		}
+		l.pruned = now
+	}
+
+	for _, key := range keys {
+		recent := l.sent[key][:0]
+		for _, at := range l.sent[key] {
+			if now.Sub(at) < time.Hour {
+				recent = append(recent, at)
+			}
+		}
+		l.sent[key] = recent
+		if len(recent) >= l.max {
+			return false
+		}
+	}
+	for _, key := range keys {
+		l.sent[key] = append(l.sent[key], now)
+	}
+	return true
+}
+
+// emailLoginHandler shows and submits the form that mails a sign in link to
+// someone a post was shared with by email.  Whether the address was given
+// any posts isn't let on: every submission queues the same job and says a
+// link is on its way, the job finds out whether there's anything to send.
+func emailLoginHandler(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+	cfg := config.Current()
+
+	if !mailer.Enabled(cfg.Mail) {
+		renderError(w, r, http.StatusNotFound, "Signing in by email isn't set up here, run ssh lists.sh login instead.")
+		return
+	}
+
+	data := LoginPageData{}
+	if next, err := login.NextPath(r.FormValue("next"), cfg.Domain); err == nil {
+		data.Next = next
+	}
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, 4096)
+		email := strings.ToLower(strings.TrimSpace(r.FormValue("email")))
+		data.Email = email
+		if err := db.ValidateEmail(email); err != nil {
+			data.Error = err.Error()
+		} else if !emailLogins.allow(time.Now(), "client "+clientIP(r), "email "+email) {
+			renderError(w, r, http.StatusTooManyRequests, "That's a lot of sign in emails, try again in a bit.")
+			return
+		} else {
+			err = jobs.Enqueue(dbpool, emailLoginJob, emailLoginPayload{Email: email, Next: data.Next})
+			if err != nil {
+				logger.Error(err)
+				renderError(w, r, http.StatusInternalServerError, "")
+				return
+			}
+			data.Sent = true
+		}
+	}
+
+	ts, err := renderTemplate(i18n.English, []string{"login.page.tmpl"})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	err = ts.Execute(w, data)
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// emailLoginJob is the kind of job that sends a sign in email.
+const emailLoginJob = "email_login"
+
+// emailLoginPayload is an emailLoginJob's payload.
+type emailLoginPayload struct {
+	Email string `json:"email"`
+	Next  string `json:"next"`
+}
+
+// emailLoginJobHandler runs emailLoginJobs.
+func emailLoginJobHandler(dbpool db.DB) jobs.Handler {
+	return func(payload []byte) error {
+		var p emailLoginPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		return sendEmailLogin(dbpool, config.Current(), p.Email, p.Next)
+	}
+}
+
+// sendEmailLogin mails a sign in link to the address when a post is shared
+// with it, and does nothing otherwise.
+func sendEmailLogin(dbpool db.DB, cfg *config.Config, email string, next string) error {
+	shared, err := dbpool.IsReaderEmail(email)
+	if err != nil || !shared {
+		return err
+	}
+
+	token, err := login.NewToken()
+	if err != nil {
+		return err
+	}
+	err = dbpool.InsertEmailLogin(email, token, time.Now().Add(login.LinkTTL))
+	if err != nil {
+		return err
+	}
+
+	link := cfg.URL("login", token)
+	if next != "" {
+		link += "?next=" + url.QueryEscape(next)
+	}
+	body := fmt.Sprintf("Someone, hopefully you, asked to sign in to %s to read the posts shared with %s.\n\nOpen this link within %d minutes, it works once:\n%s\n\nIf it wasn't you, ignore this email.\n", cfg.Domain, email, int(login.LinkTTL.Minutes()), link)
+	return mailer.Send(cfg.Mail, email, "Sign in to "+cfg.Domain, body)
+}
+
+// loginHandler opens a link from `ssh lists.sh login` or a sign in email,
+// swapping its token for a session kept in a cookie.
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	token := routeHelper.GetField(r, 0)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	session, err := login.NewToken()
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	expires := time.Now().Add(login.SessionTTL)
+	err = dbpool.RedeemReaderLogin(token, session, expires)
+	if err != nil {
+		logger.Infof("login failed: %v", err)
+		renderError(w, r, http.StatusNotFound, "This sign in link has expired or was already used, sign in again for a new one.")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     login.CookieName,
+		Value:    session,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	next, err := login.NextPath(r.URL.Query().Get("next"), config.Current().Domain)
+	if err != nil {
+		next = "/read"
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// logoutHandler signs the browser out.
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	if cookie, err := r.Cookie(login.CookieName); err == nil && cookie.Value != "" {
+		if err := dbpool.RemoveReaderSession(cookie.Value); err != nil {
+			logger.Error(err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     login.CookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/pkg"
+	"go.uber.org/zap"
+)
+
+// readerDB knows who follows the blog and who's a member of it.
+type readerDB struct {
+	db.DB
+	followers []string
+	members   []string
+}
+
+func (d *readerDB) FindFollowing(userID string) ([]string, error) {
+	for _, id := range d.followers {
+		if id == userID {
+			return []string{"blog"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *readerDB) FindBlogs(userID string) ([]*db.Blog, error) {
+	for _, id := range d.members {
+		if id == userID {
+			return []*db.Blog{{UserID: "blog"}}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestCanRead(t *testing.T) {
+	dbpool := &readerDB{followers: []string{"u-fan"}, members: []string{"u-mia"}}
+	logger := zap.NewNop().Sugar()
+	fan := &db.Reader{User: &db.User{ID: "u-fan", Name: "fan"}}
+	mia := &db.Reader{User: &db.User{ID: "u-mia", Name: "mia"}}
+	bo := &db.Reader{User: &db.User{ID: "u-bo", Name: "bo"}}
+	aunt := &db.Reader{Email: "aunt@example.com"}
+
+	t.Run("followers", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{UserID: "blog", Visibility: pkg.VisibilityFollowers}
+		is.True(post.IsPublished() && !post.IsPublic())
+		is.True(canRead(dbpool, logger, fan, post))
+		is.True(!canRead(dbpool, logger, bo, post))
+		is.True(!canRead(dbpool, logger, nil, post))
+	})
+
+	t.Run("named readers", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{UserID: "blog", Visibility: pkg.VisibilityReaders, Readers: []string{"bo"}}
+		is.True(canRead(dbpool, logger, bo, post))
+		is.True(!canRead(dbpool, logger, fan, post))
+	})
+
+	t.Run("the blog and its members always can", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{UserID: "blog", Visibility: pkg.VisibilityReaders}
+		is.True(canRead(dbpool, logger, &db.Reader{User: &db.User{ID: "blog", Name: "team"}}, post))
+		is.True(canRead(dbpool, logger, mia, post))
+	})
+
+	t.Run("readers signed in by email", func(t *testing.T) {
+		is := is.New(t)
+		post := &db.Post{UserID: "blog", Visibility: pkg.VisibilityReaders, Readers: []string{"bo", "aunt@example.com"}}
+		is.True(canRead(dbpool, logger, aunt, post))
+		is.True(!canRead(dbpool, logger, &db.Reader{Email: "uncle@example.com"}, post))
+		is.True(!canRead(dbpool, logger, aunt, &db.Post{UserID: "blog", Visibility: pkg.VisibilityFollowers}))
+	})
+}
+
+func TestLoginLimiter(t *testing.T) {
+	is := is.New(t)
+	l := newLoginLimiter(maxEmailLoginsPerHour)
+	now := time.Now()
+
+	for i := 0; i < maxEmailLoginsPerHour; i++ {
+		is.True(l.allow(now, "client 1.2.3.4", fmt.Sprintf("email %d@example.com", i)))
+	}
+	is.True(!l.allow(now, "client 1.2.3.4", "email new@example.com"))
+	is.True(l.allow(now, "client 5.6.7.8", "email new@example.com"))
+	is.True(l.allow(now.Add(time.Hour), "client 1.2.3.4", "email new@example.com"))
+
+	// Sign ins with IndieAuth have their own limit.
+	l = newLoginLimiter(maxAuthRequestsPerHour)
+	for i := 0; i < maxAuthRequestsPerHour; i++ {
+		is.True(l.allow(now, "client 1.2.3.4"))
+	}
+	is.True(!l.allow(now, "client 1.2.3.4"))
+}
+
+// loginDB has no posts shared by email and remembers the sign in links
+// made.
+type loginDB struct {
+	db.DB
+	tokens []string
+}
+
+func (d *loginDB) IsReaderEmail(email string) (bool, error) {
+	return false, nil
+}
+
+func (d *loginDB) InsertEmailLogin(email string, token string, expiresAt time.Time) error {
+	d.tokens = append(d.tokens, token)
+	return nil
+}
+
+func TestEmailLoginJob(t *testing.T) {
+	is := is.New(t)
+	dbpool := &loginDB{}
+	run := emailLoginJobHandler(dbpool)
+
+	is.NoErr(run([]byte(`{"email":"stranger@example.com","next":"/erock/tacos"}`)))
+	is.Equal(len(dbpool.tokens), 0) // nothing shared, nothing sent
+	is.True(run([]byte(`not json`)) != nil)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+const (
+	// maxRelatedPosts is how many related posts a post page links to.
+	maxRelatedPosts = 3
+	relatedTTL      = 10 * time.Minute
+	// relatedCacheSize caps the cache, it's emptied when it fills up.
+	relatedCacheSize = 1000
+)
+
+type relatedEntry struct {
+	updatedAt time.Time
+	expires   time.Time
+	posts     []PostItemData
+}
+
+// relatedCache remembers the related posts for each post for a little while
+// so popular posts don't query for them on every view. Editing the post
+// invalidates its entry.
+type relatedCache struct {
+	mu      sync.Mutex
+	entries map[string]relatedEntry
+}
+
+var related = &relatedCache{entries: map[string]relatedEntry{}}
+
+func (c *relatedCache) get(post *db.Post, now time.Time) ([]PostItemData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[post.ID]
+	if !ok || now.After(entry.expires) || !entry.updatedAt.Equal(updatedAt(post)) {
+		return nil, false
+	}
+	return entry.posts, true
+}
+
+func (c *relatedCache) set(post *db.Post, posts []PostItemData, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= relatedCacheSize {
+		c.entries = map[string]relatedEntry{}
+	}
+	c.entries[post.ID] = relatedEntry{
+		updatedAt: updatedAt(post),
+		expires:   now.Add(relatedTTL),
+		posts:     posts,
+	}
+}
+
+func updatedAt(post *db.Post) time.Time {
+	if post.UpdatedAt == nil {
+		return time.Time{}
+	}
+	return *post.UpdatedAt
+}
+
+// findRelated returns the author's posts that share tags with post.
+func findRelated(dbpool db.DB, post *db.Post, tags []string, now time.Time) ([]PostItemData, error) {
+	if posts, ok := related.get(post, now); ok {
+		return posts, nil
+	}
+
+	found, err := dbpool.FindRelatedPosts(post, tags, now, maxRelatedPosts)
+	if err != nil {
+		return nil, err
+	}
+	posts := make([]PostItemData, 0, len(found))
+	for _, p := range found {
+		posts = append(posts, *navItem(p))
+	}
+	related.set(post, posts, now)
+	return posts, nil
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestRelatedCache(t *testing.T) {
+	now := time.Date(2022, 5, 18, 12, 0, 0, 0, time.UTC)
+	edited := now.Add(-time.Hour)
+	post := &db.Post{ID: "1", UpdatedAt: &edited}
+	posts := []PostItemData{{URL: "/erock/tacos", Title: "Tacos"}}
+
+	t.Run("hit", func(t *testing.T) {
+		is := is.New(t)
+		c := &relatedCache{entries: map[string]relatedEntry{}}
+		c.set(post, posts, now)
+		got, ok := c.get(post, now.Add(time.Minute))
+		is.True(ok)
+		is.Equal(got, posts)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		is := is.New(t)
+		c := &relatedCache{entries: map[string]relatedEntry{}}
+		c.set(post, posts, now)
+		_, ok := c.get(post, now.Add(relatedTTL+time.Second))
+		is.True(!ok)
+	})
+
+	t.Run("post edited", func(t *testing.T) {
+		is := is.New(t)
+		c := &relatedCache{entries: map[string]relatedEntry{}}
+		c.set(post, posts, now)
+		later := now.Add(time.Minute)
+		_, ok := c.get(&db.Post{ID: "1", UpdatedAt: &later}, now.Add(2*time.Minute))
+		is.True(!ok)
+	})
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"sort"
+	"sync"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+const (
+	listTemplate = "list.partial.tmpl"
+	// renderCacheSize caps the cache, it's emptied when it fills up.
+	renderCacheSize = 1000
+)
+
+// renderedPost is everything a page needs from a post's text, with the list
+// already turned into HTML.
+type renderedPost struct {
+	List      template.HTML
+	Tags      []string
+	Authors   []string
+	Changelog []ChangelogItem
+	HasEvents bool // some items are dated, see pkg.Events
+}
+
+type renderEntry struct {
+	version  string
+	userID   string
+	rendered *renderedPost
+}
+
+// renderCache remembers rendered posts so long lists aren't parsed and
+// templated again on every view. Entries are keyed by post and only used
+// while the version still matches, so editing the post, renaming its author
+// or changing the list template invalidates them.  Includes aren't looked
+// up on a hit, a change to any of the author's posts drops their entries
+// instead, see forgetRendered.
+type renderCache struct {
+	mu      sync.Mutex
+	entries map[string]renderEntry
+}
+
+var rendered = &renderCache{entries: map[string]renderEntry{}}
+
+func (c *renderCache) get(postID string, version string) (*renderedPost, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[postID]
+	if !ok || entry.version != version {
+		return nil, false
+	}
+	return entry.rendered, true
+}
+
+func (c *renderCache) set(postID string, userID string, version string, rp *renderedPost) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= renderCacheSize {
+		c.entries = map[string]renderEntry{}
+	}
+	c.entries[postID] = renderEntry{version: version, userID: userID, rendered: rp}
+}
+
+// forgetUser drops the entries of every post by the user.
+func (c *renderCache) forgetUser(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for postID, entry := range c.entries {
+		if entry.userID == userID {
+			delete(c.entries, postID)
+		}
+	}
+}
+
+// forgetRendered is subscribed to post events.  Any of the author's posts
+// could be included by the others, so a change to one drops them all; it
+// also keeps deleted posts from holding on to memory.
+func forgetRendered(event *db.PostEvent) error {
+	rendered.forgetUser(event.UserID)
+	return nil
+}
+
+// renderVersion identifies what went into rendering a post: when it was last
+// changed, the values its placeholders expand to and which list template
+// drew it. The parser doesn't need to be part of it, changing that means a
+// new binary and an empty cache.
+func renderVersion(post *db.Post, vars map[string]string, tpl []byte) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	_, _ = h.Write(tpl)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "\x00%s=%s", name, vars[name])
+	}
+	return updatedAt(post).Format("20060102150405.000000000") + " " + fmt.Sprintf("%x", h.Sum64())
+}
+
+// renderPost returns the post's list as HTML along with its tags and
+// changelog, from the cache when nothing has changed since the last time.
+func renderPost(dbpool db.DB, post *db.Post) (*renderedPost, error) {
+	tpl, err := listTemplateSet()
+	if err != nil {
+		return nil, err
+	}
+	version := renderVersion(post, expandVars(post), tpl.src)
+	if rp, ok := rendered.get(post.ID, version); ok {
+		return rp, nil
+	}
+
+	text, _ := expandPost(dbpool, post)
+	parsed := pkg.ParseText(text)
+	var b bytes.Buffer
+	err = tpl.ts.ExecuteTemplate(&b, "list", struct {
+		ListType string
+		Items    []*pkg.ListItem
+	}{parsed.MetaData.ListType, parsed.Items})
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &renderedPost{
+		List:      template.HTML(b.String()),
+		Tags:      parsed.MetaData.Tags,
+		Authors:   parsed.MetaData.Authors,
+		Changelog: changelogItems(parsed.Changelog),
+		HasEvents: len(pkg.Events(parsed.Items)) > 0,
+	}
+	rendered.set(post.ID, post.UserID, version, rp)
+	return rp, nil
+}
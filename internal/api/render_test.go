@@ -0,0 +1,99 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestRenderVersion(t *testing.T) {
+	now := time.Date(2022, 5, 18, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+	post := &db.Post{ID: "1", UpdatedAt: &now}
+	vars := map[string]string{"user.name": "erock", "site.url": "https://lists.sh"}
+	tpl := []byte(`{{define "list"}}<ul></ul>{{end}}`)
+	version := renderVersion(post, vars, tpl)
+
+	t.Run("unchanged", func(t *testing.T) {
+		is := is.New(t)
+		same := map[string]string{"site.url": "https://lists.sh", "user.name": "erock"}
+		is.Equal(renderVersion(post, same, tpl), version)
+	})
+
+	t.Run("post edited", func(t *testing.T) {
+		is := is.New(t)
+		edited := &db.Post{ID: "1", UpdatedAt: &later}
+		is.True(renderVersion(edited, vars, tpl) != version)
+	})
+
+	t.Run("author renamed", func(t *testing.T) {
+		is := is.New(t)
+		renamed := map[string]string{"user.name": "eric", "site.url": "https://lists.sh"}
+		is.True(renderVersion(post, renamed, tpl) != version)
+	})
+
+	t.Run("site moved", func(t *testing.T) {
+		is := is.New(t)
+		moved := map[string]string{"user.name": "erock", "site.url": "https://lists.example"}
+		is.True(renderVersion(post, moved, tpl) != version)
+	})
+
+	t.Run("template changed", func(t *testing.T) {
+		is := is.New(t)
+		changed := []byte(`{{define "list"}}<ol></ol>{{end}}`)
+		is.True(renderVersion(post, vars, changed) != version)
+	})
+}
+
+func TestRenderCache(t *testing.T) {
+	is := is.New(t)
+	c := &renderCache{entries: map[string]renderEntry{}}
+	rp := &renderedPost{List: "<ul></ul>"}
+	c.set("1", "erock", "v1", rp)
+	c.set("2", "erock", "v1", rp)
+	c.set("3", "other", "v1", rp)
+
+	got, ok := c.get("1", "v1")
+	is.True(ok)
+	is.Equal(got, rp)
+
+	_, ok = c.get("1", "v2") // edited since
+	is.True(!ok)
+
+	// One of erock's posts changed, it could be included by the others.
+	c.forgetUser("erock")
+	_, ok = c.get("2", "v1")
+	is.True(!ok)
+	_, ok = c.get("3", "v1")
+	is.True(ok)
+}
+
+func TestRenderPostLooksUpIncludesOnce(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2022, 5, 18, 12, 0, 0, 0, time.UTC)
+	dbpool := &includeDB{posts: map[string]*db.Post{
+		"snippet": {Filename: "snippet", Text: "- snippet"},
+	}}
+	post := &db.Post{ID: "render-includes", UserID: "includer", Filename: "tacos", Text: "{{include snippet}}\n", UpdatedAt: &now}
+	defer rendered.forgetUser("includer")
+
+	first, err := renderPost(dbpool, post)
+	is.NoErr(err)
+	is.Equal(dbpool.lookups, 1)
+	second, err := renderPost(dbpool, post)
+	is.NoErr(err)
+	is.Equal(second, first)
+	is.Equal(dbpool.lookups, 1) // a hit doesn't expand the post again
+}
+
+func TestListTemplateSet(t *testing.T) {
+	is := is.New(t)
+	first, err := listTemplateSet()
+	is.NoErr(err)
+	is.True(len(first.src) > 0)
+	second, err := listTemplateSet()
+	is.NoErr(err)
+	is.True(first.ts == second.ts) // read and parsed once
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+	"github.com/neurosnap/lists.sh/internal/share"
+)
+
+// hasShareLink reports whether the request was opened from a share link for
+// the post that's still good, see internal/share.  Posts taken down or in
+// the trash can't be opened this way, nor can posts of blogs the share flag
+// is off for.
+func hasShareLink(r *http.Request, dbpool db.DB, post *db.Post) bool {
+	token := r.URL.Query().Get(share.Param)
+	if token == "" || post.HiddenAt != nil || post.DeletedAt != nil {
+		return false
+	}
+	cfg := config.Current()
+	if share.Verify(cfg.Web.ShareSecret, post.ID, token, time.Now()) != nil {
+		return false
+	}
+	return flags.Enabled(cfg.Flags, dbpool, post.UserID, flags.Share)
+}
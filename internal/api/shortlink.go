@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// shortLinkHandler counts a click on a short link like /-/Ab3xZ and sends
+// the browser on to the post.  The redirect isn't permanent, or browsers
+// would skip the count next time.
+func shortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	slug := routeHelper.GetField(r, 0)
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	if len(slug) != internal.ShortSlugLength {
+		renderError(w, r, http.StatusNotFound, "")
+		return
+	}
+	link, err := dbpool.FindShortLink(slug)
+	if err != nil {
+		logger.Infof("short link not found: %s", slug)
+		renderError(w, r, http.StatusNotFound, "")
+		return
+	}
+	post, err := dbpool.FindPost(link.PostID)
+	if err != nil || !post.IsPublished() {
+		renderError(w, r, http.StatusNotFound, "")
+		return
+	}
+	// Only redirects that are served count.
+	if err := dbpool.ClickShortLink(slug); err != nil {
+		logger.Error(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, config.Current().URL(post.Username, post.Filename), http.StatusFound)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// sourceTypes are the media types a post URL answers with the post's
+// source instead of the page.
+var sourceTypes = []string{"text/markdown", "text/plain"}
+
+// htmlTypes are the ones that mean the page, */* included so plain curl
+// still gets what a browser does.
+var htmlTypes = []string{"text/html", "application/xhtml+xml", "text/*", "*/*"}
+
+// sourceType returns the media type to send a post's source as, or "" when
+// the Accept header prefers the page.
+func sourceType(accept string) string {
+	var best string
+	var bestQ, htmlQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		for _, t := range htmlTypes {
+			if mediaType == t && q > htmlQ {
+				htmlQ = q
+			}
+		}
+		for _, t := range sourceTypes {
+			if mediaType == t && q > bestQ {
+				best, bestQ = t, q
+			}
+		}
+	}
+	if bestQ > 0 && bestQ > htmlQ {
+		return best
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/avatar"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/export"
+	"github.com/neurosnap/lists.sh/public"
+	"go.uber.org/zap"
+)
+
+// staticAssets are the files from public the pages link to.
+var staticAssets = []string{"main.css", "favicon-16x16.png"}
+
+var linkAttr = regexp.MustCompile(`(href|src|action)="([^"]*)"`)
+
+// StaticSite draws the user's blog with the same templates as the web
+// server: index.html, a page for each public post, rss.xml and the assets.
+// Links between those files are made relative so the site works from any
+// directory, the rest of the links point back at lists.sh.
+func StaticSite(logger *zap.SugaredLogger, dbpool db.DB, user *db.User) ([]*export.File, error) {
+	posts, err := dbpool.PostsForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	posts = publicPosts(posts)
+
+	locale := db.DefaultUserSettings().Locale
+	if settings, err := dbpool.FindUserSettings(user.ID); err == nil {
+		locale = settings.Locale
+	}
+
+	cfg := config.Current()
+	local := map[string]string{
+		"/" + user.Name:                 "index.html",
+		"/" + user.Name + "/rss":        "rss.xml",
+		"/" + user.Name + "/avatar.png": "avatar.png",
+	}
+	for _, asset := range staticAssets {
+		local["/"+asset] = asset
+	}
+	var pages []*db.Post
+	for _, post := range posts {
+		if strings.HasPrefix(post.Filename, "_") {
+			continue
+		}
+		local["/"+user.Name+"/"+post.Filename] = post.Filename + ".html"
+		pages = append(pages, post)
+	}
+
+	now := time.Now()
+	var files []*export.File
+	add := func(name string, data []byte, modTime time.Time) {
+		files = append(files, &export.File{Name: name, Data: data, ModTime: modTime})
+	}
+
+	ts, blog, err := blogPage(dbpool, logger, user, posts, locale)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := ts.Execute(&b, blog); err != nil {
+		return nil, err
+	}
+	add("index.html", relink(b.Bytes(), cfg.URL(user.Name), local), now)
+
+	for _, post := range pages {
+		ts, data, err := postPage(dbpool, logger, user, post, locale)
+		if err != nil {
+			return nil, err
+		}
+		b.Reset()
+		if err := ts.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		add(post.Filename+".html", relink(b.Bytes(), cfg.URL(user.Name, post.Filename), local), updatedAt(post))
+	}
+
+	rss, err := blogFeed(dbpool, logger, user, posts).ToAtom()
+	if err != nil {
+		return nil, err
+	}
+	add("rss.xml", []byte(rss), now)
+
+	for _, asset := range staticAssets {
+		data, err := fs.ReadFile(public.FS, asset)
+		if err != nil {
+			return nil, err
+		}
+		add(asset, data, now)
+	}
+
+	b.Reset()
+	if err := avatar.WritePNG(&b, user.ID); err != nil {
+		return nil, err
+	}
+	add("avatar.png", append([]byte(nil), b.Bytes()...), now)
+	return files, nil
+}
+
+// relink rewrites the links in a page drawn for pageURL: the ones to a file
+// in local become relative, the rest of the site's become absolute and
+// links elsewhere are left alone.
+func relink(page []byte, pageURL string, local map[string]string) []byte {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return page
+	}
+	return linkAttr.ReplaceAllFunc(page, func(m []byte) []byte {
+		parts := linkAttr.FindSubmatch(m)
+		u, err := base.Parse(html.UnescapeString(string(parts[2])))
+		if err != nil || u.Host != base.Host || (u.Scheme != "http" && u.Scheme != "https") {
+			return m
+		}
+
+		link := u.String()
+		if name, ok := local[strings.TrimSuffix(u.Path, "/")]; ok && u.RawQuery == "" {
+			link = name
+			if u.Fragment != "" {
+				link += "#" + u.Fragment
+			}
+		}
+		return []byte(string(parts[1]) + `="` + template.HTMLEscapeString(link) + `"`)
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRelink(t *testing.T) {
+	local := map[string]string{
+		"/erock":       "index.html",
+		"/erock/rss":   "rss.xml",
+		"/erock/tacos": "tacos.html",
+		"/main.css":    "main.css",
+	}
+	relinked := func(page string, pageURL string) string {
+		return string(relink([]byte(page), pageURL, local))
+	}
+
+	t.Run("local files become relative", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(relinked(`<a href="/erock/tacos">`, "https://lists.sh/erock"), `<a href="tacos.html">`)
+		is.Equal(relinked(`<a href="erock/rss">`, "https://lists.sh/erock"), `<a href="rss.xml">`)
+		is.Equal(relinked(`<link href="/main.css" />`, "https://lists.sh/erock/tacos"), `<link href="main.css" />`)
+		is.Equal(relinked(`<a href="https://lists.sh/erock">`, "https://lists.sh/erock/tacos"), `<a href="index.html">`)
+		is.Equal(relinked(`<a href="/erock/tacos#salsa">`, "https://lists.sh/erock"), `<a href="tacos.html#salsa">`)
+	})
+
+	t.Run("the rest of the site becomes absolute", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(relinked(`<a href="/">`, "https://lists.sh/erock"), `<a href="https://lists.sh/">`)
+		is.Equal(
+			relinked(`<form action="/erock/tacos/like">`, "https://lists.sh/erock/tacos"),
+			`<form action="https://lists.sh/erock/tacos/like">`,
+		)
+		is.Equal(
+			relinked(`<a href="/oembed?url=x&amp;format=json">`, "https://lists.sh/erock/tacos"),
+			`<a href="https://lists.sh/oembed?url=x&amp;format=json">`,
+		)
+	})
+
+	t.Run("other sites are left alone", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(relinked(`<a href="https://erock.io">`, "https://lists.sh/erock"), `<a href="https://erock.io">`)
+		is.Equal(relinked(`<a href="mailto:me@erock.io">`, "https://lists.sh/erock"), `<a href="mailto:me@erock.io">`)
+	})
+}
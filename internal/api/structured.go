@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// The types of the items in a list's JSON and CSV.
+const (
+	itemText       = "text"
+	itemTodo       = "todo"
+	itemNumbered   = "numbered"
+	itemDefinition = "definition"
+	itemLink       = "link"
+	itemImage      = "image"
+	itemQuote      = "quote"
+	itemHeader     = "header"
+	itemSubheader  = "subheader"
+	itemDivider    = "divider"
+)
+
+// ListItemData is an item of a list for scripts and spreadsheets.
+type ListItemData struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	URL     string `json:"url,omitempty"`
+	Checked *bool  `json:"checked,omitempty"` // only set on todos
+}
+
+// ListData is a post as structured data, served at /username/slug.json.
+type ListData struct {
+	Title       string          `json:"title"`
+	URL         string          `json:"url"`
+	Username    string          `json:"username"`
+	Description string          `json:"description,omitempty"`
+	PublishAt   *time.Time      `json:"publish_at"`
+	Tags        []string        `json:"tags"`
+	Items       []*ListItemData `json:"items"`
+}
+
+// listItems turns parsed items into data, dropping blank lines.  Struck out
+// text is kept, only the markup goes.
+func listItems(items []*pkg.ListItem) []*ListItemData {
+	data := []*ListItemData{}
+	for _, li := range items {
+		text, _ := spansText(li.Spans)
+		item := &ListItemData{Type: itemText, Text: text}
+		switch {
+		case li.IsHeaderOne:
+			item.Type = itemHeader
+		case li.IsHeaderTwo:
+			item.Type = itemSubheader
+		case li.IsDivider:
+			item.Type = itemDivider
+		case li.IsTodo || li.IsDone:
+			checked := li.IsDone
+			item.Type, item.Checked = itemTodo, &checked
+		case li.IsNumbered:
+			item.Type = itemNumbered
+		case li.IsDefinition:
+			// The term and its definition read back the way they were
+			// written.
+			item.Type, item.Text = itemDefinition, li.Term+": "+text
+		case li.IsURL:
+			item.Type, item.URL = itemLink, li.URL
+		case li.IsImg:
+			item.Type, item.Text, item.URL = itemImage, li.Value, li.URL
+		case li.IsBlock:
+			item.Type = itemQuote
+		case li.Value == "":
+			continue
+		}
+		data = append(data, item)
+	}
+	return data
+}
+
+// listData loads the post and its items for the .json and .csv routes,
+// writing the error page when there's nothing to serve.  Posts named like
+// slug.json from before the extensions were reserved keep their page.
+func listData(w http.ResponseWriter, r *http.Request) (*ListData, bool) {
+	username := routeHelper.GetField(r, 0)
+	filename := routeHelper.GetField(r, 1)
+	dbpool := routeHelper.GetDB(r)
+
+	user, err := dbpool.UserForName(username)
+	if err != nil || !user.IsActive() {
+		renderError(w, r, http.StatusNotFound, "This blog doesn't exist.")
+		return nil, false
+	}
+
+	if _, err := dbpool.FindPostWithFilename(filename, user.ID); err == nil {
+		postHandler(w, r)
+		return nil, false
+	}
+	post, err := dbpool.FindPostWithFilename(strings.TrimSuffix(filename, path.Ext(filename)), user.ID)
+	if err != nil || !post.IsPublic() {
+		renderError(w, r, http.StatusNotFound, "Post not found")
+		return nil, false
+	}
+
+	text, _ := expandPost(dbpool, post)
+	parsed := pkg.ParseText(text)
+	tags := parsed.MetaData.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &ListData{
+		Title:       internal.FilenameToTitle(post.Filename, post.Title),
+		URL:         config.Current().URL(post.Username, post.Filename),
+		Username:    post.Username,
+		Description: post.Description,
+		PublishAt:   post.PublishAt,
+		Tags:        tags,
+		Items:       listItems(parsed.Items),
+	}, true
+}
+
+// listJSONHandler serves the post's items as JSON.
+func listJSONHandler(w http.ResponseWriter, r *http.Request) {
+	data, ok := listData(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	writeJSON(w, r, http.StatusOK, data)
+}
+
+// listCSVHandler serves the post's items as a spreadsheet, one row each
+// under a header row.
+func listCSVHandler(w http.ResponseWriter, r *http.Request) {
+	data, ok := listData(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", routeHelper.GetField(r, 1)))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"type", "text", "url", "checked"})
+	for _, item := range data.Items {
+		checked := ""
+		if item.Checked != nil {
+			checked = strconv.FormatBool(*item.Checked)
+		}
+		_ = cw.Write([]string{item.Type, item.Text, item.URL, checked})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		routeHelper.GetLogger(r).Error(err)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/neurosnap/lists.sh/html"
+	"github.com/neurosnap/lists.sh/internal/config"
+)
+
+// overlayFS opens files from dir when it has them and from fallback
+// otherwise.
+type overlayFS struct {
+	dir      fs.FS
+	fallback fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.dir.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	return o.fallback.Open(name)
+}
+
+// templateFS is the built in templates with the operator's own on top.
+func templateFS() fs.FS {
+	dir := config.Current().Web.TemplatesDir
+	if dir == "" {
+		return html.FS
+	}
+	return overlayFS{dir: os.DirFS(dir), fallback: html.FS}
+}
+
+// cachedTemplate is a parsed template set, with its source when something
+// needs to know which version it is.
+type cachedTemplate struct {
+	ts  *template.Template
+	src []byte
+}
+
+// templateCache keeps parsed templates for the life of the process, keyed
+// by locale and the files they're made of.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedTemplate
+}
+
+var parsedTemplates = &templateCache{entries: map[string]cachedTemplate{}}
+
+func (c *templateCache) get(key string) (cachedTemplate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ct, ok := c.entries[key]
+	return ct, ok
+}
+
+func (c *templateCache) set(key string, ct cachedTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ct
+}
+
+// listTemplateSet returns the template posts' lists are drawn with and its
+// source, read once like the page templates unless they're reloaded on
+// every request.
+func listTemplateSet() (cachedTemplate, error) {
+	if ct, ok := parsedTemplates.get(listTemplate); ok && !config.Current().Web.ReloadTemplates {
+		return ct, nil
+	}
+
+	src, err := fs.ReadFile(templateFS(), listTemplate)
+	if err != nil {
+		return cachedTemplate{}, err
+	}
+	ts, err := template.New("list").Parse(string(src))
+	if err != nil {
+		return cachedTemplate{}, err
+	}
+	ct := cachedTemplate{ts: ts, src: src}
+	parsedTemplates.set(listTemplate, ct)
+	return ct, nil
+}
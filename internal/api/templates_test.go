@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestOverlayFS(t *testing.T) {
+	is := is.New(t)
+	fsys := overlayFS{
+		dir: fstest.MapFS{"post.page.tmpl": {Data: []byte("custom")}},
+		fallback: fstest.MapFS{
+			"post.page.tmpl": {Data: []byte("built in")},
+			"blog.page.tmpl": {Data: []byte("built in")},
+		},
+	}
+
+	b, err := fs.ReadFile(fsys, "post.page.tmpl")
+	is.NoErr(err)
+	is.Equal(string(b), "custom")
+
+	b, err = fs.ReadFile(fsys, "blog.page.tmpl")
+	is.NoErr(err)
+	is.Equal(string(b), "built in")
+
+	_, err = fs.ReadFile(fsys, "nope.tmpl")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/feeds"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	routeHelper "github.com/neurosnap/lists.sh/internal/router"
+)
+
+// maxTopicLength keeps topic URLs to something a tag could reasonably be.
+const maxTopicLength = 64
+
+type TopicPageData struct {
+	Tag      string
+	RSSURL   string
+	NextPage string
+	PrevPage string
+	Posts    []PostItemData
+}
+
+// topicTag reads the tag from a topic URL the way the parser reads the
+// `=: tags` line, ok is false when it could never match a post.
+func topicTag(raw string) (string, bool) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if tag == "" || strings.Contains(tag, ",") || utf8.RuneCountInString(tag) > maxTopicLength {
+		return "", false
+	}
+	return tag, true
+}
+
+// topicURL links to a page of the topic.
+func topicURL(tag string, page int) string {
+	u := "/topics/" + url.PathEscape(tag)
+	if page > 0 {
+		u += fmt.Sprintf("?page=%d", page)
+	}
+	return u
+}
+
+// discoverItems lists posts from across the site for the discovery pages.
+func discoverItems(posts []*db.Post) []PostItemData {
+	var items []PostItemData
+	for _, post := range posts {
+		items = append(items, PostItemData{
+			URL:          fmt.Sprintf("/%s/%s", post.Username, post.Filename),
+			Title:        internal.FilenameToTitle(post.Filename, post.Title),
+			Description:  post.Description,
+			Username:     post.Username,
+			PublishAt:    post.PublishAt.Format("02 Jan, 2006"),
+			PublishAtISO: post.PublishAt.Format(time.RFC3339),
+		})
+	}
+	return items
+}
+
+// topicHandler lists everyone's posts with a tag, newest first.
+func topicHandler(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	tag, ok := topicTag(routeHelper.GetField(r, 0))
+	if !ok {
+		renderError(w, r, http.StatusNotFound, "")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pager, err := dbpool.FindTagPosts(tag, &db.Pager{Limit: 20, Offset: page})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	if len(pager.Data) == 0 {
+		renderError(w, r, http.StatusNotFound, "Nobody has published a post with this tag yet.")
+		return
+	}
+
+	data := TopicPageData{
+		Tag:    tag,
+		RSSURL: topicURL(tag, 0) + "/rss",
+		Posts:  discoverItems(pager.Data),
+	}
+	if page < pager.Total-1 {
+		data.NextPage = topicURL(tag, page+1)
+	}
+	if page > 0 {
+		data.PrevPage = topicURL(tag, page-1)
+	}
+
+	ts, err := renderTemplate(requestLocale(r), []string{"topic.page.tmpl"})
+	if err != nil {
+		logger.Error(err)
+		renderError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	err = ts.Execute(w, data)
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// rssTopicHandler is the atom feed of a topic.
+func rssTopicHandler(w http.ResponseWriter, r *http.Request) {
+	dbpool := routeHelper.GetDB(r)
+	logger := routeHelper.GetLogger(r)
+
+	tag, ok := topicTag(routeHelper.GetField(r, 0))
+	if !ok {
+		http.Error(w, "topic not found", http.StatusNotFound)
+		return
+	}
+
+	pager, err := dbpool.FindTagPosts(tag, &db.Pager{Limit: 50, Offset: 0})
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed := &feeds.Feed{
+		Title:       "#" + tag + " on lists.sh",
+		Link:        &feeds.Link{Href: config.Current().URL("topics", url.PathEscape(tag))},
+		Description: "lists.sh latest posts tagged " + tag,
+		Author:      &feeds.Author{Name: "lists.sh"},
+		Created:     time.Now(),
+	}
+	writePostsFeed(w, r, feed, pager.Data)
+}
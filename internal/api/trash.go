@@ -0,0 +1,42 @@
+package api
+
+import (
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"go.uber.org/zap"
+)
+
+// purgeTrashJob is the kind of job that removes posts deleted more than
+// db.TrashRetention ago, everyone's at once.
+const purgeTrashJob = "purge_trash"
+
+// purgeTrashInterval is how often the web server queues a purgeTrashJob.
+const purgeTrashInterval = time.Hour
+
+// purgeTrashJobHandler empties the trash, the job has no payload.
+func purgeTrashJobHandler(dbpool db.DB) jobs.Handler {
+	return func(payload []byte) error {
+		return dbpool.PurgeDeletedPosts(time.Now().Add(-db.TrashRetention))
+	}
+}
+
+// queuePurges queues a purgeTrashJob every purgeTrashInterval until done is
+// closed.
+func queuePurges(dbpool db.DB, logger *zap.SugaredLogger, done <-chan struct{}) {
+	ticker := time.NewTicker(purgeTrashInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := jobs.Enqueue(dbpool, purgeTrashJob, struct{}{}); err != nil {
+			logger.Errorf("queueing the trash purge failed: %v", err)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
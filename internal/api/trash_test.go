@@ -0,0 +1,28 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// purgeDB remembers when the trash was last emptied up to.
+type purgeDB struct {
+	db.DB
+	before time.Time
+}
+
+func (d *purgeDB) PurgeDeletedPosts(before time.Time) error {
+	d.before = before
+	return nil
+}
+
+func TestPurgeTrashJob(t *testing.T) {
+	is := is.New(t)
+	dbpool := &purgeDB{}
+	is.NoErr(purgeTrashJobHandler(dbpool)([]byte(`{}`)))
+	is.True(time.Since(dbpool.before) >= db.TrashRetention)
+	is.True(time.Since(dbpool.before) < db.TrashRetention+time.Minute)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"go.uber.org/zap"
+)
+
+// trendingRefresh is how often the trending ranking is recomputed.
+const trendingRefresh = 10 * time.Minute
+
+// Sorts for the discovery page.
+const (
+	sortNewest   = "newest"
+	sortTrending = "trending"
+	sortPopular  = "popular"
+)
+
+var readSorts = []string{sortNewest, sortTrending, sortPopular}
+
+// readSort returns the sort asked for, newest unless it's one we know.
+func readSort(sort string) string {
+	for _, s := range readSorts {
+		if s == sort {
+			return s
+		}
+	}
+	return sortNewest
+}
+
+// readURL links to a page of the discovery feed, leaving out the defaults.
+func readURL(sort string, page int) string {
+	url := "/read"
+	sep := "?"
+	if sort != sortNewest {
+		url += sep + "sort=" + sort
+		sep = "&"
+	}
+	if page > 0 {
+		url += fmt.Sprintf("%spage=%d", sep, page)
+	}
+	return url
+}
+
+// findReadPosts pages through the discovery feed in the given sort.
+func findReadPosts(dbpool db.DB, sort string, pager *db.Pager) (*db.Paginate[*db.Post], error) {
+	switch sort {
+	case sortTrending:
+		return dbpool.FindTrendingPosts(pager)
+	case sortPopular:
+		return dbpool.FindPopularPosts(pager)
+	}
+	return dbpool.FindAllPosts(pager)
+}
+
+// refreshTrending recomputes the trending ranking every trendingRefresh
+// until done is closed.
+func refreshTrending(dbpool db.DB, logger *zap.SugaredLogger, done <-chan struct{}) {
+	ticker := time.NewTicker(trendingRefresh)
+	defer ticker.Stop()
+
+	for {
+		if err := dbpool.RefreshTrending(); err != nil {
+			logger.Errorf("trending refresh failed: %v", err)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package avatar draws each user an identicon: a symmetric pattern of
+// squares in a color of its own, picked from a hash of the user's id so it
+// stays the same across renames and never needs storing.
+package avatar
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+const (
+	// grid is how many squares wide and tall the pattern is.
+	grid = 5
+	// cell is how many pixels wide each square is.
+	cell = 20
+	// margin is the blank border around the pattern.
+	margin = 10
+	// Size is how many pixels wide and tall the avatar is.
+	Size = grid*cell + 2*margin
+)
+
+var background = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+// Identicon draws the avatar for seed, a user's id.
+func Identicon(seed string) image.Image {
+	sum := sha256.Sum256([]byte(seed))
+	hue := float64(int(sum[0])<<8|int(sum[1])) / 65536 * 360
+	fg := fromHSL(hue, 0.55, 0.5)
+
+	img := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+	for row := 0; row < grid; row++ {
+		// Only the left half and the middle column come from the hash,
+		// the right half mirrors them.
+		for col := 0; col < (grid+1)/2; col++ {
+			if sum[2+row*grid+col]&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, grid - 1 - col} {
+				r := image.Rect(margin+c*cell, margin+row*cell, margin+(c+1)*cell, margin+(row+1)*cell)
+				draw.Draw(img, r, &image.Uniform{fg}, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the avatar for seed as a PNG.
+func WritePNG(w io.Writer, seed string) error {
+	return png.Encode(w, Identicon(seed))
+}
+
+// fromHSL converts a hue in degrees, saturation and lightness to a color.
+func fromHSL(h float64, s float64, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,50 @@
+package avatar
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestIdenticon(t *testing.T) {
+	t.Run("same seed same avatar", func(t *testing.T) {
+		is := is.New(t)
+		var a, b bytes.Buffer
+		is.NoErr(WritePNG(&a, "user-1"))
+		is.NoErr(WritePNG(&b, "user-1"))
+		is.Equal(a.Bytes(), b.Bytes())
+
+		var c bytes.Buffer
+		is.NoErr(WritePNG(&c, "user-2"))
+		is.True(!bytes.Equal(a.Bytes(), c.Bytes()))
+	})
+
+	t.Run("mirrored", func(t *testing.T) {
+		is := is.New(t)
+		img := Identicon("user-1")
+		for y := 0; y < Size; y++ {
+			for x := 0; x < Size; x++ {
+				is.Equal(img.At(x, y), img.At(Size-1-x, y))
+			}
+		}
+	})
+
+	t.Run("decodes", func(t *testing.T) {
+		is := is.New(t)
+		var b bytes.Buffer
+		is.NoErr(WritePNG(&b, "user-1"))
+		img, err := png.Decode(&b)
+		is.NoErr(err)
+		is.Equal(img.Bounds().Dx(), Size)
+		is.Equal(img.Bounds().Dy(), Size)
+	})
+}
+
+func TestFromHSL(t *testing.T) {
+	is := is.New(t)
+	is.Equal(fromHSL(0, 1, 0.5), fromHSL(360-1e-9, 1, 0.5))
+	c := fromHSL(120, 1, 0.5)
+	is.Equal([]uint8{c.R, c.G, c.B}, []uint8{0, 255, 0})
+}
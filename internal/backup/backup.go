@@ -0,0 +1,184 @@
+// Package backup periodically uploads a snapshot of the database to
+// S3-compatible object storage and can restore from those snapshots.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"go.uber.org/zap"
+)
+
+const keyPrefix = "lists-backup-"
+
+// maxSnapshotSize caps how much of a backup is read on restore, compressed
+// or not, so a corrupt or hostile object can't exhaust memory.
+var maxSnapshotSize int64 = 1 << 30
+
+// Config describes where backups are stored and how many are kept.
+type Config struct {
+	Interval  time.Duration
+	Retention int
+	Store     *S3
+}
+
+// NewConfig builds the backup configuration from the backup section of the
+// app config.
+func NewConfig(cfg config.BackupConfig) (*Config, error) {
+	store := &S3{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+	}
+	if store.Bucket == "" || store.AccessKey == "" || store.SecretKey == "" {
+		return nil, fmt.Errorf("LISTS_BACKUP_S3_BUCKET, LISTS_BACKUP_S3_ACCESS_KEY, and LISTS_BACKUP_S3_SECRET_KEY are required")
+	}
+
+	return &Config{
+		Interval:  cfg.Interval,
+		Retention: cfg.Retention,
+		Store:     store,
+	}, nil
+}
+
+// Backup uploads a single compressed snapshot and prunes old ones.
+func Backup(dbpool db.DB, cfg *Config) (string, error) {
+	snapshot, err := dbpool.Snapshot()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	err = json.NewEncoder(gw).Encode(snapshot)
+	if err != nil {
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s%s.json.gz", keyPrefix, snapshot.CreatedAt.Format("20060102T150405Z"))
+	err = cfg.Store.Put(key, buf.Bytes(), "application/gzip")
+	if err != nil {
+		return "", err
+	}
+
+	return key, Prune(cfg)
+}
+
+// List returns the stored backups, newest first.
+func List(cfg *Config) ([]Object, error) {
+	objects, err := cfg.Store.List(keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys embed the timestamp so sorting by name sorts by age
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key > objects[j].Key
+	})
+	return objects, nil
+}
+
+// Prune removes every backup beyond the retention count, a retention of zero
+// keeps them all.
+func Prune(cfg *Config) error {
+	if cfg.Retention == 0 {
+		return nil
+	}
+
+	objects, err := List(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) <= cfg.Retention {
+		return nil
+	}
+
+	for _, obj := range objects[cfg.Retention:] {
+		err := cfg.Store.Delete(obj.Key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore downloads a backup (the latest when key is empty) and upserts it
+// into the database.  It merges rather than replaces: rows that changed since
+// the backup are put back the way they were, but rows created since are left
+// alone.
+func Restore(dbpool db.DB, cfg *Config, key string) (*db.Snapshot, error) {
+	if key == "" {
+		objects, err := List(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("no backups found")
+		}
+		key = objects[0].Key
+	}
+
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, fmt.Errorf("%s is not a lists.sh backup", key)
+	}
+
+	b, err := cfg.Store.Get(key, maxSnapshotSize)
+	if err != nil {
+		return nil, err
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(io.LimitReader(gr, maxSnapshotSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > maxSnapshotSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes uncompressed", key, maxSnapshotSize)
+	}
+
+	snapshot := &db.Snapshot{}
+	err = json.Unmarshal(raw, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, dbpool.RestoreSnapshot(snapshot)
+}
+
+// Run takes a backup every interval until done is closed.
+func Run(dbpool db.DB, cfg *Config, logger *zap.SugaredLogger, done <-chan struct{}) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		key, err := Backup(dbpool, cfg)
+		if err != nil {
+			logger.Errorf("backup failed: %v", err)
+		} else {
+			logger.Infof("backup uploaded: %s", key)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package bodies keeps the text of long posts out of the posts table, on
+// disk or in S3-compatible object storage, so rows and database backups
+// stay small.  Every save writes a fresh key and nothing is written to a
+// key twice, the old text is deleted once the row points at the new one.
+package bodies
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/neurosnap/lists.sh/internal/backup"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+)
+
+// Store keeps post text by key.
+type Store interface {
+	Put(key string, text string) error
+	Get(key string) (string, error)
+	Delete(key string) error
+}
+
+// cacheBytes is how much text New keeps in memory so pages listing long
+// posts don't fetch them every time.
+const cacheBytes = 32 * 1024 * 1024
+
+// maxObjectBytes is the most read back for a single text, well over the
+// largest post anyone can save.
+const maxObjectBytes = 16 * 1024 * 1024
+
+// New returns the store set up in cfg, nil when text stays in the database.
+func New(cfg config.BodiesConfig) (Store, error) {
+	switch cfg.Store {
+	case config.BodiesDisk:
+		return NewCache(&Disk{Dir: cfg.Dir}, cacheBytes), nil
+	case config.BodiesS3:
+		return NewCache(&Object{S3: &backup.S3{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}}, cacheBytes), nil
+	case config.BodiesDB, "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown body store %q", cfg.Store)
+}
+
+// DeleteJob is the kind of job that deletes text no post points at anymore,
+// queued by the database and run by the web server.
+const DeleteJob = "delete_body"
+
+// DeletePayload is a DeleteJob's payload.
+type DeletePayload struct {
+	Key string `json:"key"`
+}
+
+// DeleteHandler runs DeleteJobs against the store.
+func DeleteHandler(store Store) jobs.Handler {
+	return func(payload []byte) error {
+		var p DeletePayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		if store == nil {
+			return fmt.Errorf("no body store is set up to delete %s from", p.Key)
+		}
+		return store.Delete(p.Key)
+	}
+}
+
+// NewKey makes the key a post's text is saved under.
+func NewKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "posts/" + hex.EncodeToString(b), nil
+}
+
+// Disk keeps text in files under Dir.
+type Disk struct {
+	Dir string
+}
+
+func (d *Disk) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("invalid body key %q", key)
+	}
+	return filepath.Join(d.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the text to a temporary file first so a crash can't leave half
+// of it behind.
+func (d *Disk) Put(key string, text string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (d *Disk) Get(key string) (string, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	return string(b), err
+}
+
+func (d *Disk) Delete(key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Object keeps text in an S3 bucket.
+type Object struct {
+	S3 *backup.S3
+}
+
+func (o *Object) Put(key string, text string) error {
+	return o.S3.Put(key, []byte(text), "text/plain; charset=utf-8")
+}
+
+func (o *Object) Get(key string) (string, error) {
+	b, err := o.S3.Get(key, maxObjectBytes)
+	return string(b), err
+}
+
+func (o *Object) Delete(key string) error {
+	return o.S3.Delete(key)
+}
+
+// Cache keeps recently read text in memory in front of another store.  Keys
+// are never rewritten, so what's cached can't go stale.
+type Cache struct {
+	store Store
+	max   int
+
+	mu    sync.Mutex
+	texts map[string]string
+	order []string // oldest first
+	size  int
+}
+
+// NewCache holds up to max bytes of text from store.
+func NewCache(store Store, max int) *Cache {
+	return &Cache{store: store, max: max, texts: map[string]string{}}
+}
+
+func (c *Cache) Put(key string, text string) error {
+	err := c.store.Put(key, text)
+	if err == nil {
+		c.add(key, text)
+	}
+	return err
+}
+
+func (c *Cache) Get(key string) (string, error) {
+	c.mu.Lock()
+	text, ok := c.texts[key]
+	c.mu.Unlock()
+	if ok {
+		return text, nil
+	}
+
+	text, err := c.store.Get(key)
+	if err != nil {
+		return "", err
+	}
+	c.add(key, text)
+	return text, nil
+}
+
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	if text, ok := c.texts[key]; ok {
+		delete(c.texts, key)
+		c.size -= len(text)
+		for i, k := range c.order {
+			if k == key {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+	return c.store.Delete(key)
+}
+
+// add caches the text, dropping the oldest entries to make room.
+func (c *Cache) add(key string, text string) {
+	if len(text) > c.max {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.texts[key]; ok {
+		return
+	}
+	for c.size+len(text) > c.max && len(c.order) > 0 {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		c.size -= len(c.texts[oldest])
+		delete(c.texts, oldest)
+	}
+	c.texts[key] = text
+	c.order = append(c.order, key)
+	c.size += len(text)
+}
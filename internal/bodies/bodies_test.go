@@ -0,0 +1,88 @@
+package bodies
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+)
+
+func TestDisk(t *testing.T) {
+	is := is.New(t)
+	store := &Disk{Dir: t.TempDir()}
+	key, err := NewKey()
+	is.NoErr(err)
+	is.True(strings.HasPrefix(key, "posts/"))
+
+	is.NoErr(store.Put(key, "- one\n- two"))
+	text, err := store.Get(key)
+	is.NoErr(err)
+	is.Equal(text, "- one\n- two")
+
+	is.NoErr(store.Delete(key))
+	_, err = store.Get(key)
+	is.True(errors.Is(err, os.ErrNotExist))
+	is.NoErr(store.Delete(key))
+
+	is.True(store.Put("../escape", "x") != nil)
+}
+
+// countingStore counts the reads that get past the cache.
+type countingStore struct {
+	texts map[string]string
+	gets  int
+}
+
+func (s *countingStore) Put(key string, text string) error {
+	s.texts[key] = text
+	return nil
+}
+
+func (s *countingStore) Get(key string) (string, error) {
+	s.gets++
+	text, ok := s.texts[key]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return text, nil
+}
+
+func (s *countingStore) Delete(key string) error {
+	delete(s.texts, key)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	is := is.New(t)
+	inner := &countingStore{texts: map[string]string{"a": "aaaa", "b": "bbbb"}}
+	cache := NewCache(inner, 6)
+
+	_, _ = cache.Get("a")
+	text, err := cache.Get("a")
+	is.NoErr(err)
+	is.Equal(text, "aaaa")
+	is.Equal(inner.gets, 1)
+
+	// b doesn't fit next to a, so a is dropped.
+	_, _ = cache.Get("b")
+	_, _ = cache.Get("a")
+	is.Equal(inner.gets, 3)
+
+	is.NoErr(cache.Delete("a"))
+	_, err = cache.Get("a")
+	is.True(err != nil)
+}
+
+func TestNew(t *testing.T) {
+	is := is.New(t)
+	store, err := New(config.BodiesConfig{Store: config.BodiesDB})
+	is.NoErr(err)
+	is.True(store == nil)
+
+	store, err = New(config.BodiesConfig{Store: config.BodiesDisk, Dir: t.TempDir()})
+	is.NoErr(err)
+	is.True(store != nil)
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charsets uploads are transcoded from.
+const (
+	CharsetUTF8    = "UTF-8"
+	CharsetUTF16LE = "UTF-16LE"
+	CharsetUTF16BE = "UTF-16BE"
+	CharsetLatin1  = "Latin-1"
+)
+
+// sniffLen is how much of a file is looked at to guess what it is.
+const sniffLen = 1024
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// DecodeText returns a reader with the upload in r as UTF-8 and the charset
+// it was written in. UTF-8 with or without a byte order mark, UTF-16 and
+// Latin-1 are understood, anything else gets an error naming what the file
+// looks like instead.
+func DecodeText(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	prefix, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	charset, bom, err := DetectCharset(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	_, _ = br.Discard(bom)
+
+	switch charset {
+	case CharsetUTF16LE:
+		return &utf16Reader{r: br, order: binary.LittleEndian}, charset, nil
+	case CharsetUTF16BE:
+		return &utf16Reader{r: br, order: binary.BigEndian}, charset, nil
+	case CharsetLatin1:
+		return &latin1Reader{r: br}, charset, nil
+	}
+	return br, charset, nil
+}
+
+// DecodeString is DecodeText for text already in memory.
+func DecodeString(text string) (string, string, error) {
+	r, charset, err := DecodeText(strings.NewReader(text))
+	if err != nil {
+		return "", "", err
+	}
+	if charset == CharsetUTF8 {
+		return strings.TrimPrefix(text, string(bomUTF8)), charset, nil
+	}
+	b, err := io.ReadAll(r)
+	return string(b), charset, err
+}
+
+// DetectCharset guesses the charset from the start of a file, returning
+// the length of its byte order mark to skip.
+func DetectCharset(prefix []byte) (string, int, error) {
+	switch {
+	case bytes.HasPrefix(prefix, bomUTF8):
+		return CharsetUTF8, len(bomUTF8), nil
+	case bytes.HasPrefix(prefix, bomUTF16LE):
+		return CharsetUTF16LE, len(bomUTF16LE), nil
+	case bytes.HasPrefix(prefix, bomUTF16BE):
+		return CharsetUTF16BE, len(bomUTF16BE), nil
+	}
+
+	// Without a byte order mark UTF-16 gives itself away with every other
+	// byte being zero for plain ASCII text.
+	if len(prefix) >= 4 {
+		var even, odd int
+		for i := 0; i+1 < len(prefix); i += 2 {
+			if prefix[i] == 0 {
+				even++
+			}
+			if prefix[i+1] == 0 {
+				odd++
+			}
+		}
+		pairs := len(prefix) / 2
+		switch {
+		case odd*10 > pairs*4 && even*10 < pairs:
+			return CharsetUTF16LE, 0, nil
+		case even*10 > pairs*4 && odd*10 < pairs:
+			return CharsetUTF16BE, 0, nil
+		}
+	}
+
+	for _, c := range prefix {
+		if c < ' ' && c != '\n' && c != '\r' && c != '\t' && c != '\f' {
+			return "", 0, fmt.Errorf("looks like %s, not plain text", describeBinary(prefix))
+		}
+	}
+
+	// The last rune may have been cut off by the prefix.
+	valid := prefix
+	if len(prefix) == sniffLen {
+		for i := 0; i < utf8.UTFMax && len(valid) > 0 && !utf8.Valid(valid); i++ {
+			valid = valid[:len(valid)-1]
+		}
+	}
+	if utf8.Valid(valid) {
+		return CharsetUTF8, 0, nil
+	}
+	return CharsetLatin1, 0, nil
+}
+
+// describeBinary names the kind of file for rejection messages.
+func describeBinary(prefix []byte) string {
+	kind := http.DetectContentType(prefix)
+	if kind == "application/octet-stream" || strings.HasPrefix(kind, "text/") {
+		return "binary data"
+	}
+	return "a binary file (" + kind + ")"
+}
+
+// utf16Reader transcodes UTF-16 to UTF-8 as it's read.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   []byte
+	next  uint16 // a unit read ahead that didn't pair with a surrogate
+	ahead bool
+	err   error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.buf) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		u.fill()
+	}
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	return n, nil
+}
+
+// fill decodes a chunk of units into buf.
+func (u *utf16Reader) fill() {
+	for i := 0; i < sniffLen && u.err == nil; i++ {
+		r1, ok := u.unit()
+		if !ok {
+			return
+		}
+		r := rune(r1)
+		if utf16.IsSurrogate(r) {
+			r2, ok := u.unit()
+			if !ok {
+				u.buf = utf8.AppendRune(u.buf, utf8.RuneError)
+				return
+			}
+			r = utf16.DecodeRune(r, rune(r2))
+			if r == utf8.RuneError {
+				u.next, u.ahead = r2, true
+			}
+		}
+		u.buf = utf8.AppendRune(u.buf, r)
+	}
+}
+
+// unit reads the next UTF-16 code unit, a dangling odd byte at the end
+// becomes a replacement character.
+func (u *utf16Reader) unit() (uint16, bool) {
+	if u.ahead {
+		u.ahead = false
+		return u.next, true
+	}
+	var b [2]byte
+	n, err := io.ReadFull(u.r, b[:])
+	if err != nil {
+		if n == 1 {
+			u.buf = utf8.AppendRune(u.buf, utf8.RuneError)
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		u.err = err
+		return 0, false
+	}
+	return u.order.Uint16(b[:]), true
+}
+
+// windows1252 maps the 0x80-0x9f bytes that Windows uses for punctuation,
+// the rest of Latin-1 lines up with unicode.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// latin1Reader transcodes Latin-1, read as Windows-1252 like browsers do,
+// to UTF-8.
+type latin1Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	if len(l.buf) == 0 {
+		var chunk [sniffLen]byte
+		n, err := l.r.Read(chunk[:])
+		for _, c := range chunk[:n] {
+			r := rune(c)
+			if c >= 0x80 && c < 0xa0 {
+				r = windows1252[c-0x80]
+			}
+			l.buf = utf8.AppendRune(l.buf, r)
+		}
+		if n == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/matryer/is"
+)
+
+func encodeUTF16(text string, bigEndian bool, bom bool) string {
+	var b []byte
+	if bom {
+		text = "\ufeff" + text
+	}
+	for _, u := range utf16.Encode([]rune(text)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return string(b)
+}
+
+func decode(t *testing.T, raw string) (string, string) {
+	t.Helper()
+	is := is.New(t)
+	r, charset, err := DecodeText(strings.NewReader(raw))
+	is.NoErr(err)
+	b, err := io.ReadAll(r)
+	is.NoErr(err)
+	return string(b), charset
+}
+
+func TestDecodeText(t *testing.T) {
+	const text = "=: title Tacos 🌮\r\ncafé con leche\n"
+
+	t.Run("utf-8", func(t *testing.T) {
+		is := is.New(t)
+		got, charset := decode(t, text)
+		is.Equal(got, text)
+		is.Equal(charset, CharsetUTF8)
+
+		got, charset = decode(t, "\xef\xbb\xbf"+text)
+		is.Equal(got, text) // the byte order mark is dropped
+		is.Equal(charset, CharsetUTF8)
+	})
+
+	t.Run("utf-16", func(t *testing.T) {
+		is := is.New(t)
+		for _, tt := range []struct {
+			bigEndian, bom bool
+			charset        string
+		}{
+			{false, true, CharsetUTF16LE},
+			{true, true, CharsetUTF16BE},
+			{false, false, CharsetUTF16LE},
+			{true, false, CharsetUTF16BE},
+		} {
+			got, charset := decode(t, encodeUTF16(text, tt.bigEndian, tt.bom))
+			is.Equal(got, text)
+			is.Equal(charset, tt.charset)
+		}
+	})
+
+	t.Run("broken utf-16", func(t *testing.T) {
+		is := is.New(t)
+		got, _ := decode(t, "\xff\xfe"+"a\x00"+"\x3d\xd8"+"b\x00"+"c")
+		is.Equal(got, "a�b�") // lone surrogate, odd byte at the end
+	})
+
+	t.Run("latin-1", func(t *testing.T) {
+		is := is.New(t)
+		got, charset := decode(t, "caf\xe9 \x93quoted\x94 \x80")
+		is.Equal(got, "café “quoted” €")
+		is.Equal(charset, CharsetLatin1)
+	})
+
+	t.Run("long utf-8 cut mid rune", func(t *testing.T) {
+		is := is.New(t)
+		long := strings.Repeat("a", sniffLen-1) + "é"
+		got, charset := decode(t, long)
+		is.Equal(got, long)
+		is.Equal(charset, CharsetUTF8)
+	})
+
+	t.Run("binary files are named", func(t *testing.T) {
+		is := is.New(t)
+		_, _, err := DecodeText(strings.NewReader("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "image/png"))
+
+		_, _, err = DecodeText(strings.NewReader("\x01\x02\x03"))
+		is.Equal(err.Error(), "looks like binary data, not plain text")
+	})
+}
+
+func TestCheckTextFile(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(CheckTextFile("tacos\r\nburritos\r\n", "food.txt"))
+	is.Equal(CheckTextFile("tacos", "food.md").Error(), "only .txt files are supported")
+	is.True(CheckTextFile("%PDF-1.4\n\x00\x01\x02\x03\x04\x05", "food.txt") != nil)
+}
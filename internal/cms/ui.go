@@ -6,6 +6,7 @@ package cms
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,11 +18,24 @@ import (
 	"github.com/neurosnap/lists.sh/internal"
 	"github.com/neurosnap/lists.sh/internal/db"
 	"github.com/neurosnap/lists.sh/internal/db/postgres"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/scp"
 	"github.com/neurosnap/lists.sh/internal/ui/account"
 	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/housekeeping"
 	"github.com/neurosnap/lists.sh/internal/ui/info"
+	"github.com/neurosnap/lists.sh/internal/ui/invites"
+	"github.com/neurosnap/lists.sh/internal/ui/keys"
+	"github.com/neurosnap/lists.sh/internal/ui/onboarding"
 	"github.com/neurosnap/lists.sh/internal/ui/posts"
+	"github.com/neurosnap/lists.sh/internal/ui/privacy"
+	"github.com/neurosnap/lists.sh/internal/ui/read"
+	"github.com/neurosnap/lists.sh/internal/ui/settings"
+	"github.com/neurosnap/lists.sh/internal/ui/signins"
+	"github.com/neurosnap/lists.sh/internal/ui/spelling"
+	"github.com/neurosnap/lists.sh/internal/ui/stats"
 	"github.com/neurosnap/lists.sh/internal/ui/username"
+	"go.uber.org/zap"
 )
 
 // status is used to indicate a high level application state.
@@ -29,11 +43,21 @@ type status int
 
 const (
 	statusInit status = iota
+	statusFindingUser
 	statusReady
 	statusNoAccount
+	statusOnboarding
 	statusLinking
 	statusBrowsingPosts
-	statusSettingUsername
+	statusKeys
+	statusReading
+	statusStats
+	statusSettings
+	statusSpellcheck
+	statusHousekeeping
+	statusInvites
+	statusPrivacy
+	statusSignins
 	statusQuitting
 	statusError
 )
@@ -41,9 +65,21 @@ const (
 func (s status) String() string {
 	return [...]string{
 		"initializing",
+		"finding account",
 		"ready",
-		"setting username",
+		"no account",
+		"onboarding",
+		"linking",
 		"browsing posts",
+		"managing keys",
+		"reading",
+		"stats",
+		"settings",
+		"spellcheck report",
+		"housekeeping",
+		"invites",
+		"your data",
+		"sign in requests",
 		"quitting",
 		"error",
 	}[s]
@@ -54,17 +90,33 @@ type menuChoice int
 
 // menu choices
 const (
-	setUserChoice menuChoice = iota
-	postsChoice
+	postsChoice menuChoice = iota
+	keysChoice
+	readChoice
+	statsChoice
+	spellcheckChoice
+	housekeepingChoice
+	invitesChoice
+	privacyChoice
+	signinsChoice
+	settingsChoice
 	exitChoice
 	unsetChoice // set when no choice has been made
 )
 
 // menu text corresponding to menu choices. these are presented to the user.
 var menuChoices = map[menuChoice]string{
-	setUserChoice: "Set username",
-	postsChoice:   "Manage posts",
-	exitChoice:    "Exit",
+	postsChoice:        "Manage posts",
+	keysChoice:         "Manage keys",
+	readChoice:         "Read",
+	statsChoice:        "Stats",
+	spellcheckChoice:   "Spellcheck report",
+	housekeepingChoice: "Housekeeping",
+	invitesChoice:      "Invites",
+	privacyChoice:      "Your data",
+	signinsChoice:      "Sign in requests",
+	settingsChoice:     "Settings",
+	exitChoice:         "Exit",
 }
 
 var (
@@ -87,9 +139,9 @@ type GotDBMsg db.DB
 // pass it to the new model. You can also return tea.ProgramOptions (such as
 // teaw.WithAltScreen) on a session by session basis
 func Handler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-	logger := internal.CreateLogger()
+	logger := internal.SessionLogger(s)
 
-	_, _, active := s.Pty()
+	pty, _, active := s.Pty()
 	if !active {
 		logger.Error("no active terminal, skipping")
 		return nil, nil
@@ -102,40 +154,92 @@ func Handler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	sshUser := s.User()
 
 	dbpool := postgres.NewDB()
-	user, err := FindUser(dbpool, key, sshUser)
-	if err != nil {
-		_, _ = fmt.Fprintln(s.Stderr(), err)
+	user, findErr := FindUser(logger, dbpool, key, sshUser)
+	var multipleKeys *db.ErrMultiplePublicKeys
+	if errors.As(findErr, &multipleKeys) {
+		_, _ = fmt.Fprintln(s.Stderr(), findErr)
+		return nil, nil
+	}
+
+	if user != nil && !user.IsActive() {
+		_, _ = fmt.Fprintln(s.Stderr(), db.ErrUserSuspended)
 		return nil, nil
 	}
 
+	theme, locale := db.ThemeAuto, i18n.Default
+	if user != nil {
+		if prefs, err := dbpool.FindUserSettings(user.ID); err == nil {
+			theme, locale = prefs.Theme, prefs.Locale
+		}
+	}
+	noColor := common.WantsNoColor(s.Environ())
+	if noColor {
+		theme = db.ThemeNoColor
+	}
+
 	m := model{
 		publicKey:  key,
+		sshUser:    sshUser,
 		dbpool:     dbpool,
+		logger:     logger,
 		user:       user,
 		status:     statusInit,
 		menuChoice: unsetChoice,
+		noColor:    noColor,
+		styles:     common.NewStyles(theme),
 		spinner:    common.NewSpinner(),
+		clipboard:  common.NewClipboard(s, pty.Term),
+	}
+	m.styles.Locale = locale
+	if findErr != nil {
+		// Don't mistake a database hiccup for a new user, let them retry.
+		m.status = statusError
+		m.errFrom = statusFindingUser
+		m.err = findErr
+		m.retry = findUser(logger, dbpool, key, sshUser)
+	}
+
+	if err := dbpool.RecordActivity("", db.ActivitySession); err != nil {
+		logger.Error(err)
 	}
 
-	return m, []tea.ProgramOption{tea.WithAltScreen()}
+	return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
 }
 
 // Just a generic tea.Model to demo terminal information of ssh.
 type model struct {
-	publicKey     string
-	dbpool        db.DB
-	user          *db.User
-	err           error
-	status        status
-	menuIndex     int
-	menuChoice    menuChoice
-	terminalWidth int
-	styles        common.Styles
-	info          info.Model
-	spinner       spinner.Model
-	username      username.Model
-	posts         posts.Model
-	createAccount account.CreateModel
+	publicKey      string
+	sshUser        string
+	dbpool         db.DB
+	logger         *zap.SugaredLogger
+	clipboard      *common.Clipboard
+	user           *db.User
+	blogs          []*db.Blog // the account's extra blogs
+	blog           *db.User   // the blog being managed, nil for the account's own
+	err            error
+	errFrom        status  // the status to go back to when retrying
+	retry          tea.Cmd // runs the command that failed again
+	status         status
+	menuIndex      int
+	menuChoice     menuChoice
+	terminalWidth  int
+	terminalHeight int
+	styles         common.Styles
+	noColor        bool // the client sent NO_COLOR, which beats the theme
+	info           info.Model
+	spinner        spinner.Model
+	posts          posts.Model
+	keys           keys.Model
+	read           read.Model
+	stats          stats.Model
+	spelling       spelling.Model
+	housekeeping   housekeeping.Model
+	invites        invites.Model
+	privacy        privacy.Model
+	signins        signins.Model
+	settings       settings.Model
+	createAccount  account.CreateModel
+	onboarding     onboarding.Model
 }
 
 func (m model) Init() tea.Cmd {
@@ -144,8 +248,7 @@ func (m model) Init() tea.Cmd {
 	)
 }
 
-func FindUser(dbpool db.DB, publicKey string, sshUser string) (*db.User, error) {
-	logger := internal.CreateLogger()
+func FindUser(logger *zap.SugaredLogger, dbpool db.DB, publicKey string, sshUser string) (*db.User, error) {
 	var user *db.User
 
 	logger.Infof("Finding user based on ssh user (%s)", sshUser)
@@ -157,27 +260,92 @@ func FindUser(dbpool db.DB, publicKey string, sshUser string) (*db.User, error)
 		user, err = dbpool.UserForKey(publicKey)
 	}
 
-	if err != nil {
-		logger.Error(err)
-		// we only want to throw an error for specific cases
-		if errors.Is(err, &db.ErrMultiplePublicKeys{}) {
-			return nil, err
-		}
+	if errors.Is(err, db.ErrPublicKeyNotFound) {
+		// A key we haven't seen before belongs to someone signing up.
 		return nil, nil
+	} else if err != nil {
+		logger.Error(err)
+		return nil, err
 	}
 
 	return user, nil
 }
 
+type userFoundMsg struct {
+	user *db.User
+}
+
+type blogsLoadedMsg []*db.Blog
+
+type blogSwitchedMsg struct {
+	blog *db.User
+}
+
+// loadBlogs finds the extra blogs the user can switch between. Not finding
+// them only hides the switcher.
+func loadBlogs(logger *zap.SugaredLogger, dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		blogs, err := dbpool.FindBlogs(user.ID)
+		if err != nil {
+			logger.Error(err)
+		}
+		return blogsLoadedMsg(blogs)
+	}
+}
+
+// switchBlog moves to the blog after the current one, wrapping around to
+// the account's own.
+func switchBlog(dbpool db.DB, account *db.User, blogs []*db.Blog, current *db.User) tea.Cmd {
+	next := 0
+	if current != nil {
+		for i, blog := range blogs {
+			if blog.UserID == current.ID {
+				next = i + 1
+			}
+		}
+	}
+	return func() tea.Msg {
+		if next >= len(blogs) {
+			return blogSwitchedMsg{}
+		}
+		user, err := scp.AsBlog(dbpool, account, blogs[next].UserID)
+		if err != nil {
+			return common.ErrorMsg{Err: err}
+		}
+		return blogSwitchedMsg{user}
+	}
+}
+
+// findUser looks the user up again after it failed when they connected.
+func findUser(logger *zap.SugaredLogger, dbpool db.DB, publicKey string, sshUser string) tea.Cmd {
+	var retry tea.Cmd
+	retry = func() tea.Msg {
+		user, err := FindUser(logger, dbpool, publicKey, sshUser)
+		if err != nil {
+			return common.ErrorMsg{Err: err, Retry: retry}
+		}
+		return userFoundMsg{user}
+	}
+	return retry
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmds []tea.Cmd
 		cmd  tea.Cmd
 	)
 
+	// Screens get mouse positions relative to where they're drawn.
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		msg = m.childMouse(mouse)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.terminalWidth = msg.Width
+		m.terminalHeight = msg.Height
+		m.posts.SetSize(m.childSize())
+		m.keys.SetSize(m.childSize())
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
@@ -185,6 +353,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+		if m.status == statusError {
+			return m.updateError(msg)
+		}
+
 		if m.status == statusReady { // Process keys for the menu
 			switch msg.String() {
 			// Quit
@@ -210,30 +382,70 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.menuIndex >= len(menuChoices) {
 					m.menuIndex = 0
 				}
+
+			// Next blog
+			case "tab":
+				if len(m.blogs) > 0 {
+					return m, switchBlog(m.dbpool, m.user, m.blogs, m.blog)
+				}
 			}
 		}
+	case common.ErrorMsg:
+		m.logger.Errorw("command failed", "status", m.status.String(), "err", msg.Err)
+		m.err = msg.Err
+		m.retry = msg.Retry
+		if m.status != statusError {
+			m.errFrom = m.status
+		}
+		m.status = statusError
+		return m, nil
+	case userFoundMsg:
+		m.user = msg.user
+		m.status = statusInit
+	case blogsLoadedMsg:
+		m.blogs = msg
+	case blogSwitchedMsg:
+		m.blog = msg.blog
+		m.resetChildren()
+	case spinner.TickMsg:
+		if m.status == statusFindingUser {
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
 	case username.NameSetMsg:
-		m.status = statusReady
 		m.info.User.Name = string(msg)
 		m.user = m.info.User
-		m.username = username.NewModel(m.dbpool, m.user) // reset the state
+	case settings.SavedMsg:
+		// Restyle everything else, the settings screen keeps its own state.
+		theme := msg.Theme
+		if m.noColor {
+			theme = db.ThemeNoColor
+		}
+		m.styles = common.NewStyles(theme)
+		m.styles.Locale = msg.Locale
+		m.resetChildren()
 	case account.CreateAccountMsg:
-		m.status = statusReady
+		// New users get walked through publishing before the menu.
+		m.status = statusOnboarding
 		m.info.User = msg
 		m.user = msg
+		m.resetChildren()
+		m.settings = settings.NewModel(m.dbpool, m.user, m.styles)
 		m.createAccount = account.NewCreateModel(m.dbpool, m.publicKey)
+		m.onboarding = onboarding.NewModel(m.dbpool, m.user, m.styles)
 	}
 
 	switch m.status {
 	case statusInit:
-		m.username = username.NewModel(m.dbpool, m.user)
-		m.info = info.NewModel(m.user)
-		m.posts = posts.NewModel(m.dbpool, m.user)
+		m.blog, m.blogs = nil, nil
+		m.resetChildren()
+		m.settings = settings.NewModel(m.dbpool, m.user, m.styles)
 		m.createAccount = account.NewCreateModel(m.dbpool, m.publicKey)
 		if m.user == nil {
 			m.status = statusNoAccount
 		} else {
 			m.status = statusReady
+			cmds = append(cmds, loadBlogs(m.logger, m.dbpool, m.user))
 		}
 	}
 
@@ -245,6 +457,47 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// childSize returns the room left for a screen under the logo.
+func (m model) childSize() (int, int) {
+	width := m.terminalWidth - m.styles.App.GetHorizontalFrameSize()
+	height := m.terminalHeight - m.styles.App.GetVerticalFrameSize() - lipgloss.Height(m.styles.Logo.String()+"\n\n") + 1
+	return width, height
+}
+
+// childMouse moves a mouse event from the terminal's coordinates to those of
+// the screen under the logo.
+func (m model) childMouse(msg tea.MouseMsg) tea.MouseMsg {
+	msg.X -= m.styles.App.GetMarginLeft()
+	msg.Y -= m.styles.App.GetMarginTop() + lipgloss.Height(m.styles.Logo.String()+"\n\n") - 1
+	return msg
+}
+
+// blogUser is who the blog screens work on: the blog picked with tab, or the
+// account's own.
+func (m model) blogUser() *db.User {
+	if m.blog != nil {
+		return m.blog
+	}
+	return m.user
+}
+
+// resetChildren rebuilds the screens reachable from the menu with the
+// current user, blog and styles.
+func (m *model) resetChildren() {
+	m.info = info.NewModel(m.user, m.styles)
+	m.posts = posts.NewModel(m.dbpool, m.blogUser(), m.clipboard, m.styles)
+	m.posts.SetSize(m.childSize())
+	m.keys = keys.NewModel(m.dbpool, m.user, m.styles)
+	m.keys.SetSize(m.childSize())
+	m.read = read.NewModel(m.dbpool, m.user, m.styles)
+	m.stats = stats.NewModel(m.dbpool, m.blogUser(), m.styles)
+	m.spelling = spelling.NewModel(m.dbpool, m.blogUser(), m.styles)
+	m.housekeeping = housekeeping.NewModel(m.dbpool, m.blogUser(), m.styles)
+	m.invites = invites.NewModel(m.dbpool, m.user, m.styles)
+	m.privacy = privacy.NewModel(m.dbpool, m.user, m.styles)
+	m.signins = signins.NewModel(m.dbpool, m.user, m.styles)
+}
+
 func updateChilden(msg tea.Msg, m model) (model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -260,19 +513,92 @@ func updateChilden(msg tea.Msg, m model) (model, tea.Cmd) {
 		cmd = newCmd
 
 		if m.posts.Exit {
-			m.posts = posts.NewModel(m.dbpool, m.user)
+			m.posts = posts.NewModel(m.dbpool, m.blogUser(), m.clipboard, m.styles)
+			m.posts.SetSize(m.childSize())
 			m.status = statusReady
 		} else if m.posts.Quit {
 			m.status = statusQuitting
 			return m, tea.Quit
 		}
-	// Username tool
-	case statusSettingUsername:
-		m.username, cmd = username.Update(msg, m.username)
-		if m.username.Done {
-			m.username = username.NewModel(m.dbpool, m.user) // reset the state
+	case statusKeys:
+		m.keys, cmd = keys.Update(msg, m.keys)
+		if m.keys.Done {
+			m.keys = keys.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.keys.SetSize(m.childSize())
 			m.status = statusReady
-		} else if m.username.Quit {
+		} else if m.keys.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusReading:
+		m.read, cmd = read.Update(msg, m.read)
+		if m.read.Done {
+			m.read = read.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.status = statusReady
+		} else if m.read.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusStats:
+		m.stats, cmd = stats.Update(msg, m.stats)
+		if m.stats.Done {
+			m.stats = stats.NewModel(m.dbpool, m.blogUser(), m.styles) // reset the state
+			m.status = statusReady
+		} else if m.stats.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusSettings:
+		m.settings, cmd = settings.Update(msg, m.settings)
+		if m.settings.Done {
+			m.settings = settings.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.status = statusReady
+		} else if m.settings.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusSpellcheck:
+		m.spelling, cmd = spelling.Update(msg, m.spelling)
+		if m.spelling.Done {
+			m.spelling = spelling.NewModel(m.dbpool, m.blogUser(), m.styles) // reset the state
+			m.status = statusReady
+		} else if m.spelling.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusHousekeeping:
+		m.housekeeping, cmd = housekeeping.Update(msg, m.housekeeping)
+		if m.housekeeping.Done {
+			m.housekeeping = housekeeping.NewModel(m.dbpool, m.blogUser(), m.styles) // reset the state
+			m.status = statusReady
+		} else if m.housekeeping.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusInvites:
+		m.invites, cmd = invites.Update(msg, m.invites)
+		if m.invites.Done {
+			m.invites = invites.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.status = statusReady
+		} else if m.invites.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusPrivacy:
+		m.privacy, cmd = privacy.Update(msg, m.privacy)
+		if m.privacy.Done {
+			m.privacy = privacy.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.status = statusReady
+		} else if m.privacy.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
+	case statusSignins:
+		m.signins, cmd = signins.Update(msg, m.signins)
+		if m.signins.Done {
+			m.signins = signins.NewModel(m.dbpool, m.user, m.styles) // reset the state
+			m.status = statusReady
+		} else if m.signins.Quit {
 			m.status = statusQuitting
 			return m, tea.Quit
 		}
@@ -285,18 +611,58 @@ func updateChilden(msg tea.Msg, m model) (model, tea.Cmd) {
 			m.status = statusQuitting
 			return m, tea.Quit
 		}
+	case statusOnboarding:
+		m.onboarding, cmd = onboarding.Update(msg, m.onboarding)
+		if m.onboarding.Done {
+			m.status = statusReady
+		} else if m.onboarding.Quit {
+			m.status = statusQuitting
+			return m, tea.Quit
+		}
 	}
 
 	// Handle the menu
 	switch m.menuChoice {
-	case setUserChoice:
-		m.status = statusSettingUsername
-		m.menuChoice = unsetChoice
-		cmd = username.InitialCmd()
 	case postsChoice:
 		m.status = statusBrowsingPosts
 		m.menuChoice = unsetChoice
 		cmd = posts.LoadPosts(m.posts)
+	case keysChoice:
+		m.status = statusKeys
+		m.menuChoice = unsetChoice
+		cmd = keys.LoadKeys(m.keys)
+	case readChoice:
+		m.status = statusReading
+		m.menuChoice = unsetChoice
+		cmd = read.LoadFeed(m.read)
+	case statsChoice:
+		m.status = statusStats
+		m.menuChoice = unsetChoice
+		cmd = stats.LoadStats(m.stats)
+	case spellcheckChoice:
+		m.status = statusSpellcheck
+		m.menuChoice = unsetChoice
+		cmd = spelling.LoadReport(m.spelling)
+	case housekeepingChoice:
+		m.status = statusHousekeeping
+		m.menuChoice = unsetChoice
+		cmd = housekeeping.LoadDuplicates(m.housekeeping)
+	case invitesChoice:
+		m.status = statusInvites
+		m.menuChoice = unsetChoice
+		cmd = invites.LoadInvites(m.invites)
+	case privacyChoice:
+		m.status = statusPrivacy
+		m.menuChoice = unsetChoice
+		cmd = privacy.LoadData(m.privacy)
+	case signinsChoice:
+		m.status = statusSignins
+		m.menuChoice = unsetChoice
+		cmd = signins.LoadRequests(m.signins)
+	case settingsChoice:
+		m.status = statusSettings
+		m.menuChoice = unsetChoice
+		cmd = settings.LoadSettings(m.settings)
 	case exitChoice:
 		m.status = statusQuitting
 		m.dbpool.Close()
@@ -310,7 +676,7 @@ func (m model) menuView() string {
 	var s string
 	for i := 0; i < len(menuChoices); i++ {
 		e := "  "
-		menuItem := menuChoices[menuChoice(i)]
+		menuItem := m.styles.T(menuChoices[menuChoice(i)])
 		if i == m.menuIndex {
 			e = m.styles.SelectionMarker.String() +
 				m.styles.SelectedMenuItem.Render(menuItem)
@@ -326,18 +692,73 @@ func (m model) menuView() string {
 	return s
 }
 
-func (m model) quitView() string {
-	if m.err != nil {
-		return fmt.Sprintf("Uh oh, there’s been an error: %s\n", m.err)
+// blogsView lists the blogs the menu can work on, with the current one
+// picked out.
+func (m model) blogsView() string {
+	if len(m.blogs) == 0 {
+		return ""
+	}
+	names := []string{m.user.Name}
+	current := 0
+	for i, blog := range m.blogs {
+		names = append(names, blog.Name)
+		if m.blog != nil && blog.UserID == m.blog.ID {
+			current = i + 1
+		}
+	}
+	for i, name := range names {
+		if i == current {
+			names[i] = m.styles.SelectedMenuItem.Render(name)
+		} else {
+			names[i] = m.styles.Subtle.Render(name)
+		}
 	}
-	return "Thanks for using lists.sh!\n"
+	return "\n\n" + m.styles.T("Blog") + ": " + strings.Join(names, " · ")
 }
 
 func footerView(m model) string {
-	if m.err != nil {
-		return m.errorView(m.err)
+	if len(m.blogs) > 0 {
+		return "\n\n" + m.styles.HelpView("j/k, ↑/↓: choose", "enter: select", "tab: switch blog")
 	}
-	return "\n\n" + common.HelpView("j/k, ↑/↓: choose", "enter: select")
+	return "\n\n" + m.styles.HelpView("j/k, ↑/↓: choose", "enter: select")
+}
+
+// updateError handles keys on the error screen: r goes back to where the
+// error happened and runs the failed command again.
+func (m model) updateError(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		if m.retry == nil {
+			return m, nil
+		}
+		m.status = m.errFrom
+		m.err = nil
+		return m, tea.Batch(m.retry, spinner.Tick)
+	case "q", "esc":
+		if m.user == nil {
+			m.status = statusQuitting
+			m.dbpool.Close()
+			return m, tea.Quit
+		}
+		m.err = nil
+		m.resetChildren()
+		m.status = statusReady
+	}
+	return m, nil
+}
+
+func (m model) errorScreenView() string {
+	s := "Something went wrong" + m.errorView(m.err) + "\n\n"
+	s += m.styles.Subtle.Render("It's been logged. Trying again usually does the trick.") + "\n\n"
+
+	exit := "esc: back to the menu"
+	if m.user == nil {
+		exit = "esc: exit"
+	}
+	if m.retry == nil {
+		return s + m.styles.HelpView(exit)
+	}
+	return s + m.styles.HelpView("r: retry", exit)
 }
 
 func (m model) errorView(err error) string {
@@ -351,16 +772,44 @@ func (m model) View() string {
 	w := m.terminalWidth - m.styles.App.GetHorizontalFrameSize()
 	s := m.styles.Logo.String() + "\n\n"
 	switch m.status {
+	case statusFindingUser:
+		s += m.spinner.View() + " Finding your account..."
+	case statusError:
+		s += m.errorScreenView()
 	case statusNoAccount:
 		s += account.View(m.createAccount)
+	case statusOnboarding:
+		s += onboarding.View(m.onboarding)
 	case statusReady:
 		s += m.info.View()
+		s += m.blogsView()
 		s += "\n\n" + m.menuView()
 		s += footerView(m)
-	case statusSettingUsername:
-		s += username.View(m.username)
+	case statusKeys:
+		s += keys.View(m.keys)
+	case statusReading:
+		s += read.View(m.read)
+	case statusStats:
+		s += stats.View(m.stats)
+	case statusSettings:
+		s += settings.View(m.settings)
 	case statusBrowsingPosts:
 		s += m.posts.View()
+	case statusSpellcheck:
+		s += spelling.View(m.spelling)
+	case statusHousekeeping:
+		s += housekeeping.View(m.housekeeping)
+	case statusInvites:
+		s += invites.View(m.invites)
+	case statusPrivacy:
+		s += privacy.View(m.privacy)
+	case statusSignins:
+		s += signins.View(m.signins)
+	}
+	s = m.styles.App.Render(wrap.String(wordwrap.String(s, w), w))
+	if m.styles.NoColor {
+		// Shared helpers and bubbles render their own colors.
+		s = common.StripColor(s)
 	}
-	return m.styles.App.Render(wrap.String(wordwrap.String(s, w), w))
+	return s
 }
@@ -0,0 +1,73 @@
+package cms
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"go.uber.org/zap"
+)
+
+func TestErrorScreen(t *testing.T) {
+	retry := func() tea.Msg { return nil }
+	r := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}
+
+	t.Run("an error replaces the screen it happened on", func(t *testing.T) {
+		is := is.New(t)
+		m := model{logger: zap.NewNop().Sugar(), user: &db.User{}, status: statusStats}
+		next, _ := m.Update(common.ErrorMsg{Err: errors.New("boom"), Retry: retry})
+		m = next.(model)
+		is.Equal(m.status, statusError)
+		is.Equal(m.errFrom, statusStats)
+	})
+
+	t.Run("r goes back and runs the command again", func(t *testing.T) {
+		is := is.New(t)
+		m := model{status: statusError, errFrom: statusFindingUser, err: errors.New("boom"), retry: retry}
+		next, cmd := m.Update(r)
+		m = next.(model)
+		is.Equal(m.status, statusFindingUser)
+		is.True(m.err == nil)
+		is.True(cmd != nil)
+	})
+}
+
+// usersDB finds users by ID and nothing else.
+type usersDB struct {
+	db.DB
+	users map[string]*db.User
+}
+
+func (d usersDB) User(userID string) (*db.User, error) {
+	user := *d.users[userID]
+	return &user, nil
+}
+
+func TestSwitchBlog(t *testing.T) {
+	own := &db.User{ID: "u1", Name: "erock"}
+	work := &db.User{ID: "u2", Name: "work"}
+	notes := &db.User{ID: "u3", Name: "notes"}
+	dbpool := usersDB{users: map[string]*db.User{"u2": work, "u3": notes}}
+	blogs := []*db.Blog{{UserID: "u2", Name: "work"}, {UserID: "u3", Name: "notes"}}
+
+	t.Run("the account's own blog moves to the first extra one", func(t *testing.T) {
+		is := is.New(t)
+		msg := switchBlog(dbpool, own, blogs, nil)().(blogSwitchedMsg)
+		is.Equal(msg.blog.Name, "work")
+		is.Equal(msg.blog.PublicKey.UserID, "u1") // posts are credited to the account
+	})
+
+	t.Run("moves along the blogs", func(t *testing.T) {
+		is := is.New(t)
+		msg := switchBlog(dbpool, own, blogs, work)().(blogSwitchedMsg)
+		is.Equal(msg.blog.Name, "notes")
+	})
+
+	t.Run("wraps around to the account's own", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(switchBlog(dbpool, own, blogs, notes)(), blogSwitchedMsg{})
+	})
+}
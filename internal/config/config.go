@@ -0,0 +1,498 @@
+// Package config loads the settings for every lists.sh binary from an
+// optional TOML file with environment variable overrides.
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is the validated configuration shared by the ssh, web and backup
+// servers.
+type Config struct {
+	Domain          string
+	Host            string
+	MetricsHost     string // where the metrics listeners bind, apart from Host
+	DatabaseURL     string
+	DictionaryDir   string
+	ShutdownTimeout time.Duration
+
+	SSH          SSHConfig
+	Web          WebConfig
+	Log          LogConfig
+	Backup       BackupConfig
+	Spam         SpamConfig
+	Registration RegistrationConfig
+	Quota        QuotaConfig
+	Mail         MailConfig
+	Bodies       BodiesConfig
+	Jobs         JobsConfig
+	Flags        FlagsConfig
+}
+
+type SSHConfig struct {
+	Port        int
+	MetricsPort int
+	// MaxSessions and MaxTransfers cap the open sessions and the scp
+	// uploads and imports among them, zero for no limit.  Sessions past
+	// the cap wait up to QueueTimeout for a slot.
+	MaxSessions  int
+	MaxTransfers int
+	QueueTimeout time.Duration
+}
+
+type WebConfig struct {
+	Port        int
+	MetricsPort int
+	// CORSOrigins can read the public pages and feeds from the browser,
+	// "*" for any.
+	CORSOrigins []string
+	// TemplatesDir has templates that replace the built in ones with the
+	// same name, empty to use the built in ones only.
+	TemplatesDir string
+	// ReloadTemplates reads the templates again on every request instead
+	// of once, for working on them.
+	ReloadTemplates bool
+	// ShareSecret signs links to a single post, see internal/share, empty
+	// turns them off.
+	ShareSecret string
+}
+
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+type BackupConfig struct {
+	Interval    time.Duration
+	Retention   int // how many backups are kept, zero keeps them all
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+type SpamConfig struct {
+	Enabled           bool
+	BannedDomains     []string
+	MinLinks          int
+	MaxLinkRatio      float64
+	DuplicateAccounts int
+}
+
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+type RegistrationConfig struct {
+	Mode           string
+	InvitesPerUser int
+}
+
+// QuotaConfig caps what a single account can store, zero means no limit.
+type QuotaConfig struct {
+	MaxPosts int
+	MaxBytes int
+	MaxBlogs int // besides the account's own
+}
+
+// MailConfig is the SMTP server that sends readers their sign in links, an
+// empty SMTPAddr turns signing in by email off.
+type MailConfig struct {
+	SMTPAddr string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+// Where post text is kept, see internal/bodies.
+const (
+	BodiesDB   = "db"
+	BodiesDisk = "disk"
+	BodiesS3   = "s3"
+)
+
+// BodiesConfig is where the text of posts longer than InlineMax bytes is
+// kept, the posts table keeps all of it with BodiesDB.
+type BodiesConfig struct {
+	Store       string
+	InlineMax   int
+	Dir         string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// JobsConfig is how the web server runs background jobs, see internal/jobs.
+type JobsConfig struct {
+	Workers int
+}
+
+// FlagsConfig turns features on or off for everyone or for a share of
+// users, see internal/flags.  Overrides for single users are kept in the
+// database.
+type FlagsConfig struct {
+	On      []string
+	Off     []string
+	Rollout map[string]int // percent of users
+}
+
+// setting ties a key in the config file to its environment variable.
+type setting struct {
+	key string
+	env string
+	def string
+}
+
+var settings = []setting{
+	{"domain", "LISTS_DOMAIN", "lists.sh"},
+	{"host", "LISTS_HOST", "0.0.0.0"},
+	{"metrics_host", "LISTS_METRICS_HOST", "127.0.0.1"},
+	{"database_url", "DATABASE_URL", ""},
+	{"dictionary_dir", "LISTS_DICTIONARY_DIR", ""},
+	{"shutdown_timeout", "LISTS_SHUTDOWN_TIMEOUT", "30s"},
+	{"ssh.port", "LISTS_SSH_PORT", "2222"},
+	{"ssh.metrics_port", "LISTS_SSH_METRICS_PORT", "9222"},
+	{"ssh.max_sessions", "LISTS_SSH_MAX_SESSIONS", "200"},
+	{"ssh.max_transfers", "LISTS_SSH_MAX_TRANSFERS", "20"},
+	{"ssh.queue_timeout", "LISTS_SSH_QUEUE_TIMEOUT", "30s"},
+	{"web.port", "LISTS_WEB_PORT", "3000"},
+	{"web.metrics_port", "LISTS_WEB_METRICS_PORT", "9300"},
+	{"web.cors_origins", "LISTS_WEB_CORS_ORIGINS", "*"},
+	{"web.templates_dir", "LISTS_WEB_TEMPLATES_DIR", ""},
+	{"web.reload_templates", "LISTS_WEB_RELOAD_TEMPLATES", "false"},
+	{"web.share_secret", "LISTS_WEB_SHARE_SECRET", ""},
+	{"log.level", "LISTS_LOG_LEVEL", "info"},
+	{"log.format", "LISTS_LOG_FORMAT", "json"},
+	{"backup.interval", "LISTS_BACKUP_INTERVAL", "24h"},
+	{"backup.retention", "LISTS_BACKUP_RETENTION", "7"},
+	{"backup.s3_endpoint", "LISTS_BACKUP_S3_ENDPOINT", "https://s3.amazonaws.com"},
+	{"backup.s3_region", "LISTS_BACKUP_S3_REGION", "us-east-1"},
+	{"backup.s3_bucket", "LISTS_BACKUP_S3_BUCKET", ""},
+	{"backup.s3_access_key", "LISTS_BACKUP_S3_ACCESS_KEY", ""},
+	{"backup.s3_secret_key", "LISTS_BACKUP_S3_SECRET_KEY", ""},
+	{"spam.enabled", "LISTS_SPAM_ENABLED", "true"},
+	{"spam.banned_domains", "LISTS_SPAM_BANNED_DOMAINS", ""},
+	{"spam.min_links", "LISTS_SPAM_MIN_LINKS", "5"},
+	{"spam.max_link_ratio", "LISTS_SPAM_MAX_LINK_RATIO", "0.8"},
+	{"spam.duplicate_accounts", "LISTS_SPAM_DUPLICATE_ACCOUNTS", "1"},
+	{"registration.mode", "LISTS_REGISTRATION_MODE", "open"},
+	{"registration.invites_per_user", "LISTS_INVITES_PER_USER", "3"},
+	{"quota.max_posts", "LISTS_QUOTA_MAX_POSTS", "500"},
+	{"quota.max_mb", "LISTS_QUOTA_MAX_MB", "10"},
+	{"quota.max_blogs", "LISTS_QUOTA_MAX_BLOGS", "3"},
+	{"mail.smtp_addr", "LISTS_MAIL_SMTP_ADDR", ""},
+	{"mail.username", "LISTS_MAIL_USERNAME", ""},
+	{"mail.password", "LISTS_MAIL_PASSWORD", ""},
+	{"mail.from", "LISTS_MAIL_FROM", "hello@lists.sh"},
+	{"bodies.store", "LISTS_BODIES_STORE", "db"},
+	{"bodies.inline_max_kb", "LISTS_BODIES_INLINE_MAX_KB", "64"},
+	{"bodies.dir", "LISTS_BODIES_DIR", ""},
+	{"bodies.s3_endpoint", "LISTS_BODIES_S3_ENDPOINT", "https://s3.amazonaws.com"},
+	{"bodies.s3_region", "LISTS_BODIES_S3_REGION", "us-east-1"},
+	{"bodies.s3_bucket", "LISTS_BODIES_S3_BUCKET", ""},
+	{"bodies.s3_access_key", "LISTS_BODIES_S3_ACCESS_KEY", ""},
+	{"bodies.s3_secret_key", "LISTS_BODIES_S3_SECRET_KEY", ""},
+	{"jobs.workers", "LISTS_JOBS_WORKERS", "4"},
+	{"flags.on", "LISTS_FLAGS_ON", ""},
+	{"flags.off", "LISTS_FLAGS_OFF", ""},
+	{"flags.rollout", "LISTS_FLAGS_ROLLOUT", ""},
+}
+
+// LookupFunc finds an environment variable, os.LookupEnv in production.
+type LookupFunc func(key string) (string, bool)
+
+// Load reads the config file at path (skipped when empty), applies
+// environment overrides and validates the result.
+func Load(path string, lookup LookupFunc) (*Config, error) {
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.key] = s.def
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for key, value := range file {
+			if _, ok := values[key]; !ok {
+				return nil, fmt.Errorf("%s: unknown setting %q", path, key)
+			}
+			values[key] = value
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookup(s.env); ok {
+			values[s.key] = value
+		}
+	}
+
+	cfg, err := build(values)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func build(values map[string]string) (*Config, error) {
+	var errs []string
+	fail := func(key string, format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("%s (%s): %s", key, envFor(key), fmt.Sprintf(format, args...)))
+	}
+
+	duration := func(key string) time.Duration {
+		d, err := time.ParseDuration(values[key])
+		if err != nil || d <= 0 {
+			fail(key, "must be a positive duration like 30s, got %q", values[key])
+		}
+		return d
+	}
+	port := func(key string) int {
+		p, err := strconv.Atoi(values[key])
+		if err != nil || p < 1 || p > 65535 {
+			fail(key, "must be a port between 1 and 65535, got %q", values[key])
+		}
+		return p
+	}
+	number := func(key string) int {
+		n, err := strconv.Atoi(values[key])
+		if err != nil || n < 0 {
+			fail(key, "must be a number, got %q", values[key])
+		}
+		return n
+	}
+	boolean := func(key string) bool {
+		b, err := strconv.ParseBool(values[key])
+		if err != nil {
+			fail(key, "must be true or false, got %q", values[key])
+		}
+		return b
+	}
+	list := func(key string) []string {
+		var items []string
+		for _, item := range strings.Split(values[key], ",") {
+			item = strings.ToLower(strings.TrimSpace(item))
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	oneOf := func(key string, options ...string) string {
+		for _, o := range options {
+			if values[key] == o {
+				return o
+			}
+		}
+		fail(key, "must be one of %s, got %q", strings.Join(options, ", "), values[key])
+		return ""
+	}
+
+	cfg := &Config{
+		Domain:          values["domain"],
+		Host:            values["host"],
+		MetricsHost:     values["metrics_host"],
+		DatabaseURL:     values["database_url"],
+		DictionaryDir:   values["dictionary_dir"],
+		ShutdownTimeout: duration("shutdown_timeout"),
+		SSH: SSHConfig{
+			Port:         port("ssh.port"),
+			MetricsPort:  port("ssh.metrics_port"),
+			MaxSessions:  number("ssh.max_sessions"),
+			MaxTransfers: number("ssh.max_transfers"),
+			QueueTimeout: duration("ssh.queue_timeout"),
+		},
+		Web: WebConfig{
+			Port:            port("web.port"),
+			MetricsPort:     port("web.metrics_port"),
+			CORSOrigins:     list("web.cors_origins"),
+			TemplatesDir:    values["web.templates_dir"],
+			ReloadTemplates: boolean("web.reload_templates"),
+			ShareSecret:     values["web.share_secret"],
+		},
+		Log: LogConfig{
+			Level:  oneOf("log.level", "debug", "info", "warn", "error"),
+			Format: oneOf("log.format", "json", "console"),
+		},
+		Backup: BackupConfig{
+			Interval:    duration("backup.interval"),
+			Retention:   number("backup.retention"),
+			S3Endpoint:  values["backup.s3_endpoint"],
+			S3Region:    values["backup.s3_region"],
+			S3Bucket:    values["backup.s3_bucket"],
+			S3AccessKey: values["backup.s3_access_key"],
+			S3SecretKey: values["backup.s3_secret_key"],
+		},
+	}
+
+	cfg.Spam = SpamConfig{
+		Enabled:           boolean("spam.enabled"),
+		BannedDomains:     list("spam.banned_domains"),
+		MinLinks:          number("spam.min_links"),
+		DuplicateAccounts: number("spam.duplicate_accounts"),
+	}
+	cfg.Registration = RegistrationConfig{
+		Mode:           oneOf("registration.mode", RegistrationOpen, RegistrationInvite, RegistrationClosed),
+		InvitesPerUser: number("registration.invites_per_user"),
+	}
+	cfg.Quota = QuotaConfig{
+		MaxPosts: number("quota.max_posts"),
+		MaxBytes: number("quota.max_mb") * 1024 * 1024,
+		MaxBlogs: number("quota.max_blogs"),
+	}
+	cfg.Mail = MailConfig{
+		SMTPAddr: values["mail.smtp_addr"],
+		Username: values["mail.username"],
+		Password: values["mail.password"],
+		From:     values["mail.from"],
+	}
+	cfg.Bodies = BodiesConfig{
+		Store:       oneOf("bodies.store", BodiesDB, BodiesDisk, BodiesS3),
+		InlineMax:   number("bodies.inline_max_kb") * 1024,
+		Dir:         values["bodies.dir"],
+		S3Endpoint:  values["bodies.s3_endpoint"],
+		S3Region:    values["bodies.s3_region"],
+		S3Bucket:    values["bodies.s3_bucket"],
+		S3AccessKey: values["bodies.s3_access_key"],
+		S3SecretKey: values["bodies.s3_secret_key"],
+	}
+	cfg.Jobs = JobsConfig{
+		Workers: number("jobs.workers"),
+	}
+	if cfg.Jobs.Workers < 1 {
+		fail("jobs.workers", "must be at least 1")
+	}
+	cfg.Flags = FlagsConfig{
+		On:      list("flags.on"),
+		Off:     list("flags.off"),
+		Rollout: map[string]int{},
+	}
+	for _, item := range list("flags.rollout") {
+		name, value, _ := strings.Cut(item, ":")
+		percent, err := strconv.Atoi(strings.TrimSpace(value))
+		if strings.TrimSpace(name) == "" || err != nil || percent < 0 || percent > 100 {
+			fail("flags.rollout", "must look like name:percent with a percent from 0 to 100, got %q", item)
+			continue
+		}
+		cfg.Flags.Rollout[strings.TrimSpace(name)] = percent
+	}
+
+	ratio, err := strconv.ParseFloat(values["spam.max_link_ratio"], 64)
+	if err != nil || ratio <= 0 || ratio > 1 {
+		fail("spam.max_link_ratio", "must be a number between 0 and 1, got %q", values["spam.max_link_ratio"])
+	}
+	cfg.Spam.MaxLinkRatio = ratio
+
+	if cfg.Domain == "" {
+		fail("domain", "is required")
+	}
+	if cfg.DatabaseURL == "" {
+		fail("database_url", "is required")
+	}
+	if secret := cfg.Web.ShareSecret; secret != "" && len(secret) < 32 {
+		fail("web.share_secret", "must be at least 32 characters")
+	}
+	if addr := cfg.Mail.SMTPAddr; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			fail("mail.smtp_addr", "must be host:port, got %q", addr)
+		}
+		if _, err := mail.ParseAddress(cfg.Mail.From); err != nil {
+			fail("mail.from", "must be an email address, got %q", cfg.Mail.From)
+		}
+	}
+	switch cfg.Bodies.Store {
+	case BodiesDisk:
+		if cfg.Bodies.Dir == "" {
+			fail("bodies.dir", "is required to keep post text on disk")
+		}
+	case BodiesS3:
+		if cfg.Bodies.S3Bucket == "" || cfg.Bodies.S3AccessKey == "" || cfg.Bodies.S3SecretKey == "" {
+			fail("bodies.s3_bucket", "and the s3 access and secret keys are required to keep post text in s3")
+		}
+	}
+	if dir := cfg.Web.TemplatesDir; dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fail("web.templates_dir", "must be a directory, got %q", dir)
+		}
+	}
+
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("invalid configuration:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return cfg, nil
+}
+
+func envFor(key string) string {
+	for _, s := range settings {
+		if s.key == key {
+			return s.env
+		}
+	}
+	return ""
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Config
+
+	defaultsOnce sync.Once
+	defaults     *Config
+)
+
+// Init loads the process wide configuration from LISTS_CONFIG (if set) and
+// the environment.  Every main calls it before anything else so invalid
+// settings are reported at startup.
+func Init() (*Config, error) {
+	cfg, err := Load(os.Getenv("LISTS_CONFIG"), os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	currentMu.Lock()
+	current = cfg
+	currentMu.Unlock()
+	return cfg, nil
+}
+
+// Current returns the configuration loaded by Init, or the built in
+// defaults when nothing was loaded, like in tests.
+func Current() *Config {
+	currentMu.RLock()
+	cfg := current
+	currentMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	defaultsOnce.Do(func() {
+		values := map[string]string{}
+		for _, s := range settings {
+			values[s.key] = s.def
+		}
+		// Only the database url is missing, and nothing that runs without
+		// Init connects to one.
+		defaults, _ = build(values)
+	})
+	return defaults
+}
+
+// URL builds an absolute url on the configured domain.
+func (c *Config) URL(parts ...string) string {
+	return fmt.Sprintf("https://%s/%s", c.Domain, strings.Join(parts, "/"))
+}
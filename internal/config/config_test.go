@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func env(vars map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, text string) string {
+	path := filepath.Join(t.TempDir(), "lists.toml")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		is := is.New(t)
+		cfg, err := Load("", env(map[string]string{"DATABASE_URL": "postgres://db"}))
+		is.NoErr(err)
+		is.Equal(cfg.Domain, "lists.sh")
+		is.Equal(cfg.SSH.Port, 2222)
+		is.Equal(cfg.SSH.MaxSessions, 200)
+		is.Equal(cfg.SSH.QueueTimeout, 30*time.Second)
+		is.Equal(cfg.Web.Port, 3000)
+		is.Equal(cfg.ShutdownTimeout, 30*time.Second)
+		is.Equal(cfg.URL("erock", "rss"), "https://lists.sh/erock/rss")
+		is.Equal(cfg.Registration.Mode, RegistrationOpen)
+		is.Equal(cfg.Quota.MaxBytes, 10*1024*1024)
+		is.Equal(cfg.Web.CORSOrigins, []string{"*"})
+		is.Equal(cfg.Backup.Retention, 7)
+	})
+
+	t.Run("keep every backup", func(t *testing.T) {
+		is := is.New(t)
+		cfg, err := Load("", env(map[string]string{
+			"DATABASE_URL":           "postgres://db",
+			"LISTS_BACKUP_RETENTION": "0",
+		}))
+		is.NoErr(err)
+		is.Equal(cfg.Backup.Retention, 0)
+	})
+
+	t.Run("file with env overrides", func(t *testing.T) {
+		is := is.New(t)
+		path := writeFile(t, `
+# comment
+domain = "example.com" # trailing comment
+database_url = "postgres://file"
+
+[web]
+port = 8080
+cors_origins = "https://Widgets.example.com, https://other.example.com"
+
+[backup]
+retention = 14
+`)
+		cfg, err := Load(path, env(map[string]string{"LISTS_WEB_PORT": "9000"}))
+		is.NoErr(err)
+		is.Equal(cfg.Domain, "example.com")
+		is.Equal(cfg.DatabaseURL, "postgres://file")
+		is.Equal(cfg.Web.Port, 9000)
+		is.Equal(cfg.Web.CORSOrigins, []string{"https://widgets.example.com", "https://other.example.com"})
+		is.Equal(cfg.Backup.Retention, 14)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Load("", env(map[string]string{
+			"LISTS_SSH_PORT":          "abc",
+			"LISTS_LOG_LEVEL":         "loud",
+			"LISTS_REGISTRATION_MODE": "secret",
+			"LISTS_WEB_TEMPLATES_DIR": "/does/not/exist",
+			"LISTS_MAIL_SMTP_ADDR":    "smtp.example.com",
+			"LISTS_WEB_SHARE_SECRET":  "short",
+			"LISTS_BODIES_STORE":      "disk",
+			"LISTS_FLAGS_ROLLOUT":     "share:150",
+			"LISTS_BACKUP_RETENTION":  "-1",
+		}))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "LISTS_SSH_PORT"))
+		is.True(strings.Contains(err.Error(), "LISTS_LOG_LEVEL"))
+		is.True(strings.Contains(err.Error(), "LISTS_REGISTRATION_MODE"))
+		is.True(strings.Contains(err.Error(), "LISTS_WEB_TEMPLATES_DIR"))
+		is.True(strings.Contains(err.Error(), "LISTS_MAIL_SMTP_ADDR"))
+		is.True(strings.Contains(err.Error(), "LISTS_WEB_SHARE_SECRET"))
+		is.True(strings.Contains(err.Error(), "LISTS_BODIES_DIR"))
+		is.True(strings.Contains(err.Error(), "LISTS_FLAGS_ROLLOUT"))
+		is.True(strings.Contains(err.Error(), "LISTS_BACKUP_RETENTION"))
+		is.True(strings.Contains(err.Error(), "DATABASE_URL"))
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		is := is.New(t)
+		cfg, err := Load("", env(map[string]string{
+			"DATABASE_URL":        "postgres://db",
+			"LISTS_FLAGS_ON":      "share",
+			"LISTS_FLAGS_ROLLOUT": "email-readers:25, share:0",
+		}))
+		is.NoErr(err)
+		is.Equal(cfg.Flags.On, []string{"share"})
+		is.Equal(cfg.Flags.Rollout, map[string]int{"email-readers": 25, "share": 0})
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		is := is.New(t)
+		path := writeFile(t, "[web]\nprot = 80\n")
+		_, err := Load(path, env(map[string]string{"DATABASE_URL": "postgres://db"}))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "web.prot"))
+	})
+}
+
+func TestCurrent(t *testing.T) {
+	is := is.New(t)
+	// Nothing called Init, so the built in defaults are used rather than
+	// exiting over the missing database url.
+	cfg := Current()
+	is.Equal(cfg.Domain, "lists.sh")
+	is.Equal(cfg.DatabaseURL, "")
+	is.Equal(cfg.Web.Port, 3000)
+}
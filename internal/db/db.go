@@ -2,23 +2,53 @@ package db
 
 import (
 	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
 	"time"
+	"unicode/utf8"
+
+	"github.com/neurosnap/lists.sh/pkg"
 )
 
 var ErrNameTaken = errors.New("name taken")
+var ErrInviteInvalid = errors.New("invite code is invalid or has already been used")
+var ErrUserSuspended = errors.New("this account has been suspended, contact hello@lists.sh")
+var ErrLastKey = errors.New("you can't remove your only key, add another one first")
+var ErrLoginInvalid = errors.New("this sign in link has expired or was already used")
+var ErrAuthRequestInvalid = errors.New("this sign in request has expired or was already answered")
+var ErrLastOwner = errors.New("a blog needs an owner, make another member one first")
+var ErrPublicKeyNotFound = errors.New("no public keys found for key provided")
+
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
 
 type PublicKey struct {
-	ID        string     `json:"id"`
-	UserID    string     `json:"user_id"`
-	Key       string     `json:"key"`
-	CreatedAt *time.Time `json:"created_at"`
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Key        string     `json:"key"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
 }
 
 type User struct {
-	ID        string     `json:"id"`
-	Name      string     `json:"name"`
-	PublicKey *PublicKey `json:"public_key,omitempty"`
-	CreatedAt *time.Time `json:"created_at"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name,omitempty"`
+	Bio         string     `json:"bio,omitempty"`
+	PublicKey   *PublicKey `json:"public_key,omitempty"`
+	CreatedAt   *time.Time `json:"created_at"`
+	Status      string     `json:"status,omitempty"`
+	// Delisted is set by an admin to keep the user out of discovery.
+	Delisted bool `json:"delisted,omitempty"`
+}
+
+// IsActive reports whether the user is allowed to use the service.
+func (u *User) IsActive() bool {
+	return u.Status == "" || u.Status == UserStatusActive
 }
 
 type Post struct {
@@ -29,7 +59,300 @@ type Post struct {
 	Text        string     `json:"text"`
 	Description string     `json:"description"`
 	PublishAt   *time.Time `json:"publish_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
 	Username    string     `json:"username"`
+	// HiddenAt is set when an admin takes the post down.
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+	HiddenReason string     `json:"hidden_reason,omitempty"`
+	// FlaggedReason is set when the spam check holds the post back from
+	// the discovery feed.
+	FlaggedReason string `json:"flagged_reason,omitempty"`
+	Views         int    `json:"views"`
+	// Draft posts are only visible to their author.
+	Draft bool `json:"draft,omitempty"`
+	// DeletedAt is set while the post sits in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ItemCount and WordCount are counted from the text when it's saved.
+	ItemCount int `json:"item_count"`
+	WordCount int `json:"word_count"`
+	// EditedAt is the last time the text changed, nil if it never has.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// Stars come from signed in readers, likes from anyone on the web.
+	Stars int `json:"stars"`
+	Likes int `json:"likes"`
+	// AuthorID and AuthorName are the member who wrote a post on a shared
+	// blog, empty when the blog's own account did.
+	AuthorID   string `json:"author_id,omitempty"`
+	AuthorName string `json:"author,omitempty"`
+	// Visibility and Readers are who may read the post, kept from its
+	// `=: visibility` variable, see pkg.MetaData.
+	Visibility string   `json:"visibility,omitempty"`
+	Readers    []string `json:"readers,omitempty"`
+}
+
+// Post statuses, one for each tab of the TUI posts list.
+const (
+	PostStatusPublished = "published"
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusDeleted   = "deleted"
+)
+
+// TrashRetention is how long deleted posts wait in the trash before they're
+// gone for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+// Status returns which of the post statuses the post is in.
+func (p *Post) Status() string {
+	switch {
+	case p.DeletedAt != nil:
+		return PostStatusDeleted
+	case p.Draft:
+		return PostStatusDraft
+	case p.IsScheduled():
+		return PostStatusScheduled
+	}
+	return PostStatusPublished
+}
+
+// IsScheduled reports whether the post's publish date is still to come.
+func (p *Post) IsScheduled() bool {
+	return p.PublishAt != nil && p.PublishAt.After(time.Now())
+}
+
+// IsPublic reports whether anyone can see the post.
+func (p *Post) IsPublic() bool {
+	return p.IsPublished() && !p.IsRestricted()
+}
+
+// IsPublished reports whether the post is out, to everyone or only to the
+// readers it's restricted to.
+func (p *Post) IsPublished() bool {
+	return p.HiddenAt == nil && !p.Draft && p.DeletedAt == nil && !p.IsScheduled()
+}
+
+// IsRestricted reports whether only some readers may see the post.
+func (p *Post) IsRestricted() bool {
+	return p.Visibility != "" && p.Visibility != pkg.VisibilityPublic
+}
+
+// Usage is what an account stores, counted against its quota.  Posts in the
+// trash count until they're purged, and those of the account's other blogs
+// count too.
+type Usage struct {
+	Posts int
+	Bytes int
+}
+
+// UserStats summarizes an account for moderation.
+type UserStats struct {
+	User  *User
+	Posts int
+	Bytes int
+	Keys  int
+}
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusHidden    = "hidden"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Report is an abuse report filed by a reader against a post.
+type Report struct {
+	ID        string     `json:"id"`
+	PostID    string     `json:"post_id"`
+	Username  string     `json:"username"`
+	Filename  string     `json:"filename"`
+	Note      string     `json:"note"`
+	Status    string     `json:"status"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// Invite is a single use registration code.  CreatedBy is empty for codes
+// minted by an admin.
+type Invite struct {
+	ID        string     `json:"id"`
+	Code      string     `json:"code"`
+	CreatedBy string     `json:"created_by"`
+	UsedBy    string     `json:"used_by"`
+	CreatedAt *time.Time `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// Post sort orders for the TUI posts list.
+const (
+	PostSortDate    = "date"
+	PostSortUpdated = "updated"
+	PostSortTitle   = "title"
+	PostSortViews   = "views"
+)
+
+// TUI color themes.  Auto picks light or dark colors from the terminal and
+// no-color drops colors for text markers, for screen readers and monochrome
+// terminals.
+const (
+	ThemeAuto    = "auto"
+	ThemeDark    = "dark"
+	ThemeLight   = "light"
+	ThemeNoColor = "no-color"
+)
+
+// Blog index layouts.  List is the plain chronological list, digest adds
+// each post's description and tags groups posts under their tags.
+const (
+	LayoutList   = "list"
+	LayoutDigest = "digest"
+	LayoutTags   = "tags"
+)
+
+// TUI key bindings.  Emacs swaps hjkl for the control keys.
+const (
+	KeyMapDefault = "default"
+	KeyMapEmacs   = "emacs"
+)
+
+// What happens to a new upload that's word for word the same as another of
+// the user's posts, usually from a sloppy scp glob.
+const (
+	DuplicatesWarn = "warn"
+	DuplicatesSkip = "skip"
+)
+
+// Limits for the profile shown at the top of a blog.
+const (
+	MaxDisplayNameLength = 80
+	MaxBioLength         = 400
+)
+
+// ValidateProfile checks a display name and bio before they're saved.
+func ValidateProfile(displayName string, bio string) error {
+	if utf8.RuneCountInString(displayName) > MaxDisplayNameLength {
+		return fmt.Errorf("display name is longer than %d characters", MaxDisplayNameLength)
+	}
+	if utf8.RuneCountInString(bio) > MaxBioLength {
+		return fmt.Errorf("bio is longer than %d characters", MaxBioLength)
+	}
+	return nil
+}
+
+// MaxReplyEmailLength is the longest address SMTP allows.
+const MaxReplyEmailLength = 254
+
+// ValidateReplyEmail checks the address readers reply to, empty turns
+// replies off.
+func ValidateReplyEmail(email string) error {
+	if email == "" {
+		return nil
+	}
+	return ValidateEmail(email)
+}
+
+// ValidateEmail checks an address mail is sent to.
+func ValidateEmail(email string) error {
+	if len(email) > MaxReplyEmailLength {
+		return fmt.Errorf("email is longer than %d characters", MaxReplyEmailLength)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%q is not an email address, try one like you@example.com", email)
+	}
+	return nil
+}
+
+// Limits for the links to other sites at the top of a blog.
+const (
+	MaxProfileLinks      = 5
+	MaxProfileLinkLength = 256
+)
+
+// ValidateProfileLinks checks the links a user lists on their blog before
+// they're saved, each has to be a full http or https URL.
+func ValidateProfileLinks(links []string) error {
+	if len(links) > MaxProfileLinks {
+		return fmt.Errorf("you can list up to %d links", MaxProfileLinks)
+	}
+	seen := map[string]bool{}
+	for _, link := range links {
+		if len(link) > MaxProfileLinkLength {
+			return fmt.Errorf("links can't be longer than %d characters", MaxProfileLinkLength)
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%q is not a link, try one like https://example.com/@you", link)
+		}
+		if seen[link] {
+			return fmt.Errorf("%q is listed twice", link)
+		}
+		seen[link] = true
+	}
+	return nil
+}
+
+// Bounds for the number of posts or keys per page in the TUI.
+const (
+	MinPerPage = 2
+	MaxPerPage = 20
+)
+
+// KeysPerPageAuto fits as many keys on a page as the terminal has room for.
+const KeysPerPageAuto = 0
+
+// UserSettings holds per-user preferences.
+type UserSettings struct {
+	PostSort string `json:"post_sort"`
+	Timezone string `json:"timezone"`
+	PerPage  int    `json:"per_page"`
+	Theme    string `json:"theme"`
+	KeyMap   string `json:"keymap"`
+	Layout   string `json:"layout"`
+	// KeysPerPage is KeysPerPageAuto unless the user picked a number.
+	KeysPerPage int `json:"keys_per_page"`
+	// Locale is the language of the TUI, like "en" or "es".
+	Locale string `json:"locale"`
+	// Duplicates is DuplicatesWarn or DuplicatesSkip.
+	Duplicates string `json:"duplicates"`
+	// ReplyEmail gets a "reply by email" link on every post, empty for none.
+	ReplyEmail string `json:"reply_email,omitempty"`
+	// Discoverable lists the user's posts in the discovery feed, trending
+	// and topic pages, their blog is up at its own URL either way.
+	Discoverable bool `json:"discoverable"`
+}
+
+// DefaultUserSettings is used until the user changes something.
+func DefaultUserSettings() *UserSettings {
+	return &UserSettings{
+		PostSort:     PostSortDate,
+		Timezone:     "UTC",
+		PerPage:      4,
+		Theme:        ThemeAuto,
+		KeyMap:       KeyMapDefault,
+		Layout:       LayoutList,
+		KeysPerPage:  KeysPerPageAuto,
+		Locale:       "en",
+		Duplicates:   DuplicatesWarn,
+		Discoverable: true,
+	}
+}
+
+// Location returns the user's timezone, falling back to UTC when it can't
+// be loaded.
+func (s *UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// AuditLog records a single moderation action.
+type AuditLog struct {
+	ID        string     `json:"id"`
+	Actor     string     `json:"actor"`
+	Action    string     `json:"action"`
+	Target    string     `json:"target"`
+	Note      string     `json:"note"`
+	CreatedAt *time.Time `json:"created_at"`
 }
 
 type Paginate[T any] struct {
@@ -37,6 +360,12 @@ type Paginate[T any] struct {
 	Total int
 }
 
+// FeedPost is a post in a user's following feed.
+type FeedPost struct {
+	Post   *Post
+	Unread bool
+}
+
 type Analytics struct {
 	TotalUsers     int
 	UsersLastMonth int
@@ -44,11 +373,275 @@ type Analytics struct {
 	PostsLastMonth int
 }
 
+// DailyViews is the number of post views on a single day.
+type DailyViews struct {
+	Day   time.Time `json:"day"`
+	Views int       `json:"views"`
+}
+
+// Referrer is a site that sent readers to a user's posts.
+type Referrer struct {
+	Host  string `json:"host"`
+	Views int    `json:"views"`
+}
+
+// UserAnalytics is what a user can see about their own readers.
+type UserAnalytics struct {
+	Daily       []*DailyViews `json:"daily"`
+	Referrers   []*Referrer   `json:"referrers"`
+	Subscribers int           `json:"subscribers"`
+}
+
+// Hosting a blog can be pushed to as a static site.
+const (
+	PublishS3      = "s3"
+	PublishNetlify = "netlify"
+)
+
+// PublishTarget is where a user's blog is pushed after every change. Only
+// the fields for its kind are set.
+type PublishTarget struct {
+	UserID        string     `json:"user_id"`
+	Kind          string     `json:"kind"`
+	S3Endpoint    string     `json:"s3_endpoint,omitempty"`
+	S3Region      string     `json:"s3_region,omitempty"`
+	S3Bucket      string     `json:"s3_bucket,omitempty"`
+	S3AccessKey   string     `json:"-"`
+	S3SecretKey   string     `json:"-"`
+	NetlifySiteID string     `json:"netlify_site_id,omitempty"`
+	NetlifyToken  string     `json:"-"`
+	QueuedAt      *time.Time `json:"queued_at"`
+	PublishedAt   *time.Time `json:"published_at"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Reader is who's signed in on the web: an account, or someone without one
+// that posts were shared with by email.
+type Reader struct {
+	User  *User
+	Email string
+}
+
+// Name is the reader's username, or their email without an account.
+func (r *Reader) Name() string {
+	if r.User != nil {
+		return r.User.Name
+	}
+	return r.Email
+}
+
+// Blog is an extra blog run from someone's account. It's a user of its own,
+// without keys, so posts, profile and URL work just like the main blog's.
+// Role is what the user it was looked up for may do on it.
+type Blog struct {
+	ID        string     `json:"id"`
+	OwnerID   string     `json:"owner_id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// Roles on a shared blog: owners manage its members, editors publish.
+const (
+	BlogOwner  = "owner"
+	BlogEditor = "editor"
+)
+
+// BlogMember is someone who can publish to a blog, or has been invited to
+// while JoinedAt is nil.
+type BlogMember struct {
+	BlogID    string     `json:"blog_id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"created_at"`
+	JoinedAt  *time.Time `json:"joined_at"`
+}
+
+// Kinds of site activity counted in the daily stats.  Posts and edits count
+// their author as a publisher for the day, the other kinds don't need to
+// know who it was.
+const (
+	ActivitySignup  = "signup"
+	ActivitySession = "session"
+	ActivityPost    = "post"
+	ActivityEdit    = "edit"
+)
+
+// SiteStats is how much happened across the site on a single day.
+type SiteStats struct {
+	Day        time.Time `json:"day"`
+	Signups    int       `json:"signups"`
+	Publishers int       `json:"publishers"`
+	Posts      int       `json:"posts"`
+	Sessions   int       `json:"sessions"`
+}
+
 type Pager struct {
 	Limit  int
 	Offset int
 }
 
+// Job is a piece of background work, see internal/jobs.  Payload is what
+// the job's handler needs, as JSON.
+type Job struct {
+	ID          string
+	Kind        string
+	Payload     string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	CreatedAt   time.Time
+}
+
+// Job statuses.  Jobs that ran are deleted, ones that keep failing stay
+// dead until an admin requeues them.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDead    = "dead"
+)
+
+// PostEvent records that a post changed, written along with the change so
+// nothing that has to react to it can miss it, see internal/events.
+type PostEvent struct {
+	ID        int64     `json:"id"`
+	PostID    string    `json:"post_id"`
+	UserID    string    `json:"user_id"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Post event kinds.  Moving a post to the trash deletes it as far as
+// anyone else can tell, restoring it creates it again.
+const (
+	PostCreated = "created"
+	PostUpdated = "updated"
+	PostDeleted = "deleted"
+)
+
+// UserFlag turns a feature on or off for a single user, whatever the
+// rollout says, see internal/flags.
+type UserFlag struct {
+	UserID    string
+	Username  string
+	Flag      string
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+// ProfileLink is a page elsewhere, like a Mastodon profile, that the user
+// lists at the top of their blog with rel="me".  VerifiedAt is when the
+// page was last seen linking back to the blog, see internal/relme.
+type ProfileLink struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	URL        string     `json:"url"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CheckedAt  *time.Time `json:"checked_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// ShortLink redirects lists.sh/-/Slug to a post and counts the clicks.
+type ShortLink struct {
+	Slug      string     `json:"slug"`
+	PostID    string     `json:"post_id"`
+	Clicks    int        `json:"clicks"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// AuthRequest statuses, a request moves through them in this order or
+// stops at denied.
+const (
+	AuthRequestPending  = "pending"
+	AuthRequestApproved = "approved"
+	AuthRequestDenied   = "denied"
+	AuthRequestIssued   = "issued"
+	AuthRequestUsed     = "used"
+)
+
+// AuthRequest is an IndieAuth sign in to another site as the user's blog,
+// see internal/indieauth.  Challenge is the code shown in the browser that
+// the user matches up before approving it in the TUI.
+type AuthRequest struct {
+	ID            string
+	UserID        string
+	Challenge     string
+	ClientID      string
+	RedirectURI   string
+	State         string
+	CodeChallenge string
+	Scope         string
+	Status        string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// Snapshot is a full copy of the data required to restore the service.
+type Snapshot struct {
+	CreatedAt  time.Time     `json:"created_at"`
+	Users      []*User       `json:"users"`
+	PublicKeys []*PublicKey  `json:"public_keys"`
+	Posts      []*Post       `json:"posts"`
+	Blogs      []*Blog       `json:"blogs"`
+	Members    []*BlogMember `json:"blog_members"`
+}
+
+// Without returns a copy of the snapshot leaving out the erased accounts and
+// everything of theirs, so restoring an old backup can't bring them back.
+// Posts they wrote on blogs that are kept lose their author.
+func (s *Snapshot) Without(erased map[string]bool) *Snapshot {
+	out := &Snapshot{CreatedAt: s.CreatedAt}
+	for _, user := range s.Users {
+		if !erased[user.ID] {
+			out.Users = append(out.Users, user)
+		}
+	}
+	blogs := map[string]bool{}
+	for _, blog := range s.Blogs {
+		if erased[blog.OwnerID] || erased[blog.UserID] {
+			continue
+		}
+		blogs[blog.ID] = true
+		out.Blogs = append(out.Blogs, blog)
+	}
+	for _, m := range s.Members {
+		if blogs[m.BlogID] && !erased[m.UserID] {
+			out.Members = append(out.Members, m)
+		}
+	}
+	for _, pk := range s.PublicKeys {
+		if !erased[pk.UserID] {
+			out.PublicKeys = append(out.PublicKeys, pk)
+		}
+	}
+	for _, post := range s.Posts {
+		if erased[post.UserID] {
+			continue
+		}
+		if erased[post.AuthorID] {
+			kept := *post
+			kept.AuthorID = ""
+			post = &kept
+		}
+		out.Posts = append(out.Posts, post)
+	}
+	return out
+}
+
+// ErrEraseIncomplete is returned when personal data is still found after an
+// account was erased.
+type ErrEraseIncomplete struct {
+	Rows int
+}
+
+func (e *ErrEraseIncomplete) Error() string {
+	return fmt.Sprintf("account erasure incomplete, %d rows remain", e.Rows)
+}
+
 type ErrMultiplePublicKeys struct{}
 
 func (m *ErrMultiplePublicKeys) Error() string {
@@ -60,6 +653,8 @@ type DB interface {
 	LinkUserKey(userID string, key string) error
 	PublicKeyForKey(key string) (*PublicKey, error)
 	ListKeysForUser(user *User) ([]*PublicKey, error)
+	TouchPublicKey(keyID string) error
+	RemoveUserKey(userID string, keyID string) error
 
 	SiteAnalytics() (*Analytics, error)
 
@@ -69,14 +664,132 @@ type DB interface {
 	User(userID string) (*User, error)
 	ValidateName(name string) bool
 	SetUserName(userID string, name string) error
+	SetUserProfile(userID string, displayName string, bio string) error
 
 	FindPost(postID string) (*Post, error)
 	PostsForUser(userID string) ([]*Post, error)
+	PostsForUserWithStatus(userID string, status string) ([]*Post, error)
+	SearchPostsForUser(userID string, query string) ([]*Post, error)
 	FindPostWithFilename(filename string, userID string) (*Post, error)
 	FindAllPosts(pager *Pager) (*Paginate[*Post], error)
+	FindTrendingPosts(pager *Pager) (*Paginate[*Post], error)
+	FindPopularPosts(pager *Pager) (*Paginate[*Post], error)
+	FindTagPosts(tag string, pager *Pager) (*Paginate[*Post], error)
+	RefreshTrending() error
 	InsertPost(userID string, filename string, title string, text string, description string, publishAt *time.Time) (*Post, error)
 	UpdatePost(postID string, title string, text string, description string, publishAt *time.Time) (*Post, error)
 	RemovePosts(postIDs []string) error
+	SoftDeletePosts(postIDs []string) error
+	UndeletePosts(postIDs []string) error
+	PurgeDeletedPosts(before time.Time) error
+	RecordPostView(postID string, referrer string) error
+	RecordFeedSubscribers(userID string, fetcher string, subscribers int) error
+	FindUserAnalytics(userID string, since time.Time) (*UserAnalytics, error)
+	FindUserUsage(userID string) (*Usage, error)
+	UpdatePostVisibility(postIDs []string, draft bool) error
+	RenamePost(post *Post, filename string) error
+	FindPostRedirect(userID string, filename string) (string, error)
+	FindAdjacentPosts(post *Post, now time.Time) (*Post, *Post, error)
+	FindRelatedPosts(post *Post, tags []string, now time.Time, limit int) ([]*Post, error)
+
+	FollowUser(userID string, authorID string) error
+	UnfollowUser(userID string, authorID string) error
+	FindFollowing(userID string) ([]string, error)
+	FindFollowingPosts(userID string, pager *Pager) (*Paginate[*FeedPost], error)
+	MarkPostRead(userID string, postID string) error
+	StarPost(userID string, postID string) error
+	UnstarPost(userID string, postID string) error
+	FindStarred(userID string) ([]string, error)
+	LikePost(postID string) error
+
+	UserStats() ([]*UserStats, error)
+	SetUserStatus(userID string, status string) error
+	SetUserDelisted(userID string, delisted bool) error
+	RemoveKeysForUser(userID string) error
+	HidePost(postID string, reason string) error
+	UnhidePost(postID string) error
+	InsertAuditLog(entry *AuditLog) error
+	FindAuditLog(limit int) ([]*AuditLog, error)
+	RecordActivity(userID string, kind string) error
+	FindSiteStats(since time.Time) ([]*SiteStats, error)
+
+	FindBlogs(userID string) ([]*Blog, error)
+	AddBlog(ownerID string, name string) (*Blog, error)
+	FindBlogInvites(userID string) ([]*Blog, error)
+	FindBlogMembers(blogID string) ([]*BlogMember, error)
+	InviteBlogMember(blogID string, userID string, role string, invitedBy string) error
+	JoinBlog(blogID string, userID string) error
+	SetBlogMemberRole(blogID string, userID string, role string) error
+	RemoveBlogMember(blogID string, userID string) error
+	SetPostAuthor(postID string, authorID string) error
+
+	FindPublishTarget(userID string) (*PublishTarget, error)
+	SetPublishTarget(target *PublishTarget) error
+	RemovePublishTarget(userID string) error
+	QueuePublish(userID string) error
+	FindQueuedPublishes() ([]*PublishTarget, error)
+	FinishPublish(userID string, queuedAt time.Time, publishErr string) error
+
+	CountDuplicatePosts(userID string, text string) (int, error)
+	FindDuplicatePost(userID string, filename string, text string) (*Post, error)
+	FlagPost(postID string, reason string) error
+	FindFlaggedPosts() ([]*Post, error)
+
+	InsertInvite(userID string, code string) (*Invite, error)
+	FindInvitesForUser(userID string) ([]*Invite, error)
+	RedeemInvite(code string, userID string) error
+	RemoveUser(userID string) error
+	EraseUser(userID string, name string) error
+
+	FindUserSettings(userID string) (*UserSettings, error)
+	UpdateUserSettings(userID string, settings *UserSettings) error
+
+	InsertReport(postID string, note string) error
+	FindReport(reportID string) (*Report, error)
+	FindReports(status string) ([]*Report, error)
+	SetReportStatus(reportID string, status string) error
+
+	InsertReaderLogin(userID string, loginToken string, expiresAt time.Time) error
+	RedeemReaderLogin(loginToken string, sessionToken string, expiresAt time.Time) error
+	InsertEmailLogin(email string, loginToken string, expiresAt time.Time) error
+	IsReaderEmail(email string) (bool, error)
+	ReaderForSession(sessionToken string) (*Reader, error)
+	RemoveReaderSession(sessionToken string) error
+	RemoveReaderSessions(userID string) error
+
+	EnqueueJob(kind string, payload string, maxAttempts int, runAt time.Time) error
+	ClaimJobs(limit int, now time.Time, lease time.Duration) ([]*Job, error)
+	FinishJob(jobID string) error
+	RetryJob(jobID string, runAt time.Time, jobErr string) error
+	BuryJob(jobID string, jobErr string) error
+	FindDeadJobs() ([]*Job, error)
+	RequeueJob(jobID string) error
+	DispatchPostEvents(jobKinds []string, limit int, maxAttempts int) (int, error)
+
+	FindUserFlags(userID string) ([]*UserFlag, error)
+	FindFlagUsers(flag string) ([]*UserFlag, error)
+	SetUserFlag(userID string, flag string, enabled bool) error
+	RemoveUserFlag(userID string, flag string) error
+
+	FindProfileLinks(userID string) ([]*ProfileLink, error)
+	FindProfileLink(linkID string) (*ProfileLink, error)
+	SetProfileLinks(userID string, urls []string) ([]*ProfileLink, error)
+	SetProfileLinkChecked(linkID string, verified bool, checkedAt time.Time) error
+
+	ShortLinkForPost(postID string) (*ShortLink, error)
+	FindShortLink(slug string) (*ShortLink, error)
+	ClickShortLink(slug string) error
+
+	InsertAuthRequest(req *AuthRequest, handle string) error
+	FindAuthRequest(handle string) (*AuthRequest, error)
+	FindPendingAuthRequests(userID string) ([]*AuthRequest, error)
+	AnswerAuthRequest(requestID string, userID string, approved bool) error
+	IssueAuthCode(handle string, code string, expiresAt time.Time) error
+	RedeemAuthCode(code string) (*AuthRequest, error)
+
+	Snapshot() (*Snapshot, error)
+	RestoreSnapshot(snapshot *Snapshot) error
 
+	Ping() error
 	Close() error
 }
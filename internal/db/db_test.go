@@ -0,0 +1,48 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSnapshotWithout(t *testing.T) {
+	is := is.New(t)
+	snapshot := &Snapshot{
+		Users: []*User{{ID: "erased"}, {ID: "kept"}, {ID: "erased-blog"}, {ID: "kept-blog"}},
+		PublicKeys: []*PublicKey{
+			{ID: "k1", UserID: "erased"},
+			{ID: "k2", UserID: "kept"},
+		},
+		Blogs: []*Blog{
+			{ID: "b1", OwnerID: "erased", UserID: "erased-blog"},
+			{ID: "b2", OwnerID: "kept", UserID: "kept-blog"},
+		},
+		Members: []*BlogMember{
+			{BlogID: "b1", UserID: "kept"},
+			{BlogID: "b2", UserID: "erased"},
+			{BlogID: "b2", UserID: "kept"},
+		},
+		Posts: []*Post{
+			{ID: "p1", UserID: "erased"},
+			{ID: "p2", UserID: "erased-blog"},
+			{ID: "p3", UserID: "kept-blog", AuthorID: "erased"},
+			{ID: "p4", UserID: "kept"},
+		},
+	}
+
+	got := snapshot.Without(map[string]bool{"erased": true, "erased-blog": true})
+	is.Equal(len(got.Users), 2)
+	is.Equal(got.Users[0].ID, "kept")
+	is.Equal(len(got.PublicKeys), 1)
+	is.Equal(got.PublicKeys[0].ID, "k2")
+	is.Equal(len(got.Blogs), 1)
+	is.Equal(got.Blogs[0].ID, "b2")
+	is.Equal(len(got.Members), 1)
+	is.Equal(*got.Members[0], BlogMember{BlogID: "b2", UserID: "kept"})
+	is.Equal(len(got.Posts), 2)
+	is.Equal(got.Posts[0].ID, "p3")
+	is.Equal(got.Posts[0].AuthorID, "") // kept, without its author
+	is.Equal(snapshot.Posts[2].AuthorID, "erased")
+	is.Equal(got.Posts[1].ID, "p4")
+}
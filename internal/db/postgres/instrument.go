@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+)
+
+const instrumentedDriverName = "postgres+metrics"
+
+var (
+	queryDuration = metrics.NewHistogram(
+		"lists_db_query_duration_seconds",
+		"Latency of database queries by statement type.",
+		nil,
+		"statement",
+	)
+	queryErrors = metrics.NewCounter(
+		"lists_db_query_errors_total",
+		"Database queries that returned an error, by statement type.",
+		"statement",
+	)
+)
+
+func init() {
+	sql.Register(instrumentedDriverName, instrumentedDriver{&pq.Driver{}})
+}
+
+// statement returns the leading keyword of a query, e.g. "select", so the
+// metric cardinality stays small.
+func statement(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(fields[0])
+}
+
+func observe(query string, start time.Time, err error) {
+	stmt := statement(query)
+	queryDuration.Since(start, stmt)
+	if err != nil && err != driver.ErrSkip {
+		queryErrors.Inc(stmt)
+	}
+}
+
+// instrumentedDriver wraps pq so every query is timed without touching the
+// individual storage methods.
+type instrumentedDriver struct {
+	driver.Driver
+}
+
+func (d instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn}, nil
+}
+
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	observe(query, start, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	observe(query, start, err)
+	return res, err
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
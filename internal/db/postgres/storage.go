@@ -1,63 +1,323 @@
 package postgres
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"math"
-	"os"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/bodies"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"github.com/neurosnap/lists.sh/pkg"
 )
 
 var PAGER_SIZE = 15
 
+// likeEscaper escapes the LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 const (
-	sqlSelectPublicKey         = `SELECT id, user_id, public_key, created_at FROM public_keys WHERE public_key = $1`
-	sqlSelectPublicKeys        = `SELECT id, user_id, public_key, created_at FROM public_keys WHERE user_id = $1`
-	sqlSelectUser              = `SELECT id, name, created_at FROM app_users WHERE id = $1`
-	sqlSelectUserForName       = `SELECT id, name, created_at FROM app_users WHERE name = $1`
-	sqlSelectUserForNameAndKey = `SELECT app_users.id, app_users.name, app_users.created_at, public_keys.id as pk_id, public_keys.public_key, public_keys.created_at as pk_created_at FROM app_users LEFT OUTER JOIN public_keys ON public_keys.user_id = app_users.id WHERE app_users.name = $1 AND public_keys.public_key = $2`
+	postColumns = `posts.id, user_id, filename, title, text, description, publish_at, posts.updated_at, app_users.name as username, hidden_at, hidden_reason, flagged_reason, views, draft, deleted_at, item_count, word_count, edited_at, stars, likes, posts.author_id, (SELECT authors.name FROM app_users AS authors WHERE authors.id = posts.author_id), posts.visibility, posts.readers, posts.body_key`
+	// Statements that change posts record what they did in post_events as
+	// part of the same statement, from the id and user_id of the rows they
+	// return as "changed".
+	sqlPostsCreated = `INSERT INTO post_events (post_id, user_id, kind) SELECT id, user_id, 'created' FROM changed`
+	sqlPostsUpdated = `INSERT INTO post_events (post_id, user_id, kind) SELECT id, user_id, 'updated' FROM changed`
+	sqlPostsDeleted = `INSERT INTO post_events (post_id, user_id, kind) SELECT id, user_id, 'deleted' FROM changed`
+	userColumns     = `app_users.id, app_users.name, app_users.created_at, app_users.status, app_users.display_name, app_users.bio, app_users.delisted`
+
+	sqlSelectPublicKey         = `SELECT id, user_id, public_key, created_at, last_used_at FROM public_keys WHERE public_key = $1`
+	sqlSelectPublicKeys        = `SELECT id, user_id, public_key, created_at, last_used_at FROM public_keys WHERE user_id = $1 ORDER BY created_at`
+	sqlSelectUser              = `SELECT ` + userColumns + ` FROM app_users WHERE id = $1`
+	sqlSelectUserForName       = `SELECT ` + userColumns + ` FROM app_users WHERE name = $1`
+	sqlSelectUserForNameAndKey = `SELECT ` + userColumns + `, public_keys.id as pk_id, public_keys.public_key, public_keys.created_at as pk_created_at FROM app_users LEFT OUTER JOIN public_keys ON public_keys.user_id = app_users.id WHERE app_users.name = $1 AND public_keys.public_key = $2`
 
 	sqlSelectTotalUsers     = `SELECT count(id) FROM app_users`
 	sqlSelectUsersLastMonth = `SELECT count(id) FROM app_users WHERE created_at >= $1`
 	sqlSelectTotalPosts     = `SELECT count(id) FROM posts`
 	sqlSelectPostsLastMonth = `SELECT count(id) FROM posts WHERE created_at >= $1`
 
-	sqlSelectPostWithFilename = `SELECT posts.id, user_id, filename, title, text, description, publish_at, app_users.name as username FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename = $1 AND user_id = $2`
-	sqlSelectPost             = `SELECT posts.id, user_id, filename, title, text, description, publish_at, app_users.name as username FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE posts.id = $1`
-	sqlSelectPostsForUser     = `SELECT posts.id, user_id, filename, title, text, description, publish_at, app_users.name as username FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 ORDER BY publish_at DESC`
-	sqlSelectAllPosts         = `SELECT posts.id, user_id, filename, title, text, description, publish_at, app_users.name as username FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' ORDER BY publish_at DESC LIMIT $1 OFFSET $2`
-	sqlSelectPostCount        = `SELECT count(id) FROM posts`
+	sqlSelectPostWithFilename = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename = $1 AND user_id = $2`
+	sqlSelectPost             = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE posts.id = $1`
+	sqlSelectPostsForUser     = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NULL ORDER BY publish_at DESC`
+	sqlSearchPostsForUser     = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NULL AND (title ILIKE $2 OR filename ILIKE $2) ORDER BY publish_at DESC`
+	sqlSelectAllPosts         = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $3 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false) ORDER BY publish_at DESC LIMIT $1 OFFSET $2`
+	sqlSelectPublishedPosts   = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NULL AND draft = false AND publish_at <= $2 ORDER BY publish_at DESC`
+	sqlSelectDraftPosts       = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NULL AND draft = true ORDER BY publish_at DESC`
+	sqlSelectScheduledPosts   = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NULL AND draft = false AND publish_at > $2 ORDER BY publish_at`
+	sqlSelectDeletedPosts     = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	sqlSelectDiscoverCount    = `SELECT count(posts.id) FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $1 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false)`
+	sqlSelectTrendingPosts    = `SELECT ` + postColumns + ` FROM trending_posts INNER JOIN posts ON posts.id = trending_posts.post_id LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $3 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false) ORDER BY trending_posts.score DESC, publish_at DESC LIMIT $1 OFFSET $2`
+	sqlSelectTrendingCount    = `SELECT count(posts.id) FROM trending_posts INNER JOIN posts ON posts.id = trending_posts.post_id LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $1 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false)`
+	sqlSelectPopularPosts     = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $3 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false) ORDER BY views + 5 * stars DESC, publish_at DESC LIMIT $1 OFFSET $2`
+	sqlRefreshTrending        = `REFRESH MATERIALIZED VIEW CONCURRENTLY trending_posts`
+	sqlSelectTagPosts         = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE tags @> ARRAY[$4]::text[] AND filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $3 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false) ORDER BY publish_at DESC LIMIT $1 OFFSET $2`
+	sqlSelectTagCount         = `SELECT count(posts.id) FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE tags @> ARRAY[$1]::text[] AND filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $2 AND flagged_reason = '' AND visibility = 'public' AND app_users.status = 'active' AND app_users.delisted = false AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_settings.user_id = posts.user_id AND user_settings.discoverable = false)`
+	sqlSelectFollowingPosts   = `SELECT ` + postColumns + `, NOT EXISTS (SELECT 1 FROM post_reads WHERE post_reads.user_id = $4 AND post_reads.post_id = posts.id) FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE posts.user_id IN (SELECT author_id FROM follows WHERE user_id = $4) AND filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $3 AND flagged_reason = '' AND (visibility IN ('public', 'followers') OR (SELECT name FROM app_users AS readers WHERE readers.id = $4) = ANY(readers)) AND app_users.status = 'active' ORDER BY publish_at DESC LIMIT $1 OFFSET $2`
+	sqlSelectFollowingCount   = `SELECT count(posts.id) FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE posts.user_id IN (SELECT author_id FROM follows WHERE user_id = $1) AND filename <> '_readme' AND filename <> '_header' AND filename <> '_footer' AND filename <> '_404' AND filename <> '_dictionary' AND hidden_at IS NULL AND draft = false AND deleted_at IS NULL AND publish_at <= $2 AND flagged_reason = '' AND (visibility IN ('public', 'followers') OR (SELECT name FROM app_users AS readers WHERE readers.id = $1) = ANY(readers)) AND app_users.status = 'active'`
 
 	sqlInsertPublicKey = `INSERT INTO public_keys (user_id, public_key) VALUES ($1, $2)`
-	sqlInsertPost      = `INSERT INTO posts (user_id, filename, title, text, description, publish_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	sqlTouchPublicKey  = `UPDATE public_keys SET last_used_at = $1 WHERE id = $2`
+	sqlCountUserKeys   = `SELECT count(id) FROM public_keys WHERE user_id = $1`
+	sqlRemoveUserKey   = `DELETE FROM public_keys WHERE id = $1 AND user_id = $2`
+	sqlInsertPost      = `WITH changed AS (INSERT INTO posts (user_id, filename, title, text, description, publish_at, item_count, word_count, tags, visibility, readers, body_key, text_bytes, text_md5) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, user_id), events AS (` + sqlPostsCreated + `) SELECT id FROM changed`
 	sqlInsertUser      = `INSERT INTO app_users DEFAULT VALUES returning id`
 
-	sqlUpdatePost     = `UPDATE posts SET title = $1, text = $2, description = $3, updated_at = $4, publish_at = $5 WHERE id = $6`
-	sqlUpdateUserName = `UPDATE app_users SET name = $1 WHERE id = $2`
+	sqlUpdatePost        = `WITH old AS (SELECT body_key FROM posts WHERE id = $6 FOR UPDATE), changed AS (UPDATE posts SET title = $1, text = $2, description = $3, updated_at = $4, edited_at = CASE WHEN text_md5 = $14 THEN edited_at ELSE $4 END, publish_at = $5, deleted_at = NULL, item_count = $7, word_count = $8, tags = $9, visibility = $10, readers = $11, body_key = $12, text_bytes = $13, text_md5 = $14 FROM old WHERE posts.id = $6 RETURNING posts.id, posts.user_id, old.body_key), events AS (` + sqlPostsUpdated + `) SELECT body_key FROM changed`
+	sqlUpdateUserName    = `UPDATE app_users SET name = $1 WHERE id = $2`
+	sqlUpdateUserProfile = `UPDATE app_users SET display_name = $1, bio = $2 WHERE id = $3`
+
+	sqlRemovePosts          = `WITH changed AS (DELETE FROM posts WHERE id = ANY($1) RETURNING id, user_id, body_key, deleted_at), events AS (` + sqlPostsDeleted + ` WHERE deleted_at IS NULL) SELECT body_key FROM changed`
+	sqlSoftDeletePosts      = `WITH changed AS (UPDATE posts SET deleted_at = $1 WHERE id = ANY($2) RETURNING id, user_id) ` + sqlPostsDeleted
+	sqlUndeletePosts        = `WITH changed AS (UPDATE posts SET deleted_at = NULL WHERE id = ANY($1) RETURNING id, user_id) ` + sqlPostsCreated
+	sqlPurgeDeletedPosts    = `DELETE FROM posts WHERE deleted_at < $1 RETURNING body_key`
+	sqlIncrementPostViews   = `UPDATE posts SET views = views + 1 WHERE id = $1`
+	sqlIncrementDailyViews  = `INSERT INTO post_views_daily (post_id, day, views) VALUES ($1, $2, 1) ON CONFLICT (post_id, day) DO UPDATE SET views = post_views_daily.views + 1`
+	sqlIncrementReferrer    = `INSERT INTO post_referrers (post_id, host, views) VALUES ($1, $2, 1) ON CONFLICT (post_id, host) DO UPDATE SET views = post_referrers.views + 1`
+	sqlUpsertSubscribers    = `INSERT INTO feed_subscribers (user_id, fetcher, subscribers, updated_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, fetcher) DO UPDATE SET subscribers = EXCLUDED.subscribers, updated_at = EXCLUDED.updated_at`
+	sqlIncrementSiteStats   = `INSERT INTO site_stats_daily (day, signups, publishers, posts, sessions) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (day) DO UPDATE SET signups = site_stats_daily.signups + EXCLUDED.signups, publishers = site_stats_daily.publishers + EXCLUDED.publishers, posts = site_stats_daily.posts + EXCLUDED.posts, sessions = site_stats_daily.sessions + EXCLUDED.sessions`
+	sqlMarkPublisher        = `UPDATE app_users SET last_published_on = $2 WHERE id = $1 AND (last_published_on IS NULL OR last_published_on < $2)`
+	sqlSelectSiteStats      = `SELECT day, signups, publishers, posts, sessions FROM site_stats_daily WHERE day >= $1 ORDER BY day`
+	publishTargetColumns    = `user_id, kind, s3_endpoint, s3_region, s3_bucket, s3_access_key, s3_secret_key, netlify_site_id, netlify_token, queued_at, published_at, last_error`
+	sqlSelectPublishTarget  = `SELECT ` + publishTargetColumns + ` FROM publish_targets WHERE user_id = $1`
+	sqlSelectQueuedTargets  = `SELECT ` + publishTargetColumns + ` FROM publish_targets WHERE queued_at IS NOT NULL ORDER BY queued_at`
+	sqlUpsertPublishTarget  = `INSERT INTO publish_targets (user_id, kind, s3_endpoint, s3_region, s3_bucket, s3_access_key, s3_secret_key, netlify_site_id, netlify_token, queued_at, last_error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, '') ON CONFLICT (user_id) DO UPDATE SET kind = EXCLUDED.kind, s3_endpoint = EXCLUDED.s3_endpoint, s3_region = EXCLUDED.s3_region, s3_bucket = EXCLUDED.s3_bucket, s3_access_key = EXCLUDED.s3_access_key, s3_secret_key = EXCLUDED.s3_secret_key, netlify_site_id = EXCLUDED.netlify_site_id, netlify_token = EXCLUDED.netlify_token, queued_at = EXCLUDED.queued_at, last_error = ''`
+	sqlDeletePublishTarget  = `DELETE FROM publish_targets WHERE user_id = $1`
+	sqlQueuePublish         = `UPDATE publish_targets SET queued_at = $2 WHERE user_id = $1`
+	blogColumns             = `blogs.id, blogs.owner_id, blogs.user_id, app_users.name, blog_members.role, blogs.created_at`
+	sqlSelectBlogs          = `SELECT ` + blogColumns + ` FROM blogs INNER JOIN blog_members ON blog_members.blog_id = blogs.id INNER JOIN app_users ON app_users.id = blogs.user_id WHERE blog_members.user_id = $1 AND blog_members.joined_at IS NOT NULL ORDER BY app_users.name`
+	sqlSelectBlogInvites    = `SELECT ` + blogColumns + ` FROM blogs INNER JOIN blog_members ON blog_members.blog_id = blogs.id INNER JOIN app_users ON app_users.id = blogs.user_id WHERE blog_members.user_id = $1 AND blog_members.joined_at IS NULL ORDER BY app_users.name`
+	sqlSelectBlogMembers    = `SELECT blog_members.blog_id, blog_members.user_id, app_users.name, blog_members.role, blog_members.created_at, blog_members.joined_at FROM blog_members INNER JOIN app_users ON app_users.id = blog_members.user_id WHERE blog_members.blog_id = $1 ORDER BY blog_members.joined_at IS NULL, app_users.name`
+	sqlSelectIsBlog         = `SELECT EXISTS (SELECT 1 FROM blogs WHERE user_id = $1)`
+	sqlInsertBlogMember     = `INSERT INTO blog_members (blog_id, user_id, role, invited_by, joined_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (blog_id, user_id) DO NOTHING`
+	sqlJoinBlog             = `UPDATE blog_members SET joined_at = $3 WHERE blog_id = $1 AND user_id = $2 AND joined_at IS NULL`
+	sqlSelectMemberRole     = `SELECT role, joined_at IS NOT NULL FROM blog_members WHERE blog_id = $1 AND user_id = $2`
+	sqlCountOtherOwners     = `SELECT count(user_id) FROM blog_members WHERE blog_id = $1 AND user_id <> $2 AND role = 'owner' AND joined_at IS NOT NULL`
+	sqlUpdateMemberRole     = `UPDATE blog_members SET role = $3 WHERE blog_id = $1 AND user_id = $2`
+	sqlRemoveBlogMember     = `DELETE FROM blog_members WHERE blog_id = $1 AND user_id = $2`
+	sqlUpdatePostAuthor     = `UPDATE posts SET author_id = $2 WHERE id = $1`
+	sqlHandOverBlog         = `UPDATE blogs SET owner_id = (SELECT user_id FROM blog_members WHERE blog_id = blogs.id AND user_id <> $2 AND role = 'owner' AND joined_at IS NOT NULL ORDER BY joined_at LIMIT 1) WHERE id = $1 AND owner_id = $2`
+	sqlSelectErasedBodyKeys = `SELECT body_key FROM posts WHERE body_key <> '' AND (user_id = $1 OR user_id IN (SELECT user_id FROM blogs WHERE owner_id = $1))`
+	sqlHandOverBlogs        = `UPDATE blogs SET owner_id = (SELECT user_id FROM blog_members WHERE blog_id = blogs.id AND user_id <> $1 AND role = 'owner' AND joined_at IS NOT NULL ORDER BY joined_at LIMIT 1) WHERE owner_id = $1 AND EXISTS (SELECT 1 FROM blog_members WHERE blog_id = blogs.id AND user_id <> $1 AND role = 'owner' AND joined_at IS NOT NULL)`
+	sqlInsertBlogUser       = `INSERT INTO app_users (name, status) SELECT $1, status FROM app_users WHERE id = $2 RETURNING id`
+	sqlInsertBlog           = `INSERT INTO blogs (owner_id, user_id) VALUES ($1, $2) RETURNING id, created_at`
+	sqlRemoveBlogsForOwner  = `DELETE FROM app_users WHERE id IN (SELECT user_id FROM blogs WHERE owner_id = $1)`
+	sqlFinishPublish        = `UPDATE publish_targets SET published_at = CASE WHEN $4 = '' THEN $3 ELSE published_at END, last_error = $4, queued_at = CASE WHEN queued_at <= $2 THEN NULL ELSE queued_at END WHERE user_id = $1`
+	sqlSelectDailyViews     = `SELECT day, sum(post_views_daily.views) FROM post_views_daily INNER JOIN posts ON posts.id = post_views_daily.post_id WHERE posts.user_id = $1 AND day >= $2 GROUP BY day ORDER BY day`
+	sqlSelectTopReferrers   = `SELECT host, sum(post_referrers.views) FROM post_referrers INNER JOIN posts ON posts.id = post_referrers.post_id WHERE posts.user_id = $1 GROUP BY host ORDER BY 2 DESC LIMIT 5`
+	sqlSelectUserUsage      = `WITH account AS (SELECT coalesce((SELECT owner_id FROM blogs WHERE user_id = $1), $1) AS id) SELECT count(id), coalesce(sum(text_bytes), 0) FROM posts WHERE user_id = (SELECT id FROM account) OR user_id IN (SELECT user_id FROM blogs WHERE owner_id = (SELECT id FROM account))`
+	sqlSelectSubscribers    = `SELECT coalesce(sum(subscribers), 0) FROM feed_subscribers WHERE user_id = $1 AND updated_at >= $2`
+	sqlUpdatePostVisibility = `WITH changed AS (UPDATE posts SET draft = $1 WHERE id = ANY($2) RETURNING id, user_id) ` + sqlPostsUpdated
+	sqlUpdatePostFilename   = `WITH changed AS (UPDATE posts SET filename = $1 WHERE id = $2 RETURNING id, user_id) ` + sqlPostsUpdated
+	sqlUpsertPostRedirect   = `INSERT INTO post_redirects (user_id, from_filename, to_filename, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, from_filename) DO UPDATE SET to_filename = EXCLUDED.to_filename, created_at = EXCLUDED.created_at`
+	sqlRepointPostRedirects = `UPDATE post_redirects SET to_filename = $1 WHERE user_id = $2 AND to_filename = $3`
+	sqlRemovePostRedirect   = `DELETE FROM post_redirects WHERE user_id = $1 AND from_filename = $2`
+	sqlSelectPostRedirect   = `SELECT to_filename FROM post_redirects WHERE user_id = $1 AND from_filename = $2`
+	sqlSelectOlderPost      = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND (publish_at, posts.id) < ($2, $3) AND left(filename, 1) <> '_' AND hidden_at IS NULL AND visibility = 'public' AND draft = false AND deleted_at IS NULL ORDER BY publish_at DESC, posts.id DESC LIMIT 1`
+	sqlSelectNewerPost      = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND (publish_at, posts.id) > ($2, $3) AND publish_at <= $4 AND left(filename, 1) <> '_' AND hidden_at IS NULL AND visibility = 'public' AND draft = false AND deleted_at IS NULL ORDER BY publish_at, posts.id LIMIT 1`
+	sqlSelectRelatedPosts   = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND posts.id <> $2 AND tags && $3 AND publish_at <= $4 AND left(filename, 1) <> '_' AND hidden_at IS NULL AND visibility = 'public' AND draft = false AND deleted_at IS NULL ORDER BY (SELECT count(*) FROM unnest(tags) AS tag WHERE tag = ANY($3)) DESC, publish_at DESC LIMIT $5`
+	sqlInsertFollow         = `INSERT INTO follows (user_id, author_id) VALUES ($1, $2) ON CONFLICT (user_id, author_id) DO NOTHING`
+	sqlRemoveFollow         = `DELETE FROM follows WHERE user_id = $1 AND author_id = $2`
+	sqlSelectFollowing      = `SELECT author_id FROM follows WHERE user_id = $1`
+	sqlInsertStar           = `INSERT INTO post_stars (user_id, post_id) VALUES ($1, $2) ON CONFLICT (user_id, post_id) DO NOTHING`
+	sqlRemoveStar           = `DELETE FROM post_stars WHERE user_id = $1 AND post_id = $2`
+	sqlSelectStarred        = `SELECT post_id FROM post_stars WHERE user_id = $1`
+	sqlAddPostStars         = `UPDATE posts SET stars = greatest(stars + $2, 0) WHERE id = $1`
+	sqlIncrementPostLikes   = `UPDATE posts SET likes = likes + 1 WHERE id = $1`
+	sqlInsertReaderLogin    = `INSERT INTO reader_sessions (user_id, login_token, expires_at) VALUES ($1, $2, $3)`
+	sqlPurgeReaderSessions  = `DELETE FROM reader_sessions WHERE expires_at < $1`
+	sqlRedeemReaderLogin    = `UPDATE reader_sessions SET login_token = NULL, session_token = $2, expires_at = $3 WHERE login_token = $1 AND expires_at > $4`
+	sqlInsertEmailLogin     = `INSERT INTO reader_sessions (email, login_token, expires_at) VALUES ($1, $2, $3)`
+	sqlSelectReaderEmail    = `SELECT EXISTS (SELECT 1 FROM posts WHERE readers @> ARRAY[$1]::text[] AND deleted_at IS NULL)`
+	sqlSelectReader         = `SELECT coalesce(user_id::text, ''), coalesce(email, '') FROM reader_sessions WHERE session_token = $1 AND expires_at > $2`
+	sqlRemoveReaderSession  = `DELETE FROM reader_sessions WHERE session_token = $1`
+	sqlRemoveReaderSessions = `DELETE FROM reader_sessions WHERE user_id = $1`
+	jobColumns              = `id, kind, payload, status, attempts, max_attempts, run_at, last_error, created_at`
+	sqlDispatchPostEvents   = `WITH dispatched AS (DELETE FROM post_events WHERE id IN (SELECT id FROM post_events ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED) RETURNING id, post_id, user_id, kind, created_at), queued AS (INSERT INTO jobs (kind, payload, max_attempts, run_at) SELECT consumer, json_build_object('id', e.id, 'post_id', e.post_id, 'user_id', e.user_id, 'kind', e.kind, 'created_at', e.created_at::timestamptz)::text, $3, $4 FROM dispatched e CROSS JOIN unnest($1::text[]) AS consumer ORDER BY e.id) SELECT count(*) FROM dispatched`
+	sqlSelectUserFlags      = `SELECT user_flags.user_id, app_users.name, flag, enabled, user_flags.created_at FROM user_flags LEFT OUTER JOIN app_users ON app_users.id = user_flags.user_id WHERE user_flags.user_id = $1 ORDER BY flag`
+	sqlSelectFlagUsers      = `SELECT user_flags.user_id, app_users.name, flag, enabled, user_flags.created_at FROM user_flags LEFT OUTER JOIN app_users ON app_users.id = user_flags.user_id WHERE flag = $1 ORDER BY app_users.name`
+	sqlUpsertUserFlag       = `INSERT INTO user_flags (user_id, flag, enabled, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, flag) DO UPDATE SET enabled = EXCLUDED.enabled, created_at = EXCLUDED.created_at`
+	sqlRemoveUserFlag       = `DELETE FROM user_flags WHERE user_id = $1 AND flag = $2`
+
+	profileLinkColumns          = `id, user_id, url, verified_at, checked_at, created_at`
+	sqlSelectProfileLinks       = `SELECT ` + profileLinkColumns + ` FROM profile_links WHERE user_id = $1 ORDER BY position`
+	sqlSelectProfileLink        = `SELECT ` + profileLinkColumns + ` FROM profile_links WHERE id = $1`
+	sqlRemoveOtherProfileLinks  = `DELETE FROM profile_links WHERE user_id = $1 AND NOT (url = ANY($2::text[]))`
+	sqlUpsertProfileLink        = `INSERT INTO profile_links (user_id, url, position) VALUES ($1, $2, $3) ON CONFLICT (user_id, url) DO UPDATE SET position = EXCLUDED.position`
+	sqlUpdateProfileLinkChecked = `UPDATE profile_links SET verified_at = CASE WHEN $2 THEN $3 ELSE NULL END, checked_at = $3 WHERE id = $1`
+	shortLinkColumns            = `slug, post_id, clicks, created_at`
+	sqlSelectShortLink          = `SELECT ` + shortLinkColumns + ` FROM short_links WHERE post_id = $1`
+	sqlSelectShortLinkForSlug   = `SELECT ` + shortLinkColumns + ` FROM short_links WHERE slug = $1`
+	sqlInsertShortLink          = `INSERT INTO short_links (slug, post_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	sqlClickShortLink           = `UPDATE short_links SET clicks = clicks + 1 WHERE slug = $1`
+	authRequestColumns          = `id, user_id, challenge, client_id, redirect_uri, state, code_challenge, scope, status, expires_at, created_at`
+	sqlPurgeAuthRequests        = `DELETE FROM auth_requests WHERE expires_at < $1`
+	sqlInsertAuthRequest        = `INSERT INTO auth_requests (user_id, handle, challenge, client_id, redirect_uri, state, code_challenge, scope, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, status, created_at`
+	sqlSelectAuthRequest        = `SELECT ` + authRequestColumns + ` FROM auth_requests WHERE handle = $1`
+	sqlSelectPendingAuthReqs    = `SELECT ` + authRequestColumns + ` FROM auth_requests WHERE user_id = $1 AND status = 'pending' AND expires_at > $2 ORDER BY created_at DESC`
+	sqlAnswerAuthRequest        = `UPDATE auth_requests SET status = $3 WHERE id = $1 AND user_id = $2 AND status = 'pending' AND expires_at > $4`
+	sqlIssueAuthCode            = `UPDATE auth_requests SET status = 'issued', code = $2, expires_at = $3 WHERE handle = $1 AND status = 'approved' AND expires_at > $4`
+	sqlRedeemAuthCode           = `UPDATE auth_requests SET status = 'used' WHERE code = $1 AND status = 'issued' AND expires_at > $2 RETURNING ` + authRequestColumns
+	sqlInsertJob                = `INSERT INTO jobs (kind, payload, max_attempts, run_at) VALUES ($1, $2, $3, $4)`
+	sqlClaimJobs                = `UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = $2 WHERE id IN (SELECT id FROM jobs WHERE (status = 'queued' AND run_at <= $2) OR (status = 'running' AND locked_at < $3) ORDER BY run_at LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING ` + jobColumns
+	sqlFinishJob                = `DELETE FROM jobs WHERE id = $1`
+	sqlRetryJob                 = `UPDATE jobs SET status = 'queued', locked_at = NULL, run_at = $2, last_error = $3 WHERE id = $1`
+	sqlBuryJob                  = `UPDATE jobs SET status = 'dead', locked_at = NULL, last_error = $2 WHERE id = $1`
+	sqlSelectDeadJobs           = `SELECT ` + jobColumns + ` FROM jobs WHERE status = 'dead' ORDER BY created_at`
+	sqlRequeueJob               = `UPDATE jobs SET status = 'queued', attempts = 0, run_at = $2 WHERE id = $1 AND status = 'dead'`
+	sqlInsertPostRead           = `INSERT INTO post_reads (user_id, post_id, read_at) VALUES ($1, $2, $3) ON CONFLICT (user_id, post_id) DO NOTHING`
+
+	sqlSelectUserStats   = `SELECT ` + userColumns + `, (SELECT count(id) FROM posts WHERE posts.user_id = app_users.id), (SELECT coalesce(sum(text_bytes), 0) FROM posts WHERE posts.user_id = app_users.id), (SELECT count(id) FROM public_keys WHERE public_keys.user_id = app_users.id) FROM app_users ORDER BY app_users.created_at`
+	sqlUpdateUserStatus  = `UPDATE app_users SET status = $1 WHERE id = $2 OR id IN (SELECT user_id FROM blogs WHERE owner_id = $2)`
+	sqlUpdateDelisted    = `UPDATE app_users SET delisted = $1 WHERE id = $2 OR id IN (SELECT user_id FROM blogs WHERE owner_id = $2)`
+	sqlRemoveKeysForUser = `DELETE FROM public_keys WHERE user_id = $1`
+	sqlHidePost          = `WITH changed AS (UPDATE posts SET hidden_at = $1, hidden_reason = $2 WHERE id = $3 RETURNING id, user_id) ` + sqlPostsUpdated
+	sqlUnhidePost        = `WITH changed AS (UPDATE posts SET hidden_at = NULL, hidden_reason = '' WHERE id = $1 RETURNING id, user_id) ` + sqlPostsUpdated
+
+	sqlSelectDuplicateCount = `SELECT count(DISTINCT user_id) FROM posts WHERE user_id <> $1 AND text_md5 = md5($2)`
+	sqlSelectDuplicatePost  = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE user_id = $1 AND filename <> $2 AND deleted_at IS NULL AND text_md5 = md5($3) ORDER BY publish_at LIMIT 1`
+	sqlUpdatePostFlag       = `WITH changed AS (UPDATE posts SET flagged_reason = $1 WHERE id = $2 RETURNING id, user_id) ` + sqlPostsUpdated
+	sqlSelectFlaggedPosts   = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id WHERE flagged_reason <> '' ORDER BY updated_at DESC`
+	sqlInsertAuditLog       = `INSERT INTO audit_log (actor, action, target, note) VALUES ($1, $2, $3, $4)`
+	sqlSelectAuditLog       = `SELECT id, actor, action, target, note, created_at FROM audit_log ORDER BY created_at DESC LIMIT $1`
+
+	inviteColumns           = `id, code, coalesce(created_by::text, ''), coalesce(used_by::text, ''), created_at, used_at`
+	sqlInsertInvite         = `INSERT INTO invites (created_by, code) VALUES ($1, $2) RETURNING ` + inviteColumns
+	sqlSelectInvitesForUser = `SELECT ` + inviteColumns + ` FROM invites WHERE created_by = $1 ORDER BY created_at`
+
+	sqlSelectUserSettings = `SELECT post_sort, timezone, per_page, theme, keymap, layout, keys_per_page, locale, duplicates, reply_email, discoverable FROM user_settings WHERE user_id = $1`
+	sqlUpsertUserSettings = `INSERT INTO user_settings (user_id, post_sort, timezone, per_page, theme, keymap, layout, keys_per_page, locale, duplicates, reply_email, discoverable, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) ON CONFLICT (user_id) DO UPDATE SET post_sort = EXCLUDED.post_sort, timezone = EXCLUDED.timezone, per_page = EXCLUDED.per_page, theme = EXCLUDED.theme, keymap = EXCLUDED.keymap, layout = EXCLUDED.layout, keys_per_page = EXCLUDED.keys_per_page, locale = EXCLUDED.locale, duplicates = EXCLUDED.duplicates, reply_email = EXCLUDED.reply_email, discoverable = EXCLUDED.discoverable, updated_at = EXCLUDED.updated_at`
+	sqlRedeemInvite       = `UPDATE invites SET used_by = $1, used_at = $2 WHERE code = $3 AND used_at IS NULL`
+	sqlRemoveUser         = `DELETE FROM app_users WHERE id = $1`
+	sqlInsertErasedUsers  = `INSERT INTO erased_users (user_id) SELECT $1::uuid UNION SELECT user_id FROM blogs WHERE owner_id = $1 ON CONFLICT (user_id) DO NOTHING`
+	sqlSelectErasedUsers  = `SELECT user_id FROM erased_users`
 
-	sqlRemovePosts = `DELETE FROM posts WHERE id IN ($1)`
+	// Audit targets are a username or username/filename, compared whole so
+	// names with LIKE wildcards only ever match themselves.
+	sqlRemoveAuditLogForName = `DELETE FROM audit_log WHERE split_part(target, '/', 1) = $1`
+	sqlSelectUserDataCount   = `SELECT (SELECT count(id) FROM app_users WHERE id = $1) + (SELECT count(id) FROM posts WHERE user_id = $1) + (SELECT count(id) FROM public_keys WHERE user_id = $1) + (SELECT count(id) FROM invites WHERE created_by = $1 OR used_by = $1) + (SELECT count(user_id) FROM user_settings WHERE user_id = $1) + (SELECT count(user_id) FROM feed_subscribers WHERE user_id = $1) + (SELECT count(user_id) FROM post_redirects WHERE user_id = $1) + (SELECT count(user_id) FROM follows WHERE user_id = $1 OR author_id = $1) + (SELECT count(user_id) FROM post_reads WHERE user_id = $1) + (SELECT count(user_id) FROM post_stars WHERE user_id = $1) + (SELECT count(user_id) FROM publish_targets WHERE user_id = $1) + (SELECT count(id) FROM blogs WHERE owner_id = $1 OR user_id = $1) + (SELECT count(user_id) FROM blog_members WHERE user_id = $1 OR invited_by = $1) + (SELECT count(id) FROM reader_sessions WHERE user_id = $1) + (SELECT count(user_id) FROM user_flags WHERE user_id = $1) + (SELECT count(id) FROM profile_links WHERE user_id = $1) + (SELECT count(id) FROM auth_requests WHERE user_id = $1) + (SELECT count(id) FROM audit_log WHERE $2 <> '' AND split_part(target, '/', 1) = $2)`
+
+	reportColumns         = `reports.id, reports.post_id, app_users.name, posts.filename, reports.note, reports.status, reports.created_at`
+	sqlInsertReport       = `INSERT INTO reports (post_id, note) VALUES ($1, $2)`
+	sqlSelectReport       = `SELECT ` + reportColumns + ` FROM reports INNER JOIN posts ON posts.id = reports.post_id INNER JOIN app_users ON app_users.id = posts.user_id WHERE reports.id = $1`
+	sqlSelectReports      = `SELECT ` + reportColumns + ` FROM reports INNER JOIN posts ON posts.id = reports.post_id INNER JOIN app_users ON app_users.id = posts.user_id WHERE $1 = '' OR reports.status = $1 ORDER BY reports.created_at`
+	sqlUpdateReportStatus = `UPDATE reports SET status = $1, resolved_at = $2 WHERE id = $3`
+
+	sqlSelectSnapshotUsers      = `SELECT ` + userColumns + ` FROM app_users`
+	sqlSelectSnapshotPublicKeys = `SELECT id, user_id, public_key, created_at FROM public_keys`
+	sqlSelectRestoredBodyKeys   = `SELECT body_key FROM posts WHERE id = ANY($1) AND body_key <> ''`
+	sqlSelectSnapshotPosts      = `SELECT ` + postColumns + ` FROM posts LEFT OUTER JOIN app_users ON app_users.id = posts.user_id`
+	sqlSelectSnapshotBlogs      = `SELECT blogs.id, blogs.owner_id, blogs.user_id, app_users.name, blogs.created_at FROM blogs INNER JOIN app_users ON app_users.id = blogs.user_id`
+	sqlSelectSnapshotMembers    = `SELECT blog_members.blog_id, blog_members.user_id, app_users.name, blog_members.role, blog_members.created_at, blog_members.joined_at FROM blog_members INNER JOIN app_users ON app_users.id = blog_members.user_id`
+	sqlRestoreUser              = `INSERT INTO app_users (id, name, created_at, status, display_name, bio, delisted) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, status = EXCLUDED.status, display_name = EXCLUDED.display_name, bio = EXCLUDED.bio, delisted = EXCLUDED.delisted`
+	sqlRestoreBlog              = `INSERT INTO blogs (id, owner_id, user_id, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`
+	sqlRestoreBlogMember        = `INSERT INTO blog_members (blog_id, user_id, role, created_at, joined_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (blog_id, user_id) DO UPDATE SET role = EXCLUDED.role, joined_at = EXCLUDED.joined_at`
+	sqlRestorePublicKey         = `INSERT INTO public_keys (id, user_id, public_key, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`
+	sqlRestorePost              = `INSERT INTO posts (id, user_id, filename, title, text, description, publish_at, hidden_at, hidden_reason, flagged_reason, views, draft, deleted_at, item_count, word_count, tags, edited_at, stars, likes, author_id, visibility, readers, body_key, text_bytes, text_md5) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25) ON CONFLICT (id) DO UPDATE SET filename = EXCLUDED.filename, title = EXCLUDED.title, text = EXCLUDED.text, description = EXCLUDED.description, publish_at = EXCLUDED.publish_at, hidden_at = EXCLUDED.hidden_at, hidden_reason = EXCLUDED.hidden_reason, flagged_reason = EXCLUDED.flagged_reason, views = EXCLUDED.views, draft = EXCLUDED.draft, deleted_at = EXCLUDED.deleted_at, item_count = EXCLUDED.item_count, word_count = EXCLUDED.word_count, tags = EXCLUDED.tags, edited_at = EXCLUDED.edited_at, stars = EXCLUDED.stars, likes = EXCLUDED.likes, author_id = EXCLUDED.author_id, visibility = EXCLUDED.visibility, readers = EXCLUDED.readers, body_key = EXCLUDED.body_key, text_bytes = EXCLUDED.text_bytes, text_md5 = EXCLUDED.text_md5`
 )
 
 type PsqlDB struct {
 	db *sql.DB
+	// bodies keeps the text of posts longer than inlineMax bytes, nil
+	// keeps all of it in the posts table.
+	bodies    bodies.Store
+	inlineMax int
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPost reads a row selected with postColumns followed by any extra
+// destinations, fetching the text from the body store when it's kept there.
+func (me *PsqlDB) scanPost(r scanner, extra ...interface{}) (*db.Post, error) {
+	post := &db.Post{}
+	var username, authorID, authorName sql.NullString
+	var bodyKey string
+	dest := append([]interface{}{
+		&post.ID,
+		&post.UserID,
+		&post.Filename,
+		&post.Title,
+		&post.Text,
+		&post.Description,
+		&post.PublishAt,
+		&post.UpdatedAt,
+		&username,
+		&post.HiddenAt,
+		&post.HiddenReason,
+		&post.FlaggedReason,
+		&post.Views,
+		&post.Draft,
+		&post.DeletedAt,
+		&post.ItemCount,
+		&post.WordCount,
+		&post.EditedAt,
+		&post.Stars,
+		&post.Likes,
+		&authorID,
+		&authorName,
+		&post.Visibility,
+		pq.Array(&post.Readers),
+		&bodyKey,
+	}, extra...)
+	err := r.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	if bodyKey != "" {
+		if me.bodies == nil {
+			return nil, fmt.Errorf("post %s is kept in a body store but none is set up", post.ID)
+		}
+		post.Text, err = me.bodies.Get(bodyKey)
+		if err != nil {
+			return nil, fmt.Errorf("post %s text: %w", post.ID, err)
+		}
+	}
+	post.Username = username.String
+	post.AuthorID = authorID.String
+	post.AuthorName = authorName.String
+	return post, nil
+}
+
+// scanUser reads a row selected with userColumns followed by any extra
+// destinations.
+func scanUser(r scanner, extra ...interface{}) (*db.User, error) {
+	user := &db.User{}
+	var name sql.NullString
+	dest := append([]interface{}{&user.ID, &name, &user.CreatedAt, &user.Status, &user.DisplayName, &user.Bio, &user.Delisted}, extra...)
+	err := r.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	user.Name = name.String
+	return user, nil
 }
 
 func NewDB() *PsqlDB {
-	databaseUrl := os.Getenv("DATABASE_URL")
+	databaseUrl := config.Current().DatabaseURL
 	var err error
 	logger := internal.CreateLogger()
 	logger.Infof("Connecting to postgres: %s", databaseUrl)
 
-	db, err := sql.Open("postgres", databaseUrl)
+	db, err := sql.Open(instrumentedDriverName, databaseUrl)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	store, err := bodies.New(config.Current().Bodies)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	d := &PsqlDB{db: db}
+	d := &PsqlDB{db: db, bodies: store, inlineMax: config.Current().Bodies.InlineMax}
 	return d
 }
 
@@ -78,9 +338,13 @@ func (me *PsqlDB) LinkUserKey(userID string, key string) error {
 func (me *PsqlDB) PublicKeyForKey(key string) (*db.PublicKey, error) {
 	var keys []*db.PublicKey
 	rs, err := me.db.Query(sqlSelectPublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
 	for rs.Next() {
 		pk := &db.PublicKey{}
-		err := rs.Scan(&pk.ID, &pk.UserID, &pk.Key, &pk.CreatedAt)
+		err := rs.Scan(&pk.ID, &pk.UserID, &pk.Key, &pk.CreatedAt, &pk.LastUsedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -88,16 +352,12 @@ func (me *PsqlDB) PublicKeyForKey(key string) (*db.PublicKey, error) {
 		keys = append(keys, pk)
 	}
 
-	if err != nil {
-		return nil, err
-	}
-
 	if rs.Err() != nil {
 		return nil, rs.Err()
 	}
 
 	if len(keys) == 0 {
-		return nil, errors.New("no public keys found for key provided")
+		return nil, db.ErrPublicKeyNotFound
 	}
 
 	// When we run PublicKeyForKey and there are multiple public keys returned from the database
@@ -111,12 +371,45 @@ func (me *PsqlDB) PublicKeyForKey(key string) (*db.PublicKey, error) {
 	return keys[0], nil
 }
 
+// TouchPublicKey records that the key was just used to sign in.
+func (me *PsqlDB) TouchPublicKey(keyID string) error {
+	_, err := me.db.Exec(sqlTouchPublicKey, time.Now(), keyID)
+	return err
+}
+
+// RemoveUserKey unlinks the key from the user, as long as it isn't the only
+// key they can sign in with.
+func (me *PsqlDB) RemoveUserKey(userID string, keyID string) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var count int
+	err = tx.QueryRow(sqlCountUserKeys, userID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return db.ErrLastKey
+	}
+
+	_, err = tx.Exec(sqlRemoveUserKey, keyID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (me *PsqlDB) ListKeysForUser(user *db.User) ([]*db.PublicKey, error) {
 	var keys []*db.PublicKey
 	rs, err := me.db.Query(sqlSelectPublicKeys, user.ID)
 	for rs.Next() {
 		pk := &db.PublicKey{}
-		err := rs.Scan(&pk.ID, &pk.UserID, &pk.Key, &pk.CreatedAt)
+		err := rs.Scan(&pk.ID, &pk.UserID, &pk.Key, &pk.CreatedAt, &pk.LastUsedAt)
 		if err != nil {
 			return keys, err
 		}
@@ -165,6 +458,8 @@ func (me *PsqlDB) SiteAnalytics() (*db.Analytics, error) {
 	return analytics, nil
 }
 
+// UserForKey finds the user the key belongs to and records that the key was
+// used.
 func (me *PsqlDB) UserForKey(key string) (*db.User, error) {
 	pk, err := me.PublicKeyForKey(key)
 	if err != nil {
@@ -176,46 +471,44 @@ func (me *PsqlDB) UserForKey(key string) (*db.User, error) {
 		return nil, err
 	}
 
+	err = me.TouchPublicKey(pk.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	user.PublicKey = pk
 
 	return user, nil
 }
 
 func (me *PsqlDB) User(userID string) (*db.User, error) {
-	user := &db.User{}
-	var un sql.NullString
-	r := me.db.QueryRow(sqlSelectUser, userID)
-	err := r.Scan(&user.ID, &un, &user.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	if un.Valid {
-		user.Name = un.String
-	}
-	return user, nil
+	return scanUser(me.db.QueryRow(sqlSelectUser, userID))
 }
 
+// ValidateName reports whether name is free and follows the rules for blog
+// names, which usernames share.
 func (me *PsqlDB) ValidateName(name string) bool {
+	if internal.ValidateBlogName(strings.ToLower(name)) != nil {
+		return false
+	}
 	user, _ := me.UserForName(strings.ToLower(name))
 	return user == nil
 }
 
 func (me *PsqlDB) UserForName(name string) (*db.User, error) {
-	user := &db.User{}
-	r := me.db.QueryRow(sqlSelectUserForName, strings.ToLower(name))
-	err := r.Scan(&user.ID, &user.Name, &user.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
+	return scanUser(me.db.QueryRow(sqlSelectUserForName, strings.ToLower(name)))
 }
 
 func (me *PsqlDB) UserForNameAndKey(name string, key string) (*db.User, error) {
-	user := &db.User{}
 	pk := &db.PublicKey{}
 
 	r := me.db.QueryRow(sqlSelectUserForNameAndKey, strings.ToLower(name), key)
-	err := r.Scan(&user.ID, &user.Name, &user.CreatedAt, &pk.ID, &pk.Key, &pk.CreatedAt)
+	user, err := scanUser(r, &pk.ID, &pk.Key, &pk.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = me.TouchPublicKey(pk.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -234,139 +527,1925 @@ func (me *PsqlDB) SetUserName(userID string, name string) error {
 	return err
 }
 
-func (me *PsqlDB) FindPostWithFilename(filename string, persona_id string) (*db.Post, error) {
-	post := &db.Post{}
-	r := me.db.QueryRow(sqlSelectPostWithFilename, filename, persona_id)
-	err := r.Scan(
-		&post.ID,
-		&post.UserID,
-		&post.Filename,
-		&post.Title,
-		&post.Text,
-		&post.Description,
-		&post.PublishAt,
-		&post.Username,
-	)
-	if err != nil {
-		return nil, err
+func (me *PsqlDB) SetUserProfile(userID string, displayName string, bio string) error {
+	if err := db.ValidateProfile(displayName, bio); err != nil {
+		return err
 	}
 
-	return post, nil
+	_, err := me.db.Exec(sqlUpdateUserProfile, displayName, bio, userID)
+	return err
+}
+
+func (me *PsqlDB) FindPostWithFilename(filename string, persona_id string) (*db.Post, error) {
+	return me.scanPost(me.db.QueryRow(sqlSelectPostWithFilename, filename, persona_id))
 }
 
 func (me *PsqlDB) FindPost(postID string) (*db.Post, error) {
-	post := &db.Post{}
-	r := me.db.QueryRow(sqlSelectPost, postID)
-	err := r.Scan(
-		&post.ID,
-		&post.UserID,
-		&post.Filename,
-		&post.Title,
-		&post.Text,
-		&post.Description,
-		&post.PublishAt,
-		&post.Username,
-	)
+	return me.scanPost(me.db.QueryRow(sqlSelectPost, postID))
+}
+
+func (me *PsqlDB) FindAllPosts(page *db.Pager) (*db.Paginate[*db.Post], error) {
+	return me.findDiscoverPosts(sqlSelectAllPosts, sqlSelectDiscoverCount, page)
+}
+
+// FindTrendingPosts pages through the discovery feed ranked by recent views
+// and stars, as of the last RefreshTrending.
+func (me *PsqlDB) FindTrendingPosts(page *db.Pager) (*db.Paginate[*db.Post], error) {
+	return me.findDiscoverPosts(sqlSelectTrendingPosts, sqlSelectTrendingCount, page)
+}
+
+// FindPopularPosts pages through the discovery feed ranked by views and
+// stars of all time.
+func (me *PsqlDB) FindPopularPosts(page *db.Pager) (*db.Paginate[*db.Post], error) {
+	return me.findDiscoverPosts(sqlSelectPopularPosts, sqlSelectDiscoverCount, page)
+}
+
+// RefreshTrending recomputes the trending ranking.
+func (me *PsqlDB) RefreshTrending() error {
+	_, err := me.db.Exec(sqlRefreshTrending)
+	return err
+}
+
+// FindTagPosts pages through the discovery feed narrowed to posts with the
+// tag, newest first.
+func (me *PsqlDB) FindTagPosts(tag string, page *db.Pager) (*db.Paginate[*db.Post], error) {
+	now := time.Now()
+	var posts []*db.Post
+	rs, err := me.db.Query(sqlSelectTagPosts, page.Limit, page.Limit*page.Offset, now, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	var count int
+	err = me.db.QueryRow(sqlSelectTagCount, tag, now).Scan(&count)
 	if err != nil {
 		return nil, err
 	}
 
-	return post, nil
+	return &db.Paginate[*db.Post]{
+		Data:  posts,
+		Total: int(math.Ceil(float64(count) / float64(page.Limit))),
+	}, nil
 }
 
-func (me *PsqlDB) FindAllPosts(page *db.Pager) (*db.Paginate[*db.Post], error) {
+// findDiscoverPosts runs one of the discovery feed queries.
+func (me *PsqlDB) findDiscoverPosts(query string, countQuery string, page *db.Pager) (*db.Paginate[*db.Post], error) {
 	var posts []*db.Post
-	rs, err := me.db.Query(sqlSelectAllPosts, page.Limit, page.Limit*page.Offset)
-	for rs.Next() {
-		post := &db.Post{}
-		err := rs.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Filename,
-			&post.Title,
-			&post.Text,
-			&post.Description,
-			&post.PublishAt,
-			&post.Username,
-		)
+	now := time.Now()
+	rs, err := me.db.Query(query, page.Limit, page.Limit*page.Offset, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
 		if err != nil {
 			return nil, err
 		}
 
 		posts = append(posts, post)
 	}
-	if err != nil {
-		return nil, err
-	}
 	if rs.Err() != nil {
 		return nil, rs.Err()
 	}
 
 	var count int
-	err = me.db.QueryRow(sqlSelectPostCount).Scan(&count)
+	// Counted with the same filters, or the pager runs past the last page.
+	err = me.db.QueryRow(countQuery, now).Scan(&count)
 	if err != nil {
 		return nil, err
 	}
 
 	pager := &db.Paginate[*db.Post]{
 		Data:  posts,
-		Total: int(math.Ceil(float64(count) / float64(PAGER_SIZE))),
+		Total: int(math.Ceil(float64(count) / float64(page.Limit))),
 	}
 	return pager, nil
 }
 
+// putBody saves text to the body store when it's too long to keep in the
+// row, it returns what goes in the text column and the key it was saved
+// under, if any.
+func (me *PsqlDB) putBody(text string) (string, string, error) {
+	if me.bodies == nil || len(text) <= me.inlineMax {
+		return text, "", nil
+	}
+	key, err := bodies.NewKey()
+	if err != nil {
+		return "", "", err
+	}
+	err = me.bodies.Put(key, text)
+	if err != nil {
+		return "", "", err
+	}
+	return "", key, nil
+}
+
+// removeBodies queues jobs deleting text rows no longer point at.  It's
+// best effort, a leftover only takes up space.
+func (me *PsqlDB) removeBodies(keys []string) {
+	if me.bodies == nil {
+		return
+	}
+	for _, key := range keys {
+		if key != "" {
+			_ = jobs.Enqueue(me, bodies.DeleteJob, bodies.DeletePayload{Key: key})
+		}
+	}
+}
+
+// textMD5 matches postgres' md5(text), duplicate checks compare it.
+func textMD5(text string) string {
+	sum := md5.Sum([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
 func (me *PsqlDB) InsertPost(userID string, filename string, title string, text string, description string, publishAt *time.Time) (*db.Post, error) {
 	var id string
-	err := me.db.QueryRow(sqlInsertPost, userID, filename, title, text, description, publishAt).Scan(&id)
+	parsed := pkg.ParseText(text)
+	inline, bodyKey, err := me.putBody(text)
+	if err != nil {
+		return nil, err
+	}
+	err = me.db.QueryRow(sqlInsertPost, userID, filename, title, inline, description, publishAt, parsed.ItemCount, parsed.WordCount, pq.Array(parsed.MetaData.Tags), parsed.MetaData.Visibility, pq.Array(parsed.MetaData.Readers), bodyKey, len(text), textMD5(text)).Scan(&id)
 	if err != nil {
+		me.removeBodies([]string{bodyKey})
 		return nil, err
 	}
+	// The post is saved either way, its page makes the link when this
+	// fails.
+	_, _ = me.ShortLinkForPost(id)
 
 	return me.FindPost(id)
 }
 
 func (me *PsqlDB) UpdatePost(postID string, title string, text string, description string, publishAt *time.Time) (*db.Post, error) {
-	_, err := me.db.Exec(sqlUpdatePost, title, text, description, time.Now(), publishAt, postID)
+	parsed := pkg.ParseText(text)
+	inline, bodyKey, err := me.putBody(text)
+	if err != nil {
+		return nil, err
+	}
+	var oldKey string
+	err = me.db.QueryRow(sqlUpdatePost, title, inline, description, time.Now(), publishAt, postID, parsed.ItemCount, parsed.WordCount, pq.Array(parsed.MetaData.Tags), parsed.MetaData.Visibility, pq.Array(parsed.MetaData.Readers), bodyKey, len(text), textMD5(text)).Scan(&oldKey)
 	if err != nil {
+		me.removeBodies([]string{bodyKey})
 		return nil, err
 	}
+	me.removeBodies([]string{oldKey})
 
 	return me.FindPost(postID)
 }
 
+// queryBodyKeys runs a query returning body keys, as deleting posts does.
+func queryBodyKeys(q interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]string, error) {
+	rs, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var keys []string
+	for rs.Next() {
+		var key string
+		if err := rs.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rs.Err()
+}
+
 func (me *PsqlDB) RemovePosts(postIDs []string) error {
-	_, err := me.db.Exec(sqlRemovePosts, strings.Join(postIDs, ","))
+	keys, err := queryBodyKeys(me.db, sqlRemovePosts, pq.Array(postIDs))
+	if err != nil {
+		return err
+	}
+	me.removeBodies(keys)
+	return nil
+}
+
+// SoftDeletePosts moves the posts to the trash, which hides them everywhere.
+// PurgeDeletedPosts removes them for good.
+func (me *PsqlDB) SoftDeletePosts(postIDs []string) error {
+	_, err := me.db.Exec(sqlSoftDeletePosts, time.Now(), pq.Array(postIDs))
 	return err
 }
 
-func (me *PsqlDB) PostsForUser(userID string) ([]*db.Post, error) {
+func (me *PsqlDB) UndeletePosts(postIDs []string) error {
+	_, err := me.db.Exec(sqlUndeletePosts, pq.Array(postIDs))
+	return err
+}
+
+// PurgeDeletedPosts removes every post soft deleted before the given time,
+// whoever it belongs to.  Only the web server's purge job calls it.
+func (me *PsqlDB) PurgeDeletedPosts(before time.Time) error {
+	keys, err := queryBodyKeys(me.db, sqlPurgeDeletedPosts, before)
+	if err != nil {
+		return err
+	}
+	me.removeBodies(keys)
+	return nil
+}
+
+func (me *PsqlDB) UpdatePostVisibility(postIDs []string, draft bool) error {
+	_, err := me.db.Exec(sqlUpdatePostVisibility, draft, pq.Array(postIDs))
+	return err
+}
+
+// RenamePost changes the post's filename and redirects the old one to it,
+// along with any older names that redirected to the old one.
+func (me *PsqlDB) RenamePost(post *db.Post, filename string) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(sqlUpdatePostFilename, filename, post.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlRepointPostRedirects, filename, post.UserID, post.Filename)
+	if err != nil {
+		return err
+	}
+	// The post lives at its new name now, it can't redirect anywhere.
+	_, err = tx.Exec(sqlRemovePostRedirect, post.UserID, filename)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlUpsertPostRedirect, post.UserID, post.Filename, filename, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindPostRedirect returns the filename a renamed post moved to.
+func (me *PsqlDB) FindPostRedirect(userID string, filename string) (string, error) {
+	var to string
+	err := me.db.QueryRow(sqlSelectPostRedirect, userID, filename).Scan(&to)
+	if err != nil {
+		return "", err
+	}
+	return to, nil
+}
+
+// FindAdjacentPosts returns the public posts published just before and just
+// after post on the same blog, either of which is nil at the ends.
+func (me *PsqlDB) FindAdjacentPosts(post *db.Post, now time.Time) (*db.Post, *db.Post, error) {
+	older, err := me.scanPost(me.db.QueryRow(sqlSelectOlderPost, post.UserID, post.PublishAt, post.ID))
+	if err == sql.ErrNoRows {
+		older = nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	newer, err := me.scanPost(me.db.QueryRow(sqlSelectNewerPost, post.UserID, post.PublishAt, post.ID, now))
+	if err == sql.ErrNoRows {
+		newer = nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return older, newer, nil
+}
+
+// FindRelatedPosts returns up to limit public posts on the same blog that
+// share any of the tags, the ones with the most tags in common first.
+func (me *PsqlDB) FindRelatedPosts(post *db.Post, tags []string, now time.Time, limit int) ([]*db.Post, error) {
 	var posts []*db.Post
-	rs, err := me.db.Query(sqlSelectPostsForUser, userID)
+	if len(tags) == 0 {
+		return posts, nil
+	}
+
+	rs, err := me.db.Query(sqlSelectRelatedPosts, post.UserID, post.ID, pq.Array(tags), now, limit)
+	if err != nil {
+		return posts, err
+	}
+	defer rs.Close()
 	for rs.Next() {
-		post := &db.Post{}
-		err := rs.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Filename,
-			&post.Title,
-			&post.Text,
-			&post.Description,
-			&post.PublishAt,
-			&post.Username,
-		)
+		related, err := me.scanPost(rs)
 		if err != nil {
 			return posts, err
 		}
+		posts = append(posts, related)
+	}
+	return posts, rs.Err()
+}
 
-		posts = append(posts, post)
+// FollowUser adds the author's posts to the user's following feed.
+func (me *PsqlDB) FollowUser(userID string, authorID string) error {
+	_, err := me.db.Exec(sqlInsertFollow, userID, authorID)
+	return err
+}
+
+// UnfollowUser removes the author from the user's following feed.
+func (me *PsqlDB) UnfollowUser(userID string, authorID string) error {
+	_, err := me.db.Exec(sqlRemoveFollow, userID, authorID)
+	return err
+}
+
+// StarPost stars the post for the user, the post's count only goes up the
+// first time.
+func (me *PsqlDB) StarPost(userID string, postID string) error {
+	return me.changeStar(sqlInsertStar, userID, postID, 1)
+}
+
+// UnstarPost takes the user's star back.
+func (me *PsqlDB) UnstarPost(userID string, postID string) error {
+	return me.changeStar(sqlRemoveStar, userID, postID, -1)
+}
+
+// changeStar runs the star query and moves the post's count by step when it
+// changed anything.
+func (me *PsqlDB) changeStar(query string, userID string, postID string, step int) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.Exec(query, userID, postID)
 	if err != nil {
-		return posts, err
+		return err
 	}
-	if rs.Err() != nil {
-		return posts, rs.Err()
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return err
 	}
-	return posts, nil
+	if changed > 0 {
+		_, err = tx.Exec(sqlAddPostStars, postID, step)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindStarred returns the IDs of the posts the user starred.
+func (me *PsqlDB) FindStarred(userID string) ([]string, error) {
+	var ids []string
+	rs, err := me.db.Query(sqlSelectStarred, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var id string
+		if err := rs.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return ids, nil
+}
+
+// LikePost counts a like from a reader on the web.
+func (me *PsqlDB) LikePost(postID string) error {
+	_, err := me.db.Exec(sqlIncrementPostLikes, postID)
+	return err
+}
+
+// FindFollowing returns the IDs of the authors the user follows.
+func (me *PsqlDB) FindFollowing(userID string) ([]string, error) {
+	var ids []string
+	rs, err := me.db.Query(sqlSelectFollowing, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var id string
+		if err := rs.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return ids, nil
+}
+
+// FindFollowingPosts pages through the published posts of the authors the
+// user follows, newest first, marking the ones the user hasn't opened yet.
+func (me *PsqlDB) FindFollowingPosts(userID string, page *db.Pager) (*db.Paginate[*db.FeedPost], error) {
+	now := time.Now()
+	var posts []*db.FeedPost
+	rs, err := me.db.Query(sqlSelectFollowingPosts, page.Limit, page.Limit*page.Offset, now, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		item := &db.FeedPost{}
+		item.Post, err = me.scanPost(rs, &item.Unread)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, item)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	var count int
+	err = me.db.QueryRow(sqlSelectFollowingCount, userID, now).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := &db.Paginate[*db.FeedPost]{
+		Data:  posts,
+		Total: int(math.Ceil(float64(count) / float64(page.Limit))),
+	}
+	return pager, nil
+}
+
+// MarkPostRead clears the unread marker on the post for the user.
+func (me *PsqlDB) MarkPostRead(userID string, postID string) error {
+	_, err := me.db.Exec(sqlInsertPostRead, userID, postID, time.Now())
+	return err
+}
+
+// RecordPostView counts a view of the post for the day and, when the reader
+// came from another site, for the referring host.
+func (me *PsqlDB) RecordPostView(postID string, referrer string) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(sqlIncrementPostViews, postID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlIncrementDailyViews, postID, time.Now().UTC().Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	if referrer != "" {
+		_, err = tx.Exec(sqlIncrementReferrer, postID, referrer)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordFeedSubscribers stores the subscriber count a feed reader reported
+// for the user's feed.
+func (me *PsqlDB) RecordFeedSubscribers(userID string, fetcher string, subscribers int) error {
+	_, err := me.db.Exec(sqlUpsertSubscribers, userID, fetcher, subscribers, time.Now())
+	return err
+}
+
+// FindUserAnalytics returns daily views since the given time, the top
+// referrers and the feed subscribers reported since then.
+func (me *PsqlDB) FindUserAnalytics(userID string, since time.Time) (*db.UserAnalytics, error) {
+	analytics := &db.UserAnalytics{}
+
+	rs, err := me.db.Query(sqlSelectDailyViews, userID, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		daily := &db.DailyViews{}
+		err = rs.Scan(&daily.Day, &daily.Views)
+		if err != nil {
+			return nil, err
+		}
+		analytics.Daily = append(analytics.Daily, daily)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	rs, err = me.db.Query(sqlSelectTopReferrers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		referrer := &db.Referrer{}
+		err = rs.Scan(&referrer.Host, &referrer.Views)
+		if err != nil {
+			return nil, err
+		}
+		analytics.Referrers = append(analytics.Referrers, referrer)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	err = me.db.QueryRow(sqlSelectSubscribers, userID, since).Scan(&analytics.Subscribers)
+	if err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// FindUserUsage counts the posts of the user's account, across all its blogs,
+// and their size in bytes.
+func (me *PsqlDB) FindUserUsage(userID string) (*db.Usage, error) {
+	usage := &db.Usage{}
+	err := me.db.QueryRow(sqlSelectUserUsage, userID).Scan(&usage.Posts, &usage.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (me *PsqlDB) PostsForUser(userID string) ([]*db.Post, error) {
+	var posts []*db.Post
+	rs, err := me.db.Query(sqlSelectPostsForUser, userID)
+	if err != nil {
+		return posts, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+	if rs.Err() != nil {
+		return posts, rs.Err()
+	}
+	return posts, nil
+}
+
+// PostsForUserWithStatus returns the user's posts in one of the post
+// statuses, scheduled posts come soonest first and the trash most recently
+// deleted first.
+func (me *PsqlDB) PostsForUserWithStatus(userID string, status string) ([]*db.Post, error) {
+	var (
+		rs  *sql.Rows
+		err error
+	)
+	switch status {
+	case db.PostStatusPublished:
+		rs, err = me.db.Query(sqlSelectPublishedPosts, userID, time.Now())
+	case db.PostStatusDraft:
+		rs, err = me.db.Query(sqlSelectDraftPosts, userID)
+	case db.PostStatusScheduled:
+		rs, err = me.db.Query(sqlSelectScheduledPosts, userID, time.Now())
+	case db.PostStatusDeleted:
+		rs, err = me.db.Query(sqlSelectDeletedPosts, userID)
+	default:
+		return nil, fmt.Errorf("unknown post status %q", status)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	var posts []*db.Post
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rs.Err()
+}
+
+// SearchPostsForUser finds the user's posts whose title or filename contains
+// query, ignoring case.
+func (me *PsqlDB) SearchPostsForUser(userID string, query string) ([]*db.Post, error) {
+	var posts []*db.Post
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	rs, err := me.db.Query(sqlSearchPostsForUser, userID, pattern)
+	if err != nil {
+		return posts, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+	if rs.Err() != nil {
+		return posts, rs.Err()
+	}
+	return posts, nil
+}
+
+func (me *PsqlDB) UserStats() ([]*db.UserStats, error) {
+	var stats []*db.UserStats
+	rs, err := me.db.Query(sqlSelectUserStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		st := &db.UserStats{}
+		st.User, err = scanUser(rs, &st.Posts, &st.Bytes, &st.Keys)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return stats, nil
+}
+
+func (me *PsqlDB) SetUserStatus(userID string, status string) error {
+	_, err := me.db.Exec(sqlUpdateUserStatus, status, userID)
+	return err
+}
+
+// SetUserDelisted keeps the user's posts out of discovery, or lets them
+// back in.
+func (me *PsqlDB) SetUserDelisted(userID string, delisted bool) error {
+	_, err := me.db.Exec(sqlUpdateDelisted, delisted, userID)
+	return err
+}
+
+func (me *PsqlDB) RemoveKeysForUser(userID string) error {
+	_, err := me.db.Exec(sqlRemoveKeysForUser, userID)
+	return err
+}
+
+func (me *PsqlDB) HidePost(postID string, reason string) error {
+	_, err := me.db.Exec(sqlHidePost, time.Now(), reason, postID)
+	return err
+}
+
+func (me *PsqlDB) UnhidePost(postID string) error {
+	_, err := me.db.Exec(sqlUnhidePost, postID)
+	return err
+}
+
+func (me *PsqlDB) InsertAuditLog(entry *db.AuditLog) error {
+	_, err := me.db.Exec(sqlInsertAuditLog, entry.Actor, entry.Action, entry.Target, entry.Note)
+	return err
+}
+
+func (me *PsqlDB) FindAuditLog(limit int) ([]*db.AuditLog, error) {
+	var entries []*db.AuditLog
+	rs, err := me.db.Query(sqlSelectAuditLog, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		entry := &db.AuditLog{}
+		err := rs.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Target, &entry.Note, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return entries, nil
+}
+
+// RecordActivity adds to today's site stats.  Publishers are counted the
+// first time they post or edit on a given day.
+func (me *PsqlDB) RecordActivity(userID string, kind string) error {
+	day := time.Now().UTC().Format("2006-01-02")
+	var signups, publishers, posts, sessions int
+	switch kind {
+	case db.ActivitySignup:
+		signups = 1
+	case db.ActivitySession:
+		sessions = 1
+	case db.ActivityPost:
+		posts = 1
+	case db.ActivityEdit:
+	default:
+		return fmt.Errorf("unknown activity %q", kind)
+	}
+
+	if kind == db.ActivityPost || kind == db.ActivityEdit {
+		res, err := me.db.Exec(sqlMarkPublisher, userID, day)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			publishers = 1
+		}
+	}
+	if signups+publishers+posts+sessions == 0 {
+		return nil
+	}
+
+	_, err := me.db.Exec(sqlIncrementSiteStats, day, signups, publishers, posts, sessions)
+	return err
+}
+
+// FindSiteStats returns the daily totals since the given day, days nothing
+// happened on are left out.
+func (me *PsqlDB) FindSiteStats(since time.Time) ([]*db.SiteStats, error) {
+	var stats []*db.SiteStats
+	rs, err := me.db.Query(sqlSelectSiteStats, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		st := &db.SiteStats{}
+		err := rs.Scan(&st.Day, &st.Signups, &st.Publishers, &st.Posts, &st.Sessions)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return stats, nil
+}
+
+func scanPublishTarget(r scanner) (*db.PublishTarget, error) {
+	t := &db.PublishTarget{}
+	err := r.Scan(
+		&t.UserID, &t.Kind, &t.S3Endpoint, &t.S3Region, &t.S3Bucket, &t.S3AccessKey, &t.S3SecretKey,
+		&t.NetlifySiteID, &t.NetlifyToken, &t.QueuedAt, &t.PublishedAt, &t.LastError,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (me *PsqlDB) FindPublishTarget(userID string) (*db.PublishTarget, error) {
+	return scanPublishTarget(me.db.QueryRow(sqlSelectPublishTarget, userID))
+}
+
+// SetPublishTarget saves where the user's blog is pushed and queues a push
+// so the new target gets the whole site right away.
+func (me *PsqlDB) SetPublishTarget(t *db.PublishTarget) error {
+	_, err := me.db.Exec(
+		sqlUpsertPublishTarget, t.UserID, t.Kind, t.S3Endpoint, t.S3Region, t.S3Bucket, t.S3AccessKey,
+		t.S3SecretKey, t.NetlifySiteID, t.NetlifyToken, time.Now(),
+	)
+	return err
+}
+
+func (me *PsqlDB) RemovePublishTarget(userID string) error {
+	_, err := me.db.Exec(sqlDeletePublishTarget, userID)
+	return err
+}
+
+// QueuePublish asks for the user's blog to be pushed again, it does nothing
+// for users without a target.
+func (me *PsqlDB) QueuePublish(userID string) error {
+	_, err := me.db.Exec(sqlQueuePublish, userID, time.Now())
+	return err
+}
+
+func (me *PsqlDB) FindQueuedPublishes() ([]*db.PublishTarget, error) {
+	var targets []*db.PublishTarget
+	rs, err := me.db.Query(sqlSelectQueuedTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		t, err := scanPublishTarget(rs)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return targets, nil
+}
+
+// FinishPublish records the outcome of a push.  The target stays queued
+// when it was queued again after queuedAt, while the push was running.
+func (me *PsqlDB) FinishPublish(userID string, queuedAt time.Time, publishErr string) error {
+	_, err := me.db.Exec(sqlFinishPublish, userID, queuedAt, time.Now(), publishErr)
+	return err
+}
+
+// FindBlogs lists the extra blogs the user can publish to, by name: the
+// ones they started and the shared ones they joined.
+func (me *PsqlDB) FindBlogs(userID string) ([]*db.Blog, error) {
+	return me.findBlogs(sqlSelectBlogs, userID)
+}
+
+// FindBlogInvites lists the blogs the user has been invited to and hasn't
+// answered yet.
+func (me *PsqlDB) FindBlogInvites(userID string) ([]*db.Blog, error) {
+	return me.findBlogs(sqlSelectBlogInvites, userID)
+}
+
+func (me *PsqlDB) findBlogs(query string, userID string) ([]*db.Blog, error) {
+	var blogs []*db.Blog
+	rs, err := me.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		blog := &db.Blog{}
+		err := rs.Scan(&blog.ID, &blog.OwnerID, &blog.UserID, &blog.Name, &blog.Role, &blog.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		blogs = append(blogs, blog)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return blogs, nil
+}
+
+// AddBlog starts a blog called name for the account, which becomes its
+// first owner. Blogs share the namespace of usernames, so a name that's
+// taken by either is refused.
+func (me *PsqlDB) AddBlog(ownerID string, name string) (*db.Blog, error) {
+	lowerName := strings.ToLower(name)
+	if !me.ValidateName(lowerName) {
+		return nil, db.ErrNameTaken
+	}
+
+	tx, err := me.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	blog := &db.Blog{OwnerID: ownerID, Name: lowerName, Role: db.BlogOwner}
+	err = tx.QueryRow(sqlInsertBlogUser, lowerName, ownerID).Scan(&blog.UserID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(sqlInsertBlog, ownerID, blog.UserID).Scan(&blog.ID, &blog.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(sqlInsertBlogMember, blog.ID, ownerID, db.BlogOwner, nil, blog.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return blog, tx.Commit()
+}
+
+func (me *PsqlDB) FindBlogMembers(blogID string) ([]*db.BlogMember, error) {
+	var members []*db.BlogMember
+	rs, err := me.db.Query(sqlSelectBlogMembers, blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		m := &db.BlogMember{}
+		err := rs.Scan(&m.BlogID, &m.UserID, &m.Name, &m.Role, &m.CreatedAt, &m.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return members, nil
+}
+
+// InviteBlogMember asks the user to join the blog with the role, they're a
+// member once they accept with JoinBlog.
+func (me *PsqlDB) InviteBlogMember(blogID string, userID string, role string, invitedBy string) error {
+	var isBlog bool
+	err := me.db.QueryRow(sqlSelectIsBlog, userID).Scan(&isBlog)
+	if err != nil {
+		return err
+	}
+	if isBlog {
+		return errors.New("blogs can't be members of other blogs, invite a person")
+	}
+
+	res, err := me.db.Exec(sqlInsertBlogMember, blogID, userID, role, invitedBy, nil)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("they're already a member or have been invited")
+	}
+	return nil
+}
+
+// JoinBlog accepts the user's invite to the blog.
+func (me *PsqlDB) JoinBlog(blogID string, userID string) error {
+	res, err := me.db.Exec(sqlJoinBlog, blogID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("no invite to that blog")
+	}
+	return nil
+}
+
+// SetBlogMemberRole changes what a member may do, the last owner can't stop
+// being one.
+func (me *PsqlDB) SetBlogMemberRole(blogID string, userID string, role string) error {
+	return me.changeBlogMember(blogID, userID, role != db.BlogOwner, func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlUpdateMemberRole, blogID, userID, role)
+		return err
+	})
+}
+
+// RemoveBlogMember takes the user off the blog, or withdraws their invite.
+// The last owner can't leave.
+func (me *PsqlDB) RemoveBlogMember(blogID string, userID string) error {
+	return me.changeBlogMember(blogID, userID, true, func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlRemoveBlogMember, blogID, userID)
+		return err
+	})
+}
+
+// changeBlogMember runs change on a member of the blog, refusing when
+// demoting would leave the blog without an owner.  The blog's storage is
+// counted against one of its owners, so a demoted owner hands it over.
+func (me *PsqlDB) changeBlogMember(blogID string, userID string, demotes bool, change func(tx *sql.Tx) error) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var role string
+	var joined bool
+	err = tx.QueryRow(sqlSelectMemberRole, blogID, userID).Scan(&role, &joined)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("not a member of that blog")
+	} else if err != nil {
+		return err
+	}
+
+	if demotes && joined && role == db.BlogOwner {
+		var owners int
+		err = tx.QueryRow(sqlCountOtherOwners, blogID, userID).Scan(&owners)
+		if err != nil {
+			return err
+		}
+		if owners == 0 {
+			return db.ErrLastOwner
+		}
+		_, err = tx.Exec(sqlHandOverBlog, blogID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = change(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPostAuthor credits the post to the member of a shared blog who wrote
+// it.
+func (me *PsqlDB) SetPostAuthor(postID string, authorID string) error {
+	_, err := me.db.Exec(sqlUpdatePostAuthor, postID, authorID)
+	return err
+}
+
+func (me *PsqlDB) CountDuplicatePosts(userID string, text string) (int, error) {
+	var count int
+	err := me.db.QueryRow(sqlSelectDuplicateCount, userID, text).Scan(&count)
+	return count, err
+}
+
+// FindDuplicatePost returns the user's oldest post, under another filename,
+// with exactly the same text.
+func (me *PsqlDB) FindDuplicatePost(userID string, filename string, text string) (*db.Post, error) {
+	return me.scanPost(me.db.QueryRow(sqlSelectDuplicatePost, userID, filename, text))
+}
+
+func (me *PsqlDB) FlagPost(postID string, reason string) error {
+	_, err := me.db.Exec(sqlUpdatePostFlag, reason, postID)
+	return err
+}
+
+func (me *PsqlDB) FindFlaggedPosts() ([]*db.Post, error) {
+	var posts []*db.Post
+	rs, err := me.db.Query(sqlSelectFlaggedPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return posts, nil
+}
+
+func scanInvite(r scanner) (*db.Invite, error) {
+	invite := &db.Invite{}
+	err := r.Scan(
+		&invite.ID,
+		&invite.Code,
+		&invite.CreatedBy,
+		&invite.UsedBy,
+		&invite.CreatedAt,
+		&invite.UsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (me *PsqlDB) InsertInvite(userID string, code string) (*db.Invite, error) {
+	var createdBy sql.NullString
+	if userID != "" {
+		createdBy = sql.NullString{String: userID, Valid: true}
+	}
+	return scanInvite(me.db.QueryRow(sqlInsertInvite, createdBy, code))
+}
+
+func (me *PsqlDB) FindInvitesForUser(userID string) ([]*db.Invite, error) {
+	var invites []*db.Invite
+	rs, err := me.db.Query(sqlSelectInvitesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		invite, err := scanInvite(rs)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return invites, nil
+}
+
+// RedeemInvite marks the code as used in a single statement so two people
+// can't register with the same code.
+func (me *PsqlDB) RedeemInvite(code string, userID string) error {
+	res, err := me.db.Exec(sqlRedeemInvite, userID, time.Now(), strings.TrimSpace(code))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrInviteInvalid
+	}
+	return nil
+}
+
+func (me *PsqlDB) RemoveUser(userID string) error {
+	_, err := me.db.Exec(sqlRemoveUser, userID)
+	return err
+}
+
+// EraseUser deletes the account along with everything that references it.
+// Posts, keys, reports and invites go with the user through their foreign
+// keys while moderation history is matched by name.  Blogs the account
+// started go to another owner when they have one and are deleted the same
+// way otherwise.  Once committed the tables are checked again so the caller
+// knows nothing was left behind.
+func (me *PsqlDB) EraseUser(userID string, name string) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if name != "" {
+		_, err = tx.Exec(sqlRemoveAuditLogForName, name)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(sqlHandOverBlogs, userID)
+	if err != nil {
+		return err
+	}
+	bodyKeys, err := queryBodyKeys(tx, sqlSelectErasedBodyKeys, userID)
+	if err != nil {
+		return err
+	}
+	// Remembered so a restore from an older backup leaves them out.
+	_, err = tx.Exec(sqlInsertErasedUsers, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlRemoveBlogsForOwner, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlRemoveUser, userID)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	me.removeBodies(bodyKeys)
+
+	var remaining int
+	err = me.db.QueryRow(sqlSelectUserDataCount, userID, name).Scan(&remaining)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return &db.ErrEraseIncomplete{Rows: remaining}
+	}
+	return nil
+}
+
+// FindUserSettings returns the user's preferences, or the defaults when they
+// haven't changed any.
+func (me *PsqlDB) FindUserSettings(userID string) (*db.UserSettings, error) {
+	settings := db.DefaultUserSettings()
+	err := me.db.QueryRow(sqlSelectUserSettings, userID).Scan(
+		&settings.PostSort,
+		&settings.Timezone,
+		&settings.PerPage,
+		&settings.Theme,
+		&settings.KeyMap,
+		&settings.Layout,
+		&settings.KeysPerPage,
+		&settings.Locale,
+		&settings.Duplicates,
+		&settings.ReplyEmail,
+		&settings.Discoverable,
+	)
+	if err == sql.ErrNoRows {
+		return db.DefaultUserSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (me *PsqlDB) UpdateUserSettings(userID string, settings *db.UserSettings) error {
+	_, err := me.db.Exec(sqlUpsertUserSettings,
+		userID,
+		settings.PostSort,
+		settings.Timezone,
+		settings.PerPage,
+		settings.Theme,
+		settings.KeyMap,
+		settings.Layout,
+		settings.KeysPerPage,
+		settings.Locale,
+		settings.Duplicates,
+		settings.ReplyEmail,
+		settings.Discoverable,
+		time.Now(),
+	)
+	return err
+}
+
+func scanReport(r scanner) (*db.Report, error) {
+	report := &db.Report{}
+	var username sql.NullString
+	err := r.Scan(
+		&report.ID,
+		&report.PostID,
+		&username,
+		&report.Filename,
+		&report.Note,
+		&report.Status,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	report.Username = username.String
+	return report, nil
+}
+
+func (me *PsqlDB) InsertReport(postID string, note string) error {
+	_, err := me.db.Exec(sqlInsertReport, postID, note)
+	return err
+}
+
+func (me *PsqlDB) FindReport(reportID string) (*db.Report, error) {
+	return scanReport(me.db.QueryRow(sqlSelectReport, reportID))
+}
+
+func (me *PsqlDB) FindReports(status string) ([]*db.Report, error) {
+	var reports []*db.Report
+	rs, err := me.db.Query(sqlSelectReports, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		report, err := scanReport(rs)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+	return reports, nil
+}
+
+func (me *PsqlDB) SetReportStatus(reportID string, status string) error {
+	var resolvedAt *time.Time
+	if status != db.ReportStatusOpen {
+		now := time.Now()
+		resolvedAt = &now
+	}
+	_, err := me.db.Exec(sqlUpdateReportStatus, status, resolvedAt, reportID)
+	return err
+}
+
+// tokenHash is what's stored of a reader's tokens, a leaked table can't be
+// used to sign in.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InsertReaderLogin starts a web sign in for the user, expired sessions are
+// cleared out on the way.
+func (me *PsqlDB) InsertReaderLogin(userID string, loginToken string, expiresAt time.Time) error {
+	_, err := me.db.Exec(sqlPurgeReaderSessions, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = me.db.Exec(sqlInsertReaderLogin, userID, tokenHash(loginToken), expiresAt)
+	return err
+}
+
+// RedeemReaderLogin trades a login token for a session, each login token
+// works once.
+func (me *PsqlDB) RedeemReaderLogin(loginToken string, sessionToken string, expiresAt time.Time) error {
+	res, err := me.db.Exec(sqlRedeemReaderLogin, tokenHash(loginToken), tokenHash(sessionToken), expiresAt, time.Now())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrLoginInvalid
+	}
+	return nil
+}
+
+// InsertEmailLogin starts a web sign in for someone without an account,
+// see IsReaderEmail.
+func (me *PsqlDB) InsertEmailLogin(email string, loginToken string, expiresAt time.Time) error {
+	_, err := me.db.Exec(sqlPurgeReaderSessions, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = me.db.Exec(sqlInsertEmailLogin, email, tokenHash(loginToken), expiresAt)
+	return err
+}
+
+// IsReaderEmail reports whether any post is shared with the address.
+func (me *PsqlDB) IsReaderEmail(email string) (bool, error) {
+	var shared bool
+	err := me.db.QueryRow(sqlSelectReaderEmail, email).Scan(&shared)
+	return shared, err
+}
+
+func (me *PsqlDB) ReaderForSession(sessionToken string) (*db.Reader, error) {
+	var userID string
+	reader := &db.Reader{}
+	err := me.db.QueryRow(sqlSelectReader, tokenHash(sessionToken), time.Now()).Scan(&userID, &reader.Email)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" {
+		reader.User, err = me.User(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+func (me *PsqlDB) RemoveReaderSession(sessionToken string) error {
+	_, err := me.db.Exec(sqlRemoveReaderSession, tokenHash(sessionToken))
+	return err
+}
+
+// RemoveReaderSessions signs the user out everywhere.
+func (me *PsqlDB) RemoveReaderSessions(userID string) error {
+	_, err := me.db.Exec(sqlRemoveReaderSessions, userID)
+	return err
+}
+
+func scanJob(r scanner) (*db.Job, error) {
+	job := &db.Job{}
+	err := r.Scan(&job.ID, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LastError, &job.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func scanJobs(rs *sql.Rows) ([]*db.Job, error) {
+	defer rs.Close()
+	var jobs []*db.Job
+	for rs.Next() {
+		job, err := scanJob(rs)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rs.Err()
+}
+
+func (me *PsqlDB) EnqueueJob(kind string, payload string, maxAttempts int, runAt time.Time) error {
+	_, err := me.db.Exec(sqlInsertJob, kind, payload, maxAttempts, runAt)
+	return err
+}
+
+// DispatchPostEvents turns up to limit of the oldest post events into a job
+// of each kind in jobKinds, removing them in the same statement so each is
+// handed over exactly once.  It returns how many events it dispatched.
+func (me *PsqlDB) DispatchPostEvents(jobKinds []string, limit int, maxAttempts int) (int, error) {
+	var count int
+	err := me.db.QueryRow(sqlDispatchPostEvents, pq.Array(jobKinds), limit, maxAttempts, time.Now()).Scan(&count)
+	return count, err
+}
+
+// ClaimJobs marks up to limit jobs that are due as running and returns
+// them.  Jobs left running longer than lease, by a worker that died, are
+// claimed again.  Other workers skip the rows while they're being claimed.
+func (me *PsqlDB) ClaimJobs(limit int, now time.Time, lease time.Duration) ([]*db.Job, error) {
+	rs, err := me.db.Query(sqlClaimJobs, limit, now, now.Add(-lease))
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rs)
+}
+
+func (me *PsqlDB) FinishJob(jobID string) error {
+	_, err := me.db.Exec(sqlFinishJob, jobID)
+	return err
+}
+
+func (me *PsqlDB) RetryJob(jobID string, runAt time.Time, jobErr string) error {
+	_, err := me.db.Exec(sqlRetryJob, jobID, runAt, jobErr)
+	return err
+}
+
+func (me *PsqlDB) BuryJob(jobID string, jobErr string) error {
+	_, err := me.db.Exec(sqlBuryJob, jobID, jobErr)
+	return err
+}
+
+func (me *PsqlDB) FindDeadJobs() ([]*db.Job, error) {
+	rs, err := me.db.Query(sqlSelectDeadJobs)
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rs)
+}
+
+// RequeueJob gives a dead job a fresh set of attempts.
+func (me *PsqlDB) RequeueJob(jobID string) error {
+	res, err := me.db.Exec(sqlRequeueJob, jobID, time.Now())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanUserFlags(rs *sql.Rows) ([]*db.UserFlag, error) {
+	defer rs.Close()
+	var flags []*db.UserFlag
+	for rs.Next() {
+		f := &db.UserFlag{}
+		err := rs.Scan(&f.UserID, &f.Username, &f.Flag, &f.Enabled, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	return flags, rs.Err()
+}
+
+// FindUserFlags returns the features turned on or off for the user alone.
+func (me *PsqlDB) FindUserFlags(userID string) ([]*db.UserFlag, error) {
+	rs, err := me.db.Query(sqlSelectUserFlags, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanUserFlags(rs)
+}
+
+// FindFlagUsers returns the users the feature is turned on or off for.
+func (me *PsqlDB) FindFlagUsers(flag string) ([]*db.UserFlag, error) {
+	rs, err := me.db.Query(sqlSelectFlagUsers, flag)
+	if err != nil {
+		return nil, err
+	}
+	return scanUserFlags(rs)
+}
+
+func (me *PsqlDB) SetUserFlag(userID string, flag string, enabled bool) error {
+	_, err := me.db.Exec(sqlUpsertUserFlag, userID, flag, enabled, time.Now())
+	return err
+}
+
+func (me *PsqlDB) RemoveUserFlag(userID string, flag string) error {
+	_, err := me.db.Exec(sqlRemoveUserFlag, userID, flag)
+	return err
+}
+
+func scanProfileLink(row interface {
+	Scan(dest ...interface{}) error
+}) (*db.ProfileLink, error) {
+	link := &db.ProfileLink{}
+	err := row.Scan(&link.ID, &link.UserID, &link.URL, &link.VerifiedAt, &link.CheckedAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// FindProfileLinks returns the links the user lists on their blog, in the
+// order they gave them.
+func (me *PsqlDB) FindProfileLinks(userID string) ([]*db.ProfileLink, error) {
+	rs, err := me.db.Query(sqlSelectProfileLinks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var links []*db.ProfileLink
+	for rs.Next() {
+		link, err := scanProfileLink(rs)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rs.Err()
+}
+
+func (me *PsqlDB) FindProfileLink(linkID string) (*db.ProfileLink, error) {
+	return scanProfileLink(me.db.QueryRow(sqlSelectProfileLink, linkID))
+}
+
+// SetProfileLinks replaces the user's links with urls.  Links the user
+// already had keep when they were last verified.
+func (me *PsqlDB) SetProfileLinks(userID string, urls []string) ([]*db.ProfileLink, error) {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(sqlRemoveOtherProfileLinks, userID, pq.Array(urls))
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range urls {
+		_, err = tx.Exec(sqlUpsertProfileLink, userID, u, i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return me.FindProfileLinks(userID)
+}
+
+func (me *PsqlDB) SetProfileLinkChecked(linkID string, verified bool, checkedAt time.Time) error {
+	_, err := me.db.Exec(sqlUpdateProfileLinkChecked, linkID, verified, checkedAt)
+	return err
+}
+
+// ShortLinkForPost returns the post's short link, making one the first
+// time it's asked for.  InsertPost asks straight away so every post has one.
+func (me *PsqlDB) ShortLinkForPost(postID string) (*db.ShortLink, error) {
+	// A new slug can collide with another post's, or another request can
+	// make the post's link first, either way look again and retry.
+	for i := 0; i < 5; i++ {
+		link := &db.ShortLink{}
+		err := me.db.QueryRow(sqlSelectShortLink, postID).Scan(&link.Slug, &link.PostID, &link.Clicks, &link.CreatedAt)
+		if err == nil {
+			return link, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		_, err = me.db.Exec(sqlInsertShortLink, internal.NewShortSlug(), postID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("couldn't find a free short link for post %s", postID)
+}
+
+// FindShortLink returns the short link with the slug.
+func (me *PsqlDB) FindShortLink(slug string) (*db.ShortLink, error) {
+	link := &db.ShortLink{}
+	err := me.db.QueryRow(sqlSelectShortLinkForSlug, slug).Scan(&link.Slug, &link.PostID, &link.Clicks, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// ClickShortLink counts a click on the short link.
+func (me *PsqlDB) ClickShortLink(slug string) error {
+	_, err := me.db.Exec(sqlClickShortLink, slug)
+	return err
+}
+
+func scanAuthRequest(row interface {
+	Scan(dest ...interface{}) error
+}) (*db.AuthRequest, error) {
+	req := &db.AuthRequest{}
+	err := row.Scan(&req.ID, &req.UserID, &req.Challenge, &req.ClientID, &req.RedirectURI, &req.State, &req.CodeChallenge, &req.Scope, &req.Status, &req.ExpiresAt, &req.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// InsertAuthRequest starts an IndieAuth sign in, the browser waiting on it
+// finds it again with handle.  Expired requests are cleared out on the way.
+func (me *PsqlDB) InsertAuthRequest(req *db.AuthRequest, handle string) error {
+	_, err := me.db.Exec(sqlPurgeAuthRequests, time.Now())
+	if err != nil {
+		return err
+	}
+	return me.db.QueryRow(
+		sqlInsertAuthRequest,
+		req.UserID,
+		tokenHash(handle),
+		req.Challenge,
+		req.ClientID,
+		req.RedirectURI,
+		req.State,
+		req.CodeChallenge,
+		req.Scope,
+		req.ExpiresAt,
+	).Scan(&req.ID, &req.Status, &req.CreatedAt)
+}
+
+func (me *PsqlDB) FindAuthRequest(handle string) (*db.AuthRequest, error) {
+	return scanAuthRequest(me.db.QueryRow(sqlSelectAuthRequest, tokenHash(handle)))
+}
+
+// FindPendingAuthRequests returns the sign ins waiting on the user, newest
+// first.
+func (me *PsqlDB) FindPendingAuthRequests(userID string) ([]*db.AuthRequest, error) {
+	rs, err := me.db.Query(sqlSelectPendingAuthReqs, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var reqs []*db.AuthRequest
+	for rs.Next() {
+		req, err := scanAuthRequest(rs)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, rs.Err()
+}
+
+// AnswerAuthRequest approves or denies one of the user's pending sign ins.
+func (me *PsqlDB) AnswerAuthRequest(requestID string, userID string, approved bool) error {
+	status := db.AuthRequestDenied
+	if approved {
+		status = db.AuthRequestApproved
+	}
+	res, err := me.db.Exec(sqlAnswerAuthRequest, requestID, userID, status, time.Now())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrAuthRequestInvalid
+	}
+	return nil
+}
+
+// IssueAuthCode hands the browser waiting on an approved sign in the code it
+// redirects back with, once.
+func (me *PsqlDB) IssueAuthCode(handle string, code string, expiresAt time.Time) error {
+	res, err := me.db.Exec(sqlIssueAuthCode, tokenHash(handle), tokenHash(code), expiresAt, time.Now())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrAuthRequestInvalid
+	}
+	return nil
+}
+
+// RedeemAuthCode returns the sign in an authorization code was issued for,
+// each code works once.
+func (me *PsqlDB) RedeemAuthCode(code string) (*db.AuthRequest, error) {
+	req, err := scanAuthRequest(me.db.QueryRow(sqlRedeemAuthCode, tokenHash(code), time.Now()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrAuthRequestInvalid
+	}
+	return req, err
+}
+
+func (me *PsqlDB) Snapshot() (*db.Snapshot, error) {
+	snapshot := &db.Snapshot{CreatedAt: time.Now().UTC()}
+
+	rs, err := me.db.Query(sqlSelectSnapshotUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		user, err := scanUser(rs)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Users = append(snapshot.Users, user)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	rs, err = me.db.Query(sqlSelectSnapshotPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		pk := &db.PublicKey{}
+		err := rs.Scan(&pk.ID, &pk.UserID, &pk.Key, &pk.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.PublicKeys = append(snapshot.PublicKeys, pk)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	rs, err = me.db.Query(sqlSelectSnapshotPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		post, err := me.scanPost(rs)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Posts = append(snapshot.Posts, post)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	rs, err = me.db.Query(sqlSelectSnapshotBlogs)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		blog := &db.Blog{}
+		err := rs.Scan(&blog.ID, &blog.OwnerID, &blog.UserID, &blog.Name, &blog.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Blogs = append(snapshot.Blogs, blog)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	rs, err = me.db.Query(sqlSelectSnapshotMembers)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		m := &db.BlogMember{}
+		err := rs.Scan(&m.BlogID, &m.UserID, &m.Name, &m.Role, &m.CreatedAt, &m.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Members = append(snapshot.Members, m)
+	}
+	if rs.Err() != nil {
+		return nil, rs.Err()
+	}
+
+	return snapshot, nil
+}
+
+// RestoreSnapshot upserts every record in the snapshot inside a single
+// transaction so a failed restore leaves the database untouched.  Accounts
+// erased since the snapshot was taken stay erased.
+func (me *PsqlDB) RestoreSnapshot(snapshot *db.Snapshot) error {
+	tx, err := me.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	erased := map[string]bool{}
+	rs, err := tx.Query(sqlSelectErasedUsers)
+	if err != nil {
+		return err
+	}
+	for rs.Next() {
+		var id string
+		if err := rs.Scan(&id); err != nil {
+			rs.Close()
+			return err
+		}
+		erased[id] = true
+	}
+	rs.Close()
+	if rs.Err() != nil {
+		return rs.Err()
+	}
+	snapshot = snapshot.Without(erased)
+
+	for _, user := range snapshot.Users {
+		var name sql.NullString
+		if user.Name != "" {
+			name = sql.NullString{String: user.Name, Valid: true}
+		}
+		status := user.Status
+		if status == "" {
+			status = db.UserStatusActive
+		}
+		_, err := tx.Exec(sqlRestoreUser, user.ID, name, user.CreatedAt, status, user.DisplayName, user.Bio, user.Delisted)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, blog := range snapshot.Blogs {
+		_, err := tx.Exec(sqlRestoreBlog, blog.ID, blog.OwnerID, blog.UserID, blog.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range snapshot.Members {
+		_, err := tx.Exec(sqlRestoreBlogMember, m.BlogID, m.UserID, m.Role, m.CreatedAt, m.JoinedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pk := range snapshot.PublicKeys {
+		_, err := tx.Exec(sqlRestorePublicKey, pk.ID, pk.UserID, pk.Key, pk.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Snapshots hold the whole text, the posts replaced may have theirs in
+	// the body store.
+	postIDs := make([]string, 0, len(snapshot.Posts))
+	for _, post := range snapshot.Posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	oldKeys, err := queryBodyKeys(tx, sqlSelectRestoredBodyKeys, pq.Array(postIDs))
+	if err != nil {
+		return err
+	}
+	var newKeys []string
+	committed := false
+	defer func() {
+		if !committed {
+			me.removeBodies(newKeys)
+		}
+	}()
+
+	for _, post := range snapshot.Posts {
+		// Older snapshots don't have the counts.
+		parsed := pkg.ParseText(post.Text)
+		inline, bodyKey, err := me.putBody(post.Text)
+		if err != nil {
+			return err
+		}
+		newKeys = append(newKeys, bodyKey)
+		_, err = tx.Exec(
+			sqlRestorePost,
+			post.ID,
+			post.UserID,
+			post.Filename,
+			post.Title,
+			inline,
+			post.Description,
+			post.PublishAt,
+			post.HiddenAt,
+			post.HiddenReason,
+			post.FlaggedReason,
+			post.Views,
+			post.Draft,
+			post.DeletedAt,
+			parsed.ItemCount,
+			parsed.WordCount,
+			pq.Array(parsed.MetaData.Tags),
+			post.EditedAt,
+			post.Stars,
+			post.Likes,
+			sql.NullString{String: post.AuthorID, Valid: post.AuthorID != ""},
+			parsed.MetaData.Visibility,
+			pq.Array(parsed.MetaData.Readers),
+			bodyKey,
+			len(post.Text),
+			textMD5(post.Text),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	committed = true
+	me.removeBodies(oldKeys)
+	return nil
+}
+
+func (me *PsqlDB) Ping() error {
+	return me.db.Ping()
 }
 
 func (me *PsqlDB) Close() error {
@@ -0,0 +1,107 @@
+// Package events hands post changes to whatever has to react to them.  The
+// statements that create, update and delete posts record a row in
+// post_events as they run, so a change can't be saved without its event.
+// The web server's Dispatcher moves those rows into the jobs table, one job
+// for each subscriber, which runs them with its usual retries.  Webhooks,
+// ActivityPub deliveries and caches subscribe here instead of being called
+// after each write.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"go.uber.org/zap"
+)
+
+const (
+	// pollInterval is how often the dispatcher looks for new events.
+	pollInterval = 2 * time.Second
+	// batchSize is how many events are dispatched in one statement.
+	batchSize = 100
+	// jobPrefix starts the kind of the jobs that deliver events, which
+	// leaves 26 characters of the jobs.kind column for the subscriber.
+	jobPrefix = "event:"
+)
+
+// Consumer reacts to a post event.  It runs as a job, so an error runs it
+// again later and it has to cope with seeing an event twice.
+type Consumer func(event *db.PostEvent) error
+
+// Dispatcher delivers post events to its subscribers.
+type Dispatcher struct {
+	dbpool    db.DB
+	logger    *zap.SugaredLogger
+	queue     *jobs.Queue
+	consumers []string
+}
+
+// NewDispatcher delivers events through the queue's workers.
+func NewDispatcher(dbpool db.DB, logger *zap.SugaredLogger, queue *jobs.Queue) *Dispatcher {
+	return &Dispatcher{dbpool: dbpool, logger: logger, queue: queue}
+}
+
+// JobKind is the kind of the jobs that deliver events to the named
+// subscriber.
+func JobKind(name string) string {
+	return jobPrefix + name
+}
+
+// Subscribe delivers every event from now on to the consumer.  The name
+// identifies it in the jobs table, so it has to stay the same across
+// restarts for queued events to reach it.
+func (d *Dispatcher) Subscribe(name string, consumer Consumer) {
+	kind := JobKind(name)
+	d.consumers = append(d.consumers, kind)
+	d.queue.Handle(kind, handler(consumer))
+}
+
+// handler decodes the event the dispatcher saved in the job's payload.
+func handler(consumer Consumer) jobs.Handler {
+	return func(payload []byte) error {
+		event := &db.PostEvent{}
+		if err := json.Unmarshal(payload, event); err != nil {
+			return err
+		}
+		return consumer(event)
+	}
+}
+
+// Run dispatches events until done is closed.  Without subscribers it
+// leaves them in the table for a server that has some.
+func (d *Dispatcher) Run(done <-chan struct{}) {
+	if len(d.consumers) == 0 {
+		return
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		for d.dispatch() == batchSize {
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// dispatch hands a batch of events to the jobs table and returns how many
+// it moved.
+func (d *Dispatcher) dispatch() int {
+	n, err := d.dbpool.DispatchPostEvents(d.consumers, batchSize, jobs.MaxAttempts)
+	if err != nil {
+		d.logger.Errorf("dispatching post events failed: %v", err)
+		return 0
+	}
+	return n
+}
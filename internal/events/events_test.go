@@ -0,0 +1,68 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+	"go.uber.org/zap"
+)
+
+// eventsDB remembers how it was asked to dispatch events.
+type eventsDB struct {
+	db.DB
+	kinds       []string
+	limit       int
+	maxAttempts int
+}
+
+func (d *eventsDB) DispatchPostEvents(jobKinds []string, limit int, maxAttempts int) (int, error) {
+	d.kinds = jobKinds
+	d.limit = limit
+	d.maxAttempts = maxAttempts
+	return 3, nil
+}
+
+func TestDispatch(t *testing.T) {
+	is := is.New(t)
+	dbpool := &eventsDB{}
+	logger := zap.NewNop().Sugar()
+	d := NewDispatcher(dbpool, logger, jobs.NewQueue(dbpool, logger, 1))
+	d.Subscribe("publish", func(*db.PostEvent) error { return nil })
+	d.Subscribe("webhooks", func(*db.PostEvent) error { return nil })
+
+	is.Equal(d.dispatch(), 3)
+	is.Equal(dbpool.kinds, []string{"event:publish", "event:webhooks"})
+	is.Equal(dbpool.limit, batchSize)
+	is.Equal(dbpool.maxAttempts, jobs.MaxAttempts)
+}
+
+func TestRunWithoutSubscribers(t *testing.T) {
+	dbpool := &eventsDB{}
+	logger := zap.NewNop().Sugar()
+	d := NewDispatcher(dbpool, logger, jobs.NewQueue(dbpool, logger, 1))
+	// Returns straight away instead of dropping events nobody would get.
+	d.Run(make(chan struct{}))
+}
+
+func TestHandler(t *testing.T) {
+	is := is.New(t)
+	var got *db.PostEvent
+	h := handler(func(event *db.PostEvent) error {
+		got = event
+		return nil
+	})
+
+	// What postgres' json_build_object makes of a post_events row.
+	payload := `{"id" : 7, "post_id" : "6b0c1c3e-3f4e-4a8e-9a52-0a2d6f0c1b11", "user_id" : "0f5b0e5c-6a8b-4c35-9d0b-86d1d0c4e0aa", "kind" : "deleted", "created_at" : "2022-06-04T10:30:00.123456+00:00"}`
+	is.NoErr(h([]byte(payload)))
+	is.Equal(got.ID, int64(7))
+	is.Equal(got.PostID, "6b0c1c3e-3f4e-4a8e-9a52-0a2d6f0c1b11")
+	is.Equal(got.UserID, "0f5b0e5c-6a8b-4c35-9d0b-86d1d0c4e0aa")
+	is.Equal(got.Kind, db.PostDeleted)
+	is.True(got.CreatedAt.Equal(time.Date(2022, 6, 4, 10, 30, 0, 123456000, time.UTC)))
+
+	is.True(h([]byte("not json")) != nil)
+}
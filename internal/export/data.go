@@ -0,0 +1,167 @@
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// UserData is everything lists.sh stores about a single account.
+type UserData struct {
+	User    *db.User
+	Posts   []*db.Post
+	Keys    []*db.PublicKey
+	Invites []*db.Invite
+	Blogs   []*db.Blog
+	Links   []*db.ProfileLink
+}
+
+// KeyMeta identifies a public key without repeating the key itself.
+type KeyMeta struct {
+	Type        string     `json:"type"`
+	Fingerprint string     `json:"fingerprint"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// InviteMeta describes an invite code the user created.
+type InviteMeta struct {
+	Code      string     `json:"code"`
+	CreatedAt *time.Time `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// AccountStats summarizes the account.
+type AccountStats struct {
+	Posts          int `json:"posts"`
+	Bytes          int `json:"bytes"`
+	HiddenPosts    int `json:"hidden_posts"`
+	FlaggedPosts   int `json:"flagged_posts"`
+	Keys           int `json:"keys"`
+	InvitesCreated int `json:"invites_created"`
+	InvitesUsed    int `json:"invites_used"`
+}
+
+// Account is written to account.json at the root of the data archive.
+type Account struct {
+	ID         string            `json:"id"`
+	Username   string            `json:"username"`
+	Status     string            `json:"status"`
+	CreatedAt  *time.Time        `json:"created_at"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Stats      AccountStats      `json:"stats"`
+	Keys       []*KeyMeta        `json:"keys"`
+	Invites    []*InviteMeta     `json:"invites"`
+	Blogs      []*db.Blog        `json:"blogs"`
+	Links      []*db.ProfileLink `json:"profile_links"`
+}
+
+// CollectUserData gathers every record that belongs to the user.
+func CollectUserData(dbpool db.DB, user *db.User) (*UserData, error) {
+	posts, err := dbpool.PostsForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := dbpool.ListKeysForUser(user)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := dbpool.FindInvitesForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	blogs, err := dbpool.FindBlogs(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := dbpool.FindProfileLinks(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserData{User: user, Posts: posts, Keys: keys, Invites: invites, Blogs: blogs, Links: links}, nil
+}
+
+// Stats counts what the account has stored.
+func (d *UserData) Stats() AccountStats {
+	stats := AccountStats{
+		Posts:          len(d.Posts),
+		Keys:           len(d.Keys),
+		InvitesCreated: len(d.Invites),
+	}
+	for _, post := range d.Posts {
+		stats.Bytes += len(post.Text)
+		if post.HiddenAt != nil {
+			stats.HiddenPosts++
+		}
+		if post.FlaggedReason != "" {
+			stats.FlaggedPosts++
+		}
+	}
+	for _, invite := range d.Invites {
+		if invite.UsedAt != nil {
+			stats.InvitesUsed++
+		}
+	}
+	return stats
+}
+
+// Account returns the contents of account.json.
+func (d *UserData) Account() *Account {
+	account := &Account{
+		ID:         d.User.ID,
+		Username:   d.User.Name,
+		Status:     d.User.Status,
+		CreatedAt:  d.User.CreatedAt,
+		ExportedAt: time.Now(),
+		Stats:      d.Stats(),
+		Keys:       []*KeyMeta{},
+		Invites:    []*InviteMeta{},
+		Blogs:      []*db.Blog{},
+		Links:      []*db.ProfileLink{},
+	}
+	for _, pk := range d.Keys {
+		account.Keys = append(account.Keys, &KeyMeta{
+			Type:        strings.SplitN(pk.Key, " ", 2)[0],
+			Fingerprint: internal.KeyFingerprint(pk.Key),
+			CreatedAt:   pk.CreatedAt,
+		})
+	}
+	account.Blogs = append(account.Blogs, d.Blogs...)
+	account.Links = append(account.Links, d.Links...)
+	for _, invite := range d.Invites {
+		account.Invites = append(account.Invites, &InviteMeta{
+			Code:      invite.Code,
+			CreatedAt: invite.CreatedAt,
+			UsedAt:    invite.UsedAt,
+		})
+	}
+	return account
+}
+
+// WriteDataArchive writes the regular export plus account.json with the
+// profile, key fingerprints, invites, extra blogs, profile links and usage
+// stats.
+func WriteDataArchive(w io.Writer, data *UserData) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := writePosts(tw, data.User, data.Posts)
+	if err != nil {
+		return err
+	}
+
+	account := data.Account()
+	err = writeJSON(tw, "account.json", account, account.ExportedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestWriteDataArchive(t *testing.T) {
+	is := is.New(t)
+	now := time.Now()
+	data := &UserData{
+		User: &db.User{ID: "u1", Name: "erock", Status: db.UserStatusActive, CreatedAt: &now},
+		Posts: []*db.Post{
+			{ID: "p1", Filename: "hello", Title: "hello", Text: "- one"},
+			{ID: "p2", Filename: "spam", Title: "spam", Text: "- two", HiddenAt: &now, HiddenReason: "takedown"},
+		},
+		Keys: []*db.PublicKey{
+			{ID: "k1", Key: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl", CreatedAt: &now},
+		},
+		Invites: []*db.Invite{
+			{Code: "abc", CreatedAt: &now, UsedAt: &now},
+			{Code: "def", CreatedAt: &now},
+		},
+		Blogs: []*db.Blog{
+			{ID: "b1", OwnerID: "u1", UserID: "u2", Name: "work", CreatedAt: &now},
+		},
+	}
+
+	var buf bytes.Buffer
+	is.NoErr(WriteDataArchive(&buf, data))
+
+	gr, err := gzip.NewReader(&buf)
+	is.NoErr(err)
+	tr := tar.NewReader(gr)
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		is.NoErr(err)
+		b, err := io.ReadAll(tr)
+		is.NoErr(err)
+		files[hdr.Name] = b
+	}
+
+	is.Equal(string(files["hello.txt"]), "- one")
+	is.True(files["metadata.json"] != nil)
+
+	var account Account
+	is.NoErr(json.Unmarshal(files["account.json"], &account))
+	is.Equal(account.Username, "erock")
+	is.Equal(account.Stats.Posts, 2)
+	is.Equal(account.Stats.HiddenPosts, 1)
+	is.Equal(account.Stats.InvitesCreated, 2)
+	is.Equal(account.Stats.InvitesUsed, 1)
+	is.Equal(len(account.Keys), 1)
+	is.Equal(account.Keys[0].Type, "ssh-ed25519")
+	is.Equal(account.Keys[0].Fingerprint, "SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU")
+	is.Equal(len(account.Blogs), 1)
+	is.Equal(account.Blogs[0].Name, "work")
+}
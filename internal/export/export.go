@@ -0,0 +1,235 @@
+// Package export streams a backup of a user's posts, or everything we store
+// about them, to their ssh client.
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/pkg"
+	"go.uber.org/zap"
+)
+
+// PostMeta describes a single exported post inside metadata.json.
+type PostMeta struct {
+	ID          string     `json:"id"`
+	Filename    string     `json:"filename"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	PublishAt   *time.Time `json:"publish_at"`
+	// HiddenAt, HiddenReason and FlaggedReason record moderation actions
+	// taken on the post.
+	HiddenAt      *time.Time `json:"hidden_at,omitempty"`
+	HiddenReason  string     `json:"hidden_reason,omitempty"`
+	FlaggedReason string     `json:"flagged_reason,omitempty"`
+}
+
+// Metadata is written to metadata.json at the root of the archive.
+type Metadata struct {
+	Username   string      `json:"username"`
+	ExportedAt time.Time   `json:"exported_at"`
+	Posts      []*PostMeta `json:"posts"`
+}
+
+// File is a single file of a static site.
+type File struct {
+	Name    string
+	Data    []byte
+	ModTime time.Time
+}
+
+// SiteFunc draws a user's blog as static files, the web server knows how.
+type SiteFunc func(logger *zap.SugaredLogger, dbpool db.DB, user *db.User) ([]*File, error)
+
+// Middleware handles `ssh lists.sh export > backup.tar.gz`,
+// `ssh lists.sh export --html > blog.tar.gz` and
+// `ssh lists.sh export data > lists-data.tar.gz`.
+func Middleware(dbpool db.DB, site SiteFunc) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if len(cmd) == 0 || cmd[0] != "export" {
+				sh(s)
+				return
+			}
+
+			user, err := internal.SessionUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			// Everyone can download their data, even when their account has
+			// been suspended.
+			if len(cmd) > 1 && cmd[1] == "data" {
+				data, err := CollectUserData(dbpool, user)
+				if err == nil {
+					err = WriteDataArchive(s, data)
+				}
+				if err != nil {
+					internal.ErrHandler(s, err)
+					return
+				}
+				sh(s)
+				return
+			}
+
+			if err := internal.CanRunCommands(user); err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			if len(cmd) > 1 && cmd[1] == "--html" {
+				files, err := site(internal.SessionLogger(s), dbpool, user)
+				if err == nil {
+					err = WriteSiteArchive(s, files)
+				}
+				if err != nil {
+					internal.ErrHandler(s, err)
+					return
+				}
+				sh(s)
+				return
+			}
+
+			posts, err := dbpool.PostsForUser(user.ID)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			err = WriteArchive(s, user, posts)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+// WriteArchive writes a gzipped tarball with one text file per post and a
+// metadata.json describing them.
+func WriteArchive(w io.Writer, user *db.User, posts []*db.Post) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := writePosts(tw, user, posts)
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// WriteSiteArchive writes a gzipped tarball of a static site.
+func WriteSiteArchive(w io.Writer, files []*File) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, f := range files {
+		err := writeFile(tw, f.Name, f.Data, f.ModTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func writePosts(tw *tar.Writer, user *db.User, posts []*db.Post) error {
+	meta := &Metadata{
+		Username:   user.Name,
+		ExportedAt: time.Now(),
+		Posts:      []*PostMeta{},
+	}
+
+	for _, post := range posts {
+		text := SourceText(post)
+		modTime := time.Now()
+		if post.PublishAt != nil {
+			modTime = *post.PublishAt
+		}
+		err := writeFile(tw, post.Filename+".txt", []byte(text), modTime)
+		if err != nil {
+			return err
+		}
+
+		meta.Posts = append(meta.Posts, &PostMeta{
+			ID:          post.ID,
+			Filename:    post.Filename,
+			Title:       post.Title,
+			Description: post.Description,
+			PublishAt:   post.PublishAt,
+
+			HiddenAt:      post.HiddenAt,
+			HiddenReason:  post.HiddenReason,
+			FlaggedReason: post.FlaggedReason,
+		})
+	}
+
+	return writeJSON(tw, "metadata.json", meta, meta.ExportedAt)
+}
+
+func writeJSON(tw *tar.Writer, name string, v interface{}, modTime time.Time) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(tw, name, b, modTime)
+}
+
+// SourceText returns the stored text for a post with any variables that only
+// live in the database (title, description, publish date) written back to the
+// top of the file, so re-uploading the export reproduces the same post.
+func SourceText(post *db.Post) string {
+	parsed := pkg.ParseText(post.Text)
+	var vars []string
+
+	if parsed.MetaData.Title == "" && post.Title != "" && post.Title != post.Filename {
+		vars = append(vars, fmt.Sprintf("=: title %s", post.Title))
+	}
+	if parsed.MetaData.Description == "" && post.Description != "" {
+		vars = append(vars, fmt.Sprintf("=: description %s", post.Description))
+	}
+	if parsed.MetaData.PublishAt == nil && post.PublishAt != nil {
+		vars = append(vars, fmt.Sprintf("=: publish_at %s", post.PublishAt.Format("2006-01-02")))
+	}
+
+	if len(vars) == 0 {
+		return post.Text
+	}
+	return strings.Join(vars, "\n") + "\n" + post.Text
+}
+
+func writeFile(tw *tar.Writer, name string, contents []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxFilenameLength keeps post URLs a sensible length.
+const MaxFilenameLength = 100
+
+// reservedFilenames would be shadowed by, or collide with, the routes under
+// a blog.
+var reservedFilenames = []string{"rss", "atom", "feed", "api", "assets", "avatar.png"}
+
+// reservedExtensions are served as the post without them, like slug.json.
+var reservedExtensions = []string{".json", ".csv"}
+
+// specialFilenames are the files that configure a blog instead of being
+// posts, they're the only names allowed to start with an underscore.
+var specialFilenames = []string{"_readme", "_header", "_footer", "_404", "_dictionary"}
+
+// ValidateFilename checks a post's filename, without the .txt extension,
+// makes a working URL.
+func ValidateFilename(filename string) error {
+	switch {
+	case filename == "":
+		return fmt.Errorf("the filename is empty")
+	case filename == "." || filename == ".." || strings.ContainsAny(filename, "/\\"):
+		return fmt.Errorf("%q is a path, not a filename", filename)
+	case utf8.RuneCountInString(filename) > MaxFilenameLength:
+		return fmt.Errorf("the filename is longer than %d characters", MaxFilenameLength)
+	case !utf8.ValidString(filename):
+		return fmt.Errorf("the filename isn't valid UTF-8")
+	}
+
+	for _, c := range filename {
+		if unicode.IsSpace(c) || unicode.IsControl(c) {
+			return fmt.Errorf("filenames can't contain spaces, use - or _ instead")
+		}
+		if strings.ContainsRune("?#%", c) {
+			return fmt.Errorf("filenames can't contain %q", c)
+		}
+	}
+
+	for _, name := range reservedFilenames {
+		if strings.EqualFold(filename, name) {
+			return fmt.Errorf("%q is reserved, pick another filename", filename)
+		}
+	}
+	for _, ext := range reservedExtensions {
+		if len(filename) > len(ext) && strings.EqualFold(filename[len(filename)-len(ext):], ext) {
+			return fmt.Errorf("filenames ending in %s are reserved for exporting posts", ext)
+		}
+	}
+	if strings.HasPrefix(filename, "_") {
+		for _, name := range specialFilenames {
+			if filename == name {
+				return nil
+			}
+		}
+		return fmt.Errorf("filenames starting with _ are reserved for special files like %s", strings.Join(specialFilenames, ", "))
+	}
+	return nil
+}
+
+// MaxBlogNameLength matches the longest username, blogs share their
+// namespace.
+const MaxBlogNameLength = 50
+
+// reservedBlogNames are the site's own pages and files, a blog called one of
+// them would never be reachable.  Every route at the root of the site is
+// here.
+var reservedBlogNames = []string{
+	"spec", "ops", "privacy", "help", "healthz", "readyz", "metrics", "transparency",
+	"read", "oembed", "rss", "topics", "api", "assets", "login", "logout", "indieauth",
+	"rss.xml", "atom.xml", "feed.xml", "main.css", "card.png", "favicon.ico",
+	"favicon-16x16.png", "favicon-32x32.png", "apple-touch-icon.png", "robots.txt",
+	".well-known",
+}
+
+// IsReservedName reports whether name is one of the site's own pages, or
+// starts with a dash like ShortLinkPath.  Usernames share the namespace with
+// blogs so they're checked too.
+func IsReservedName(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "-") {
+		return true
+	}
+	for _, reserved := range reservedBlogNames {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// BlogFromPath finds the blog an scp upload is aimed at from its target
+// directory: `lists.sh:/projectname/` is the blog projectname while the home
+// directory, or no directory at all, is the account's own blog and gives "".
+func BlogFromPath(dir string) (string, error) {
+	name := strings.Trim(strings.TrimPrefix(dir, "~"), "/")
+	if name == "" || name == "." {
+		return "", nil
+	}
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("%q is nested, blogs are a single directory like /projectname/", dir)
+	}
+	name = strings.ToLower(name)
+	if err := ValidateBlogName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ValidateBlogName checks a lowercased blog name or username, they share the
+// root of the site: letters, digits, - and _, starting with a letter or
+// digit, and not one of the site's own pages.
+func ValidateBlogName(name string) error {
+	if name == "" {
+		return fmt.Errorf("the name is empty")
+	}
+	if len(name) > MaxBlogNameLength {
+		return fmt.Errorf("names are at most %d characters", MaxBlogNameLength)
+	}
+	for i, c := range name {
+		letter := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+		if !letter && (i == 0 || (c != '-' && c != '_')) {
+			return fmt.Errorf("names are letters, digits, - and _ and start with a letter or digit, got %q", name)
+		}
+	}
+	if IsReservedName(name) {
+		return fmt.Errorf("%q is reserved, pick another name", name)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestValidateFilename(t *testing.T) {
+	t.Run("good filenames", func(t *testing.T) {
+		is := is.New(t)
+		for _, name := range []string{"hello-world", "tacos_2022", "café", "v1.2", ".json", "_header", "_readme", "rss-reader"} {
+			is.NoErr(ValidateFilename(name))
+		}
+	})
+
+	t.Run("bad filenames", func(t *testing.T) {
+		for _, tt := range []struct {
+			name string
+			want string
+		}{
+			{"", "empty"},
+			{"..", "path"},
+			{"../etc", "path"},
+			{`a\b`, "path"},
+			{"hello world", "spaces"},
+			{"tab\there", "spaces"},
+			{"what?", "'?'"},
+			{"100%", "'%'"},
+			{"rss", "reserved"},
+			{"RSS", "reserved"},
+			{"api", "reserved"},
+			{"tacos.json", "exporting"},
+			{"tacos.CSV", "exporting"},
+			{"_drafts", "special files"},
+			{strings.Repeat("a", MaxFilenameLength+1), "longer"},
+			{"bad\xff", "UTF-8"},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				is := is.New(t)
+				err := ValidateFilename(tt.name)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tt.want)) // names what's wrong
+			})
+		}
+	})
+}
+
+func TestBlogFromPath(t *testing.T) {
+	t.Run("the account's own blog", func(t *testing.T) {
+		is := is.New(t)
+		for _, dir := range []string{"", ".", "/", "~", "~/"} {
+			name, err := BlogFromPath(dir)
+			is.NoErr(err)
+			is.Equal(name, "")
+		}
+	})
+
+	t.Run("a directory names the blog", func(t *testing.T) {
+		is := is.New(t)
+		for _, dir := range []string{"/projectname/", "projectname", "/ProjectName", "~/projectname/"} {
+			name, err := BlogFromPath(dir)
+			is.NoErr(err)
+			is.Equal(name, "projectname")
+		}
+	})
+
+	t.Run("bad names", func(t *testing.T) {
+		for _, tt := range []struct {
+			dir  string
+			want string
+		}{
+			{"/a/b/", "nested"},
+			{"/-dash/", "start with"},
+			{"/my blog/", "letters"},
+			{"/café/", "letters"},
+			{"/help/", "reserved"},
+			{"/" + strings.Repeat("a", MaxBlogNameLength+1), "at most"},
+		} {
+			t.Run(tt.dir, func(t *testing.T) {
+				is := is.New(t)
+				_, err := BlogFromPath(tt.dir)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tt.want)) // names what's wrong
+			})
+		}
+	})
+}
+
+func TestIsReservedName(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		reserved bool
+	}{
+		{"Help", true},
+		{"rss.xml", true},
+		{"favicon.ico", true},
+		{"robots.txt", true},
+		{".well-known", true},
+		{ShortLinkPath, true},
+		{"-erock", true}, // could be mistaken for short links
+		{"p", false},
+		{"erock", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(IsReservedName(tt.name), tt.reserved)
+		})
+	}
+}
+
+func TestValidateBlogName(t *testing.T) {
+	t.Run("good names", func(t *testing.T) {
+		is := is.New(t)
+		for _, name := range []string{"erock", "bob_x", "my-blog", "2cool", strings.Repeat("a", MaxBlogNameLength)} {
+			is.NoErr(ValidateBlogName(name))
+		}
+	})
+
+	t.Run("bad names", func(t *testing.T) {
+		for _, tt := range []struct {
+			name string
+			want string
+		}{
+			{"", "empty"},
+			{"a/b", "letters"},
+			{"100%", "letters"},
+			{"what?", "letters"},
+			{"my blog", "letters"},
+			{"café", "letters"},
+			{"_erock", "start with"},
+			{"-erock", "start with"},
+			{"main.css", "letters"},
+			{"help", "reserved"},
+			{strings.Repeat("a", MaxBlogNameLength+1), "at most"},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				is := is.New(t)
+				err := ValidateBlogName(tt.name)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tt.want)) // names what's wrong
+			})
+		}
+	})
+}
@@ -0,0 +1,115 @@
+// Package flags decides which users get a feature that's being rolled out.
+// A flag is on or off for everyone with flags.on and flags.off, for a
+// share of users with flags.rollout, and for single users with
+// `lists-admin flag`, which beats the rest.  The ssh commands, the TUI, scp
+// uploads and the web server all ask Enabled before offering a feature
+// behind a flag.
+package flags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+const (
+	// Share is `ssh lists.sh share` and the links it prints, see
+	// internal/share.
+	Share = "share"
+	// EmailReaders lets posts name email addresses in `=: visibility`.
+	EmailReaders = "email-readers"
+)
+
+// defaults says which flags there are and whether each is on when nothing
+// is configured for it.
+var defaults = map[string]bool{
+	Share:        true,
+	EmailReaders: true,
+}
+
+// Known returns the name of every flag.
+func Known() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsKnown reports whether there's a flag with the name.
+func IsKnown(name string) bool {
+	_, ok := defaults[name]
+	return ok
+}
+
+// Enabled reports whether the user gets the feature.  An empty userID, for
+// someone who isn't signed in, only gets what's on for everyone.  The
+// override isn't cached, a failed lookup falls back to the configuration.
+func Enabled(cfg config.FlagsConfig, dbpool db.DB, userID string, flag string) bool {
+	if userID != "" && dbpool != nil {
+		overrides, err := dbpool.FindUserFlags(userID)
+		if err == nil {
+			for _, o := range overrides {
+				if o.Flag == flag {
+					return o.Enabled
+				}
+			}
+		}
+	}
+	return Configured(cfg, userID, flag)
+}
+
+// Configured is whether the user gets the feature without looking at
+// overrides.
+func Configured(cfg config.FlagsConfig, userID string, flag string) bool {
+	if contains(cfg.Off, flag) {
+		return false
+	}
+	if contains(cfg.On, flag) {
+		return true
+	}
+	if percent, ok := cfg.Rollout[flag]; ok {
+		return userID != "" && bucket(userID, flag) < percent
+	}
+	return defaults[flag]
+}
+
+// Rollout describes who the configuration gives the feature to: "on",
+// "off" or the percent of users.
+func Rollout(cfg config.FlagsConfig, flag string) string {
+	switch {
+	case contains(cfg.Off, flag):
+		return "off"
+	case contains(cfg.On, flag):
+		return "on"
+	}
+	if percent, ok := cfg.Rollout[flag]; ok {
+		return fmt.Sprintf("%d%%", percent)
+	}
+	if defaults[flag] {
+		return "on"
+	}
+	return "off"
+}
+
+// bucket spreads users over 0 to 99, differently for each flag so the same
+// users aren't always first.  Raising a rollout's percent keeps everyone
+// who already had the feature.
+func bucket(userID string, flag string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag + "\n" + userID))
+	return int(h.Sum32() % 100)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// flagsDB has overrides for some users and fails for "broken".
+type flagsDB struct {
+	db.DB
+	overrides map[string][]*db.UserFlag
+}
+
+func (d *flagsDB) FindUserFlags(userID string) ([]*db.UserFlag, error) {
+	if userID == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	return d.overrides[userID], nil
+}
+
+func TestEnabled(t *testing.T) {
+	dbpool := &flagsDB{overrides: map[string][]*db.UserFlag{
+		"early": {{Flag: Share, Enabled: true}},
+		"opted": {{Flag: Share, Enabled: false}},
+	}}
+
+	t.Run("defaults", func(t *testing.T) {
+		is := is.New(t)
+		cfg := config.FlagsConfig{}
+		is.True(Enabled(cfg, dbpool, "u1", Share))
+		is.True(Enabled(cfg, dbpool, "", EmailReaders))
+		is.True(!Enabled(cfg, dbpool, "u1", "no-such-flag"))
+	})
+
+	t.Run("off for everyone but overrides", func(t *testing.T) {
+		is := is.New(t)
+		cfg := config.FlagsConfig{Off: []string{Share}}
+		is.True(!Enabled(cfg, dbpool, "u1", Share))
+		is.True(Enabled(cfg, dbpool, "early", Share))
+		is.True(!Enabled(cfg, dbpool, "broken", Share))
+	})
+
+	t.Run("overrides beat on", func(t *testing.T) {
+		is := is.New(t)
+		cfg := config.FlagsConfig{On: []string{Share}}
+		is.True(Enabled(cfg, dbpool, "u1", Share))
+		is.True(!Enabled(cfg, dbpool, "opted", Share))
+	})
+
+	t.Run("rollout", func(t *testing.T) {
+		is := is.New(t)
+		count := func(percent int) int {
+			cfg := config.FlagsConfig{Rollout: map[string]int{Share: percent}}
+			n := 0
+			for i := 0; i < 1000; i++ {
+				if Enabled(cfg, dbpool, fmt.Sprintf("user-%d", i), Share) {
+					n++
+				}
+			}
+			return n
+		}
+		is.Equal(count(0), 0)
+		is.Equal(count(100), 1000)
+		n := count(20)
+		is.True(n > 150 && n < 250) // about a fifth
+
+		// Signed out readers only get what's on for everyone.
+		is.True(!Enabled(config.FlagsConfig{Rollout: map[string]int{Share: 99}}, dbpool, "", Share))
+	})
+
+	t.Run("raising a rollout keeps who had it", func(t *testing.T) {
+		is := is.New(t)
+		low := config.FlagsConfig{Rollout: map[string]int{Share: 10}}
+		high := config.FlagsConfig{Rollout: map[string]int{Share: 50}}
+		for i := 0; i < 1000; i++ {
+			id := fmt.Sprintf("user-%d", i)
+			if Configured(low, id, Share) {
+				is.True(Configured(high, id, Share))
+			}
+		}
+	})
+}
+
+func TestRollout(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Rollout(config.FlagsConfig{}, Share), "on")
+	is.Equal(Rollout(config.FlagsConfig{Off: []string{Share}}, Share), "off")
+	is.Equal(Rollout(config.FlagsConfig{Rollout: map[string]int{Share: 5}}, Share), "5%")
+}
@@ -0,0 +1,181 @@
+package i18n
+
+// spanish is keyed by the English text.
+var spanish = map[string]string{
+	// TUI help
+	"save":                     "guardar",
+	"exit":                     "salir",
+	"quit":                     "salir",
+	"back":                     "volver",
+	"cancel":                   "cancelar",
+	"choose":                   "elegir",
+	"select":                   "seleccionar",
+	"change":                   "cambiar",
+	"switch":                   "cambiar",
+	"switch field":             "cambiar de campo",
+	"switch feed":              "cambiar de feed",
+	"fewer/more":               "menos/más",
+	"page":                     "página",
+	"tabs":                     "pestañas",
+	"retry":                    "reintentar",
+	"refresh":                  "actualizar",
+	"done":                     "listo",
+	"next":                     "siguiente",
+	"skip":                     "omitir",
+	"read":                     "leer",
+	"follow":                   "seguir",
+	"unfollow":                 "dejar de seguir",
+	"add":                      "añadir",
+	"add key":                  "añadir clave",
+	"remove":                   "quitar",
+	"rename":                   "renombrar",
+	"merge":                    "fusionar",
+	"new invite":               "nueva invitación",
+	"delete account":           "borrar la cuenta",
+	"erase account":            "borrar la cuenta",
+	"publish a sample":         "publicar un ejemplo",
+	"no thanks":                "no, gracias",
+	"go to the menu":           "ir al menú",
+	"back to the menu":         "volver al menú",
+	"title/slug":               "título/slug",
+	"all keys":                 "todas las teclas",
+	"clear filter":             "quitar el filtro",
+	"clear marks":              "quitar las marcas",
+	"restore marked":           "restaurar las marcadas",
+	"delete marked":            "borrar las marcadas",
+	"delete marked for good":   "borrar las marcadas para siempre",
+	"delete for good":          "borrar para siempre",
+	"publish/unpublish marked": "publicar/despublicar las marcadas",
+	"up":                       "arriba",
+	"down":                     "abajo",
+	"prev page":                "página anterior",
+	"next page":                "página siguiente",
+	"prev tab":                 "pestaña anterior",
+	"next tab":                 "pestaña siguiente",
+	"mark":                     "marcar",
+	"filter":                   "filtrar",
+	"sort":                     "ordenar",
+	"new":                      "nuevo",
+	"view":                     "ver",
+	"copy url":                 "copiar url",
+	"qr code":                  "código QR",
+	"copy share link":          "copiar enlace para compartir",
+	"edit":                     "editar",
+	"delete":                   "borrar",
+	"publish":                  "publicar",
+	"unpublish":                "despublicar",
+	"publish/unpublish":        "publicar/despublicar",
+	"undo":                     "deshacer",
+	"restore":                  "restaurar",
+	"confirm":                  "confirmar",
+	"help":                     "ayuda",
+	"sort by publish date":     "ordenar por fecha de publicación",
+	"sort by last edited":      "ordenar por última edición",
+	"sort by title":            "ordenar por título",
+	"sort by views":            "ordenar por visitas",
+
+	// TUI menu
+	"Manage posts":      "Publicaciones",
+	"Manage keys":       "Claves",
+	"Read":              "Leer",
+	"Stats":             "Estadísticas",
+	"Spellcheck report": "Ortografía",
+	"Housekeeping":      "Mantenimiento",
+	"Invites":           "Invitaciones",
+	"Your data":         "Tus datos",
+	"Sign in requests":  "Solicitudes de acceso",
+	"Settings":          "Ajustes",
+	"Exit":              "Salir",
+	"switch blog":       "cambiar de blog",
+
+	// TUI settings
+	"Username":                    "Usuario",
+	"Display name":                "Nombre",
+	"Bio":                         "Bio",
+	"Blog layout":                 "Diseño del blog",
+	"Timezone":                    "Zona horaria",
+	"Posts per page":              "Publicaciones por página",
+	"Keys per page":               "Claves por página",
+	"Theme":                       "Tema",
+	"Key bindings":                "Atajos de teclado",
+	"Duplicate uploads":           "Subidas duplicadas",
+	"(same text as another post)": "(mismo texto que otra publicación)",
+	"Reply by email":              "Responder por correo",
+	"Show in discovery":           "Mostrar en descubrir",
+	"yes":                         "sí",
+	"no":                          "no",
+	"(listed on /read)":           "(aparece en /read)",
+	"(only at your URL)":          "(solo en tu URL)",
+	"Language":                    "Idioma",
+	"Usage":                       "Uso",
+	"(none set)":                  "(sin definir)",
+	"(fits the window)":           "(se ajusta a la ventana)",
+	"auto":                        "auto",
+	"Loading settings...":         "Cargando ajustes...",
+	"Your blog header is updated": "La cabecera de tu blog está actualizada",
+	"Profile links":               "Enlaces de perfil",
+	"(✓ links back)":              "(✓ enlaza de vuelta)",
+	"Your username is now %s":     "Tu usuario ahora es %s",
+	"Keys":                        "Claves",
+	"Loading keys...":             "Cargando claves...",
+	"Paste a public key:":         "Pega una clave pública:",
+	"Remove this key?":            "¿Quitar esta clave?",
+	"(this session)":              "(esta sesión)",
+	"Checking that your links point back to your blog":  "Comprobando que tus enlaces apuntan a tu blog",
+	"You're signed in with this key, remove it anyway?": "Has entrado con esta clave, ¿quitarla de todos modos?",
+
+	// Web pages
+	"published with":             "publicado con",
+	"Built and maintained by":    "Creado y mantenido por",
+	"home":                       "inicio",
+	"spec":                       "especificación",
+	"ops":                        "operaciones",
+	"source":                     "código",
+	"rss":                        "rss",
+	"links back to this blog":    "enlaza de vuelta a este blog",
+	"%s's blog":                  "blog de %s",
+	"on":                         "en",
+	"by":                         "por",
+	"older":                      "anterior",
+	"newer":                      "siguiente",
+	"Related":                    "Relacionadas",
+	"Changelog":                  "Cambios",
+	"report this post":           "denunciar esta publicación",
+	"short link":                 "enlace corto",
+	"calendar":                   "calendario",
+	"Like":                       "Me gusta",
+	"Thanks for the like!":       "¡Gracias por el me gusta!",
+	"everything else":            "todo lo demás",
+	"back to lists.sh":           "volver a lists.sh",
+	"discover lists":             "descubre listas",
+	"discover interesting lists": "descubre listas interesantes",
+	"prev":                       "anterior",
+	"newest":                     "recientes",
+	"trending":                   "en tendencia",
+	"popular":                    "populares",
+	"lists tagged %s":            "listas con la etiqueta %s",
+	"%d item":                    "%d elemento",
+	"%d items":                   "%d elementos",
+	"%d min read":                "%d min de lectura",
+	"updated today":              "actualizado hoy",
+	"updated 1 day ago":          "actualizado hace 1 día",
+	"updated %d days ago":        "actualizado hace %d días",
+	"Not public, only shared with some readers.": "No es pública, solo se comparte con algunos lectores.",
+
+	// Web errors
+	"There's nothing here, the page may have moved or never existed.": "Aquí no hay nada, puede que la página se haya movido o que nunca existiera.",
+	"Something went wrong on our end, try again in a bit.":            "Algo salió mal por nuestra parte, inténtalo de nuevo en un rato.",
+	"This blog doesn't exist.":                                        "Este blog no existe.",
+	"Post not found":                                                  "Publicación no encontrada",
+	"That's a lot of likes, try again in a bit.":                      "Son muchos me gusta, inténtalo de nuevo en un rato.",
+	"Nobody has published a post with this tag yet.":                  "Nadie ha publicado nada con esta etiqueta todavía.",
+	"This post isn't public. If it was shared with you, run ssh lists.sh login %s and open the link it prints.": "Esta publicación no es pública. Si la compartieron contigo, ejecuta ssh lists.sh login %s y abre el enlace que muestra.",
+	"This sign in link has expired or was already used, sign in again for a new one.":                           "Este enlace para iniciar sesión caducó o ya se usó, vuelve a iniciar sesión para obtener otro.",
+	"Signing in by email isn't set up here, run ssh lists.sh login instead.":                                    "Aquí no se puede iniciar sesión por correo, ejecuta ssh lists.sh login.",
+	"That's a lot of sign in emails, try again in a bit.":                                                       "Son muchos correos para iniciar sesión, inténtalo de nuevo en un rato.",
+	"Only response_type=code is supported.":                                                                     "Solo se admite response_type=code.",
+	"Sign in requests need a code_challenge made with S256.":                                                    "Las solicitudes de acceso necesitan un code_challenge hecho con S256.",
+	"There are already sign ins waiting on this blog, try again in a few minutes.":                              "Ya hay accesos esperando en este blog, inténtalo de nuevo en unos minutos.",
+	"This sign in has expired, start again from the site you were signing in to.":                               "Este acceso caducó, vuelve a empezar desde el sitio en el que entrabas.",
+	"This sign in is already finished, start again from the site you were signing in to.":                       "Este acceso ya terminó, vuelve a empezar desde el sitio en el que entrabas.",
+}
@@ -0,0 +1,101 @@
+// Package i18n translates the text of the TUI and the web pages. Messages
+// are looked up by their English text, so anything missing from a catalog
+// falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locales the text is available in. English is the source language.
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// Default is used when nothing better matches.
+const Default = English
+
+// Locales lists the supported locales in the order settings cycles through
+// them.
+var Locales = []string{English, Spanish}
+
+var names = map[string]string{
+	English: "English",
+	Spanish: "Español",
+}
+
+var catalogs = map[string]map[string]string{
+	Spanish: spanish,
+}
+
+// Name returns the name of the locale in its own language.
+func Name(locale string) string {
+	if name, ok := names[locale]; ok {
+		return name
+	}
+	return locale
+}
+
+// T translates msg, returning it unchanged when the locale has no
+// translation for it.
+func T(locale string, msg string) string {
+	if translated, ok := catalogs[locale][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Tf translates a format string and fills it in.
+func Tf(locale string, format string, args ...interface{}) string {
+	return fmt.Sprintf(T(locale, format), args...)
+}
+
+// Match returns the supported locale for a language tag like es-MX.
+func Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	lang := strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0]
+	for _, locale := range Locales {
+		if lang == locale {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate picks the supported locale the Accept-Language header prefers
+// most, Default when none of them match.
+func Negotiate(header string) string {
+	type choice struct {
+		locale string
+		q      float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		locale, ok := Match(fields[0])
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{locale, q})
+		}
+	}
+	if len(choices) == 0 {
+		return Default
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].locale
+}
@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"es-MX,es;q=0.9,en;q=0.8", Spanish},
+		{"en-US,en;q=0.9,es;q=0.8", English},
+		{"fr-FR,fr;q=0.9,es;q=0.5", Spanish},
+		{"de, es;q=0", English},
+		{"en;q=0.2, es_ES;q=0.7", Spanish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(Negotiate(tt.header), tt.want)
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Run("translates", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(T(Spanish, "cancel"), "cancelar")
+		is.Equal(Tf(Spanish, "%s's blog", "erock"), "blog de erock")
+	})
+
+	t.Run("falls back to english", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(T(Spanish, "not in the catalog"), "not in the catalog")
+		is.Equal(T("xx", "cancel"), "cancel")
+		is.Equal(Tf(English, "%s's blog", "erock"), "erock's blog")
+	})
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for locale, catalog := range catalogs {
+		for msg, translated := range catalog {
+			if got, want := verbs.FindAllString(translated, -1), verbs.FindAllString(msg, -1); len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, want %v", locale, translated, got, want)
+			}
+		}
+	}
+}
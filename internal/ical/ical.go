@@ -0,0 +1,112 @@
+// Package ical writes iCalendar feeds (RFC 5545) that calendar apps can
+// subscribe to.
+package ical
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// RefreshInterval is how often calendar apps are asked to check for changes,
+// the ones that listen poll a subscription no more than this.
+const RefreshInterval = "PT6H"
+
+// Calendar is a feed of a post's events.
+type Calendar struct {
+	ID      string // stable for the feed, it seeds the event UIDs
+	Name    string
+	URL     string // the post, for events without a link of their own
+	Domain  string
+	Updated time.Time
+	Events  []*pkg.Event
+}
+
+// Write writes the calendar with CRLF line endings, folding long lines.
+func Write(w io.Writer, cal *Calendar) error {
+	var b strings.Builder
+	line := func(name string, value string) {
+		b.WriteString(fold(name + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//lists.sh//calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escape(cal.Name))
+	line("REFRESH-INTERVAL;VALUE=DURATION", RefreshInterval)
+	line("X-PUBLISHED-TTL", RefreshInterval)
+
+	stamp := cal.Updated.UTC().Format("20060102T150405Z")
+	seen := map[string]int{}
+	for _, event := range cal.Events {
+		uid := eventUID(cal, event)
+		// The same event written twice keeps both, told apart by order.
+		seen[uid]++
+		if n := seen[uid]; n > 1 {
+			uid = fmt.Sprintf("%d-%s", n, uid)
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", uid)
+		line("DTSTAMP", stamp)
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE", event.Start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			// Floating times, the event is at 18:30 wherever it is.
+			line("DTSTART", event.Start.Format("20060102T150405"))
+			line("DURATION", "PT1H")
+		}
+		line("SUMMARY", escape(event.Summary))
+		url := event.URL
+		if url == "" {
+			url = cal.URL
+		}
+		if url != "" {
+			line("URL", url)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// eventUID names an event by when it is and what it's called, so calendar
+// apps keep track of it across edits to the rest of the list.
+func eventUID(cal *Calendar, event *pkg.Event) string {
+	sum := sha1.Sum([]byte(cal.ID + "\x00" + event.Start.Format(time.RFC3339) + "\x00" + event.Summary))
+	return fmt.Sprintf("%x@%s", sum[:10], cal.Domain)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape makes text safe for a TEXT value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// fold ends a content line, breaking it into lines of at most 75 bytes
+// without splitting a character.
+func fold(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
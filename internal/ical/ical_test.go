@@ -0,0 +1,66 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+func TestFold(t *testing.T) {
+	is := is.New(t)
+	is.Equal(fold("SUMMARY:short"), "SUMMARY:short\r\n")
+
+	folded := fold("SUMMARY:" + strings.Repeat("ñ", 50))
+	for _, line := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		is.True(len(line) <= 75)
+	}
+	is.Equal(strings.ReplaceAll(folded, "\r\n ", ""), "SUMMARY:"+strings.Repeat("ñ", 50)+"\r\n")
+}
+
+func TestEscape(t *testing.T) {
+	is := is.New(t)
+	is.Equal(escape(`tacos, salsa; chips\dip`), `tacos\, salsa\; chips\\dip`)
+}
+
+func TestWrite(t *testing.T) {
+	is := is.New(t)
+	updated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cal := &Calendar{
+		ID:      "post-1",
+		Name:    "Upcoming, talks",
+		URL:     "https://lists.sh/erock/upcoming",
+		Domain:  "lists.sh",
+		Updated: updated,
+		Events: []*pkg.Event{
+			{Start: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), AllDay: true, Summary: "conference talk"},
+			{Start: time.Date(2024, 7, 2, 18, 30, 0, 0, time.UTC), Summary: "dinner", URL: "https://example.com"},
+		},
+	}
+
+	var b strings.Builder
+	is.NoErr(Write(&b, cal))
+	out := b.String()
+	is.True(strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	is.True(strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	is.True(strings.Contains(out, "X-WR-CALNAME:Upcoming\\, talks\r\n"))
+	is.True(strings.Contains(out, "DTSTAMP:20240601T120000Z\r\n"))
+	is.True(strings.Contains(out, "DTSTART;VALUE=DATE:20240701\r\nDTEND;VALUE=DATE:20240702\r\n"))
+	is.True(strings.Contains(out, "DTSTART:20240702T183000\r\nDURATION:PT1H\r\n"))
+	is.True(strings.Contains(out, "URL:https://lists.sh/erock/upcoming\r\n"))
+	is.True(strings.Contains(out, "URL:https://example.com\r\n"))
+	is.Equal(strings.Count(out, "BEGIN:VEVENT"), 2)
+
+	// UIDs stay put when other events change.
+	uid := eventUID(cal, cal.Events[0])
+	cal.Events = cal.Events[:1]
+	is.Equal(eventUID(cal, cal.Events[0]), uid)
+	is.True(strings.HasSuffix(uid, "@lists.sh"))
+
+	cal.Events = append(cal.Events, cal.Events[0])
+	b.Reset()
+	is.NoErr(Write(&b, cal))
+	is.True(strings.Contains(b.String(), "UID:2-"+uid)) // repeated events stay apart
+}
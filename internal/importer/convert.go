@@ -0,0 +1,395 @@
+// Package importer converts posts from other static blog generators (hugo,
+// jekyll, or a plain folder of text files) into the lists.sh format.
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Post is the result of converting a single source file.
+type Post struct {
+	Filename string
+	Text     string
+	Draft    bool
+	// Stripped is the number of shortcodes and template tags removed from
+	// the source because lists.sh has no equivalent for them.
+	Stripped int
+	// Flattened is the number of lines (code blocks, tables, html) that were
+	// kept as plain list items.
+	Flattened int
+}
+
+// FrontMatter holds the subset of hugo/jekyll front matter we understand.
+type FrontMatter struct {
+	Title       string
+	Description string
+	Slug        string
+	Date        *time.Time
+	Draft       bool
+	Tags        []string
+}
+
+var (
+	reJekyllName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+	reShortcode  = regexp.MustCompile(`\{\{[<%].*?[%>]\}\}|\{%.*?%\}|\{\{.*?\}\}`)
+	reImage      = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)\s]+)[^)]*\)$`)
+	reLink       = regexp.MustCompile(`^\[([^\]]*)\]\(([^)\s]+)[^)]*\)$`)
+	reInlineLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	reBullet     = regexp.MustCompile(`^([-*+]|\d+[.)])\s+`)
+	reHeader     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	reTableSep   = regexp.MustCompile(`^\|?[\s:|-]+\|?$`)
+	// reStrong and reEm match emphasis that opens and closes at word
+	// boundaries, so `2**10` and `snake__case` keep their markers.
+	reStrong = regexp.MustCompile(`(^|[^\w*])\*\*([^\s*](?:[^*]*[^\s*])?)\*\*($|[^\w*])`)
+	reEm     = regexp.MustCompile(`(^|[^\w_])__([^\s_](?:[^_]*[^\s_])?)__($|[^\w_])`)
+	reIdent  = regexp.MustCompile(`^\w+$`)
+	reCode   = regexp.MustCompile("`([^`]+)`")
+	// reUnsafe matches the runs of characters a lists.sh filename can't
+	// have, see internal.ValidateFilename.
+	reUnsafe = regexp.MustCompile(`[\s/\\?#%]+`)
+)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// SupportedExt reports whether the importer knows how to convert a file with
+// the given name.
+func SupportedExt(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".txt":
+		return true
+	}
+	return false
+}
+
+// Convert turns the source of a hugo/jekyll markdown file (or a plain lists
+// text file) into a lists.sh post.  The path is used to derive the slug and,
+// for jekyll-style names, the publish date.
+func Convert(path string, source string) *Post {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	slug, date := slugFromPath(path)
+
+	post := &Post{Filename: sanitizeFilename(slug)}
+	if strings.ToLower(filepath.Ext(path)) == ".txt" {
+		post.Text = source
+		return post
+	}
+
+	fm, body := splitFrontMatter(source)
+	if fm.Slug != "" {
+		post.Filename = sanitizeFilename(fm.Slug)
+	}
+	if fm.Date == nil {
+		fm.Date = date
+	}
+	post.Draft = fm.Draft
+
+	var sb strings.Builder
+	if fm.Title != "" {
+		sb.WriteString(fmt.Sprintf("=: title %s\n", fm.Title))
+	}
+	if fm.Description != "" {
+		sb.WriteString(fmt.Sprintf("=: description %s\n", fm.Description))
+	}
+	if fm.Date != nil {
+		sb.WriteString(fmt.Sprintf("=: publish_at %s\n", fm.Date.Format("2006-01-02")))
+	}
+	if len(fm.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("=: tags %s\n", strings.Join(fm.Tags, ", ")))
+	}
+
+	items, stripped, flattened := convertBody(body)
+	post.Stripped = stripped
+	post.Flattened = flattened
+	sb.WriteString(strings.Join(items, "\n"))
+	post.Text = strings.TrimRight(sb.String(), "\n") + "\n"
+
+	return post
+}
+
+func slugFromPath(path string) (string, *time.Time) {
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	// hugo page bundles keep the content in `my-post/index.md`
+	if name == "index" || name == "_index" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+
+	if m := reJekyllName.FindStringSubmatch(name); m != nil {
+		date, err := time.Parse("2006-01-02", m[1])
+		if err == nil {
+			return m[2], &date
+		}
+	}
+
+	return name, nil
+}
+
+// splitFrontMatter separates yaml (`---`) or toml (`+++`) front matter from
+// the body of the document.
+func splitFrontMatter(source string) (*FrontMatter, string) {
+	fm := &FrontMatter{}
+	lines := strings.Split(source, "\n")
+	if len(lines) == 0 {
+		return fm, source
+	}
+
+	delim := strings.TrimSpace(lines[0])
+	if delim != "---" && delim != "+++" {
+		return fm, source
+	}
+
+	sep := ":"
+	if delim == "+++" {
+		sep = "="
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == delim {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return fm, source
+	}
+
+	var listKey string
+	for _, line := range lines[1:end] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// yaml block sequences (`tags:` followed by `- foo` lines)
+		if strings.HasPrefix(trimmed, "- ") && listKey != "" {
+			if listKey == "tags" || listKey == "categories" {
+				fm.Tags = append(fm.Tags, unquote(strings.TrimPrefix(trimmed, "- ")))
+			}
+			continue
+		}
+
+		kv := strings.SplitN(trimmed, sep, 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+		listKey = ""
+		if value == "" {
+			listKey = key
+			continue
+		}
+
+		switch key {
+		case "title":
+			fm.Title = unquote(value)
+		case "description", "summary", "excerpt":
+			fm.Description = unquote(value)
+		case "slug", "permalink":
+			slug := strings.Trim(unquote(value), "/")
+			// Permalink patterns like /:year/:title/ aren't a name.
+			if slug != "" && !strings.Contains(slug, ":") {
+				fm.Slug = filepath.Base(slug)
+			}
+		case "date", "publishdate":
+			fm.Date = parseDate(unquote(value))
+		case "draft", "published":
+			b := unquote(value) == "true"
+			if key == "published" {
+				b = !b
+			}
+			fm.Draft = b
+		case "tags", "categories":
+			fm.Tags = append(fm.Tags, parseList(value)...)
+		}
+	}
+
+	return fm, strings.Join(lines[end+1:], "\n")
+}
+
+func parseDate(value string) *time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func parseList(value string) []string {
+	value = strings.Trim(value, "[]")
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		v = unquote(strings.TrimSpace(v))
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// convertBody maps markdown constructs onto list items.  Hard-wrapped
+// paragraphs are joined since every line in lists.sh is its own item.
+func convertBody(body string) ([]string, int, int) {
+	var (
+		items     []string
+		paragraph []string
+		stripped  int
+		flattened int
+		inCode    bool
+	)
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			items = append(items, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			if trimmed != "" {
+				items = append(items, trimmed)
+				flattened++
+			}
+			continue
+		}
+
+		if n := len(reShortcode.FindAllString(trimmed, -1)); n > 0 {
+			stripped += n
+			trimmed = strings.TrimSpace(reShortcode.ReplaceAllString(trimmed, ""))
+			if trimmed == "" {
+				continue
+			}
+		}
+
+		if trimmed == "" {
+			flush()
+			items = append(items, "")
+			continue
+		}
+
+		if trimmed == "---" || trimmed == "***" || trimmed == "___" {
+			flush()
+			items = append(items, "---")
+			continue
+		}
+
+		if reTableSep.MatchString(trimmed) && strings.Contains(trimmed, "|") {
+			flush()
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "<") || strings.HasPrefix(trimmed, "|") {
+			flush()
+			items = append(items, trimmed)
+			flattened++
+			continue
+		}
+
+		if m := reHeader.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			if len(m[1]) == 1 {
+				items = append(items, "# "+inline(m[2]))
+			} else {
+				items = append(items, "## "+inline(m[2]))
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			flush()
+			items = append(items, "> "+inline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+			continue
+		}
+
+		isBullet := reBullet.MatchString(trimmed)
+		text := reBullet.ReplaceAllString(trimmed, "")
+
+		if m := reImage.FindStringSubmatch(text); m != nil {
+			flush()
+			items = append(items, strings.TrimSpace(fmt.Sprintf("=< %s %s", m[2], m[1])))
+			continue
+		}
+
+		if m := reLink.FindStringSubmatch(text); m != nil {
+			flush()
+			items = append(items, strings.TrimSpace(fmt.Sprintf("=> %s %s", m[2], inline(m[1]))))
+			continue
+		}
+
+		if isBullet {
+			flush()
+			items = append(items, inline(text))
+			continue
+		}
+
+		paragraph = append(paragraph, inline(text))
+	}
+	flush()
+
+	// collapse the runs of blank items left over from markdown spacing
+	var out []string
+	for _, item := range items {
+		if item == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, item)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+
+	return out, stripped, flattened
+}
+
+// inline strips inline markdown that has no lists.sh equivalent.
+func inline(text string) string {
+	text = reInlineLink.ReplaceAllString(text, "$1 ($2)")
+	text = reStrong.ReplaceAllString(text, "$1$2$3")
+	text = reEm.ReplaceAllStringFunc(text, func(m string) string {
+		sub := reEm.FindStringSubmatch(m)
+		// __init__ and friends are names, not emphasis.
+		if reIdent.MatchString(sub[2]) {
+			return m
+		}
+		return sub[1] + sub[2] + sub[3]
+	})
+	text = reCode.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(text)
+}
+
+// sanitizeFilename turns a slug from a path or front matter into a lists.sh
+// filename, dashes stand in for spaces and slashes.
+func sanitizeFilename(name string) string {
+	name = reUnsafe.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-.")
+}
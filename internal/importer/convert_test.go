@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestConvert(t *testing.T) {
+	t.Run("hugo yaml front matter", func(t *testing.T) {
+		is := is.New(t)
+		src := `---
+title: "My first post"
+description: a short one
+date: 2021-03-04T10:00:00Z
+tags: [go, lists]
+---
+
+# Intro
+This is a paragraph
+that was hard wrapped.
+
+{{< youtube abc123 >}}
+
+- [lists.sh](https://lists.sh)
+- ![a cat](https://cats.com/cat.png)
+- **bold** item
+`
+		post := Convert("content/posts/my-first-post.md", src)
+		is.Equal(post.Filename, "my-first-post")
+		is.Equal(post.Stripped, 1)
+		is.Equal(post.Text, `=: title My first post
+=: description a short one
+=: publish_at 2021-03-04
+=: tags go, lists
+# Intro
+This is a paragraph that was hard wrapped.
+
+=> https://lists.sh lists.sh
+=< https://cats.com/cat.png a cat
+bold item
+`)
+	})
+
+	t.Run("hugo toml front matter and page bundle", func(t *testing.T) {
+		is := is.New(t)
+		src := `+++
+title = "Bundle"
+draft = true
++++
+hello`
+		post := Convert("posts/bundle-post/index.md", src)
+		is.Equal(post.Filename, "bundle-post")
+		is.True(post.Draft)
+		is.Equal(post.Text, "=: title Bundle\nhello\n")
+	})
+
+	t.Run("jekyll filename date and slug override", func(t *testing.T) {
+		is := is.New(t)
+		src := `---
+layout: post
+slug: renamed
+---
+{% include header.html %}
+content`
+		post := Convert("_posts/2020-01-02-original.markdown", src)
+		is.Equal(post.Filename, "renamed")
+		is.Equal(post.Stripped, 1)
+		is.Equal(post.Text, "=: publish_at 2020-01-02\ncontent\n")
+	})
+
+	t.Run("horizontal rules become dividers", func(t *testing.T) {
+		is := is.New(t)
+		post := Convert("posts/rules.md", "tacos\n\n***\n\nhorchata")
+		is.Equal(post.Text, "tacos\n\n---\n\nhorchata\n")
+	})
+
+	t.Run("plain text is passed through", func(t *testing.T) {
+		is := is.New(t)
+		post := Convert("blog/days.txt", "Monday\nTuesday")
+		is.Equal(post.Filename, "days")
+		is.Equal(post.Text, "Monday\nTuesday")
+	})
+
+	t.Run("emphasis only at word boundaries", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(inline("**bold** and __also this__"), "bold and also this")
+		is.Equal(inline("call __init__ and 2**10 or snake__case"), "call __init__ and 2**10 or snake__case")
+		is.Equal(inline("run `make`, (**now**)"), "run make, (now)")
+	})
+
+	t.Run("yaml tag lists", func(t *testing.T) {
+		is := is.New(t)
+		post := Convert("posts/tagged.md", "---\ntags:\n  - go\n  - web dev\n---\nhi")
+		is.Equal(post.Text, "=: tags go, web dev\nhi\n")
+	})
+
+	t.Run("filenames are made safe", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Convert("posts/My Trip.md", "hi").Filename, "My-Trip")
+		is.Equal(Convert("index.md", "hi").Filename, "")
+		post := Convert("_posts/2020-01-02-trip.md", "---\npermalink: /:year/:title/\n---\nhi")
+		is.Equal(post.Filename, "trip")
+		post = Convert("posts/a.md", "---\nslug: \"what? now\"\n---\nhi")
+		is.Equal(post.Filename, "what-now")
+	})
+}
@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/scp"
+)
+
+// maxFileSize is the largest single file we are willing to import.
+const maxFileSize = 1024 * 1024
+
+// Summary tallies the result of an import run.
+type Summary struct {
+	Imported int
+	Skipped  int
+	Failed   int
+}
+
+// Middleware handles `ssh lists.sh import`, which reads a tar archive
+// (optionally gzipped) from stdin and converts every markdown or text file
+// inside it into a post:
+//
+//	tar -cz -C ~/hugo/content/posts . | ssh lists.sh import
+func Middleware(wh scp.CopyFromClientHandler, dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if len(cmd) == 0 || cmd[0] != "import" {
+				sh(s)
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			summary, err := importArchive(s, wh, user, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			_, _ = fmt.Fprintf(
+				s,
+				"\nimported %d, skipped %d, failed %d\n",
+				summary.Imported, summary.Skipped, summary.Failed,
+			)
+			sh(s)
+		}
+	}
+}
+
+func importArchive(s ssh.Session, wh scp.CopyFromClientHandler, user *db.User, dbpool db.DB) (*Summary, error) {
+	logger := internal.SessionLogger(s)
+	summary := &Summary{}
+
+	r, err := archiveReader(s)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tar.NewReader(r)
+	// One buffer for every file in the archive.
+	var buf bytes.Buffer
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read archive (did you pipe a tar file?): %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if !SupportedExt(name) {
+			summary.Skipped++
+			_, _ = fmt.Fprintf(s, "skipped %s: unsupported file type\n", name)
+			continue
+		}
+
+		if hdr.Size > maxFileSize {
+			summary.Skipped++
+			_, _ = fmt.Fprintf(s, "skipped %s: file is larger than %d bytes\n", name, maxFileSize)
+			continue
+		}
+
+		buf.Reset()
+		_, err = buf.ReadFrom(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return summary, err
+		}
+
+		post := Convert(name, buf.String())
+		if post.Draft {
+			summary.Skipped++
+			_, _ = fmt.Fprintf(s, "skipped %s: draft\n", name)
+			continue
+		}
+
+		if err := internal.ValidateFilename(post.Filename); err != nil {
+			summary.Failed++
+			_, _ = fmt.Fprintf(s, "failed %s: can't name the post %q: %v\n", name, post.Filename, err)
+			continue
+		}
+
+		fname := post.Filename + ".txt"
+		err = wh.Write(s, &scp.FileEntry{
+			Name:     fname,
+			Filepath: fname,
+			Size:     int64(len(post.Text)),
+			Reader:   strings.NewReader(post.Text),
+		}, user, dbpool)
+		if err != nil {
+			summary.Failed++
+			logger.Infof("failed to import file: %s %q: %v", user.Name, name, err)
+			_, _ = fmt.Fprintf(s, "failed %s: %v\n", name, err)
+			continue
+		}
+
+		summary.Imported++
+		notes := ""
+		if post.Stripped > 0 {
+			notes += fmt.Sprintf(" (stripped %d shortcodes)", post.Stripped)
+		}
+		if post.Flattened > 0 {
+			notes += fmt.Sprintf(" (flattened %d lines)", post.Flattened)
+		}
+		_, _ = fmt.Fprintf(s, "imported %s -> /%s/%s%s\n", name, user.Name, post.Filename, notes)
+	}
+
+	return summary, nil
+}
+
+// archiveReader sniffs the gzip magic bytes so both `tar -c` and `tar -cz`
+// can be piped into the import command.
+func archiveReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("nothing to import, pipe a tar archive to this command")
+	}
+
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return gzip.NewReader(br)
+	}
+
+	return br, nil
+}
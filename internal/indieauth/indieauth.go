@@ -0,0 +1,249 @@
+// Package indieauth lets people sign in to IndieWeb sites with their blog's
+// address.  The blog page points at the authorization endpoint, a site
+// sends the browser there, and the browser waits on a page showing a short
+// challenge until the user opens the TUI and approves the request that
+// shows the same challenge.  The browser then goes back to the site with a
+// code, which the site swaps for the blog's address at the same endpoint.
+//
+// Only signing in is supported, there are no access tokens and so no token
+// endpoint.  Authorization requests must use PKCE with S256.
+package indieauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+const (
+	// RequestTTL is how long the user has to approve a sign in.
+	RequestTTL = 10 * time.Minute
+	// CodeTTL is how long a site has to redeem the code it's sent back with.
+	CodeTTL = 2 * time.Minute
+)
+
+// MaxPending is how many sign ins can wait on a user at once, the
+// authorization endpoint is open to anyone.
+const MaxPending = 5
+
+const (
+	// MetadataPath is where the server metadata lives.
+	MetadataPath = "/.well-known/oauth-authorization-server"
+	// AuthPath is the authorization endpoint.
+	AuthPath = "/indieauth/auth"
+	// RequestsPath is where a browser waits on a sign in.
+	RequestsPath = "/indieauth/requests"
+)
+
+// ScopeProfile asks for the name, address and picture of the blog as well.
+const ScopeProfile = "profile"
+
+// challengeAlphabet leaves out letters and digits that look alike.
+const challengeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Metadata describes the server to sites, see
+// https://indieauth.spec.indieweb.org/#indieauth-server-metadata
+type Metadata struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	ResponseTypes            []string `json:"response_types_supported"`
+	GrantTypes               []string `json:"grant_types_supported"`
+	Scopes                   []string `json:"scopes_supported"`
+	CodeChallengeMethods     []string `json:"code_challenge_methods_supported"`
+	AuthorizationResponseIss bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+// Profile is what a site learns about the user when it redeems a code.
+type Profile struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Photo string `json:"photo"`
+}
+
+// Response answers a redeemed code.  Profile is only filled in when the
+// profile scope was approved.
+type Response struct {
+	Me      string   `json:"me"`
+	Profile *Profile `json:"profile,omitempty"`
+}
+
+// Issuer identifies this server in metadata and redirects.
+func Issuer(cfg *config.Config) string {
+	return cfg.URL()
+}
+
+// MetadataURL is where blogs point sites for the server metadata.
+func MetadataURL(cfg *config.Config) string {
+	return cfg.URL(strings.TrimPrefix(MetadataPath, "/"))
+}
+
+// AuthURL is the authorization endpoint.
+func AuthURL(cfg *config.Config) string {
+	return cfg.URL(strings.TrimPrefix(AuthPath, "/"))
+}
+
+// NewMetadata returns the server metadata.
+func NewMetadata(cfg *config.Config) *Metadata {
+	return &Metadata{
+		Issuer:                   Issuer(cfg),
+		AuthorizationEndpoint:    AuthURL(cfg),
+		ResponseTypes:            []string{"code"},
+		GrantTypes:               []string{"authorization_code"},
+		Scopes:                   []string{ScopeProfile},
+		CodeChallengeMethods:     []string{"S256"},
+		AuthorizationResponseIss: true,
+	}
+}
+
+// Username finds the blog me points at, something like
+// https://lists.sh/erock.  The scheme and a trailing slash can be left off,
+// people type it in.
+func Username(me string, cfg *config.Config) (string, error) {
+	me = strings.TrimSpace(me)
+	if !strings.Contains(me, "://") {
+		me = "https://" + me
+	}
+	u, err := url.Parse(me)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("%q isn't a blog address", me)
+	}
+	name := strings.Trim(u.Path, "/")
+	if !strings.EqualFold(u.Host, cfg.Domain) || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("%q isn't a %s blog, it looks like %s", me, cfg.Domain, cfg.URL("you"))
+	}
+	return name, nil
+}
+
+// ValidateClient checks the site's client_id and that redirectURI belongs
+// to it.  Sites that send people somewhere else can't be verified without
+// fetching them, so they're turned away.
+func ValidateClient(clientID string, redirectURI string) error {
+	client, err := parseClientURL(clientID)
+	if err != nil {
+		return fmt.Errorf("client_id %w", err)
+	}
+	redirect, err := parseClientURL(redirectURI)
+	if err != nil {
+		return fmt.Errorf("redirect_uri %w", err)
+	}
+	if client.Scheme != redirect.Scheme || !strings.EqualFold(client.Host, redirect.Host) {
+		return fmt.Errorf("redirect_uri must be on the same site as client_id")
+	}
+	return nil
+}
+
+func parseClientURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || len(s) > 512 {
+		return nil, fmt.Errorf("isn't a valid address")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("must be an http or https address")
+	}
+	if u.Host == "" || u.User != nil || u.Fragment != "" {
+		return nil, fmt.Errorf("must have a host and no user or fragment")
+	}
+	if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+		return nil, fmt.Errorf("must use https")
+	}
+	return u, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Scope keeps the scopes this server supports out of those asked for.
+func Scope(requested string) string {
+	for _, s := range strings.Fields(requested) {
+		if s == ScopeProfile {
+			return ScopeProfile
+		}
+	}
+	return ""
+}
+
+// VerifyChallenge reports whether verifier is the PKCE code verifier the
+// S256 challenge was made from.
+func VerifyChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+// NewChallenge returns the code shown both in the browser and in the TUI,
+// like K7QX-M2PA.
+func NewChallenge() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = challengeAlphabet[int(b[i])%len(challengeAlphabet)]
+	}
+	return string(b[:4]) + "-" + string(b[4:]), nil
+}
+
+// RedirectURL is where the browser goes back to, the site's redirect_uri
+// with params added to any query it already had.
+func RedirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Redeem checks that the site swapping a code is the one it was issued to
+// and holds the PKCE verifier, and answers with the blog it signs in as.
+func Redeem(req *db.AuthRequest, user *db.User, clientID string, redirectURI string, verifier string, cfg *config.Config) (*Response, error) {
+	if req.ClientID != clientID || req.RedirectURI != redirectURI {
+		return nil, fmt.Errorf("the code was issued to another client_id or redirect_uri")
+	}
+	if !VerifyChallenge(verifier, req.CodeChallenge) {
+		return nil, fmt.Errorf("code_verifier doesn't match the code_challenge")
+	}
+	res := &Response{Me: cfg.URL(user.Name)}
+	if req.Scope == ScopeProfile {
+		name := user.DisplayName
+		if name == "" {
+			name = user.Name
+		}
+		res.Profile = &Profile{
+			Name:  name,
+			URL:   cfg.URL(user.Name),
+			Photo: cfg.URL(user.Name, "avatar.png"),
+		}
+	}
+	return res, nil
+}
+
+// ClientName is how the TUI and the waiting page name a site.
+func ClientName(clientID string) string {
+	u, err := url.Parse(clientID)
+	if err != nil || u.Host == "" {
+		return clientID
+	}
+	return u.Host
+}
@@ -0,0 +1,110 @@
+package indieauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+var cfg = &config.Config{Domain: "lists.sh"}
+
+func TestUsername(t *testing.T) {
+	is := is.New(t)
+	for _, me := range []string{"https://lists.sh/erock", "https://lists.sh/erock/", "lists.sh/erock", "http://Lists.sh/erock"} {
+		name, err := Username(me, cfg)
+		is.NoErr(err)
+		is.Equal(name, "erock")
+	}
+	for _, me := range []string{"", "https://lists.sh/", "https://lists.sh/erock/tacos", "https://erock.io", "ftp://lists.sh/erock"} {
+		_, err := Username(me, cfg)
+		is.True(err != nil)
+	}
+}
+
+func TestValidateClient(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(ValidateClient("https://app.example/", "https://app.example/callback"))
+	is.NoErr(ValidateClient("http://localhost:8080/", "http://localhost:8080/callback"))
+	is.True(ValidateClient("https://app.example/", "https://evil.example/callback") != nil)
+	is.True(ValidateClient("https://app.example/", "http://app.example/callback") != nil)
+	is.True(ValidateClient("http://app.example/", "http://app.example/callback") != nil)
+	is.True(ValidateClient("https://app.example/#x", "https://app.example/callback") != nil)
+	is.True(ValidateClient("javascript:alert(1)", "https://app.example/callback") != nil)
+}
+
+func TestScope(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Scope("profile email create"), ScopeProfile)
+	is.Equal(Scope("create update"), "")
+	is.Equal(Scope(""), "")
+}
+
+func TestNewChallenge(t *testing.T) {
+	is := is.New(t)
+	c, err := NewChallenge()
+	is.NoErr(err)
+	is.Equal(len(c), 9)
+	is.Equal(c[4], byte('-'))
+	is.True(!strings.ContainsAny(c, "01IO"))
+}
+
+func TestRedirectURL(t *testing.T) {
+	is := is.New(t)
+	got := RedirectURL("https://app.example/callback?next=%2Fhome", url.Values{"code": {"abc"}, "state": {"x y"}})
+	u, err := url.Parse(got)
+	is.NoErr(err)
+	is.Equal(u.Query().Get("next"), "/home")
+	is.Equal(u.Query().Get("code"), "abc")
+	is.Equal(u.Query().Get("state"), "x y")
+}
+
+func TestRedeem(t *testing.T) {
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	req := &db.AuthRequest{
+		ClientID:      "https://app.example/",
+		RedirectURI:   "https://app.example/callback",
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}
+	user := &db.User{ID: "1", Name: "erock"}
+
+	t.Run("signs in as the blog", func(t *testing.T) {
+		is := is.New(t)
+		res, err := Redeem(req, user, req.ClientID, req.RedirectURI, verifier, cfg)
+		is.NoErr(err)
+		is.Equal(res.Me, "https://lists.sh/erock")
+		is.Equal(res.Profile, nil)
+	})
+
+	t.Run("profile when approved", func(t *testing.T) {
+		is := is.New(t)
+		withProfile := *req
+		withProfile.Scope = ScopeProfile
+		res, err := Redeem(&withProfile, &db.User{ID: "1", Name: "erock", DisplayName: "Eric"}, req.ClientID, req.RedirectURI, verifier, cfg)
+		is.NoErr(err)
+		is.Equal(res.Profile.Name, "Eric")
+		is.Equal(res.Profile.Photo, "https://lists.sh/erock/avatar.png")
+	})
+
+	t.Run("only the client it was issued to", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Redeem(req, user, "https://evil.example/", req.RedirectURI, verifier, cfg)
+		is.True(err != nil)
+		_, err = Redeem(req, user, req.ClientID, "https://app.example/other", verifier, cfg)
+		is.True(err != nil)
+	})
+
+	t.Run("needs the verifier", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Redeem(req, user, req.ClientID, req.RedirectURI, strings.Repeat("w", 43), cfg)
+		is.True(err != nil)
+		_, err = Redeem(req, user, req.ClientID, req.RedirectURI, "", cfg)
+		is.True(err != nil)
+	})
+}
@@ -0,0 +1,158 @@
+// Package jobs runs background work queued in the jobs table.  Any server
+// can Enqueue a job, the web server's Queue runs them with a few workers,
+// retrying failures with a growing delay until MaxAttempts, after which the
+// job is dead and waits for `lists-admin job-retry`.
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxAttempts is how many times a job runs before it's dead.
+	MaxAttempts = 5
+	// pollInterval is how often workers look for due jobs.
+	pollInterval = 2 * time.Second
+	// lease is how long a job can run before it's handed to another
+	// worker, in case the one running it died.
+	lease = 10 * time.Minute
+)
+
+var jobsTotal = metrics.NewCounter(
+	"lists_jobs_total",
+	"Background jobs run by kind and outcome (ok, retried, dead).",
+	"kind", "result",
+)
+
+// Handler does a job with its payload, an error runs it again later.
+type Handler func(payload []byte) error
+
+// Enqueue adds a job of the kind to run as soon as a worker is free, the
+// payload is saved as JSON.
+func Enqueue(dbpool db.DB, kind string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return dbpool.EnqueueJob(kind, string(b), MaxAttempts, time.Now())
+}
+
+// Queue runs jobs with the handlers registered for their kind.
+type Queue struct {
+	dbpool   db.DB
+	logger   *zap.SugaredLogger
+	workers  int
+	handlers map[string]Handler
+}
+
+// NewQueue runs up to workers jobs at once.
+func NewQueue(dbpool db.DB, logger *zap.SugaredLogger, workers int) *Queue {
+	return &Queue{
+		dbpool:   dbpool,
+		logger:   logger,
+		workers:  workers,
+		handlers: map[string]Handler{},
+	}
+}
+
+// Handle sets the handler for a kind of job.
+func (q *Queue) Handle(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// Run works through due jobs until done is closed.
+func (q *Queue) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		// Keep going while there's a backlog instead of waiting a tick.
+		for q.work(time.Now()) == q.workers {
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// work runs a batch of due jobs and returns how many it claimed.
+func (q *Queue) work(now time.Time) int {
+	batch, err := q.dbpool.ClaimJobs(q.workers, now, lease)
+	if err != nil {
+		q.logger.Errorf("claiming jobs failed: %v", err)
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range batch {
+		wg.Add(1)
+		go func(job *db.Job) {
+			defer wg.Done()
+			q.run(job, now)
+		}(job)
+	}
+	wg.Wait()
+	return len(batch)
+}
+
+func (q *Queue) run(job *db.Job, now time.Time) {
+	err := q.call(job)
+	switch {
+	case err == nil:
+		jobsTotal.Inc(job.Kind, "ok")
+		err = q.dbpool.FinishJob(job.ID)
+	case job.Attempts >= job.MaxAttempts:
+		jobsTotal.Inc(job.Kind, "dead")
+		q.logger.Errorw("job is dead", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		err = q.dbpool.BuryJob(job.ID, err.Error())
+	default:
+		jobsTotal.Inc(job.Kind, "retried")
+		q.logger.Infow("job failed, retrying", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		err = q.dbpool.RetryJob(job.ID, now.Add(Backoff(job.Attempts)), err.Error())
+	}
+	if err != nil {
+		q.logger.Error(err)
+	}
+}
+
+// call runs the job's handler, a panic fails the job instead of the server.
+func (q *Queue) call(job *db.Job) (err error) {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for %q jobs", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler([]byte(job.Payload))
+}
+
+// Backoff is how long to wait before running a job again after its
+// attempts so far failed: 30s, 1m, 2m and so on, at most 6 hours.
+func Backoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts && wait < 6*time.Hour; i++ {
+		wait *= 2
+	}
+	if wait > 6*time.Hour {
+		wait = 6 * time.Hour
+	}
+	return wait
+}
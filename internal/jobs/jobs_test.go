@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"go.uber.org/zap"
+)
+
+// queueDB hands out its jobs once and remembers what became of them.
+type queueDB struct {
+	db.DB
+	mu       sync.Mutex
+	jobs     []*db.Job
+	finished []string
+	retried  map[string]time.Time
+	buried   map[string]string
+}
+
+func (d *queueDB) ClaimJobs(limit int, now time.Time, lease time.Duration) ([]*db.Job, error) {
+	if limit > len(d.jobs) {
+		limit = len(d.jobs)
+	}
+	claimed := d.jobs[:limit]
+	d.jobs = d.jobs[limit:]
+	for _, job := range claimed {
+		job.Attempts++
+	}
+	return claimed, nil
+}
+
+func (d *queueDB) FinishJob(jobID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finished = append(d.finished, jobID)
+	return nil
+}
+
+func (d *queueDB) RetryJob(jobID string, runAt time.Time, jobErr string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retried[jobID] = runAt
+	return nil
+}
+
+func (d *queueDB) BuryJob(jobID string, jobErr string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.buried[jobID] = jobErr
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	is := is.New(t)
+	dbpool := &queueDB{
+		jobs: []*db.Job{
+			{ID: "ok", Kind: "echo", Payload: `"hi"`, MaxAttempts: 5},
+			{ID: "flaky", Kind: "fail", MaxAttempts: 5},
+			{ID: "done-for", Kind: "fail", Attempts: 4, MaxAttempts: 5},
+			{ID: "unknown", Kind: "nope", Attempts: 4, MaxAttempts: 5},
+			{ID: "panics", Kind: "panic", MaxAttempts: 5},
+		},
+		retried: map[string]time.Time{},
+		buried:  map[string]string{},
+	}
+	q := NewQueue(dbpool, zap.NewNop().Sugar(), 10)
+	var got string
+	q.Handle("echo", func(payload []byte) error {
+		got = string(payload)
+		return nil
+	})
+	q.Handle("fail", func(payload []byte) error {
+		return errors.New("down")
+	})
+	q.Handle("panic", func(payload []byte) error {
+		panic("oops")
+	})
+
+	now := time.Now()
+	is.Equal(q.work(now), 5)
+	is.Equal(got, `"hi"`)
+	is.Equal(dbpool.finished, []string{"ok"})
+	is.Equal(dbpool.retried["flaky"], now.Add(30*time.Second))
+	is.Equal(dbpool.buried["done-for"], "down")
+	is.Equal(dbpool.buried["unknown"], `no handler for "nope" jobs`)
+	is.True(!dbpool.retried["panics"].IsZero())
+	is.Equal(q.work(now), 0)
+}
+
+func TestBackoff(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Backoff(1), 30*time.Second)
+	is.Equal(Backoff(2), time.Minute)
+	is.Equal(Backoff(4), 4*time.Minute)
+	is.Equal(Backoff(50), 6*time.Hour)
+}
@@ -0,0 +1,95 @@
+// Package limit caps how much the ssh server does at once.  A Limiter hands
+// out a fixed number of slots; whoever comes when they're all taken waits in
+// line for up to a timeout and is turned away after it, so a burst of
+// connections slows down instead of running the server out of memory and
+// database connections.
+package limit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/metrics"
+)
+
+// ErrBusy is returned when no slot freed up in time.
+var ErrBusy = errors.New("the server is busy, try again in a minute")
+
+var (
+	waiting = metrics.NewGauge(
+		"lists_limit_waiting",
+		"Sessions waiting for a slot by limit (sessions, transfers).",
+		"limit",
+	)
+	waitSeconds = metrics.NewHistogram(
+		"lists_limit_wait_seconds",
+		"How long sessions waited for a slot by limit (sessions, transfers).",
+		[]float64{0.01, 0.1, 0.5, 1, 5, 10, 30},
+		"limit",
+	)
+	rejected = metrics.NewCounter(
+		"lists_limit_rejected_total",
+		"Sessions turned away after waiting too long by limit (sessions, transfers).",
+		"limit",
+	)
+)
+
+// Limiter lets a fixed number of callers in at once.
+type Limiter struct {
+	name  string
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// New lets max callers in at once, each waiting up to wait for a slot.  The
+// name labels its metrics.  A max of zero doesn't limit anything.
+func New(name string, max int, wait time.Duration) *Limiter {
+	l := &Limiter{name: name, wait: wait}
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+	return l
+}
+
+// Acquire waits for a slot and returns the function that gives it back.  It
+// gives up with ErrBusy after the limiter's wait, or with the context's
+// error when the caller leaves first.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	// Skip the metrics when there's a slot free right away.
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	start := time.Now()
+	waiting.Inc(l.name)
+	defer waiting.Dec(l.name)
+	defer waitSeconds.Since(start, l.name)
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timer.C:
+		rejected.Inc(l.name)
+		return nil, ErrBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// InUse is how many slots are taken.
+func (l *Limiter) InUse() int {
+	return len(l.slots)
+}
+
+func (l *Limiter) release() {
+	<-l.slots
+}
@@ -0,0 +1,62 @@
+package limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Run("waits for a slot", func(t *testing.T) {
+		is := is.New(t)
+		l := New("test", 1, time.Second)
+		release, err := l.Acquire(context.Background())
+		is.NoErr(err)
+		is.Equal(l.InUse(), 1)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			release()
+		}()
+		start := time.Now()
+		release, err = l.Acquire(context.Background())
+		is.NoErr(err)
+		is.True(time.Since(start) >= 20*time.Millisecond) // it waited in line
+		release()
+		is.Equal(l.InUse(), 0)
+	})
+
+	t.Run("gives up after the wait", func(t *testing.T) {
+		is := is.New(t)
+		l := New("test", 1, 10*time.Millisecond)
+		_, err := l.Acquire(context.Background())
+		is.NoErr(err)
+
+		_, err = l.Acquire(context.Background())
+		is.Equal(err, ErrBusy)
+	})
+
+	t.Run("stops waiting when the caller leaves", func(t *testing.T) {
+		is := is.New(t)
+		l := New("test", 1, time.Minute)
+		_, err := l.Acquire(context.Background())
+		is.NoErr(err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = l.Acquire(ctx)
+		is.Equal(err, context.Canceled)
+		is.Equal(l.InUse(), 1) // the abandoned wait didn't take a slot
+	})
+
+	t.Run("zero doesn't limit", func(t *testing.T) {
+		is := is.New(t)
+		l := New("test", 0, 0)
+		for i := 0; i < 100; i++ {
+			_, err := l.Acquire(context.Background())
+			is.NoErr(err)
+		}
+	})
+}
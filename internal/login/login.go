@@ -0,0 +1,142 @@
+// Package login signs readers in on the web so they can open the posts
+// shared with them:
+//
+//	ssh lists.sh login          # prints a link that signs a browser in
+//	ssh lists.sh login <url>    # the same, opening url once signed in
+//	ssh lists.sh login off      # signs every browser out
+//
+// The link works once within LinkTTL, the session it starts lasts
+// SessionTTL.
+package login
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+const (
+	// LinkTTL is how long a sign in link can be opened.
+	LinkTTL = 15 * time.Minute
+	// SessionTTL is how long a browser stays signed in.
+	SessionTTL = 30 * 24 * time.Hour
+)
+
+// CookieName is the cookie holding a signed in reader's session token.
+const CookieName = "lists_reader"
+
+const usage = `usage:
+  ssh lists.sh login [url]
+  ssh lists.sh login off`
+
+// IsCommand reports whether cmd is handled by this package.
+func IsCommand(cmd []string) bool {
+	return len(cmd) > 0 && cmd[0] == "login"
+}
+
+// Middleware handles the `ssh lists.sh login` commands.
+func Middleware(dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if !IsCommand(cmd) {
+				sh(s)
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			err = run(s, dbpool, config.Current(), user, cmd[1:])
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+func run(out io.Writer, dbpool db.DB, cfg *config.Config, user *db.User, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	if len(args) == 1 && args[0] == "off" {
+		err := dbpool.RemoveReaderSessions(user.ID)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, "signed out of every browser")
+		return nil
+	}
+
+	next := ""
+	if len(args) == 1 {
+		var err error
+		next, err = NextPath(args[0], cfg.Domain)
+		if err != nil {
+			return err
+		}
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		return err
+	}
+	err = dbpool.InsertReaderLogin(user.ID, token, time.Now().Add(LinkTTL))
+	if err != nil {
+		return err
+	}
+
+	link := cfg.URL("login", token)
+	if next != "" {
+		link += "?next=" + url.QueryEscape(next)
+	}
+	_, _ = fmt.Fprintf(out, "open this link within %d minutes to sign in as %s, it works once:\n%s\n", int(LinkTTL.Minutes()), user.Name, link)
+	return nil
+}
+
+// NewToken makes a random token for a sign in link or a session.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NextPath is where to send a reader once they're signed in, given as a
+// path or a full URL on domain.  Anything pointing off the site is refused
+// so a link can't sign someone in and bounce them elsewhere.
+func NextPath(target string, domain string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("%q isn't a link", target)
+	}
+	if u.Host != "" && !strings.EqualFold(u.Host, domain) {
+		return "", fmt.Errorf("only links on %s can be opened after signing in", domain)
+	}
+	if u.Host == "" && u.Scheme != "" {
+		return "", fmt.Errorf("%q isn't a link", target)
+	}
+	// Browsers read /\ like //, the start of another host.
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") || strings.Contains(u.Path, "\\") {
+		return "", fmt.Errorf("%q isn't a path on %s", target, domain)
+	}
+	return u.Path, nil
+}
@@ -0,0 +1,82 @@
+package login
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// loginDB remembers the sign ins it's asked to start.
+type loginDB struct {
+	db.DB
+	tokens  map[string]string
+	removed string
+}
+
+func (d *loginDB) InsertReaderLogin(userID string, loginToken string, expiresAt time.Time) error {
+	d.tokens[loginToken] = userID
+	return nil
+}
+
+func (d *loginDB) RemoveReaderSessions(userID string) error {
+	d.removed = userID
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{Domain: "lists.sh"}
+	user := &db.User{ID: "u1", Name: "erock"}
+
+	t.Run("prints a link with a fresh token", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := &loginDB{tokens: map[string]string{}}
+		var out bytes.Buffer
+		is.NoErr(run(&out, dbpool, cfg, user, nil))
+		is.NoErr(run(&out, dbpool, cfg, user, nil))
+		is.Equal(len(dbpool.tokens), 2)
+		for token, userID := range dbpool.tokens {
+			is.Equal(userID, "u1")
+			is.True(strings.Contains(out.String(), "https://lists.sh/login/"+token+"\n"))
+		}
+	})
+
+	t.Run("comes back to the page it was given", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := &loginDB{tokens: map[string]string{}}
+		var out bytes.Buffer
+		is.NoErr(run(&out, dbpool, cfg, user, []string{"https://lists.sh/mia/secret"}))
+		is.True(strings.Contains(out.String(), "?next=%2Fmia%2Fsecret\n"))
+	})
+
+	t.Run("off signs out everywhere", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := &loginDB{tokens: map[string]string{}}
+		var out bytes.Buffer
+		is.NoErr(run(&out, dbpool, cfg, user, []string{"off"}))
+		is.Equal(dbpool.removed, "u1")
+		is.Equal(len(dbpool.tokens), 0)
+	})
+}
+
+func TestNextPath(t *testing.T) {
+	is := is.New(t)
+	for target, want := range map[string]string{
+		"/mia/secret":                  "/mia/secret",
+		"https://lists.sh/mia/secret":  "/mia/secret",
+		"https://LISTS.SH/mia?x=1#top": "/mia",
+	} {
+		got, err := NextPath(target, "lists.sh")
+		is.NoErr(err)
+		is.Equal(got, want)
+	}
+
+	for _, target := range []string{"", "mia", "//evil.com/x", "https://evil.com/x", "javascript:alert(1)", "/\\evil.com"} {
+		_, err := NextPath(target, "lists.sh")
+		is.True(err != nil) // off the site
+	}
+}
@@ -0,0 +1,66 @@
+// Package mailer sends the few plain text emails lists.sh needs through the
+// SMTP server in the mail config.
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+)
+
+var sentTotal = metrics.NewCounter(
+	"lists_mail_sent_total",
+	"Emails sent by outcome (ok, failed).",
+	"result",
+)
+
+// Enabled reports whether an SMTP server is set up.
+func Enabled(cfg config.MailConfig) bool {
+	return cfg.SMTPAddr != ""
+}
+
+// Send mails body to a single address.
+func Send(cfg config.MailConfig, to string, subject string, body string) error {
+	if !Enabled(cfg) {
+		return fmt.Errorf("sending email isn't set up")
+	}
+	if err := db.ValidateEmail(to); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.SMTPAddr)
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	err := smtp.SendMail(cfg.SMTPAddr, auth, cfg.From, []string{to}, message(cfg.From, to, subject, body, time.Now()))
+	if err != nil {
+		sentTotal.Inc("failed")
+		return err
+	}
+	sentTotal.Inc("ok")
+	return nil
+}
+
+// message builds the email, the addresses are validated and the subject is
+// encoded so nothing can add headers of its own.
+func message(from string, to string, subject string, body string, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.Write(bytes.ReplaceAll(bytes.ReplaceAll([]byte(body), []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+	return b.Bytes()
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestMessage(t *testing.T) {
+	is := is.New(t)
+	msg := string(message("hello@lists.sh", "aunt@example.com", "Sign in\r\nBcc: spam@example.com", "one\ntwo\n", time.Now()))
+
+	head, body, ok := strings.Cut(msg, "\r\n\r\n")
+	is.True(ok)
+	is.True(!strings.Contains(head, "\r\nBcc:"))
+	is.True(strings.Contains(head, "\r\nTo: aunt@example.com\r\n"))
+	is.Equal(body, "one\r\ntwo\r\n")
+}
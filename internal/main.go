@@ -1,30 +1,130 @@
 package internal
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"math"
-	"os"
 	pathpkg "path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 )
 
+var (
+	loggerOnce sync.Once
+	baseLogger *zap.SugaredLogger
+)
+
+// CreateLogger returns the process wide logger configured by the log
+// section of the config.
 func CreateLogger() *zap.SugaredLogger {
-	logger, err := zap.NewProduction()
+	loggerOnce.Do(func() {
+		settings := config.Current().Log
+		cfg := zap.NewProductionConfig()
+		err := cfg.Level.UnmarshalText([]byte(settings.Level))
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Encoding = settings.Format
+
+		logger, err := cfg.Build()
+		if err != nil {
+			log.Fatal(err)
+		}
+		baseLogger = logger.Sugar()
+	})
+
+	return baseLogger
+}
+
+// NewID returns a short random id used to correlate log lines.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// KeyFingerprint returns the SHA256 fingerprint of a public key stored in
+// authorized_keys format, the same format `ssh-keygen -l` prints.
+func KeyFingerprint(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return ""
+	}
+	b, err := base64.StdEncoding.DecodeString(fields[1])
 	if err != nil {
-		log.Fatal(err)
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// NewInviteCode returns a random single use registration code.
+func NewInviteCode() string {
+	return NewID()[:12]
+}
+
+// ShortLinkPath is the directory short links are served from, like
+// /-/Ab3xZ.  No blog or username can start with a dash so it never shadows
+// one.
+const ShortLinkPath = "-"
+
+// ShortSlugLength is how many letters and digits a post's short link has.
+const ShortSlugLength = 5
+
+const slugAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// NewShortSlug returns a random slug for a post's short link, like Ab3xZ.
+func NewShortSlug() string {
+	b := make([]byte, ShortSlugLength)
+	_, _ = rand.Read(b)
+	for i := range b {
+		// 248 is the largest multiple of 62 that fits, rerolling above it
+		// keeps every letter equally likely.
+		for b[i] >= 248 {
+			_, _ = rand.Read(b[i : i+1])
+		}
+		b[i] = slugAlphabet[int(b[i])%len(slugAlphabet)]
+	}
+	return string(b)
+}
+
+type ctxSessionLoggerKey struct{}
+
+// WithSessionLogger tags every log line of an ssh session with a session id
+// and stores the logger on the session so handlers can find it.
+func WithSessionLogger(s ssh.Session) *zap.SugaredLogger {
+	logger := CreateLogger().With(
+		"session_id", NewID(),
+		"ssh_user", s.User(),
+		"remote_addr", s.RemoteAddr().String(),
+	)
+	if ctx, ok := s.Context().(ssh.Context); ok {
+		ctx.SetValue(ctxSessionLoggerKey{}, logger)
 	}
+	return logger
+}
 
-	return logger.Sugar()
+// SessionLogger returns the logger attached by WithSessionLogger, falling
+// back to the process wide logger.
+func SessionLogger(s ssh.Session) *zap.SugaredLogger {
+	if logger, ok := s.Context().Value(ctxSessionLoggerKey{}).(*zap.SugaredLogger); ok {
+		return logger
+	}
+	return CreateLogger()
 }
 
 var fnameRe = regexp.MustCompile(`[-_]+`)
@@ -52,12 +152,49 @@ func KeyText(s ssh.Session) (string, error) {
 	return fmt.Sprintf("%s %s", s.PublicKey().Type(), kb), nil
 }
 
-func GetEnv(key string, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// SessionUser finds the account the session's key belongs to.
+func SessionUser(s ssh.Session, dbpool db.DB) (*db.User, error) {
+	key, err := KeyText(s)
+	if err != nil {
+		return nil, fmt.Errorf("key not found")
 	}
+	user, err := dbpool.UserForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
 
-	return defaultVal
+// CanRunCommands is nil when the account can use the ssh commands: it
+// isn't suspended and has a username.
+func CanRunCommands(user *db.User) error {
+	if !user.IsActive() {
+		return db.ErrUserSuspended
+	}
+	if user.Name == "" {
+		return fmt.Errorf("must have username set")
+	}
+	return nil
+}
+
+// CommandUser is the account running an ssh command, the error says why
+// when there's none or it can't.
+func CommandUser(s ssh.Session, dbpool db.DB) (*db.User, error) {
+	user, err := SessionUser(s, dbpool)
+	if err != nil {
+		return nil, err
+	}
+	if err := CanRunCommands(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ErrHandler ends an ssh command with the error on stderr.
+func ErrHandler(s ssh.Session, err error) {
+	_, _ = fmt.Fprintln(s.Stderr(), err)
+	_ = s.Exit(1)
+	_ = s.Close()
 }
 
 // IsText reports whether a significant prefix of s looks like correct UTF-8;
@@ -72,7 +209,7 @@ func IsText(s string) bool {
 			// last char may be incomplete - ignore
 			break
 		}
-		if c == 0xFFFD || c < ' ' && c != '\n' && c != '\t' && c != '\f' {
+		if c == 0xFFFD || c < ' ' && c != '\n' && c != '\r' && c != '\t' && c != '\f' {
 			// decoding error or control character - not a text file
 			return false
 		}
@@ -87,11 +224,20 @@ var allowedExtensions = []string{".txt"}
 // correct UTF-8; that is, if it is likely that the file contains human-
 // readable text.
 func IsTextFile(text string, filename string) bool {
+	return CheckTextFile(text, filename) == nil
+}
+
+// CheckTextFile is IsTextFile with an error saying what's wrong with the
+// file.
+func CheckTextFile(text string, filename string) error {
 	ext := pathpkg.Ext(filename)
 	if !slices.Contains(allowedExtensions, ext) {
-		return false
+		return fmt.Errorf("only %s files are supported", strings.Join(allowedExtensions, ", "))
 	}
 
 	num := math.Min(float64(len(text)), 1024)
-	return IsText(text[0:int(num)])
+	if !IsText(text[0:int(num)]) {
+		return fmt.Errorf("looks like %s, not plain text", describeBinary([]byte(text[0:int(num)])))
+	}
+	return nil
 }
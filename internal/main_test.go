@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// keySession is a session with nothing but a public key.
+type keySession struct {
+	ssh.Session
+	key ssh.PublicKey
+}
+
+func (s keySession) PublicKey() ssh.PublicKey {
+	return s.key
+}
+
+// usersDB has one account, for the key it was given.
+type usersDB struct {
+	db.DB
+	key  string
+	user *db.User
+}
+
+func (d *usersDB) UserForKey(key string) (*db.User, error) {
+	if key != d.key {
+		return nil, errors.New("no rows")
+	}
+	return d.user, nil
+}
+
+func TestCommandUser(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := keySession{key: key}
+	text, err := KeyText(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		s    ssh.Session
+		user *db.User
+		err  string
+	}{
+		{"ok", s, &db.User{Name: "erock", Status: db.UserStatusActive}, ""},
+		{"no key", keySession{}, &db.User{Name: "erock", Status: db.UserStatusActive}, "key not found"},
+		{"no account", s, nil, "user not found"},
+		{"suspended", s, &db.User{Name: "erock", Status: db.UserStatusSuspended}, db.ErrUserSuspended.Error()},
+		{"no username", s, &db.User{Status: db.UserStatusActive}, "must have username set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			dbpool := &usersDB{key: text, user: tt.user}
+			if tt.user == nil {
+				dbpool.key = ""
+			}
+			user, err := CommandUser(tt.s, dbpool)
+			if tt.err == "" {
+				is.NoErr(err)
+				is.Equal(user, tt.user)
+				return
+			}
+			is.True(err != nil)
+			is.Equal(err.Error(), tt.err)
+		})
+	}
+}
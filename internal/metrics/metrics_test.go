@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWrite(t *testing.T) {
+	t.Run("counter", func(t *testing.T) {
+		is := is.New(t)
+		reg := &Registry{}
+		c := &Counter{desc: desc{name: "uploads_total", help: "Uploads.", labels: []string{"result"}}, values: map[string]float64{}}
+		reg.register(c)
+		c.Inc("created")
+		c.Inc("created")
+		c.Inc("failed")
+
+		var buf bytes.Buffer
+		reg.Write(&buf)
+		is.Equal(buf.String(), `# HELP uploads_total Uploads.
+# TYPE uploads_total counter
+uploads_total{result="created"} 2
+uploads_total{result="failed"} 1
+`)
+	})
+
+	t.Run("histogram", func(t *testing.T) {
+		is := is.New(t)
+		reg := &Registry{}
+		h := &Histogram{desc: desc{name: "latency_seconds", help: "Latency."}, buckets: []float64{0.1, 1}, values: map[string]*histogramValue{}}
+		reg.register(h)
+		h.Observe(0.05)
+		h.Observe(0.5)
+		h.Observe(2)
+
+		var buf bytes.Buffer
+		reg.Write(&buf)
+		out := buf.String()
+		is.True(strings.Contains(out, `latency_seconds_bucket{le="0.1"} 1`))
+		is.True(strings.Contains(out, `latency_seconds_bucket{le="1"} 2`))
+		is.True(strings.Contains(out, `latency_seconds_bucket{le="+Inf"} 3`))
+		is.True(strings.Contains(out, "latency_seconds_sum 2.55\n"))
+		is.True(strings.Contains(out, "latency_seconds_count 3\n"))
+	})
+}
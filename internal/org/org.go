@@ -0,0 +1,274 @@
+// Package org lets several accounts publish to one blog.  Whoever starts a
+// blog (see scp) owns it and can invite others over ssh:
+//
+//	ssh lists.sh org                                      # your blogs and invites
+//	ssh lists.sh org members <blog>
+//	ssh lists.sh org invite <blog> <username> [editor|owner]
+//	ssh lists.sh org accept <blog>
+//	ssh lists.sh org decline <blog>
+//	ssh lists.sh org role <blog> <username> <editor|owner>
+//	ssh lists.sh org remove <blog> <username>             # yourself to leave
+//
+// Editors publish with `scp post.txt lists.sh:/<blog>/`, owners can also
+// manage the members.
+package org
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+const usage = `usage:
+  ssh lists.sh org
+  ssh lists.sh org members <blog>
+  ssh lists.sh org invite <blog> <username> [editor|owner]
+  ssh lists.sh org accept <blog>
+  ssh lists.sh org decline <blog>
+  ssh lists.sh org role <blog> <username> <editor|owner>
+  ssh lists.sh org remove <blog> <username>`
+
+// IsCommand reports whether cmd is handled by this package.
+func IsCommand(cmd []string) bool {
+	return len(cmd) > 0 && cmd[0] == "org"
+}
+
+// Middleware handles the `ssh lists.sh org` commands.
+func Middleware(dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if !IsCommand(cmd) {
+				sh(s)
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			err = run(s, dbpool, user, cmd[1:])
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+func run(out io.Writer, dbpool db.DB, user *db.User, args []string) error {
+	if len(args) == 0 {
+		return list(out, dbpool, user)
+	}
+
+	switch {
+	case args[0] == "members" && len(args) == 2:
+		blog, err := memberOf(dbpool, user, args[1])
+		if err != nil {
+			return err
+		}
+		return members(out, dbpool, blog)
+	case args[0] == "invite" && (len(args) == 3 || len(args) == 4):
+		role := db.BlogEditor
+		if len(args) == 4 {
+			role = args[3]
+		}
+		return invite(out, dbpool, user, args[1], args[2], role)
+	case (args[0] == "accept" || args[0] == "decline") && len(args) == 2:
+		return answer(out, dbpool, user, args[1], args[0] == "accept")
+	case args[0] == "role" && len(args) == 4:
+		blog, member, err := ownedMember(dbpool, user, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		if err := validateRole(args[3]); err != nil {
+			return err
+		}
+		err = dbpool.SetBlogMemberRole(blog.ID, member.ID, args[3])
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "%s is now an %s of %s\n", member.Name, args[3], blog.Name)
+		return nil
+	case args[0] == "remove" && len(args) == 3:
+		return remove(out, dbpool, user, args[1], args[2])
+	}
+	return fmt.Errorf("%s", usage)
+}
+
+func list(out io.Writer, dbpool db.DB, user *db.User) error {
+	blogs, err := dbpool.FindBlogs(user.ID)
+	if err != nil {
+		return err
+	}
+	invites, err := dbpool.FindBlogInvites(user.ID)
+	if err != nil {
+		return err
+	}
+	if len(blogs) == 0 && len(invites) == 0 {
+		_, _ = fmt.Fprintf(out, "you don't publish to any other blogs\n%s\n", usage)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, blog := range blogs {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", blog.Name, blog.Role)
+	}
+	for _, blog := range invites {
+		_, _ = fmt.Fprintf(w, "%s\tinvited as %s, `ssh lists.sh org accept %s` to join\n", blog.Name, blog.Role, blog.Name)
+	}
+	return w.Flush()
+}
+
+func members(out io.Writer, dbpool db.DB, blog *db.Blog) error {
+	members, err := dbpool.FindBlogMembers(blog.ID)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, m := range members {
+		joined := "invited"
+		if m.JoinedAt != nil {
+			joined = "joined " + m.JoinedAt.Format("2006-01-02")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", m.Name, m.Role, joined)
+	}
+	return w.Flush()
+}
+
+func invite(out io.Writer, dbpool db.DB, user *db.User, blogName string, name string, role string) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	blog, err := ownerOf(dbpool, user, blogName)
+	if err != nil {
+		return err
+	}
+	invitee, err := dbpool.UserForName(name)
+	if err != nil {
+		return fmt.Errorf("no user called %q", name)
+	}
+
+	err = dbpool.InviteBlogMember(blog.ID, invitee.ID, role, user.ID)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "invited %s as an %s of %s, they join with `ssh lists.sh org accept %s`\n", invitee.Name, role, blog.Name, blog.Name)
+	return nil
+}
+
+func answer(out io.Writer, dbpool db.DB, user *db.User, blogName string, accept bool) error {
+	invites, err := dbpool.FindBlogInvites(user.ID)
+	if err != nil {
+		return err
+	}
+	blog := findBlog(invites, blogName)
+	if blog == nil {
+		return fmt.Errorf("you haven't been invited to %q", blogName)
+	}
+
+	if !accept {
+		err = dbpool.RemoveBlogMember(blog.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "declined the invite to %s\n", blog.Name)
+		return nil
+	}
+
+	err = dbpool.JoinBlog(blog.ID, user.ID)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "joined %s, publish with `scp post.txt lists.sh:/%s/`\n", blog.Name, blog.Name)
+	return nil
+}
+
+func remove(out io.Writer, dbpool db.DB, user *db.User, blogName string, name string) error {
+	if name == user.Name {
+		blog, err := memberOf(dbpool, user, blogName)
+		if err != nil {
+			return err
+		}
+		err = dbpool.RemoveBlogMember(blog.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "left %s\n", blog.Name)
+		return nil
+	}
+
+	blog, member, err := ownedMember(dbpool, user, blogName, name)
+	if err != nil {
+		return err
+	}
+	err = dbpool.RemoveBlogMember(blog.ID, member.ID)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "removed %s from %s, their posts stay on the blog\n", member.Name, blog.Name)
+	return nil
+}
+
+// memberOf finds the blog called name among the ones the user has joined.
+func memberOf(dbpool db.DB, user *db.User, name string) (*db.Blog, error) {
+	blogs, err := dbpool.FindBlogs(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	blog := findBlog(blogs, name)
+	if blog == nil {
+		return nil, fmt.Errorf("you're not a member of %q", name)
+	}
+	return blog, nil
+}
+
+// ownerOf is memberOf for the commands only owners may run.
+func ownerOf(dbpool db.DB, user *db.User, name string) (*db.Blog, error) {
+	blog, err := memberOf(dbpool, user, name)
+	if err != nil {
+		return nil, err
+	}
+	if blog.Role != db.BlogOwner {
+		return nil, fmt.Errorf("only owners of %s can manage its members", blog.Name)
+	}
+	return blog, nil
+}
+
+// ownedMember finds a blog the user owns and the user called name.
+func ownedMember(dbpool db.DB, user *db.User, blogName string, name string) (*db.Blog, *db.User, error) {
+	blog, err := ownerOf(dbpool, user, blogName)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := dbpool.UserForName(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no user called %q", name)
+	}
+	return blog, member, nil
+}
+
+func findBlog(blogs []*db.Blog, name string) *db.Blog {
+	for _, blog := range blogs {
+		if blog.Name == name {
+			return blog
+		}
+	}
+	return nil
+}
+
+func validateRole(role string) error {
+	if role != db.BlogOwner && role != db.BlogEditor {
+		return fmt.Errorf("roles are %s or %s, got %q", db.BlogEditor, db.BlogOwner, role)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package org
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// orgDB keeps one blog's members in memory.
+type orgDB struct {
+	db.DB
+	blog    *db.Blog
+	users   map[string]*db.User
+	members map[string]*db.BlogMember
+}
+
+func newOrgDB() *orgDB {
+	now := time.Now()
+	return &orgDB{
+		blog: &db.Blog{ID: "b1", UserID: "blog", Name: "team"},
+		users: map[string]*db.User{
+			"erock": {ID: "u1", Name: "erock"},
+			"mia":   {ID: "u2", Name: "mia"},
+		},
+		members: map[string]*db.BlogMember{
+			"u1": {BlogID: "b1", UserID: "u1", Name: "erock", Role: db.BlogOwner, JoinedAt: &now},
+		},
+	}
+}
+
+func (d *orgDB) FindBlogs(userID string) ([]*db.Blog, error) {
+	if m := d.members[userID]; m == nil || m.JoinedAt == nil {
+		return nil, nil
+	}
+	blog := *d.blog
+	blog.Role = d.members[userID].Role
+	return []*db.Blog{&blog}, nil
+}
+
+func (d *orgDB) FindBlogInvites(userID string) ([]*db.Blog, error) {
+	if m := d.members[userID]; m == nil || m.JoinedAt != nil {
+		return nil, nil
+	}
+	blog := *d.blog
+	blog.Role = d.members[userID].Role
+	return []*db.Blog{&blog}, nil
+}
+
+func (d *orgDB) FindBlogMembers(blogID string) ([]*db.BlogMember, error) {
+	var members []*db.BlogMember
+	for _, m := range d.members {
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func (d *orgDB) UserForName(name string) (*db.User, error) {
+	if user, ok := d.users[name]; ok {
+		return user, nil
+	}
+	return nil, errors.New("sql: no rows in result set")
+}
+
+func (d *orgDB) InviteBlogMember(blogID string, userID string, role string, invitedBy string) error {
+	if d.members[userID] != nil {
+		return errors.New("they're already a member or have been invited")
+	}
+	d.members[userID] = &db.BlogMember{BlogID: blogID, UserID: userID, Role: role}
+	return nil
+}
+
+func (d *orgDB) JoinBlog(blogID string, userID string) error {
+	now := time.Now()
+	d.members[userID].JoinedAt = &now
+	return nil
+}
+
+func (d *orgDB) SetBlogMemberRole(blogID string, userID string, role string) error {
+	d.members[userID].Role = role
+	return nil
+}
+
+func (d *orgDB) RemoveBlogMember(blogID string, userID string) error {
+	delete(d.members, userID)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	t.Run("invites are accepted by the invitee", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := newOrgDB()
+		erock, mia := dbpool.users["erock"], dbpool.users["mia"]
+		var out bytes.Buffer
+
+		is.NoErr(run(&out, dbpool, erock, []string{"invite", "team", "mia"}))
+		is.Equal(dbpool.members["u2"].Role, db.BlogEditor)
+		is.True(dbpool.members["u2"].JoinedAt == nil)
+
+		out.Reset()
+		is.NoErr(run(&out, dbpool, mia, nil))
+		is.True(strings.Contains(out.String(), "org accept team")) // lists the invite
+
+		is.NoErr(run(&out, dbpool, mia, []string{"accept", "team"}))
+		is.True(dbpool.members["u2"].JoinedAt != nil)
+	})
+
+	t.Run("declining drops the invite", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := newOrgDB()
+		var out bytes.Buffer
+		is.NoErr(run(&out, dbpool, dbpool.users["erock"], []string{"invite", "team", "mia", "owner"}))
+		is.NoErr(run(&out, dbpool, dbpool.users["mia"], []string{"decline", "team"}))
+		is.True(dbpool.members["u2"] == nil)
+	})
+
+	t.Run("only owners manage members", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := newOrgDB()
+		now := time.Now()
+		dbpool.members["u2"] = &db.BlogMember{UserID: "u2", Role: db.BlogEditor, JoinedAt: &now}
+		var out bytes.Buffer
+
+		err := run(&out, dbpool, dbpool.users["mia"], []string{"remove", "team", "erock"})
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "only owners"))
+		is.True(dbpool.members["u1"] != nil)
+
+		is.NoErr(run(&out, dbpool, dbpool.users["mia"], []string{"remove", "team", "mia"})) // leaving is fine
+		is.True(dbpool.members["u2"] == nil)
+	})
+
+	t.Run("roles are checked", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := newOrgDB()
+		var out bytes.Buffer
+		err := run(&out, dbpool, dbpool.users["erock"], []string{"invite", "team", "mia", "admin"})
+		is.True(err != nil)
+		is.True(dbpool.members["u2"] == nil)
+	})
+
+	t.Run("strangers can't see the members", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := newOrgDB()
+		var out bytes.Buffer
+		err := run(&out, dbpool, dbpool.users["mia"], []string{"members", "team"})
+		is.True(err != nil)
+		is.Equal(out.Len(), 0)
+	})
+}
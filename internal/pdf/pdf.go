@@ -0,0 +1,369 @@
+// Package pdf lays out simple text documents as PDF, enough to print a list:
+// wrapped paragraphs in the standard Helvetica and Courier fonts, markers,
+// checkboxes and rules.  The standard fonts don't need embedding but only
+// cover Windows-1252, so other characters, emoji included, print as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// A US Letter page and its margins, in points.
+const (
+	PageWidth  = 612.0
+	PageHeight = 792.0
+	Margin     = 54.0
+
+	footerSize = 8.0
+	// lineHeight is how far apart lines are, relative to the font size.
+	lineHeight = 1.35
+)
+
+// Font is one of the standard fonts every PDF reader has.
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+	Italic
+	Mono
+)
+
+var fontNames = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier"}
+
+// Box is the checkbox drawn in front of a paragraph.
+type Box int
+
+const (
+	NoBox Box = iota
+	Unchecked
+	Checked
+)
+
+// Paragraph is a run of text wrapped to the page.
+type Paragraph struct {
+	Text   string
+	Font   Font
+	Size   float64
+	Indent float64 // from the left margin, the marker or box hangs left of it
+	Marker string  // drawn before the first line, like "•" or "3."
+	Box    Box
+	Struck bool
+	Grey   bool
+	Bar    bool // a line down the left, for quotes
+}
+
+// Document is a PDF being laid out from the top of the first page down.
+type Document struct {
+	Title  string
+	Footer string // printed at the bottom of every page with the page number
+
+	pages []*bytes.Buffer
+	y     float64 // top of the next line, from the bottom of the page
+}
+
+// New returns an empty document.
+func New(title string) *Document {
+	return &Document{Title: title}
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// reserve makes room for h points, starting a new page if they don't fit.
+func (d *Document) reserve(h float64) {
+	if len(d.pages) == 0 || d.y-h < Margin+footerSize*2 {
+		d.pages = append(d.pages, &bytes.Buffer{})
+		d.y = PageHeight - Margin
+	}
+}
+
+// Space leaves h points blank, it's dropped at the top of a page.
+func (d *Document) Space(h float64) {
+	if len(d.pages) == 0 || d.y == PageHeight-Margin {
+		return
+	}
+	d.y -= h
+}
+
+// Rule draws a light line across the page.
+func (d *Document) Rule() {
+	d.reserve(24)
+	d.y -= 12
+	fmt.Fprintf(d.page(), "0.7 G 0.5 w %s %s m %s %s l S 0 G\n",
+		num(Margin), num(d.y), num(PageWidth-Margin), num(d.y))
+	d.y -= 12
+}
+
+// Add lays out a paragraph, breaking it across pages as needed.
+func (d *Document) Add(p Paragraph) {
+	if p.Size == 0 {
+		p.Size = 11
+	}
+	x := Margin + p.Indent
+	lh := p.Size * lineHeight
+	lines := wrap(encode(p.Text), p.Font, p.Size, PageWidth-Margin-x)
+	for i, line := range lines {
+		d.reserve(lh)
+		top := d.y
+		d.y -= lh
+		base := d.y + (lh-p.Size)/2 + p.Size*0.2
+		b := d.page()
+		grey := "0 g 0 G"
+		if p.Grey {
+			grey = "0.4 g 0.4 G"
+		}
+		fmt.Fprintf(b, "%s\n", grey)
+
+		if i == 0 {
+			switch {
+			case p.Box != NoBox:
+				side := p.Size * 0.7
+				bx := x - side - p.Size*0.5
+				fmt.Fprintf(b, "0.8 w %s %s %s %s re S\n", num(bx), num(base), num(side), num(side))
+				if p.Box == Checked {
+					fmt.Fprintf(b, "1.2 w %s %s m %s %s l %s %s l S\n",
+						num(bx+side*0.2), num(base+side*0.5),
+						num(bx+side*0.45), num(base+side*0.2),
+						num(bx+side*0.85), num(base+side*0.85))
+				}
+			case p.Marker != "":
+				marker := encode(p.Marker)
+				mx := x - width(marker, p.Font, p.Size) - p.Size*0.4
+				text(b, p.Font, p.Size, mx, base, marker)
+			}
+		}
+		if p.Bar {
+			fmt.Fprintf(b, "0.7 G 2 w %s %s m %s %s l S %s\n",
+				num(x-p.Size*0.6), num(top), num(x-p.Size*0.6), num(d.y), grey)
+		}
+		text(b, p.Font, p.Size, x, base, line)
+		if p.Struck {
+			sy := base + p.Size*0.3
+			fmt.Fprintf(b, "0.6 w %s %s m %s %s l S\n",
+				num(x), num(sy), num(x+width(line, p.Font, p.Size)), num(sy))
+		}
+	}
+}
+
+func text(b *bytes.Buffer, f Font, size, x, y float64, s []byte) {
+	fmt.Fprintf(b, "BT /F%d %s Tf %s %s Td %s Tj ET\n", f+1, num(size), num(x), num(y), literal(s))
+}
+
+// WriteTo writes the finished PDF.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.reserve(0)
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3-6 fonts, 7 info, then a page and its
+	// contents for every page.
+	const firstPage = 8
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), num(PageWidth), num(PageHeight)))
+	fonts := make([]string, len(fontNames))
+	for i, name := range fontNames {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts[i] = fmt.Sprintf("/F%d %d 0 R", i+1, i+3)
+	}
+	obj(fmt.Sprintf("<< /Title %s /Producer (lists.sh) >>", textString(d.Title)))
+
+	for i, page := range d.pages {
+		contents := page.String() + d.footer(i)
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			strings.Join(fonts, " "), firstPage+i*2+1))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(contents), contents))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 7 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
+
+// footer draws the page's footer, the page count is only known once the
+// whole document is laid out.
+func (d *Document) footer(i int) string {
+	var b bytes.Buffer
+	b.WriteString("0.4 g\n")
+	y := Margin - footerSize
+	if d.Footer != "" {
+		text(&b, Regular, footerSize, Margin, y, encode(d.Footer))
+	}
+	count := encode(fmt.Sprintf("%d / %d", i+1, len(d.pages)))
+	text(&b, Regular, footerSize, PageWidth-Margin-width(count, Regular, footerSize), y, count)
+	return b.String()
+}
+
+// wrap breaks s into lines no wider than max, splitting words that are too
+// long on their own.
+func wrap(s []byte, f Font, size, max float64) [][]byte {
+	var lines [][]byte
+	var line []byte
+	for _, word := range bytes.Fields(s) {
+		for width(word, f, size) > max {
+			if len(line) > 0 {
+				lines = append(lines, line)
+				line = nil
+			}
+			n := 1
+			for n < len(word) && width(word[:n+1], f, size) <= max {
+				n++
+			}
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		switch {
+		case len(line) == 0:
+			line = append([]byte{}, word...)
+		case width(line, f, size)+width([]byte{' '}, f, size)+width(word, f, size) <= max:
+			line = append(append(line, ' '), word...)
+		default:
+			lines = append(lines, line)
+			line = append([]byte{}, word...)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// width is how wide s is set in f, in points.
+func width(s []byte, f Font, size float64) float64 {
+	total := 0
+	for _, c := range s {
+		total += glyphWidth(c, f)
+	}
+	return float64(total) * size / 1000
+}
+
+func glyphWidth(c byte, f Font) int {
+	switch {
+	case f == Mono:
+		return 600
+	case c >= 32 && c < 127 && f == Bold:
+		return helveticaBold[c-32]
+	case c >= 32 && c < 127:
+		return helvetica[c-32]
+	case c == 0x85 || c == 0x97: // ellipsis and em dash
+		return 1000
+	case f == Bold:
+		return 611
+	default:
+		// Most of the rest are accented letters, about as wide as the
+		// letters they're made from.
+		return 556
+	}
+}
+
+// Glyph widths from the Adobe font metrics, for the printable ASCII
+// characters.  Helvetica-Oblique is as wide as Helvetica.
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// winAnsi has the Windows-1252 characters outside of Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode converts s to Windows-1252 for the standard fonts.  Whitespace
+// becomes a space and characters that modify others, like emoji variation
+// selectors, are dropped.
+func encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			b = append(b, ' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || unicode.In(r, unicode.Mn, unicode.Cf):
+		case r < 0x100:
+			b = append(b, byte(r))
+		case winAnsi[r] != 0:
+			b = append(b, winAnsi[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// literal writes s as a PDF string, escaping what the syntax needs and
+// everything that isn't printable ASCII.
+func literal(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// textString encodes s as UTF-16 for the document info, which readers show
+// in their title bar and isn't limited to the fonts' characters.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// num formats a coordinate without a trailing run of zeros.
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
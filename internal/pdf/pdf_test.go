@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEncode(t *testing.T) {
+	is := is.New(t)
+	is.Equal(encode("café — 5€\ttab"), []byte("caf\xe9 \x97 5\x80 tab"))
+	is.Equal(encode("tacos 🌮"), []byte("tacos ?"))
+	is.Equal(encode("❤️"), []byte("?")) // the variation selector is dropped
+}
+
+func TestLiteral(t *testing.T) {
+	is := is.New(t)
+	is.Equal(literal([]byte(`a (b) \c`)), `(a \(b\) \\c)`)
+	is.Equal(literal([]byte("caf\xe9")), `(caf\351)`)
+}
+
+func TestWrap(t *testing.T) {
+	is := is.New(t)
+	lines := wrap([]byte("one two three"), Mono, 10, 6*8)
+	is.Equal(len(lines), 2)
+	is.Equal(string(lines[0]), "one two")
+	is.Equal(string(lines[1]), "three")
+
+	lines = wrap([]byte("abcdefghij"), Mono, 10, 6*4)
+	is.Equal(len(lines), 3) // too long for a line on its own
+	is.Equal(string(lines[0]), "abcd")
+	is.Equal(string(lines[2]), "ij")
+
+	is.Equal(len(wrap(nil, Regular, 10, 100)), 1)
+}
+
+func TestWidth(t *testing.T) {
+	is := is.New(t)
+	is.Equal(width([]byte("Wi"), Regular, 10), 9.44+2.22)
+	is.Equal(width([]byte("Wi"), Mono, 10), 12.0)
+	is.True(width([]byte("bold"), Bold, 10) > width([]byte("bold"), Regular, 10))
+}
+
+func TestWriteTo(t *testing.T) {
+	is := is.New(t)
+	doc := New("Packing list 🏕")
+	doc.Footer = "https://lists.sh/erock/packing"
+	doc.Add(Paragraph{Text: "Packing list", Font: Bold, Size: 20})
+	for i := 0; i < 120; i++ {
+		doc.Add(Paragraph{Text: fmt.Sprintf("item %d", i), Indent: 16, Box: Box(i % 3), Struck: i%3 == 2})
+	}
+	doc.Rule()
+	doc.Add(Paragraph{Text: "a quote", Font: Italic, Bar: true, Indent: 16})
+
+	var b bytes.Buffer
+	_, err := doc.WriteTo(&b)
+	is.NoErr(err)
+	out := b.String()
+	is.True(strings.HasPrefix(out, "%PDF-1.4\n"))
+	is.True(strings.HasSuffix(out, "%%EOF\n"))
+	is.True(strings.Contains(out, "/Count 3 ")) // 120 lines take three pages
+	is.True(strings.Contains(out, "(3 / 3) Tj"))
+	is.True(strings.Contains(out, "(https://lists.sh/erock/packing) Tj"))
+	is.True(strings.Contains(out, "/Title <FEFF005000610063006B0069006E00670020006C0069007300740020D83CDFD5>"))
+
+	// Every object is where the cross reference table says it is.
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	is.True(xref != nil)
+	start, _ := strconv.Atoi(xref[1])
+	is.True(strings.HasPrefix(out[start:], "xref\n0 "))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[start:], -1)
+	is.Equal(len(entries), 7+3*2)
+	for i, entry := range entries {
+		off, _ := strconv.Atoi(entry[1])
+		is.True(strings.HasPrefix(out[off:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+
+	// And every stream is as long as it says.
+	for _, m := range regexp.MustCompile(`/Length (\d+) >>\nstream\n`).FindAllStringSubmatchIndex(out, -1) {
+		n, _ := strconv.Atoi(out[m[2]:m[3]])
+		is.True(strings.HasPrefix(out[m[1]+n:], "endstream"))
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	is := is.New(t)
+	var b bytes.Buffer
+	_, err := New("").WriteTo(&b)
+	is.NoErr(err)
+	is.True(strings.Contains(b.String(), "/Count 1 "))
+}
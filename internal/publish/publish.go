@@ -0,0 +1,247 @@
+// Package publish pushes a user's blog as a static site to hosting of their
+// own after every change:
+//
+//	echo "$ACCESS_KEY $SECRET_KEY" | ssh lists.sh publish s3 <bucket> [region] [endpoint]
+//	echo "$NETLIFY_TOKEN" | ssh lists.sh publish netlify <site-id>
+//	ssh lists.sh publish        # where it goes and how the last push went
+//	ssh lists.sh publish now    # push again
+//	ssh lists.sh publish off    # stop pushing
+//
+// Credentials are read from stdin so they don't end up in the session logs.
+package publish
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/events"
+	"github.com/neurosnap/lists.sh/internal/export"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// interval is how often queued pushes are picked up, changes made in the
+// meantime go out together.
+const interval = time.Minute
+
+const usage = `usage:
+  echo "$ACCESS_KEY $SECRET_KEY" | ssh lists.sh publish s3 <bucket> [region] [endpoint]
+  echo "$NETLIFY_TOKEN" | ssh lists.sh publish netlify <site-id>
+  ssh lists.sh publish [now|off]`
+
+var pushesTotal = metrics.NewCounter(
+	"lists_publish_pushes_total",
+	"Static sites pushed to user hosting by kind and outcome (ok, failed).",
+	"kind", "result",
+)
+
+// IsCommand reports whether cmd is handled by this package.
+func IsCommand(cmd []string) bool {
+	return len(cmd) > 0 && cmd[0] == "publish"
+}
+
+// Middleware handles the `ssh lists.sh publish` commands.
+func Middleware(dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if !IsCommand(cmd) {
+				sh(s)
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			err = run(s, dbpool, user, cmd[1:])
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+func run(s ssh.Session, dbpool db.DB, user *db.User, args []string) error {
+	if len(args) == 0 {
+		return status(s, dbpool, user)
+	}
+
+	switch args[0] {
+	case "off":
+		err := dbpool.RemovePublishTarget(user.ID)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(s, "stopped publishing, what was already pushed stays where it is")
+		return nil
+	case "now":
+		if _, err := dbpool.FindPublishTarget(user.ID); err != nil {
+			return fmt.Errorf("not publishing anywhere\n%s", usage)
+		}
+		err := dbpool.QueuePublish(user.ID)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(s, "queued, your blog will be pushed within a minute")
+		return nil
+	case db.PublishS3, db.PublishNetlify:
+		_, _ = fmt.Fprintln(s.Stderr(), "reading credentials from stdin")
+		line, err := bufio.NewReader(io.LimitReader(s, 4096)).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		target, err := parseTarget(args, line)
+		if err != nil {
+			return err
+		}
+		target.UserID = user.ID
+		err = dbpool.SetPublishTarget(target)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(s, "publishing to %s, the first push is queued\n", destination(target))
+		return nil
+	}
+	return fmt.Errorf("unknown publish command %q\n%s", args[0], usage)
+}
+
+// parseTarget builds a target from the command's arguments and the
+// credentials read from stdin.
+func parseTarget(args []string, credentials string) (*db.PublishTarget, error) {
+	fields := strings.Fields(credentials)
+	target := &db.PublishTarget{Kind: args[0]}
+
+	switch target.Kind {
+	case db.PublishS3:
+		if len(args) < 2 || len(args) > 4 {
+			return nil, fmt.Errorf("%s", usage)
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected the access key and secret key on stdin, separated by a space")
+		}
+		target.S3Bucket = args[1]
+		target.S3Region = "us-east-1"
+		target.S3Endpoint = "https://s3.amazonaws.com"
+		if len(args) > 2 {
+			target.S3Region = args[2]
+		}
+		if len(args) > 3 {
+			target.S3Endpoint = args[3]
+		}
+		if !strings.HasPrefix(target.S3Endpoint, "https://") {
+			return nil, fmt.Errorf("the endpoint must be an https:// url")
+		}
+		target.S3AccessKey, target.S3SecretKey = fields[0], fields[1]
+	case db.PublishNetlify:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s", usage)
+		}
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("expected a netlify personal access token on stdin")
+		}
+		target.NetlifySiteID = args[1]
+		target.NetlifyToken = fields[0]
+	}
+	return target, nil
+}
+
+// destination names where a target pushes to, without its credentials.
+func destination(target *db.PublishTarget) string {
+	if target.Kind == db.PublishNetlify {
+		return "netlify site " + target.NetlifySiteID
+	}
+	return fmt.Sprintf("s3://%s (%s)", target.S3Bucket, target.S3Endpoint)
+}
+
+func status(s ssh.Session, dbpool db.DB, user *db.User) error {
+	target, err := dbpool.FindPublishTarget(user.ID)
+	if err != nil {
+		_, _ = fmt.Fprintf(s, "not publishing anywhere\n%s\n", usage)
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(s, "publishing to %s\n", destination(target))
+	if target.PublishedAt != nil {
+		_, _ = fmt.Fprintf(s, "last pushed %s\n", target.PublishedAt.Format("2006-01-02 15:04 MST"))
+	}
+	if target.QueuedAt != nil {
+		_, _ = fmt.Fprintln(s, "a push is queued")
+	}
+	if target.LastError != "" {
+		_, _ = fmt.Fprintf(s, "the last push failed: %s\n", target.LastError)
+	}
+	return nil
+}
+
+// OnPostEvent queues a push of the blog a post changed on, it's subscribed
+// to post events.  QueuePublish does nothing for blogs that aren't pushed
+// anywhere.
+func OnPostEvent(dbpool db.DB) events.Consumer {
+	return func(event *db.PostEvent) error {
+		return dbpool.QueuePublish(event.UserID)
+	}
+}
+
+// Run pushes the blogs that changed every interval until done is closed.
+func Run(dbpool db.DB, logger *zap.SugaredLogger, site export.SiteFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		targets, err := dbpool.FindQueuedPublishes()
+		if err != nil {
+			logger.Errorf("finding queued publishes failed: %v", err)
+			continue
+		}
+		for _, target := range targets {
+			publishErr := ""
+			if err := publish(dbpool, logger, site, target); err != nil {
+				logger.Infow("publish failed", "user_id", target.UserID, "kind", target.Kind, "error", err)
+				publishErr = err.Error()
+				pushesTotal.Inc(target.Kind, "failed")
+			} else {
+				pushesTotal.Inc(target.Kind, "ok")
+			}
+
+			err := dbpool.FinishPublish(target.UserID, *target.QueuedAt, publishErr)
+			if err != nil {
+				logger.Error(err)
+			}
+		}
+	}
+}
+
+func publish(dbpool db.DB, logger *zap.SugaredLogger, site export.SiteFunc, target *db.PublishTarget) error {
+	user, err := dbpool.User(target.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return db.ErrUserSuspended
+	}
+
+	files, err := site(logger, dbpool, user)
+	if err != nil {
+		return err
+	}
+	return Push(target, files)
+}
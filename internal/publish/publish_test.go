@@ -0,0 +1,120 @@
+package publish
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/export"
+)
+
+func TestParseTarget(t *testing.T) {
+	t.Run("s3 defaults to aws", func(t *testing.T) {
+		is := is.New(t)
+		target, err := parseTarget([]string{"s3", "blog"}, "AKID SECRET\n")
+		is.NoErr(err)
+		is.Equal(target.Kind, db.PublishS3)
+		is.Equal(target.S3Bucket, "blog")
+		is.Equal(target.S3Region, "us-east-1")
+		is.Equal(target.S3Endpoint, "https://s3.amazonaws.com")
+		is.Equal(target.S3AccessKey, "AKID")
+		is.Equal(target.S3SecretKey, "SECRET")
+	})
+
+	t.Run("s3 with region and endpoint", func(t *testing.T) {
+		is := is.New(t)
+		target, err := parseTarget([]string{"s3", "blog", "auto", "https://r2.example.com"}, "AKID SECRET")
+		is.NoErr(err)
+		is.Equal(target.S3Region, "auto")
+		is.Equal(target.S3Endpoint, "https://r2.example.com")
+	})
+
+	t.Run("s3 needs both keys", func(t *testing.T) {
+		is := is.New(t)
+		_, err := parseTarget([]string{"s3", "blog"}, "AKID\n")
+		is.True(err != nil)
+	})
+
+	t.Run("s3 endpoint must be https", func(t *testing.T) {
+		is := is.New(t)
+		_, err := parseTarget([]string{"s3", "blog", "auto", "http://r2.example.com"}, "AKID SECRET")
+		is.True(err != nil)
+	})
+
+	t.Run("netlify", func(t *testing.T) {
+		is := is.New(t)
+		target, err := parseTarget([]string{"netlify", "site-1"}, "TOKEN\n")
+		is.NoErr(err)
+		is.Equal(target.Kind, db.PublishNetlify)
+		is.Equal(target.NetlifySiteID, "site-1")
+		is.Equal(target.NetlifyToken, "TOKEN")
+	})
+
+	t.Run("netlify needs a site", func(t *testing.T) {
+		is := is.New(t)
+		_, err := parseTarget([]string{"netlify"}, "TOKEN")
+		is.True(err != nil)
+	})
+}
+
+func TestStaleFiles(t *testing.T) {
+	is := is.New(t)
+	is.Equal(staleFiles(nil, []string{"index.html"}), nil)
+	is.Equal(
+		staleFiles([]string{"index.html", "old.html", "rss.xml"}, []string{"index.html", "rss.xml", "new.html"}),
+		[]string{"old.html"},
+	)
+}
+
+func TestPushNetlify(t *testing.T) {
+	files := []*export.File{
+		{Name: "index.html", Data: []byte("<h1>hi</h1>"), ModTime: time.Now()},
+		{Name: "rss.xml", Data: []byte("<feed/>"), ModTime: time.Now()},
+	}
+
+	t.Run("uploads a zip of the site", func(t *testing.T) {
+		is := is.New(t)
+		var got map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			is.Equal(r.Method, "POST")
+			is.Equal(r.URL.Path, "/sites/site-1/deploys")
+			is.Equal(r.Header.Get("Authorization"), "Bearer TOKEN")
+			is.Equal(r.Header.Get("Content-Type"), "application/zip")
+
+			body, err := io.ReadAll(r.Body)
+			is.NoErr(err)
+			zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			is.NoErr(err)
+			got = map[string]string{}
+			for _, f := range zr.File {
+				rc, err := f.Open()
+				is.NoErr(err)
+				b, err := io.ReadAll(rc)
+				is.NoErr(err)
+				got[f.Name] = string(b)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		is.NoErr(pushNetlify(srv.URL, "site-1", "TOKEN", files))
+		is.Equal(got, map[string]string{"index.html": "<h1>hi</h1>", "rss.xml": "<feed/>"})
+	})
+
+	t.Run("reports a rejected deploy", func(t *testing.T) {
+		is := is.New(t)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		err := pushNetlify(srv.URL, "site-1", "TOKEN", files)
+		is.True(err != nil)
+	})
+}
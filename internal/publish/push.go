@@ -0,0 +1,136 @@
+package publish
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/backup"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/export"
+)
+
+// manifestKey lists what the last push to a bucket uploaded, so files that
+// are gone from the blog can be removed without touching anything else the
+// user keeps there.
+const manifestKey = ".lists.sh-manifest.json"
+
+// maxManifestSize is the most read back of a manifest.
+const maxManifestSize = 4 * 1024 * 1024
+
+const netlifyAPI = "https://api.netlify.com/api/v1"
+
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// Push uploads the files to the target, replacing the last push.
+func Push(target *db.PublishTarget, files []*export.File) error {
+	switch target.Kind {
+	case db.PublishS3:
+		return pushS3(&backup.S3{
+			Endpoint:  target.S3Endpoint,
+			Region:    target.S3Region,
+			Bucket:    target.S3Bucket,
+			AccessKey: target.S3AccessKey,
+			SecretKey: target.S3SecretKey,
+			Client:    client,
+		}, files)
+	case db.PublishNetlify:
+		return pushNetlify(netlifyAPI, target.NetlifySiteID, target.NetlifyToken, files)
+	}
+	return fmt.Errorf("unknown publish target %q", target.Kind)
+}
+
+func pushS3(store *backup.S3, files []*export.File) error {
+	var previous []string
+	if b, err := store.Get(manifestKey, maxManifestSize); err == nil {
+		_ = json.Unmarshal(b, &previous)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		err := store.Put(f.Name, f.Data, contentType(f.Name))
+		if err != nil {
+			return err
+		}
+		names = append(names, f.Name)
+	}
+
+	for _, name := range staleFiles(previous, names) {
+		err := store.Delete(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return store.Put(manifestKey, manifest, "application/json")
+}
+
+// staleFiles are the ones pushed last time that aren't part of the site
+// anymore.
+func staleFiles(previous []string, current []string) []string {
+	keep := map[string]bool{}
+	for _, name := range current {
+		keep[name] = true
+	}
+	var stale []string
+	for _, name := range previous {
+		if !keep[name] {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+// pushNetlify deploys the files as a zip, netlify replaces the whole site
+// with each deploy.
+func pushNetlify(api string, siteID string, token string, files []*export.File) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.ModTime})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/sites/%s/deploys", api, siteID), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/zip")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("netlify deploy failed (%d): %s", res.StatusCode, string(b))
+	}
+	return nil
+}
+
+func contentType(name string) string {
+	if kind := mime.TypeByExtension(path.Ext(name)); kind != "" {
+		return kind
+	}
+	return "application/octet-stream"
+}
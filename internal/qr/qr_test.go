@@ -0,0 +1,175 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestReedSolomon(t *testing.T) {
+	is := is.New(t)
+	// The 1-M "HELLO WORLD" example from thonky.com's QR code tutorial.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	is.Equal(rsRemainder(data, rsDivisor(10)), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23})
+}
+
+func TestFormatBits(t *testing.T) {
+	is := is.New(t)
+	is.Equal(formatBits(0), 0b101010000010010)
+	is.Equal(formatBits(4), 0b100010111111001)
+	is.Equal(formatBits(7), 0b100101010100000)
+	is.Equal(versionBits(7), 0b000111110010010100)
+}
+
+// read decodes c back to its text, walking the modules the way a scanner
+// does once it has found them.
+func read(t *testing.T, c *Code) string {
+	is := is.New(t)
+	format := 0
+	for i := 0; i <= 5; i++ {
+		format |= b2i(c.dark[i][8]) << i
+	}
+	format |= b2i(c.dark[7][8])<<6 | b2i(c.dark[8][8])<<7 | b2i(c.dark[8][7])<<8
+	for i := 9; i < 15; i++ {
+		format |= b2i(c.dark[8][14-i]) << i
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	is.True(mask >= 0) // format bits are valid
+
+	var bits bitBuffer
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !c.function[y][x] {
+					bits = append(bits, c.dark[y][x] != masked(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := bits[:len(bits)/8*8].bytes()
+
+	bl := levelM[c.version]
+	n := bl.short + bl.long
+	blocks := make([][]byte, n)
+	i := 0
+	for col := 0; col <= bl.data; col++ {
+		for b := range blocks {
+			if col < bl.data || b >= bl.short {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	var data []byte
+	for b := range blocks {
+		ec := make([]byte, bl.ec)
+		for j := range ec {
+			ec[j] = codewords[i+j*n+b]
+		}
+		is.Equal(rsRemainder(blocks[b], rsDivisor(bl.ec)), ec) // error correction matches
+		data = append(data, blocks[b]...)
+	}
+
+	is.Equal(data[0]>>4, byte(0b0100)) // byte mode
+	var stream bitBuffer
+	for _, d := range data {
+		stream.append(int(d), 8)
+	}
+	stream = stream[4:]
+	length := 0
+	for _, bit := range stream[:countBits(c.version)] {
+		length = length<<1 | b2i(bit)
+	}
+	stream = stream[countBits(c.version):]
+	return string(stream[:length*8].bytes())
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncode(t *testing.T) {
+	for _, text := range []string{
+		"https://lists.sh/p/Ab3xZ",
+		"https://lists.sh/erock/packing-list-for-the-camping-trip",
+		"https://lists.sh/" + strings.Repeat("long-", 30),
+		strings.Repeat("x", MaxLength),
+	} {
+		t.Run(text[:10], func(t *testing.T) {
+			is := is.New(t)
+			c, err := Encode(text)
+			is.NoErr(err)
+			is.Equal(c.Size, 17+4*c.version)
+			is.Equal(read(t, c), text)
+		})
+	}
+
+	t.Run("smallest version that fits", func(t *testing.T) {
+		is := is.New(t)
+		c, err := Encode("https://lists.sh/p/Ab3xZ")
+		is.NoErr(err)
+		is.Equal(c.version, 2)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Encode(strings.Repeat("x", MaxLength+1))
+		is.Equal(err, ErrTooLong)
+	})
+}
+
+func TestFinders(t *testing.T) {
+	is := is.New(t)
+	c, err := Encode("https://lists.sh/p/Ab3xZ")
+	is.NoErr(err)
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		for d := 0; d < 7; d++ {
+			is.True(c.Dark(corner[0]+d, corner[1]))   // top edge
+			is.True(c.Dark(corner[0], corner[1]+d))   // left edge
+			is.True(c.Dark(corner[0]+3, corner[1]+3)) // center
+		}
+		is.True(!c.Dark(corner[0]+1, corner[1]+1))
+	}
+}
+
+func TestImage(t *testing.T) {
+	is := is.New(t)
+	var b bytes.Buffer
+	is.NoErr(WritePNG(&b, "https://lists.sh/p/Ab3xZ", 4))
+	img, err := png.Decode(&b)
+	is.NoErr(err)
+	is.Equal(img.Bounds().Dx(), (25+2*quiet)*4)
+	r, _, _, _ := img.At(0, 0).RGBA()
+	is.Equal(r, uint32(0xffff)) // quiet zone is light
+	r, _, _, _ = img.At(quiet*4, quiet*4).RGBA()
+	is.Equal(r, uint32(0)) // finder corner is dark
+}
+
+func TestTerminal(t *testing.T) {
+	is := is.New(t)
+	c, err := Encode("https://lists.sh/p/Ab3xZ")
+	is.NoErr(err)
+	lines := strings.Split(c.Terminal(), "\n")
+	is.Equal(len(lines), (c.Size+2*quiet+1)/2)
+	is.Equal(len([]rune(lines[0])), c.Size+2*quiet)
+}
@@ -0,0 +1,198 @@
+// Package relme checks the links users list at the top of their blog.  The
+// blog links to each with rel="me", and a link is verified once the page it
+// points at links back to the blog with rel="me" too, the way Mastodon
+// verifies profile links.  That makes the blog usable as an identity for
+// IndieAuth.  Saving links in Settings queues a VerifyJob for each, which
+// the web server runs.
+package relme
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/jobs"
+)
+
+// VerifyJob is the kind of job that checks a profile link for a link back.
+const VerifyJob = "verify_profile_link"
+
+// maxPage is how much of a page is read looking for links back, profile
+// pages put them near the top.
+const maxPage = 512 * 1024
+
+// VerifyPayload is a VerifyJob's payload.
+type VerifyPayload struct {
+	LinkID string `json:"link_id"`
+}
+
+// client only connects to public addresses, the links are typed in by
+// users and mustn't reach the servers next to this one.
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: publicOnly,
+		}).DialContext,
+	},
+}
+
+// publicOnly refuses connections to loopback, private and link local
+// addresses, checked after DNS so a name can't point somewhere internal.
+func publicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return fmt.Errorf("%s is not a public address", host)
+	}
+	return nil
+}
+
+// Enqueue checks each of the links again.
+func Enqueue(dbpool db.DB, links []*db.ProfileLink) error {
+	for _, link := range links {
+		if err := jobs.Enqueue(dbpool, VerifyJob, VerifyPayload{LinkID: link.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyHandler runs VerifyJobs.  A page that can't be fetched is tried
+// again later, one without a link back is marked unverified.
+func VerifyHandler(dbpool db.DB) jobs.Handler {
+	return func(payload []byte) error {
+		var p VerifyPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		link, err := dbpool.FindProfileLink(p.LinkID)
+		if err != nil {
+			// Removed since the job was queued.
+			return nil
+		}
+		user, err := dbpool.User(link.UserID)
+		if err != nil {
+			return err
+		}
+
+		verified, err := Verify(client, link.URL, config.Current().URL(user.Name))
+		if err != nil {
+			return err
+		}
+		return dbpool.SetProfileLinkChecked(link.ID, verified, time.Now())
+	}
+}
+
+// Verify fetches the page at link and reports whether it links back to
+// profileURL with rel="me".
+func Verify(client *http.Client, link string, profileURL string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "lists.sh rel=me verification")
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 500 {
+		return false, fmt.Errorf("%s answered %s", link, res.Status)
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	page, err := io.ReadAll(io.LimitReader(res.Body, maxPage))
+	if err != nil {
+		return false, err
+	}
+	for _, me := range Links(string(page), res.Request.URL) {
+		if SameProfile(me, profileURL) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var (
+	tag  = regexp.MustCompile(`(?i)<(?:a|link)\s[^>]*>`)
+	attr = regexp.MustCompile(`(?i)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Links returns the rel="me" links of an HTML page at base, made absolute.
+func Links(page string, base *url.URL) []string {
+	var links []string
+	for _, t := range tag.FindAllString(page, -1) {
+		var rel, href string
+		for _, m := range attr.FindAllStringSubmatch(t, -1) {
+			value := html.UnescapeString(m[2] + m[3] + m[4])
+			switch strings.ToLower(m[1]) {
+			case "rel":
+				rel = value
+			case "href":
+				href = value
+			}
+		}
+		if href == "" || !hasToken(rel, "me") {
+			continue
+		}
+		u, err := base.Parse(href)
+		if err != nil {
+			continue
+		}
+		links = append(links, u.String())
+	}
+	return links
+}
+
+// SameProfile reports whether link points at profileURL, either scheme and
+// a trailing slash or not.
+func SameProfile(link string, profileURL string) bool {
+	a, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(profileURL)
+	if err != nil {
+		return false
+	}
+	return (a.Scheme == "http" || a.Scheme == "https") &&
+		strings.EqualFold(a.Host, b.Host) &&
+		strings.TrimSuffix(a.Path, "/") == strings.TrimSuffix(b.Path, "/")
+}
+
+// Label is how a link is shown: its host and path without the scheme,
+// "www." or a trailing slash.
+func Label(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	return strings.TrimPrefix(u.Host, "www.") + strings.TrimSuffix(u.Path, "/")
+}
+
+func hasToken(list string, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package relme
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLinks(t *testing.T) {
+	is := is.New(t)
+	base, _ := url.Parse("https://mastodon.example/@erock")
+	page := `<html><head>
+<link rel="me" href="https://lists.sh/erock">
+<link rel="stylesheet" href="/main.css">
+</head><body>
+<a href="https://erock.io" rel="nofollow noopener me" target="_blank">erock.io</a>
+<a rel='me' href='/@other'>other</a>
+<a href="https://lists.sh/someone">not me</a>
+<A REL=ME HREF=https://github.com/neurosnap>GitHub</A>
+<a rel="me" href="https://example.com/?a=1&amp;b=2">escaped</a>
+</body></html>`
+	is.Equal(Links(page, base), []string{
+		"https://lists.sh/erock",
+		"https://erock.io",
+		"https://mastodon.example/@other",
+		"https://github.com/neurosnap",
+		"https://example.com/?a=1&b=2",
+	})
+}
+
+func TestSameProfile(t *testing.T) {
+	is := is.New(t)
+	is.True(SameProfile("https://lists.sh/erock", "https://lists.sh/erock"))
+	is.True(SameProfile("http://Lists.sh/erock/", "https://lists.sh/erock"))
+	is.True(!SameProfile("https://lists.sh/erock/tacos", "https://lists.sh/erock"))
+	is.True(!SameProfile("https://evil.example/erock", "https://lists.sh/erock"))
+	is.True(!SameProfile("javascript://lists.sh/erock", "https://lists.sh/erock"))
+}
+
+func TestLabel(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Label("https://www.github.com/neurosnap/"), "github.com/neurosnap")
+	is.Equal(Label("https://mastodon.social/@erock"), "mastodon.social/@erock")
+	is.Equal(Label("not a link"), "not a link")
+}
+
+func TestVerify(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/linked", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a rel="me" href="https://lists.sh/erock">my lists</a>`)
+	})
+	mux.HandleFunc("/unlinked", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="https://lists.sh/erock">my lists</a>`)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/linked", http.StatusFound)
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("links back", func(t *testing.T) {
+		is := is.New(t)
+		ok, err := Verify(srv.Client(), srv.URL+"/linked", "https://lists.sh/erock")
+		is.NoErr(err)
+		is.True(ok)
+
+		ok, err = Verify(srv.Client(), srv.URL+"/moved", "https://lists.sh/erock")
+		is.NoErr(err)
+		is.True(ok)
+	})
+
+	t.Run("no link back", func(t *testing.T) {
+		is := is.New(t)
+		ok, err := Verify(srv.Client(), srv.URL+"/unlinked", "https://lists.sh/erock")
+		is.NoErr(err)
+		is.True(!ok)
+
+		ok, err = Verify(srv.Client(), srv.URL+"/missing", "https://lists.sh/erock")
+		is.NoErr(err)
+		is.True(!ok)
+	})
+
+	t.Run("tries again later when the site is down", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Verify(srv.Client(), srv.URL+"/down", "https://lists.sh/erock")
+		is.True(err != nil)
+	})
+}
+
+func TestPublicOnly(t *testing.T) {
+	is := is.New(t)
+	is.True(publicOnly("tcp", "127.0.0.1:80", nil) != nil)
+	is.True(publicOnly("tcp", "10.0.0.2:443", nil) != nil)
+	is.True(publicOnly("tcp", "[::1]:443", nil) != nil)
+	is.True(publicOnly("tcp", "169.254.169.254:80", nil) != nil)
+	is.NoErr(publicOnly("tcp", "93.184.216.34:443", nil))
+}
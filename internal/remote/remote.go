@@ -0,0 +1,95 @@
+// Package remote lets people edit posts with their own editor by piping a
+// post out of and back into an ssh session:
+//
+//	ssh lists.sh cat hello-world > hello-world.txt
+//	vim hello-world.txt
+//	ssh lists.sh put hello-world < hello-world.txt
+package remote
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/scp"
+)
+
+// IsCommand reports whether cmd is handled by this package.
+func IsCommand(cmd []string) bool {
+	return len(cmd) > 0 && (cmd[0] == "cat" || cmd[0] == "put")
+}
+
+// Middleware handles `ssh lists.sh cat <post>` and `ssh lists.sh put <post>`.
+func Middleware(dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if !IsCommand(cmd) {
+				sh(s)
+				return
+			}
+
+			if len(cmd) != 2 {
+				internal.ErrHandler(s, fmt.Errorf("usage: ssh lists.sh %s <post>", cmd[0]))
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			filename := internal.SanitizeFileExt(cmd[1])
+			if cmd[0] == "cat" {
+				err = cat(s, dbpool, user, filename)
+			} else {
+				err = put(s, dbpool, user, filename)
+			}
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+func cat(s ssh.Session, dbpool db.DB, user *db.User, filename string) error {
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || post.DeletedAt != nil {
+		return fmt.Errorf("post %q not found", filename)
+	}
+	_, err = io.WriteString(s, post.Text)
+	return err
+}
+
+func put(s ssh.Session, dbpool db.DB, user *db.User, filename string) error {
+	b, err := io.ReadAll(io.LimitReader(s, scp.MaxPostSize+1))
+	if err != nil {
+		return err
+	}
+	if len(b) > scp.MaxPostSize {
+		return fmt.Errorf("%s is larger than %d bytes", filename, scp.MaxPostSize)
+	}
+
+	text, charset, err := internal.DecodeString(string(b))
+	if err != nil {
+		return fmt.Errorf("%s %v", filename, err)
+	}
+	if charset != internal.CharsetUTF8 {
+		_, _ = fmt.Fprintf(s.Stderr(), "converted %s from %s to UTF-8\n", filename, charset)
+	}
+
+	logger := internal.SessionLogger(s)
+	post, err := scp.SavePost(logger, s.Stderr(), dbpool, user, filename+".txt", text)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(s.Stderr(), "saved /%s/%s\n", user.Name, post.Filename)
+	return nil
+}
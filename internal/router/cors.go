@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// corsMethods are the only ones other sites can call, every route they
+// answer is read-only.
+const corsMethods = "GET, HEAD"
+
+// CORS lets browser clients and widgets on the allowed origins read the
+// GET routes, "*" allows any origin and an empty list turns it off. Posting
+// forms like the abuse report never gets CORS headers.
+func CORS(origins []string, next ServeFn) ServeFn {
+	anyOrigin := slices.Contains(origins, "*")
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The answer depends on the origin whenever only some are allowed,
+		// including when there's none or it's turned away, so caches have
+		// to keep the responses apart.
+		if len(origins) > 0 && !anyOrigin {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		origin := r.Header.Get("Origin")
+		allowed := allowOrigin(origins, origin)
+		if allowed == "" {
+			next(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !preflight {
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", allowed)
+
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", "X-Request-Id")
+			next(w, r)
+			return
+		}
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if method != http.MethodGet && method != http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.Set("Access-Control-Allow-Methods", corsMethods)
+		h.Set("Access-Control-Allow-Headers", "Accept, Accept-Language")
+		h.Set("Access-Control-Max-Age", "86400")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or
+// "" when it isn't allowed.
+func allowOrigin(origins []string, origin string) string {
+	if origin == "" {
+		return ""
+	}
+	if slices.Contains(origins, "*") {
+		return "*"
+	}
+	if slices.Contains(origins, strings.ToLower(origin)) {
+		return origin
+	}
+	return ""
+}
@@ -4,14 +4,38 @@ import (
 	"context"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/neurosnap/lists.sh/internal"
 	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/metrics"
 	"go.uber.org/zap"
 )
 
+var (
+	requestsTotal = metrics.NewCounter(
+		"lists_http_requests_total",
+		"HTTP requests by route pattern and status code.",
+		"route",
+		"code",
+	)
+	requestDuration = metrics.NewHistogram(
+		"lists_http_request_duration_seconds",
+		"Latency of HTTP requests by route pattern.",
+		nil,
+		"route",
+	)
+)
+
+// requestIDRe is what a request id passed in by a client has to look like
+// to end up in the logs and the response, anything else gets a fresh one.
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+
 type Route struct {
 	method  string
+	pattern string
 	regex   *regexp.Regexp
 	handler http.HandlerFunc
 }
@@ -19,14 +43,34 @@ type Route struct {
 func NewRoute(method, pattern string, handler http.HandlerFunc) Route {
 	return Route{
 		method,
+		pattern,
 		regexp.MustCompile("^" + pattern + "$"),
 		handler,
 	}
 }
 
+// Pattern is the regular expression the route matches paths with.
+func (r Route) Pattern() string {
+	return r.pattern
+}
+
+// statusWriter remembers the status code so it can be reported as a metric.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
 type ServeFn func(http.ResponseWriter, *http.Request)
 
-func CreateServe(routes []Route, dbpool db.DB, logger *zap.SugaredLogger) ServeFn {
+// CreateServe dispatches requests to the first route matching the path.
+// Paths no route matches go to notFound, which gets the same request context
+// as the routes, without any fields.
+func CreateServe(routes []Route, notFound http.HandlerFunc, dbpool db.DB, logger *zap.SugaredLogger) ServeFn {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var allow []string
 		for _, route := range routes {
@@ -36,10 +80,7 @@ func CreateServe(routes []Route, dbpool db.DB, logger *zap.SugaredLogger) ServeF
 					allow = append(allow, route.method)
 					continue
 				}
-				loggerCtx := context.WithValue(r.Context(), ctxLoggerKey{}, logger)
-				dbCtx := context.WithValue(loggerCtx, ctxDBKey{}, dbpool)
-				ctx := context.WithValue(dbCtx, ctxKey{}, matches[1:])
-				route.handler(w, r.WithContext(ctx))
+				serve(w, r, route.pattern, route.handler, matches[1:], dbpool, logger)
 				return
 			}
 		}
@@ -48,10 +89,38 @@ func CreateServe(routes []Route, dbpool db.DB, logger *zap.SugaredLogger) ServeF
 			http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-		http.NotFound(w, r)
+		serve(w, r, "(unmatched)", notFound, nil, dbpool, logger)
 	}
 }
 
+// serve runs handler with the logger, database and path fields in the
+// request context and records how it went.
+func serve(w http.ResponseWriter, r *http.Request, pattern string, handler http.HandlerFunc, fields []string, dbpool db.DB, logger *zap.SugaredLogger) {
+	requestID := r.Header.Get("X-Request-Id")
+	if !requestIDRe.MatchString(requestID) {
+		requestID = internal.NewID()
+	}
+	w.Header().Set("X-Request-Id", requestID)
+	reqLogger := logger.With("request_id", requestID)
+
+	loggerCtx := context.WithValue(r.Context(), ctxLoggerKey{}, reqLogger)
+	dbCtx := context.WithValue(loggerCtx, ctxDBKey{}, dbpool)
+	ctx := context.WithValue(dbCtx, ctxKey{}, fields)
+
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	handler(sw, r.WithContext(ctx))
+	requestDuration.Since(start, pattern)
+	requestsTotal.Inc(pattern, strconv.Itoa(sw.status))
+	reqLogger.Infow(
+		"request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", sw.status,
+		"duration", time.Since(start).String(),
+	)
+}
+
 type ctxDBKey struct{}
 type ctxKey struct{}
 type ctxLoggerKey struct{}
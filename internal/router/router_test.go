@@ -0,0 +1,148 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"go.uber.org/zap"
+)
+
+func TestCreateServe(t *testing.T) {
+	routes := []Route{
+		NewRoute("GET", "/([^/]+)", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("blog " + GetField(r, 0)))
+		}),
+	}
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		GetLogger(r).Info("not found")
+		http.Error(w, "custom 404", http.StatusNotFound)
+	}
+	serve := CreateServe(routes, notFound, nil, zap.NewNop().Sugar())
+
+	t.Run("matching route", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		serve(w, httptest.NewRequest("GET", "/erock", nil))
+		is.Equal(w.Code, http.StatusOK)
+		is.Equal(w.Body.String(), "blog erock")
+	})
+
+	t.Run("unmatched paths go to the not found handler", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		serve(w, httptest.NewRequest("GET", "/erock/a/b/c", nil))
+		is.Equal(w.Code, http.StatusNotFound)
+		is.Equal(w.Body.String(), "custom 404\n")
+		is.True(w.Header().Get("X-Request-Id") != "")
+	})
+
+	t.Run("request ids", func(t *testing.T) {
+		is := is.New(t)
+		for id, kept := range map[string]bool{
+			"b7e2c1d0-4f3a-4d8e-9c1b-2a6f5e3d7c90": true,
+			"abc123":                               true,
+			"":                                     false,
+			"has spaces":                           false,
+			"line\nbreak":                          false,
+			"<script>":                             false,
+			strings.Repeat("a", 65):                false,
+		} {
+			r := httptest.NewRequest("GET", "/erock", nil)
+			r.Header.Set("X-Request-Id", id)
+			w := httptest.NewRecorder()
+			serve(w, r)
+			got := w.Header().Get("X-Request-Id")
+			is.True(got != "")
+			is.Equal(got == id, kept) // only well formed ids are kept
+		}
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		serve(w, httptest.NewRequest("POST", "/erock", nil))
+		is.Equal(w.Code, http.StatusMethodNotAllowed)
+		is.Equal(w.Header().Get("Allow"), "GET")
+	})
+}
+
+func TestCORS(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}
+	request := func(method string, origin string) *http.Request {
+		r := httptest.NewRequest(method, "/erock/rss", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	t.Run("any origin", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		CORS([]string{"*"}, ok)(w, request("GET", "https://widget.example.com"))
+		is.Equal(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		is.Equal(w.Header().Get("Vary"), "") // the same for everyone
+		is.Equal(w.Body.String(), "ok")
+	})
+
+	t.Run("listed origins", func(t *testing.T) {
+		is := is.New(t)
+		serve := CORS([]string{"https://widget.example.com"}, ok)
+
+		w := httptest.NewRecorder()
+		serve(w, request("GET", "https://Widget.example.com"))
+		is.Equal(w.Header().Get("Access-Control-Allow-Origin"), "https://Widget.example.com")
+		is.Equal(w.Header().Get("Vary"), "Origin")
+
+		w = httptest.NewRecorder()
+		serve(w, request("GET", "https://evil.example.com"))
+		is.Equal(w.Header().Get("Access-Control-Allow-Origin"), "")
+		is.Equal(w.Header().Get("Vary"), "Origin")
+		is.Equal(w.Body.String(), "ok")
+
+		w = httptest.NewRecorder()
+		serve(w, request("GET", ""))
+		is.Equal(w.Header().Get("Vary"), "Origin")
+
+		w = httptest.NewRecorder()
+		serve(w, request("POST", "https://widget.example.com"))
+		is.Equal(w.Header().Get("Vary"), "Origin")
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		is := is.New(t)
+		serve := CORS([]string{"*"}, ok)
+
+		r := request("OPTIONS", "https://widget.example.com")
+		r.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		serve(w, r)
+		is.Equal(w.Code, http.StatusNoContent)
+		is.Equal(w.Header().Get("Access-Control-Allow-Methods"), "GET, HEAD")
+
+		r = request("OPTIONS", "https://widget.example.com")
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		w = httptest.NewRecorder()
+		serve(w, r)
+		is.Equal(w.Code, http.StatusForbidden)
+	})
+
+	t.Run("no cors for posts", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		CORS([]string{"*"}, ok)(w, request("POST", "https://widget.example.com"))
+		is.Equal(w.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+
+	t.Run("turned off", func(t *testing.T) {
+		is := is.New(t)
+		w := httptest.NewRecorder()
+		CORS(nil, ok)(w, request("GET", "https://widget.example.com"))
+		is.Equal(w.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+}
@@ -0,0 +1,77 @@
+package scp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// blogUser returns who an upload to dir is saved as: the account itself, or
+// one of the blogs it publishes to when dir names one.  A blog that doesn't
+// exist yet is started, as long as the account has room for another.  Blogs
+// carry the account's key so savePost can credit it as the author.
+func blogUser(out io.Writer, dbpool db.DB, user *db.User, dir string) (*db.User, error) {
+	name, err := internal.BlogFromPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" || name == user.Name {
+		return user, nil
+	}
+
+	blogs, err := dbpool.FindBlogs(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	started := 0
+	for _, blog := range blogs {
+		if blog.Name == name {
+			return AsBlog(dbpool, user, blog.UserID)
+		}
+		if blog.OwnerID == user.ID {
+			started++
+		}
+	}
+
+	limit := config.Current().Quota.MaxBlogs
+	if limit > 0 && started >= limit {
+		return nil, fmt.Errorf("you've reached the limit of %d blogs besides your own", limit)
+	}
+	blog, err := dbpool.AddBlog(user.ID, name)
+	if errors.Is(err, db.ErrNameTaken) {
+		return nil, fmt.Errorf("%q is already taken, pick another blog name", name)
+	} else if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(out, "NOTICE: started the blog %s at %s\n", name, config.Current().URL(name))
+	return AsBlog(dbpool, user, blog.UserID)
+}
+
+// AsBlog loads the blog's user to save posts as on behalf of account, with
+// the account's key so they're credited to it (see authorID).
+func AsBlog(dbpool db.DB, account *db.User, blogUserID string) (*db.User, error) {
+	blog, err := dbpool.User(blogUserID)
+	if err != nil {
+		return nil, err
+	}
+	blog.PublicKey = &db.PublicKey{UserID: account.ID}
+	if account.PublicKey != nil {
+		pk := *account.PublicKey
+		pk.UserID = account.ID
+		blog.PublicKey = &pk
+	}
+	return blog, nil
+}
+
+// authorID is the account that saves as user: someone else's when user is
+// a blog they publish to, empty when it's their own.
+func authorID(user *db.User) string {
+	if user.PublicKey == nil || user.PublicKey.UserID == user.ID {
+		return ""
+	}
+	return user.PublicKey.UserID
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gliderlabs/ssh"
 	"github.com/neurosnap/lists.sh/internal"
 	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/metrics"
 )
 
 var (
@@ -25,12 +26,17 @@ type parseError struct {
 	subject string
 }
 
+var parseErrorsTotal = metrics.NewCounter(
+	"lists_scp_parse_errors_total",
+	"scp sessions aborted because the protocol stream could not be parsed.",
+)
+
 func (e parseError) Error() string {
 	return fmt.Sprintf("failed to parse: %q", e.subject)
 }
 
 func copyFromClient(s ssh.Session, info Info, handler CopyFromClientHandler, user *db.User, dbpool db.DB) error {
-	logger := internal.CreateLogger()
+	logger := internal.SessionLogger(s)
 	// accepts the request
 	_, _ = s.Write(NULL)
 
@@ -86,6 +92,7 @@ func copyFromClient(s ssh.Session, info Info, handler CopyFromClientHandler, use
 			// accepts the header
 			_, _ = s.Write(NULL)
 
+			reader := newLimitReader(r, int(size))
 			err = handler.Write(s, &FileEntry{
 				Name:     name,
 				Filepath: filepath.Join(path, name),
@@ -93,8 +100,12 @@ func copyFromClient(s ssh.Session, info Info, handler CopyFromClientHandler, use
 				Size:     size,
 				Mtime:    mtime,
 				Atime:    atime,
-				Reader:   newLimitReader(r, int(size)),
+				Reader:   reader,
 			}, user, dbpool)
+			// Whatever the handler didn't read, like the rest of a file it
+			// turned down for being too large, is skipped a chunk at a time
+			// for the next file in the transfer to line up.
+			_, _ = io.Copy(io.Discard, reader)
 
 			if err != nil {
 				writeErrors = append(writeErrors, err)
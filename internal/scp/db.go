@@ -1,16 +1,45 @@
 package scp
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gliderlabs/ssh"
 	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+	"github.com/neurosnap/lists.sh/internal/metrics"
+	"github.com/neurosnap/lists.sh/internal/spam"
+	"github.com/neurosnap/lists.sh/internal/spellcheck"
 	"github.com/neurosnap/lists.sh/pkg"
+	"go.uber.org/zap"
 )
 
+var (
+	uploadsTotal = metrics.NewCounter(
+		"lists_uploads_total",
+		"Files received over scp by outcome (created, updated, rejected, failed).",
+		"result",
+	)
+	spamTotal = metrics.NewCounter(
+		"lists_spam_flagged_total",
+		"Uploads held for review by the spam check by action (flagged, quarantined).",
+		"action",
+	)
+)
+
+// MaxPostSize is the largest post we accept, in bytes.
+const MaxPostSize = 1024 * 1024
+
+// headerFilename is the post whose title and description become the display
+// name and bio at the top of the blog.
+const headerFilename = "_header"
+
 type Opener struct {
 	entry *FileEntry
 }
@@ -22,26 +51,109 @@ func (o *Opener) Open(name string) (io.Reader, error) {
 type DbHandler struct{}
 
 func (h *DbHandler) Write(s ssh.Session, entry *FileEntry, user *db.User, dbpool db.DB) error {
-	logger := internal.CreateLogger()
+	name := filepath.Base(entry.Filepath)
+	if entry.Name != name {
+		uploadsTotal.Inc("rejected")
+		return fmt.Errorf("WARNING: (%s) filenames can't contain a path, skipping", entry.Name)
+	}
+	if entry.Size > MaxPostSize {
+		uploadsTotal.Inc("rejected")
+		return fmt.Errorf("WARNING: (%s) is larger than %d bytes, skipping", name, MaxPostSize)
+	}
+
+	reader, charset, err := internal.DecodeText(entry.Reader)
+	if err != nil {
+		uploadsTotal.Inc("rejected")
+		return fmt.Errorf("WARNING: (%s) %v, skipping", name, err)
+	}
+
+	parsedText, text, err := pkg.ParseReader(reader, MaxPostSize)
+	if errors.Is(err, pkg.ErrTooLarge) {
+		uploadsTotal.Inc("rejected")
+		return fmt.Errorf("WARNING: (%s) is larger than %d bytes, skipping", name, MaxPostSize)
+	}
+	if err != nil {
+		uploadsTotal.Inc("failed")
+		return fmt.Errorf("error for %s: %v", name, err)
+	}
+
+	if charset != internal.CharsetUTF8 {
+		_, _ = fmt.Fprintf(s.Stderr(), "NOTICE: (%s) converted from %s to UTF-8\n", name, charset)
+	}
+
+	_, err = savePost(internal.SessionLogger(s), s.Stderr(), dbpool, user, entry.Filepath, text, parsedText)
+	return err
+}
+
+// SavePost validates and stores a post exactly like an scp upload would,
+// writing any notices about the post to out.  The editor in the TUI saves
+// through here so both paths stay in sync.
+func SavePost(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, user *db.User, path string, text string) (*db.Post, error) {
+	return savePost(logger, out, dbpool, user, path, text, pkg.ParseText(text))
+}
+
+func savePost(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, user *db.User, path string, text string, parsedText *pkg.ParsedText) (*db.Post, error) {
+	// Posts are stored the same whatever OS they were written on, so
+	// re-uploading from another machine isn't an edit.
+	if normalized := pkg.NormalizeText(text); normalized != text {
+		text = normalized
+		parsedText = pkg.ParseText(text)
+	}
+
 	userID := user.ID
-	filename := internal.SanitizeFileExt(entry.Name)
+	name := filepath.Base(path)
+	filename := internal.SanitizeFileExt(name)
 	title := filename
 	post, err := dbpool.FindPostWithFilename(filename, userID)
 
-	var text string
-	if b, err := io.ReadAll(entry.Reader); err == nil {
-		text = string(b)
+	if err := internal.CheckTextFile(text, path); err != nil {
+		uploadsTotal.Inc("rejected")
+		return nil, fmt.Errorf("WARNING: (%s) invalid file, %v, skipping", name, err)
+	}
+
+	// Posts from before filenames were checked can still be updated.
+	if post == nil {
+		if err := internal.ValidateFilename(filename); err != nil {
+			uploadsTotal.Inc("rejected")
+			return nil, fmt.Errorf("WARNING: (%s) invalid filename, %v, skipping", name, err)
+		}
 	}
 
-	if !internal.IsTextFile(text, entry.Filepath) {
-		return fmt.Errorf("WARNING: (%s) invalid file, format must be '.txt' and the contents must be plain text, skipping", entry.Name)
+	usage, err := dbpool.FindUserUsage(userID)
+	if err != nil {
+		uploadsTotal.Inc("failed")
+		return nil, fmt.Errorf("error for %s: %v", name, err)
+	}
+	if err := checkQuota(config.Current().Quota, usage, post, text); err != nil {
+		uploadsTotal.Inc("rejected")
+		return nil, fmt.Errorf("WARNING: (%s) %v, skipping", name, err)
 	}
 
-	parsedText := pkg.ParseText(text)
 	if parsedText.MetaData.Title != "" {
 		title = parsedText.MetaData.Title
 	}
 	description := parsedText.MetaData.Description
+	if filename == headerFilename {
+		if err := db.ValidateProfile(parsedText.MetaData.Title, description); err != nil {
+			uploadsTotal.Inc("rejected")
+			return nil, fmt.Errorf("WARNING: (%s) %v, skipping", name, err)
+		}
+	}
+
+	if err := checkUsers(dbpool, parsedText.MetaData.Authors, "authors"); err != nil {
+		uploadsTotal.Inc("rejected")
+		return nil, fmt.Errorf("WARNING: (%s) %v, skipping", name, err)
+	}
+	emailReaders := flags.Enabled(config.Current().Flags, dbpool, userID, flags.EmailReaders)
+	if err := checkReaders(dbpool, parsedText.MetaData.Readers, emailReaders); err != nil {
+		uploadsTotal.Inc("rejected")
+		return nil, fmt.Errorf("WARNING: (%s) %v, skipping", name, err)
+	}
+
+	if post == nil && skipDuplicate(logger, out, dbpool, userID, filename, text) {
+		uploadsTotal.Inc("rejected")
+		return nil, fmt.Errorf("WARNING: (%s) is the same as another one of your posts, skipping", name)
+	}
 
 	if post == nil {
 		publishAt := time.Now()
@@ -51,7 +163,17 @@ func (h *DbHandler) Write(s ssh.Session, entry *FileEntry, user *db.User, dbpool
 		logger.Infof("%s not found, adding record", title)
 		post, err = dbpool.InsertPost(userID, filename, title, text, description, &publishAt)
 		if err != nil {
-			return fmt.Errorf("error for %s: %v", title, err)
+			uploadsTotal.Inc("failed")
+			return nil, fmt.Errorf("error for %s: %v", title, err)
+		}
+		uploadsTotal.Inc("created")
+		if err := dbpool.RecordActivity(userID, db.ActivityPost); err != nil {
+			logger.Error(err)
+		}
+		if author := authorID(user); author != "" {
+			if err := dbpool.SetPostAuthor(post.ID, author); err != nil {
+				logger.Error(err)
+			}
 		}
 	} else {
 		publishAt := post.PublishAt
@@ -61,9 +183,227 @@ func (h *DbHandler) Write(s ssh.Session, entry *FileEntry, user *db.User, dbpool
 		logger.Infof("%s found, updating record", title)
 		post, err = dbpool.UpdatePost(post.ID, title, text, description, publishAt)
 		if err != nil {
-			return fmt.Errorf("error for %s: %v", title, err)
+			uploadsTotal.Inc("failed")
+			return nil, fmt.Errorf("error for %s: %v", title, err)
+		}
+		uploadsTotal.Inc("updated")
+		if err := dbpool.RecordActivity(userID, db.ActivityEdit); err != nil {
+			logger.Error(err)
 		}
 	}
 
+	spamCheck(logger, out, dbpool, config.Current().Spam, post, text, parsedText)
+	spellcheckReport(out, dbpool, post, parsedText)
+	if filename == headerFilename {
+		syncProfile(logger, out, dbpool, user, parsedText)
+	}
+
+	return post, nil
+}
+
+// skipDuplicate warns about a new post that's word for word the same as
+// another of the user's posts, usually from a sloppy scp glob, and reports
+// whether the user would rather skip those.
+func skipDuplicate(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, userID string, filename string, text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+	// Not finding one is the usual case.
+	duplicate, err := dbpool.FindDuplicatePost(userID, filename, text)
+	if err != nil {
+		return false
+	}
+
+	settings, err := dbpool.FindUserSettings(userID)
+	if err != nil {
+		logger.Error(err)
+		settings = db.DefaultUserSettings()
+	}
+	if settings.Duplicates == db.DuplicatesSkip {
+		return true
+	}
+
+	_, _ = fmt.Fprintf(
+		out,
+		"NOTICE: (%s) is the same as your post %s, delete one of them if that's a mistake\n",
+		filename,
+		duplicate.Filename,
+	)
+	return false
+}
+
+// syncProfile copies the title and description of the header post to the
+// user's display name and bio, which can also be edited from the settings.
+func syncProfile(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, user *db.User, parsedText *pkg.ParsedText) {
+	displayName := parsedText.MetaData.Title
+	bio := parsedText.MetaData.Description
+	err := dbpool.SetUserProfile(user.ID, displayName, bio)
+	if err != nil {
+		logger.Error(err)
+		_, _ = fmt.Fprintf(out, "WARNING: (%s) the blog header could not be updated: %v\n", headerFilename, err)
+		return
+	}
+	user.DisplayName = displayName
+	user.Bio = bio
+}
+
+// checkQuota rejects a save that would take the account past its quota.
+// existing is the post being replaced, nil for a new one.
+func checkQuota(quota config.QuotaConfig, usage *db.Usage, existing *db.Post, text string) error {
+	posts, bytes := usage.Posts, usage.Bytes+len(text)
+	if existing == nil {
+		posts++
+	} else {
+		bytes -= len(existing.Text)
+	}
+
+	if quota.MaxPosts > 0 && existing == nil && posts > quota.MaxPosts {
+		return fmt.Errorf("you've reached the limit of %d posts, delete some and empty the trash to make room", quota.MaxPosts)
+	}
+	if quota.MaxBytes > 0 && bytes > quota.MaxBytes && bytes > usage.Bytes {
+		return fmt.Errorf("this would take your posts past the %d MB storage limit", quota.MaxBytes/(1024*1024))
+	}
 	return nil
 }
+
+// maxPostUsers caps how many people a post can credit as authors or share
+// with as readers.
+const maxPostUsers = 10
+
+// checkUsers makes sure everyone a post names in its authors or readers has
+// an account.
+func checkUsers(dbpool db.DB, names []string, role string) error {
+	if len(names) > maxPostUsers {
+		return fmt.Errorf("a post can have at most %d %s", maxPostUsers, role)
+	}
+	for _, name := range names {
+		if _, err := dbpool.UserForName(name); err != nil {
+			return fmt.Errorf("no user called %q to add to the %s", name, role)
+		}
+	}
+	return nil
+}
+
+// checkReaders is checkUsers for a post's readers, who can also be the
+// email addresses of people without an account when emails is set.
+func checkReaders(dbpool db.DB, readers []string, emails bool) error {
+	if len(readers) > maxPostUsers {
+		return fmt.Errorf("a post can have at most %d readers", maxPostUsers)
+	}
+	names := []string{}
+	for _, reader := range readers {
+		if !strings.Contains(reader, "@") {
+			names = append(names, reader)
+			continue
+		}
+		if !emails {
+			return fmt.Errorf("can't add %q to the readers, email addresses aren't available for your account yet", reader)
+		}
+		if err := db.ValidateEmail(reader); err != nil {
+			return fmt.Errorf("can't add %q to the readers: %v", reader, err)
+		}
+	}
+	return checkUsers(dbpool, names, "readers")
+}
+
+// quarantinePrefix starts the hidden reason of posts the spam check hid.
+const quarantinePrefix = "quarantined: "
+
+// spamCheck keeps suspicious posts off the discovery feed until an admin
+// approves them.  Posts that link to banned domains are hidden entirely.
+// A post that passes again once it's fixed is let back out, unless an admin
+// hid it.
+func spamCheck(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, cfg config.SpamConfig, post *db.Post, text string, parsedText *pkg.ParsedText) {
+	if !cfg.Enabled || strings.HasPrefix(post.Filename, "_") {
+		return
+	}
+
+	duplicates, err := dbpool.CountDuplicatePosts(post.UserID, text)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	verdict := spam.NewChecker(cfg).Check(text, parsedText, duplicates)
+	if !verdict.Flagged() {
+		clearSpamFlag(logger, out, dbpool, post)
+		return
+	}
+
+	reason := verdict.Reason()
+	logger.Infow("post flagged as spam", "post_id", post.ID, "reason", reason)
+	err = dbpool.FlagPost(post.ID, reason)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	action := "flagged"
+	held := "left off the discovery feed"
+	if verdict.Quarantine && post.HiddenAt == nil {
+		action = "quarantined"
+		held = "hidden"
+		err = dbpool.HidePost(post.ID, quarantinePrefix+reason)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+	spamTotal.Inc(action)
+
+	_, _ = fmt.Fprintf(
+		out,
+		"NOTICE: (%s) %s until an admin reviews it: %s\n",
+		post.Filename,
+		held,
+		reason,
+	)
+}
+
+// clearSpamFlag lets a post that used to be held back by spamCheck out
+// again.
+func clearSpamFlag(logger *zap.SugaredLogger, out io.Writer, dbpool db.DB, post *db.Post) {
+	quarantined := post.HiddenAt != nil && strings.HasPrefix(post.HiddenReason, quarantinePrefix)
+	if post.FlaggedReason == "" && !quarantined {
+		return
+	}
+
+	logger.Infow("post no longer flagged as spam", "post_id", post.ID)
+	if err := dbpool.FlagPost(post.ID, ""); err != nil {
+		logger.Error(err)
+		return
+	}
+	if quarantined {
+		if err := dbpool.UnhidePost(post.ID); err != nil {
+			logger.Error(err)
+			return
+		}
+	}
+	_, _ = fmt.Fprintf(out, "NOTICE: (%s) passes the spam check now and isn't held back anymore\n", post.Filename)
+}
+
+// spellcheckReport lets the user know about likely typos without preventing
+// the post from being published.
+func spellcheckReport(out io.Writer, dbpool db.DB, post *db.Post, parsedText *pkg.ParsedText) {
+	checker := spellcheck.Default()
+	if checker == nil || strings.HasPrefix(post.Filename, "_") {
+		return
+	}
+
+	var userWords []string
+	dict, _ := dbpool.FindPostWithFilename(spellcheck.DictionaryFilename, post.UserID)
+	if dict != nil {
+		userWords = spellcheck.UserWords(dict.Text)
+	}
+
+	report := checker.Check(parsedText.Items, userWords)
+	if len(report.Misspelled) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(
+		out,
+		"spellcheck (%s) %s: %s\n",
+		report.Lang,
+		post.Filename,
+		strings.Join(report.Misspelled, ", "),
+	)
+}
@@ -0,0 +1,167 @@
+package scp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/pkg"
+	"go.uber.org/zap"
+)
+
+func TestCheckQuota(t *testing.T) {
+	quota := config.QuotaConfig{MaxPosts: 2, MaxBytes: 10}
+
+	t.Run("new posts count against the limit", func(t *testing.T) {
+		is := is.New(t)
+		is.NoErr(checkQuota(quota, &db.Usage{Posts: 1, Bytes: 2}, nil, "hi"))
+		is.True(checkQuota(quota, &db.Usage{Posts: 2, Bytes: 2}, nil, "hi") != nil)
+	})
+
+	t.Run("updates only count the difference", func(t *testing.T) {
+		is := is.New(t)
+		existing := &db.Post{Text: "12345678"}
+		is.NoErr(checkQuota(quota, &db.Usage{Posts: 2, Bytes: 8}, existing, "123456789"))
+		is.True(checkQuota(quota, &db.Usage{Posts: 2, Bytes: 8}, existing, strings.Repeat("x", 11)) != nil)
+	})
+
+	t.Run("shrinking a post is allowed over the limit", func(t *testing.T) {
+		is := is.New(t)
+		existing := &db.Post{Text: strings.Repeat("x", 20)}
+		is.NoErr(checkQuota(quota, &db.Usage{Posts: 2, Bytes: 30}, existing, "short"))
+	})
+
+	t.Run("zero means no limit", func(t *testing.T) {
+		is := is.New(t)
+		is.NoErr(checkQuota(config.QuotaConfig{}, &db.Usage{Posts: 1000, Bytes: 1 << 30}, nil, "hi"))
+	})
+}
+
+// authorsDB knows a fixed set of usernames.
+type authorsDB struct {
+	db.DB
+	names []string
+}
+
+func (d *authorsDB) UserForName(name string) (*db.User, error) {
+	for _, n := range d.names {
+		if n == name {
+			return &db.User{Name: n}, nil
+		}
+	}
+	return nil, errors.New("sql: no rows in result set")
+}
+
+func TestCheckUsers(t *testing.T) {
+	dbpool := &authorsDB{names: []string{"erock", "mia"}}
+
+	t.Run("existing accounts", func(t *testing.T) {
+		is := is.New(t)
+		is.NoErr(checkUsers(dbpool, nil, "authors"))
+		is.NoErr(checkUsers(dbpool, []string{"erock", "mia"}, "readers"))
+	})
+
+	t.Run("unknown usernames are rejected", func(t *testing.T) {
+		is := is.New(t)
+		err := checkUsers(dbpool, []string{"mia", "nobody"}, "authors")
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), `"nobody"`))
+	})
+
+	t.Run("too many names", func(t *testing.T) {
+		is := is.New(t)
+		names := make([]string, maxPostUsers+1)
+		for i := range names {
+			names[i] = "mia"
+		}
+		is.True(checkUsers(dbpool, names, "readers") != nil)
+	})
+}
+
+func TestCheckReaders(t *testing.T) {
+	is := is.New(t)
+	dbpool := &authorsDB{names: []string{"erock", "mia"}}
+
+	is.NoErr(checkReaders(dbpool, []string{"mia", "aunt@example.com"}, true))
+	is.True(checkReaders(dbpool, []string{"nobody", "aunt@example.com"}, true) != nil)
+	is.True(checkReaders(dbpool, []string{"aunt@"}, true) != nil)
+
+	// Without the email-readers flag only usernames are allowed.
+	is.NoErr(checkReaders(dbpool, []string{"mia"}, false))
+	is.True(checkReaders(dbpool, []string{"mia", "aunt@example.com"}, false) != nil)
+}
+
+// spamDB keeps the moderation state of a single post the way the database
+// would.
+type spamDB struct {
+	db.DB
+	post *db.Post
+}
+
+func (d *spamDB) CountDuplicatePosts(userID string, text string) (int, error) {
+	return 0, nil
+}
+
+func (d *spamDB) FlagPost(postID string, reason string) error {
+	d.post.FlaggedReason = reason
+	return nil
+}
+
+func (d *spamDB) HidePost(postID string, reason string) error {
+	now := time.Now()
+	d.post.HiddenAt, d.post.HiddenReason = &now, reason
+	return nil
+}
+
+func (d *spamDB) UnhidePost(postID string) error {
+	d.post.HiddenAt, d.post.HiddenReason = nil, ""
+	return nil
+}
+
+func TestSpamCheck(t *testing.T) {
+	cfg := config.SpamConfig{Enabled: true, BannedDomains: []string{"spam.example"}, MinLinks: 2, MaxLinkRatio: 0.5}
+	logger := zap.NewNop().Sugar()
+	clean := "- tacos\n- burritos\n- nachos\n"
+	upload := func(dbpool *spamDB, text string) string {
+		var out bytes.Buffer
+		spamCheck(logger, &out, dbpool, cfg, dbpool.post, text, pkg.ParseText(text))
+		return out.String()
+	}
+
+	t.Run("fixed posts aren't held back anymore", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := &spamDB{post: &db.Post{ID: "1", Filename: "links"}}
+		out := upload(dbpool, "=> https://a.example a\n=> https://b.example b\n=> https://c.example c\n")
+		is.True(strings.Contains(out, "left off the discovery feed"))
+		is.True(dbpool.post.FlaggedReason != "")
+
+		out = upload(dbpool, clean)
+		is.True(strings.Contains(out, "isn't held back anymore"))
+		is.Equal(dbpool.post.FlaggedReason, "")
+	})
+
+	t.Run("quarantined posts come back", func(t *testing.T) {
+		is := is.New(t)
+		dbpool := &spamDB{post: &db.Post{ID: "1", Filename: "banned"}}
+		upload(dbpool, "=> https://spam.example/buy buy now\n- tacos\n- burritos\n")
+		is.True(dbpool.post.HiddenAt != nil)
+
+		upload(dbpool, clean)
+		is.Equal(dbpool.post.FlaggedReason, "")
+		is.Equal(dbpool.post.HiddenAt, nil)
+	})
+
+	t.Run("posts an admin hid stay hidden", func(t *testing.T) {
+		is := is.New(t)
+		now := time.Now()
+		dbpool := &spamDB{post: &db.Post{ID: "1", Filename: "hidden", HiddenAt: &now, HiddenReason: "harassment"}}
+		out := upload(dbpool, clean)
+		is.Equal(out, "")
+		is.True(dbpool.post.HiddenAt != nil)
+	})
+}
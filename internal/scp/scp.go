@@ -2,6 +2,7 @@
 package scp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -39,25 +40,13 @@ func Middleware(wh CopyFromClientHandler, dbpool db.DB) wish.Middleware {
 
 			if info.Recursive {
 				err := fmt.Errorf("recursive not supported. try `scp ./blog/*.txt lists.sh` instead")
-				errHandler(s, err)
+				internal.ErrHandler(s, err)
 				return
 			}
 
-			var err error
-			key, err := internal.KeyText(s)
+			user, err := internal.CommandUser(s, dbpool)
 			if err != nil {
-				errHandler(s, fmt.Errorf("key not found"))
-				return
-			}
-
-			user, err := dbpool.UserForKey(key)
-			if err != nil {
-				errHandler(s, fmt.Errorf("user not found"))
-				return
-			}
-
-			if user.Name == "" {
-				errHandler(s, fmt.Errorf("must have username set"))
+				internal.ErrHandler(s, err)
 				return
 			}
 
@@ -70,10 +59,17 @@ func Middleware(wh CopyFromClientHandler, dbpool db.DB) wish.Middleware {
 					err = fmt.Errorf("no handler provided for scp -t")
 					break
 				}
+				user, err = blogUser(s.Stderr(), dbpool, user, info.Path)
+				if err != nil {
+					break
+				}
 				err = copyFromClient(s, info, wh, user, dbpool)
+				if errors.As(err, &parseError{}) {
+					parseErrorsTotal.Inc()
+				}
 			}
 			if err != nil {
-				errHandler(s, err)
+				internal.ErrHandler(s, err)
 				return
 			}
 
@@ -170,12 +166,6 @@ func GetInfo(cmd []string) Info {
 	return info
 }
 
-func errHandler(s ssh.Session, err error) {
-	_, _ = fmt.Fprintln(s.Stderr(), err)
-	_ = s.Exit(1)
-	_ = s.Close()
-}
-
 func octalPerms(info fs.FileMode) string {
 	return "0" + strconv.FormatUint(uint64(info.Perm()), 8)
 }
@@ -0,0 +1,179 @@
+// Package share mints links that open a single post until they expire, for
+// drafts, scheduled or private posts the reader couldn't see otherwise:
+//
+//	ssh lists.sh share <post> [ttl]    # prints the link, ttl like 12h or 3d
+//
+// The link carries its expiry and an HMAC of it and the post's ID made with
+// the web.share_secret setting, nothing is stored.  Changing the secret
+// revokes every link.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+)
+
+const (
+	// DefaultTTL is how long a link works when no ttl is given.
+	DefaultTTL = 7 * 24 * time.Hour
+	// MaxTTL caps how long a link can work.
+	MaxTTL = 90 * 24 * time.Hour
+)
+
+// Param is the query parameter holding the token on a post's URL.
+const Param = "share"
+
+var ErrNotEnabled = errors.New("share links aren't set up on this server")
+var ErrNotRolledOut = errors.New("share links aren't available for your account yet")
+var ErrInvalid = errors.New("this share link is invalid or has expired")
+
+const usage = `usage:
+  ssh lists.sh share <post> [ttl]`
+
+// IsCommand reports whether cmd is handled by this package.
+func IsCommand(cmd []string) bool {
+	return len(cmd) > 0 && cmd[0] == "share"
+}
+
+// Enabled reports whether a secret to sign links with is set up.
+func Enabled(cfg config.WebConfig) bool {
+	return cfg.ShareSecret != ""
+}
+
+// Middleware handles the `ssh lists.sh share` command.
+func Middleware(dbpool db.DB) wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmd := s.Command()
+			if !IsCommand(cmd) {
+				sh(s)
+				return
+			}
+
+			user, err := internal.CommandUser(s, dbpool)
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			err = run(s, dbpool, config.Current(), user, cmd[1:], time.Now())
+			if err != nil {
+				internal.ErrHandler(s, err)
+				return
+			}
+
+			sh(s)
+		}
+	}
+}
+
+func run(out io.Writer, dbpool db.DB, cfg *config.Config, user *db.User, args []string, now time.Time) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%s", usage)
+	}
+	if !Enabled(cfg.Web) {
+		return ErrNotEnabled
+	}
+	if !flags.Enabled(cfg.Flags, dbpool, user.ID, flags.Share) {
+		return ErrNotRolledOut
+	}
+
+	ttl := DefaultTTL
+	if len(args) == 2 {
+		var err error
+		ttl, err = ParseTTL(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	filename := internal.SanitizeFileExt(args[0])
+	post, err := dbpool.FindPostWithFilename(filename, user.ID)
+	if err != nil || post.DeletedAt != nil {
+		return fmt.Errorf("no post called %q", filename)
+	}
+	if post.HiddenAt != nil {
+		return fmt.Errorf("%q was taken down and can't be shared", filename)
+	}
+
+	expires := now.Add(ttl)
+	link := URL(cfg, user.Name, post, expires)
+	_, _ = fmt.Fprintf(out, "anyone with this link can read %s until %s:\n%s\n", post.Filename, expires.UTC().Format("2006-01-02 15:04 MST"), link)
+	return nil
+}
+
+// URL is the post's link with a token that opens it until expires.
+func URL(cfg *config.Config, username string, post *db.Post, expires time.Time) string {
+	token := Sign(cfg.Web.ShareSecret, post.ID, expires)
+	return cfg.URL(username, post.Filename) + "?" + Param + "=" + url.QueryEscape(token)
+}
+
+// ParseTTL reads how long a link works, a Go duration like 12h or a number
+// of days like 3d.
+func ParseTTL(text string) (time.Duration, error) {
+	var ttl time.Duration
+	var err error
+	if strings.HasSuffix(text, "d") {
+		var n int
+		n, err = strconv.Atoi(strings.TrimSuffix(text, "d"))
+		ttl = time.Duration(n) * 24 * time.Hour
+	} else {
+		ttl, err = time.ParseDuration(text)
+	}
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("%q isn't a time like 12h or 3d", text)
+	}
+	if ttl > MaxTTL {
+		return 0, fmt.Errorf("links can work for at most %d days", int(MaxTTL.Hours()/24))
+	}
+	return ttl, nil
+}
+
+// Sign makes the token for a link to the post that works until expires.
+func Sign(secret string, postID string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + signature(secret, postID, exp)
+}
+
+// Verify checks a token was signed for the post and hasn't expired.
+func Verify(secret string, postID string, token string, now time.Time) error {
+	if secret == "" {
+		return ErrNotEnabled
+	}
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalid
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, postID, exp))) {
+		return ErrInvalid
+	}
+	if !now.Before(time.Unix(unix, 0)) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func signature(secret string, postID string, exp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(postID + "\n" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
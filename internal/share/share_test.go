@@ -0,0 +1,120 @@
+package share
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	token := Sign(secret, "p1", now.Add(time.Hour))
+
+	t.Run("good until it expires", func(t *testing.T) {
+		is := is.New(t)
+		is.NoErr(Verify(secret, "p1", token, now))
+		is.Equal(Verify(secret, "p1", token, now.Add(time.Hour)), ErrInvalid)
+	})
+
+	t.Run("only for its post and secret", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Verify(secret, "p2", token, now), ErrInvalid)
+		is.Equal(Verify(strings.ToUpper(secret), "p1", token, now), ErrInvalid)
+		is.Equal(Verify("", "p1", token, now), ErrNotEnabled)
+	})
+
+	t.Run("the expiry can't be changed", func(t *testing.T) {
+		is := is.New(t)
+		_, sig, _ := strings.Cut(token, ".")
+		is.Equal(Verify(secret, "p1", "9999999999."+sig, now), ErrInvalid)
+		is.Equal(Verify(secret, "p1", "garbage", now), ErrInvalid)
+	})
+}
+
+func TestParseTTL(t *testing.T) {
+	is := is.New(t)
+	ttl, err := ParseTTL("3d")
+	is.NoErr(err)
+	is.Equal(ttl, 72*time.Hour)
+	ttl, err = ParseTTL("90m")
+	is.NoErr(err)
+	is.Equal(ttl, 90*time.Minute)
+
+	for _, bad := range []string{"", "d", "-1h", "0d", "soon", "365d"} {
+		_, err = ParseTTL(bad)
+		is.True(err != nil)
+	}
+}
+
+// postsDB has a draft and a post that was taken down.
+type postsDB struct {
+	db.DB
+}
+
+func (d *postsDB) FindPostWithFilename(filename string, userID string) (*db.Post, error) {
+	now := time.Now()
+	switch filename {
+	case "draft":
+		return &db.Post{ID: "p1", Filename: "draft", Draft: true}, nil
+	case "spam":
+		return &db.Post{ID: "p2", Filename: "spam", HiddenAt: &now}, nil
+	}
+	return nil, errors.New("sql: no rows in result set")
+}
+
+func (d *postsDB) FindUserFlags(userID string) ([]*db.UserFlag, error) {
+	if userID == "u2" {
+		return []*db.UserFlag{{UserID: "u2", Flag: flags.Share, Enabled: false}}, nil
+	}
+	return nil, nil
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{Domain: "lists.sh", Web: config.WebConfig{ShareSecret: secret}}
+	user := &db.User{ID: "u1", Name: "erock"}
+	now := time.Now()
+
+	t.Run("prints a signed link", func(t *testing.T) {
+		is := is.New(t)
+		var out bytes.Buffer
+		is.NoErr(run(&out, &postsDB{}, cfg, user, []string{"draft.txt", "2d"}, now))
+
+		line := strings.TrimSpace(out.String()[strings.LastIndex(out.String(), "https://"):])
+		link, err := url.Parse(line)
+		is.NoErr(err)
+		is.Equal(link.Path, "/erock/draft")
+		is.NoErr(Verify(secret, "p1", link.Query().Get(Param), now.Add(47*time.Hour)))
+		is.Equal(Verify(secret, "p1", link.Query().Get(Param), now.Add(49*time.Hour)), ErrInvalid)
+	})
+
+	t.Run("refuses posts taken down or missing", func(t *testing.T) {
+		is := is.New(t)
+		var out bytes.Buffer
+		is.True(run(&out, &postsDB{}, cfg, user, []string{"spam"}, now) != nil)
+		is.True(run(&out, &postsDB{}, cfg, user, []string{"nope"}, now) != nil)
+	})
+
+	t.Run("needs a secret", func(t *testing.T) {
+		is := is.New(t)
+		var out bytes.Buffer
+		err := run(&out, &postsDB{}, &config.Config{Domain: "lists.sh"}, user, []string{"draft"}, now)
+		is.Equal(err, ErrNotEnabled)
+	})
+
+	t.Run("needs the share flag", func(t *testing.T) {
+		is := is.New(t)
+		var out bytes.Buffer
+		err := run(&out, &postsDB{}, cfg, &db.User{ID: "u2", Name: "mia"}, []string{"draft"}, now)
+		is.Equal(err, ErrNotRolledOut)
+	})
+}
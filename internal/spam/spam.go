@@ -0,0 +1,117 @@
+// Package spam scores uploads for link farms, copy-pasted content and
+// links to banned domains so they can be held back from the discovery feed
+// until an admin has had a look.
+package spam
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// minDuplicateLength keeps short, common lists ("todo", "groceries") from
+// being reported as copies of each other.
+const minDuplicateLength = 200
+
+var urlRe = regexp.MustCompile(`https?://[^\s)]+`)
+
+// Verdict is the outcome of checking a single post.
+type Verdict struct {
+	Reasons []string
+	// Quarantine means the post should be hidden entirely, not just kept
+	// off the discovery feed.
+	Quarantine bool
+}
+
+// Flagged reports whether any heuristic matched.
+func (v *Verdict) Flagged() bool {
+	return len(v.Reasons) > 0
+}
+
+// Reason is a human readable summary of why the post was flagged.
+func (v *Verdict) Reason() string {
+	return strings.Join(v.Reasons, "; ")
+}
+
+type Checker struct {
+	BannedDomains     []string
+	MinLinks          int
+	MaxLinkRatio      float64
+	DuplicateAccounts int
+}
+
+// NewChecker builds a checker from the spam section of the config.
+func NewChecker(cfg config.SpamConfig) *Checker {
+	return &Checker{
+		BannedDomains:     cfg.BannedDomains,
+		MinLinks:          cfg.MinLinks,
+		MaxLinkRatio:      cfg.MaxLinkRatio,
+		DuplicateAccounts: cfg.DuplicateAccounts,
+	}
+}
+
+// Links returns every url found in the list, both `=>` links and urls
+// pasted into plain text items.
+func Links(items []*pkg.ListItem) []string {
+	var links []string
+	for _, item := range items {
+		if item.IsURL || item.IsImg {
+			links = append(links, item.URL)
+			continue
+		}
+		links = append(links, urlRe.FindAllString(item.Value, -1)...)
+	}
+	return links
+}
+
+func (c *Checker) bannedDomain(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range c.BannedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain
+		}
+	}
+	return ""
+}
+
+// Check scores a post.  duplicates is the number of other accounts that
+// already published the exact same text.
+func (c *Checker) Check(text string, parsed *pkg.ParsedText, duplicates int) *Verdict {
+	verdict := &Verdict{}
+
+	links := Links(parsed.Items)
+	for _, link := range links {
+		if domain := c.bannedDomain(link); domain != "" {
+			verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("links to banned domain %s", domain))
+			verdict.Quarantine = true
+			break
+		}
+	}
+
+	if len(links) >= c.MinLinks && len(parsed.Items) > 0 {
+		ratio := float64(len(links)) / float64(len(parsed.Items))
+		if ratio > c.MaxLinkRatio {
+			verdict.Reasons = append(
+				verdict.Reasons,
+				fmt.Sprintf("%d links in %d items", len(links), len(parsed.Items)),
+			)
+		}
+	}
+
+	if c.DuplicateAccounts > 0 && len(text) >= minDuplicateLength && duplicates >= c.DuplicateAccounts {
+		verdict.Reasons = append(
+			verdict.Reasons,
+			fmt.Sprintf("same content published by %d other accounts", duplicates),
+		)
+	}
+
+	return verdict
+}
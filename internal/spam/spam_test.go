@@ -0,0 +1,56 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+func newChecker() *Checker {
+	return &Checker{
+		BannedDomains:     []string{"spam.example"},
+		MinLinks:          5,
+		MaxLinkRatio:      0.8,
+		DuplicateAccounts: 1,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("plain list", func(t *testing.T) {
+		is := is.New(t)
+		text := "milk\neggs\n=> https://lists.sh lists.sh\nbread"
+		verdict := newChecker().Check(text, pkg.ParseText(text), 0)
+		is.True(!verdict.Flagged())
+	})
+
+	t.Run("link farm", func(t *testing.T) {
+		is := is.New(t)
+		var lines []string
+		for _, host := range []string{"a", "b", "c", "d", "e", "f"} {
+			lines = append(lines, "=> https://"+host+".example buy now")
+		}
+		text := strings.Join(lines, "\n")
+		verdict := newChecker().Check(text, pkg.ParseText(text), 0)
+		is.True(verdict.Flagged())
+		is.True(!verdict.Quarantine)
+	})
+
+	t.Run("banned domain", func(t *testing.T) {
+		is := is.New(t)
+		text := "good stuff\ncheck out https://www.spam.example/deal"
+		verdict := newChecker().Check(text, pkg.ParseText(text), 0)
+		is.True(verdict.Quarantine)
+		is.Equal(verdict.Reason(), "links to banned domain spam.example")
+	})
+
+	t.Run("duplicate content", func(t *testing.T) {
+		is := is.New(t)
+		short := "todo\nmilk"
+		is.True(!newChecker().Check(short, pkg.ParseText(short), 3).Flagged())
+
+		long := strings.Repeat("the same copy pasted paragraph\n", 10)
+		is.True(newChecker().Check(long, pkg.ParseText(long), 1).Flagged())
+	})
+}
@@ -0,0 +1,228 @@
+// Package spellcheck flags likely misspellings in a parsed list.  It is an
+// optional stage: it only runs when the operator points
+// dictionary_dir (LISTS_DICTIONARY_DIR) at a folder of word lists (one word per line) named
+// after their language, e.g. `en.txt` or `es.txt`.
+package spellcheck
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+// DictionaryFilename is the special post users upload to teach the checker
+// new words, one per list item.
+const DictionaryFilename = "_dictionary"
+
+// Dictionary is a set of known words for a single language.
+type Dictionary struct {
+	Lang  string
+	words map[string]struct{}
+}
+
+// Has reports whether the word is part of the dictionary.
+func (d *Dictionary) Has(word string) bool {
+	_, ok := d.words[word]
+	return ok
+}
+
+// Report is the result of checking a single post.
+type Report struct {
+	Lang       string
+	Misspelled []string
+}
+
+// Checker detects the language of a list and flags unknown words.
+type Checker struct {
+	dicts []*Dictionary
+}
+
+var (
+	defaultChecker *Checker
+	loadOnce       sync.Once
+)
+
+// Default returns the checker configured by dictionary_dir or nil when
+// spellchecking is disabled.
+func Default() *Checker {
+	loadOnce.Do(func() {
+		dir := config.Current().DictionaryDir
+		if dir == "" {
+			return
+		}
+
+		logger := internal.CreateLogger()
+		checker, err := LoadDir(dir)
+		if err != nil {
+			logger.Errorf("could not load dictionaries from %s: %v", dir, err)
+			return
+		}
+		defaultChecker = checker
+	})
+	return defaultChecker
+}
+
+// LoadDir reads every `<lang>.txt` word list inside dir.
+func LoadDir(dir string) (*Checker, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &Checker{}
+	for _, fname := range files {
+		f, err := os.Open(fname)
+		if err != nil {
+			return nil, err
+		}
+
+		dict := &Dictionary{
+			Lang:  strings.TrimSuffix(filepath.Base(fname), ".txt"),
+			words: map[string]struct{}{},
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if word != "" {
+				dict.words[word] = struct{}{}
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		checker.dicts = append(checker.dicts, dict)
+	}
+
+	return checker, nil
+}
+
+// NewChecker creates a checker from in-memory dictionaries.
+func NewChecker(dicts ...*Dictionary) *Checker {
+	return &Checker{dicts: dicts}
+}
+
+// NewDictionary creates a dictionary from a list of words.
+func NewDictionary(lang string, words []string) *Dictionary {
+	dict := &Dictionary{Lang: lang, words: map[string]struct{}{}}
+	for _, w := range words {
+		dict.words[strings.ToLower(w)] = struct{}{}
+	}
+	return dict
+}
+
+// UserWords returns the words a user taught the checker via their
+// `_dictionary.txt` upload.
+func UserWords(text string) []string {
+	var words []string
+	for _, item := range pkg.ParseText(text).Items {
+		words = append(words, Words(item.Value)...)
+	}
+	return words
+}
+
+// Check detects the language of the list and returns the words not found in
+// its dictionary (or in the user supplied words).
+func (c *Checker) Check(items []*pkg.ListItem, userWords []string) *Report {
+	report := &Report{}
+	if c == nil || len(c.dicts) == 0 {
+		return report
+	}
+
+	var words []string
+	for _, item := range items {
+		if item.IsImg {
+			continue
+		}
+		words = append(words, Words(item.Value)...)
+	}
+	if len(words) == 0 {
+		return report
+	}
+
+	// pick the dictionary that recognizes the most words
+	dict := c.dicts[0]
+	best := -1
+	for _, d := range c.dicts {
+		hits := 0
+		for _, w := range words {
+			if d.Has(w) {
+				hits++
+			}
+		}
+		if hits > best {
+			best = hits
+			dict = d
+		}
+	}
+	report.Lang = dict.Lang
+
+	known := map[string]struct{}{}
+	for _, w := range userWords {
+		known[strings.ToLower(w)] = struct{}{}
+	}
+
+	seen := map[string]struct{}{}
+	for _, w := range words {
+		if dict.Has(w) {
+			continue
+		}
+		if _, ok := known[w]; ok {
+			continue
+		}
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		report.Misspelled = append(report.Misspelled, w)
+	}
+	sort.Strings(report.Misspelled)
+
+	return report
+}
+
+// Words splits text into lowercase words worth checking.  Urls, numbers,
+// acronyms, and very short words are ignored since they produce too many
+// false positives.
+func Words(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		if strings.Contains(field, "://") || strings.Contains(field, "@") {
+			continue
+		}
+
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		if len([]rune(word)) < 3 {
+			continue
+		}
+
+		skip := false
+		upper := 0
+		for _, r := range word {
+			if unicode.IsUpper(r) {
+				upper++
+			}
+			if !unicode.IsLetter(r) && r != '\'' && r != '’' && r != '-' {
+				skip = true
+				break
+			}
+		}
+		if skip || upper > 1 {
+			continue
+		}
+
+		words = append(words, strings.ToLower(word))
+	}
+	return words
+}
@@ -0,0 +1,42 @@
+package spellcheck
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+func TestCheck(t *testing.T) {
+	en := NewDictionary("en", []string{"the", "quick", "brown", "fox", "jumps"})
+	es := NewDictionary("es", []string{"el", "rápido", "zorro", "marrón", "salta"})
+	checker := NewChecker(en, es)
+
+	t.Run("detects language and flags unknown words", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("the quick brwn fox\n=> https://lists.sh jumps ovr")
+		report := checker.Check(parsed.Items, nil)
+		is.Equal(report.Lang, "en")
+		is.Equal(report.Misspelled, []string{"brwn", "ovr"})
+	})
+
+	t.Run("picks the best matching dictionary", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("el zorro marrón salta rapdo")
+		report := checker.Check(parsed.Items, nil)
+		is.Equal(report.Lang, "es")
+		is.Equal(report.Misspelled, []string{"rapdo"})
+	})
+
+	t.Run("user words are never flagged", func(t *testing.T) {
+		is := is.New(t)
+		parsed := pkg.ParseText("the quick brwn fox")
+		report := checker.Check(parsed.Items, UserWords("brwn"))
+		is.Equal(len(report.Misspelled), 0)
+	})
+
+	t.Run("ignores acronyms, numbers, and urls", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Words("NASA x86 https://lists.sh fox, it"), []string{"fox"})
+	})
+}
@@ -1,11 +1,13 @@
 package account
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	input "github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
 	"github.com/neurosnap/lists.sh/internal/ui/common"
 )
@@ -22,6 +24,7 @@ type index int
 
 const (
 	textInput index = iota
+	inviteInput
 	okButton
 	cancelButton
 )
@@ -34,6 +37,9 @@ type NameTakenMsg struct{}
 // NameInvalidMsg is sent when the requested username has failed validation.
 type NameInvalidMsg struct{}
 
+// InviteInvalidMsg is sent when the invite code is missing or already used.
+type InviteInvalidMsg struct{}
+
 type errMsg struct{ err error }
 
 func (e errMsg) Error() string { return e.err.Error() }
@@ -43,32 +49,50 @@ type CreateModel struct {
 	Done bool // true when it's time to exit this view
 	Quit bool // true when the user wants to quit the whole program
 
-	dbpool    db.DB
-	publicKey string
-	styles    common.Styles
-	state     state
-	newName   string
-	index     index
-	errMsg    string
-	input     input.Model
-	spinner   spinner.Model
+	dbpool     db.DB
+	publicKey  string
+	mode       string
+	styles     common.Styles
+	state      state
+	newName    string
+	inviteCode string
+	index      index
+	errMsg     string
+	input      input.Model
+	invite     input.Model
+	spinner    spinner.Model
+}
+
+func (m *CreateModel) needsInvite() bool {
+	return m.mode == config.RegistrationInvite
+}
+
+func (m *CreateModel) onInput() bool {
+	return m.index == textInput || m.index == inviteInput
 }
 
 // updateFocus updates the focused states in the model based on the current
 // focus index.
 func (m *CreateModel) updateFocus() {
-	if m.index == textInput && !m.input.Focused() {
-		m.input.Focus()
-		m.input.Prompt = m.styles.FocusedPrompt.String()
-	} else if m.index != textInput && m.input.Focused() {
-		m.input.Blur()
-		m.input.Prompt = m.styles.Prompt.String()
+	focus := func(im *input.Model, focused bool) {
+		if focused && !im.Focused() {
+			im.Focus()
+			im.Prompt = m.styles.FocusedPrompt.String()
+		} else if !focused && im.Focused() {
+			im.Blur()
+			im.Prompt = m.styles.Prompt.String()
+		}
 	}
+	focus(&m.input, m.index == textInput)
+	focus(&m.invite, m.index == inviteInput)
 }
 
 // Move the focus index one unit forward.
 func (m *CreateModel) indexForward() {
 	m.index++
+	if m.index == inviteInput && !m.needsInvite() {
+		m.index++
+	}
 	if m.index > cancelButton {
 		m.index = textInput
 	}
@@ -79,6 +103,9 @@ func (m *CreateModel) indexForward() {
 // Move the focus index one unit backwards.
 func (m *CreateModel) indexBackward() {
 	m.index--
+	if m.index == inviteInput && !m.needsInvite() {
+		m.index--
+	}
 	if m.index < textInput {
 		m.index = cancelButton
 	}
@@ -97,16 +124,24 @@ func NewCreateModel(dbpool db.DB, publicKey string) CreateModel {
 	im.CharLimit = 50
 	im.Focus()
 
+	iv := input.NewModel()
+	iv.CursorStyle = st.Cursor
+	iv.Placeholder = "invite code"
+	iv.Prompt = st.Prompt.String()
+	iv.CharLimit = 50
+
 	return CreateModel{
 		Done:      false,
 		Quit:      false,
 		dbpool:    dbpool,
+		mode:      config.Current().Registration.Mode,
 		styles:    st,
 		state:     ready,
 		newName:   "",
 		index:     textInput,
 		errMsg:    "",
 		input:     im,
+		invite:    iv,
 		spinner:   common.NewSpinner(),
 		publicKey: publicKey,
 	}
@@ -143,34 +178,48 @@ func Update(msg tea.Msg, m CreateModel) (CreateModel, tea.Cmd) {
 				return m, nil
 			}
 
+			// There's nothing to fill out when registration is closed.
+			if m.mode == config.RegistrationClosed {
+				if msg.String() == "enter" || msg.String() == "q" {
+					m.Quit = true
+				}
+				return m, nil
+			}
+
 			switch msg.String() {
 			case "tab":
 				m.indexForward()
 			case "shift+tab":
 				m.indexBackward()
 			case "l", "k", "right":
-				if m.index != textInput {
+				if !m.onInput() {
 					m.indexForward()
 				}
 			case "h", "j", "left":
-				if m.index != textInput {
+				if !m.onInput() {
 					m.indexBackward()
 				}
 			case "up", "down":
-				if m.index == textInput {
+				if m.onInput() {
 					m.indexForward()
 				} else {
 					m.index = textInput
 					m.updateFocus()
 				}
 			case "enter":
+				if m.index == textInput && m.needsInvite() {
+					m.indexForward()
+					return m, nil
+				}
+
 				switch m.index {
-				case textInput:
+				case textInput, inviteInput:
 					fallthrough
 				case okButton: // Submit the form
 					m.state = submitting
 					m.errMsg = ""
 					m.newName = strings.TrimSpace(m.input.Value())
+					m.inviteCode = strings.TrimSpace(m.invite.Value())
 
 					return m, tea.Batch(
 						createAccount(m), // fire off the command, too
@@ -190,6 +239,12 @@ func Update(msg tea.Msg, m CreateModel) (CreateModel, tea.Cmd) {
 
 				return m, cmd
 			}
+			if m.index == inviteInput {
+				var cmd tea.Cmd
+				m.invite, cmd = m.invite.Update(msg)
+
+				return m, cmd
+			}
 
 			return m, nil
 		}
@@ -205,7 +260,15 @@ func Update(msg tea.Msg, m CreateModel) (CreateModel, tea.Cmd) {
 	case NameInvalidMsg:
 		m.state = ready
 		head := m.styles.Error.Render("Invalid name. ")
-		body := m.styles.Subtle.Render("Names can only contain plain letters and numbers and must be less than 50 characters. And no emojis, kiddo.")
+		body := m.styles.Subtle.Render("Names can only contain plain letters, numbers, - and _, start with a letter or number and must be less than 50 characters. And no emojis, kiddo.")
+		m.errMsg = m.styles.Wrap.Render(head + body)
+
+		return m, nil
+
+	case InviteInvalidMsg:
+		m.state = ready
+		head := m.styles.Error.Render("Invalid invite code. ")
+		body := m.styles.Subtle.Render("Ask someone with an account to create one for you from the Invites menu.")
 		m.errMsg = m.styles.Wrap.Render(head + body)
 
 		return m, nil
@@ -236,19 +299,27 @@ func Update(msg tea.Msg, m CreateModel) (CreateModel, tea.Cmd) {
 func View(m CreateModel) string {
 	s := "lists.sh\n"
 	s += "A microblog for lists\n\n"
-	s += "To get started, enter a username.\n"
-	s += "Then create a folder locally (e.g. ~/blog).\n"
-	s += "Then write your lists in plain text files (e.g. hello-world.txt).\n"
-	s += "Finally, send your list files to us:\n\n"
-	s += "scp ~/blog/*.txt lists.sh:/\n\n"
+	if m.mode == config.RegistrationClosed {
+		s += "Registration is closed right now, check back later.\n\n"
+		s += m.styles.HelpView("q: quit")
+		return s
+	}
+
+	s += m.styles.Subtle.Render("Step 1 of 4") + "  Pick a username\n\n"
+	s += "Your username is also the address of your blog. Once it's set we'll\n"
+	s += "show you how to publish your first list.\n\n"
 	s += "Enter a username\n\n"
 	s += m.input.View() + "\n\n"
+	if m.needsInvite() {
+		s += "Registration is invite only, enter your invite code\n\n"
+		s += m.invite.View() + "\n\n"
+	}
 
 	if m.state == submitting {
 		s += spinnerView(m)
 	} else {
-		s += common.OKButtonView(m.index == 1, true)
-		s += " " + common.CancelButtonView(m.index == 2, false)
+		s += common.OKButtonView(m.index == okButton, true)
+		s += " " + common.CancelButtonView(m.index == cancelButton, false)
 		if m.errMsg != "" {
 			s += "\n\n" + m.errMsg
 		}
@@ -271,11 +342,23 @@ func registerUser(m CreateModel) (*db.User, error) {
 		return nil, err
 	}
 
+	if m.needsInvite() {
+		err = m.dbpool.RedeemInvite(m.inviteCode, userID)
+		if err != nil {
+			// Don't leave a half created account behind.
+			_ = m.dbpool.RemoveUser(userID)
+			return nil, err
+		}
+	}
+
 	err = m.dbpool.LinkUserKey(userID, m.publicKey)
 	if err != nil {
 		return nil, err
 	}
 
+	// A missed count isn't worth failing the signup over.
+	_ = m.dbpool.RecordActivity(userID, db.ActivitySignup)
+
 	user, err := m.dbpool.UserForKey(m.publicKey)
 	if err != nil {
 		return nil, err
@@ -291,17 +374,26 @@ func createAccount(m CreateModel) tea.Cmd {
 		if m.newName == "" {
 			return NameInvalidMsg{}
 		}
-
-		user, err := registerUser(m)
-		if err != nil {
-			return errMsg{err}
+		if m.needsInvite() && m.inviteCode == "" {
+			return InviteInvalidMsg{}
 		}
 
-		// Validate before resetting the session to potentially save some
-		// network traffic and keep things feeling speedy.
+		// Validate before registering so a typo doesn't burn an invite code.
 		if !m.dbpool.ValidateName(m.newName) {
 			return NameInvalidMsg{}
 		}
+		if m.needsInvite() {
+			if taken, _ := m.dbpool.UserForName(m.newName); taken != nil {
+				return NameTakenMsg{}
+			}
+		}
+
+		user, err := registerUser(m)
+		if errors.Is(err, db.ErrInviteInvalid) {
+			return InviteInvalidMsg{}
+		} else if err != nil {
+			return errMsg{err}
+		}
 
 		err = m.dbpool.SetUserName(user.ID, m.newName)
 		if err == db.ErrNameTaken {
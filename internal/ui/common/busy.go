@@ -0,0 +1,80 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// slowThreshold is how long a command runs before the time it's taking is
+// shown.
+const slowThreshold = 2 * time.Second
+
+// BusyDoneMsg carries the result of a command started with Busy.Run.
+type BusyDoneMsg struct {
+	id      int
+	Msg     tea.Msg
+	Elapsed time.Duration
+}
+
+// Busy tracks the command a screen is waiting on, so it can show a spinner
+// saying what's happening and, once it's slow, how long it's been.
+type Busy struct {
+	label   string
+	started time.Time
+	id      int           // counts commands so an older one finishing is ignored
+	took    time.Duration // how long the last command ran
+}
+
+// Run starts cmd with a label for the spinner.  cmd has to be a plain
+// command rather than a batch, its result comes back in a BusyDoneMsg that
+// Finish unwraps.
+func (b *Busy) Run(label string, cmd tea.Cmd) tea.Cmd {
+	b.id++
+	b.label = label
+	b.started = time.Now()
+	id := b.id
+	run := func() tea.Msg {
+		start := time.Now()
+		msg := cmd()
+		return BusyDoneMsg{id: id, Msg: msg, Elapsed: time.Since(start)}
+	}
+	return tea.Batch(run, spinner.Tick)
+}
+
+// Finish stops the spinner and returns the message the command produced.
+func (b *Busy) Finish(msg BusyDoneMsg) tea.Msg {
+	b.took = msg.Elapsed
+	if msg.id == b.id {
+		b.label = ""
+	}
+	return msg.Msg
+}
+
+// Active reports whether a command is still running.
+func (b Busy) Active() bool {
+	return b.label != ""
+}
+
+// Took says how long the last command ran when it was slow, like " in 3.2s",
+// so it can be added to the result.  It's empty for quick commands.
+func (b Busy) Took() string {
+	if b.took < slowThreshold {
+		return ""
+	}
+	return fmt.Sprintf(" in %.1fs", b.took.Seconds())
+}
+
+// View renders the spinner and label, with the time so far once it's slow.
+func (b Busy) View(sp spinner.Model) string {
+	return sp.View() + " " + b.label + "..." + elapsedView(time.Since(b.started))
+}
+
+func elapsedView(d time.Duration) string {
+	if d < slowThreshold {
+		return ""
+	}
+	return fmt.Sprintf(" %ds", int(d.Seconds()))
+}
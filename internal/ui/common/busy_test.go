@@ -0,0 +1,46 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+)
+
+func TestBusy(t *testing.T) {
+	t.Run("finishing the latest command stops the spinner", func(t *testing.T) {
+		is := is.New(t)
+		var b Busy
+		b.Run("Deleting", func() tea.Msg { return nil })
+		is.True(b.Active())
+		b.Run("Restoring", func() tea.Msg { return nil })
+
+		msg := b.Finish(BusyDoneMsg{id: 1, Msg: "old"})
+		is.Equal(msg, "old")
+		is.True(b.Active()) // an older command finishing doesn't stop it
+
+		msg = b.Finish(BusyDoneMsg{id: 2, Msg: "new"})
+		is.Equal(msg, "new")
+		is.True(!b.Active())
+	})
+
+	t.Run("only slow commands report how long they took", func(t *testing.T) {
+		is := is.New(t)
+		var b Busy
+		b.Run("Deleting", func() tea.Msg { return nil })
+		b.Finish(BusyDoneMsg{id: 1, Elapsed: 300 * time.Millisecond})
+		is.Equal(b.Took(), "")
+
+		b.Run("Deleting", func() tea.Msg { return nil })
+		b.Finish(BusyDoneMsg{id: 2, Elapsed: 3200 * time.Millisecond})
+		is.Equal(b.Took(), " in 3.2s")
+	})
+}
+
+func TestElapsedView(t *testing.T) {
+	is := is.New(t)
+	is.Equal(elapsedView(time.Second), "")
+	is.Equal(elapsedView(2*time.Second), " 2s")
+	is.Equal(elapsedView(12500*time.Millisecond), " 12s")
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"encoding/base64"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CopiedMsg is sent once text has been handed to the terminal's clipboard.
+type CopiedMsg string
+
+// Clipboard copies text with the OSC 52 escape sequence.  The terminal on
+// the other end of the ssh connection does the copying, so it lands in the
+// user's local clipboard.
+type Clipboard struct {
+	out  io.Writer
+	term string
+}
+
+// NewClipboard writes escape sequences to out, usually the ssh session.
+// term is the client's TERM and is used to detect tmux and screen.
+func NewClipboard(out io.Writer, term string) *Clipboard {
+	return &Clipboard{out: out, term: term}
+}
+
+// Copy returns a command that copies text.
+func (c *Clipboard) Copy(text string) tea.Cmd {
+	return func() tea.Msg {
+		_, _ = io.WriteString(c.out, OSC52(text, c.term))
+		return CopiedMsg(text)
+	}
+}
+
+// OSC52 builds the escape sequence that sets the clipboard to text, wrapped
+// in a passthrough sequence when running inside tmux or screen.
+func OSC52(text string, term string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux") {
+		return "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	return seq
+}
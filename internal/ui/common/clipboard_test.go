@@ -0,0 +1,28 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestOSC52(t *testing.T) {
+	t.Run("plain terminal", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(OSC52("hi", "xterm-256color"), "\x1b]52;c;aGk=\x07")
+	})
+
+	t.Run("tmux passthrough", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(OSC52("hi", "screen-256color"), "\x1bPtmux;\x1b\x1b]52;c;aGk=\x07\x1b\\")
+	})
+
+	t.Run("copy writes to the session", func(t *testing.T) {
+		is := is.New(t)
+		var buf bytes.Buffer
+		msg := NewClipboard(&buf, "xterm").Copy("https://lists.sh")()
+		is.Equal(msg, CopiedMsg("https://lists.sh"))
+		is.Equal(buf.String(), OSC52("https://lists.sh", "xterm"))
+	})
+}
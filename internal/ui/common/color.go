@@ -0,0 +1,59 @@
+package common
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sgr = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// StripColor removes the color codes from rendered output and leaves other
+// attributes like bold, underline and reverse video alone, so cursors and
+// focused buttons can still be told apart.
+func StripColor(s string) string {
+	return sgr.ReplaceAllStringFunc(s, func(seq string) string {
+		params := sgr.FindStringSubmatch(seq)[1]
+		if params == "" {
+			return seq
+		}
+
+		codes := strings.Split(params, ";")
+		var kept []string
+		for i := 0; i < len(codes); i++ {
+			n, err := strconv.Atoi(codes[i])
+			if err != nil {
+				kept = append(kept, codes[i])
+				continue
+			}
+			switch {
+			case n == 38 || n == 48:
+				// 38;5;n and 38;2;r;g;b, skip the arguments too.
+				if i+1 < len(codes) && codes[i+1] == "5" {
+					i += 2
+				} else if i+1 < len(codes) && codes[i+1] == "2" {
+					i += 4
+				}
+			case n >= 30 && n <= 37, n == 39, n >= 40 && n <= 47, n == 49,
+				n >= 90 && n <= 97, n >= 100 && n <= 107:
+			default:
+				kept = append(kept, codes[i])
+			}
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(kept, ";") + "m"
+	})
+}
+
+// WantsNoColor reports whether the environment asks for no color, following
+// https://no-color.org: NO_COLOR set to anything but an empty string.
+func WantsNoColor(environ []string) bool {
+	for _, env := range environ {
+		if v := strings.TrimPrefix(env, "NO_COLOR="); v != env && v != "" {
+			return true
+		}
+	}
+	return false
+}
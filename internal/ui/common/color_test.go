@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestStripColor(t *testing.T) {
+	t.Run("drops true color and basic colors", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(StripColor("\x1b[38;2;238;111;248mhi\x1b[0m \x1b[31mno\x1b[39m"), "hi\x1b[0m no")
+	})
+
+	t.Run("keeps other attributes", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(StripColor("\x1b[1;48;5;99;7mOK\x1b[0m"), "\x1b[1;7mOK\x1b[0m")
+	})
+}
+
+func TestWantsNoColor(t *testing.T) {
+	is := is.New(t)
+	is.True(WantsNoColor([]string{"TERM=xterm", "NO_COLOR=1"}))
+	is.True(!WantsNoColor([]string{"NO_COLOR="}))
+	is.True(!WantsNoColor(nil))
+}
+
+func TestGutter(t *testing.T) {
+	is := is.New(t)
+	st := NewStyles(db.ThemeNoColor)
+	is.Equal(st.Gutter(StateSelected), ">")
+	is.Equal(st.Gutter(StateDeleting), "[DEL]")
+	is.Equal(StripColor(st.Label.Render("x")), "x")
+}
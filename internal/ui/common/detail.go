@@ -0,0 +1,111 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+const (
+	detailWidth  = 58
+	detailHeight = 12
+)
+
+// NewDetailViewport renders a post's text into a scrollable viewport.
+func NewDetailViewport(styles Styles, text string) viewport.Model {
+	vp := viewport.New(detailWidth, detailHeight)
+	vp.SetContent(RenderItems(styles, pkg.ParseText(text), detailWidth))
+	return vp
+}
+
+// DetailScrollHelp is the help item for scrolling the viewport, it's empty
+// when the whole post fits.
+func DetailScrollHelp(vp viewport.Model) string {
+	if vp.AtTop() && vp.AtBottom() {
+		return ""
+	}
+	return fmt.Sprintf("j/k, ↑/↓: scroll (%d%%)", int(vp.ScrollPercent()*100))
+}
+
+// RenderItems formats parsed list items roughly the way the web page does.
+func RenderItems(styles Styles, parsed *pkg.ParsedText, width int) string {
+	bullet := "•"
+	switch parsed.MetaData.ListType {
+	case "none":
+		bullet = " "
+	case "decimal":
+		bullet = ""
+	}
+
+	bold := lipgloss.NewStyle().Bold(true)
+	var lines []string
+	n := 0
+	for _, item := range parsed.Items {
+		var line string
+		switch {
+		case item.IsHeaderOne:
+			line = "\n" + bold.Copy().Underline(true).Render(item.Value)
+		case item.IsHeaderTwo:
+			line = "\n" + bold.Render(item.Value)
+		case item.IsBlock:
+			line = styles.Subtle.Render("│ " + item.Value)
+		case item.IsImg:
+			line = styles.LabelDim.Render("[image] ") + item.Value + " " + styles.Subtle.Render(item.URL)
+		case item.IsURL:
+			line = styles.Label.Render(item.Value) + " " + styles.Subtle.Render("→ "+item.URL)
+		case item.IsDivider:
+			line = styles.Subtle.Render(strings.Repeat("─", width))
+		case item.IsDefinition:
+			line = bold.Render(item.Term) + "\n  " + spansView(item.Spans)
+		case item.IsText && item.Value == "":
+			line = ""
+		default:
+			n++
+			marker := bullet
+			if marker == "" {
+				marker = fmt.Sprintf("%d.", n)
+			}
+			if item.IsNumbered {
+				marker = fmt.Sprintf("%d.", item.Number)
+			}
+			line = marker + " " + checkbox(styles, item)
+		}
+		lines = append(lines, wordwrap.String(line, width))
+	}
+
+	if len(lines) == 0 {
+		return styles.Subtle.Render("This post is empty.")
+	}
+	return strings.TrimPrefix(strings.Join(lines, "\n"), "\n")
+}
+
+var struck = lipgloss.NewStyle().Strikethrough(true)
+
+// checkbox draws todo items with a box and done ones checked, muted and
+// struck through.
+func checkbox(styles Styles, item *pkg.ListItem) string {
+	switch {
+	case item.IsTodo:
+		return "☐ " + spansView(item.Spans)
+	case item.IsDone:
+		return styles.Checkmark.String() + " " + styles.Subtle.Copy().Strikethrough(true).Render(item.Value)
+	}
+	return spansView(item.Spans)
+}
+
+// spansView strikes through the ~~struck~~ parts of an item.
+func spansView(spans []pkg.Span) string {
+	var s string
+	for _, span := range spans {
+		if span.Struck {
+			s += struck.Render(span.Text)
+		} else {
+			s += span.Text
+		}
+	}
+	return s
+}
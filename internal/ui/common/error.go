@@ -0,0 +1,11 @@
+package common
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ErrorMsg reports a command that failed badly enough to stop the current
+// screen.  The app shows Err in place of the screen and runs Retry again
+// when the user asks for it.
+type ErrorMsg struct {
+	Err   error
+	Retry tea.Cmd
+}
@@ -0,0 +1,26 @@
+package common
+
+import "strings"
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of block characters scaled to the largest
+// value.
+func Sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if peak > 0 && v > 0 {
+			i = v * (len(sparks) - 1) / peak
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSparkline(t *testing.T) {
+	t.Run("scales to the peak", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Sparkline([]int{0, 1, 7, 14}), "▁▁▄█")
+	})
+
+	t.Run("all zero", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Sparkline([]int{0, 0, 0}), "▁▁▁")
+	})
+}
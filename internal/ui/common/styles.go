@@ -1,6 +1,9 @@
 package common
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
 
 // Color definitions.
 var (
@@ -39,50 +42,92 @@ type Styles struct {
 	Checkmark,
 	Logo,
 	App lipgloss.Style
+
+	// NoColor is set for the no-color theme, views should mark state with
+	// text instead of relying on color.
+	NoColor bool
+	// Locale is the language of the UI text. It travels with the styles
+	// since every screen gets them and they're rebuilt when settings change.
+	Locale string
 }
 
 // DefaultStyles returns default styles for the Charm TUI.
 func DefaultStyles() Styles {
-	s := Styles{}
+	return NewStyles(db.ThemeAuto)
+}
+
+// NewStyles returns the styles for a theme.  Adaptive colors are pinned to
+// their light or dark variant unless the theme is auto, since the server
+// can't tell what the terminal on the other end of the session looks like.
+func NewStyles(theme string) Styles {
+	c := func(color lipgloss.TerminalColor) lipgloss.TerminalColor {
+		adaptive, ok := color.(lipgloss.AdaptiveColor)
+		switch {
+		case theme == db.ThemeNoColor:
+			return lipgloss.NoColor{}
+		case ok && theme == db.ThemeDark:
+			return lipgloss.Color(adaptive.Dark)
+		case ok && theme == db.ThemeLight:
+			return lipgloss.Color(adaptive.Light)
+		}
+		return color
+	}
 
-	s.Cursor = lipgloss.NewStyle().Foreground(fuschia)
+	s := Styles{NoColor: theme == db.ThemeNoColor}
+
+	s.Cursor = lipgloss.NewStyle().Foreground(c(fuschia))
 	s.Wrap = lipgloss.NewStyle().Width(58)
-	s.Keyword = lipgloss.NewStyle().Foreground(green)
+	s.Keyword = lipgloss.NewStyle().Foreground(c(green))
 	s.Paragraph = s.Wrap.Copy().Margin(1, 0, 0, 2)
 	s.Code = lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"}).
-		Background(lipgloss.AdaptiveColor{Light: "#EBE5EC", Dark: "#2B2A2A"}).
+		Foreground(c(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"})).
+		Background(c(lipgloss.AdaptiveColor{Light: "#EBE5EC", Dark: "#2B2A2A"})).
 		Padding(0, 1)
 	s.Subtle = lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"})
-	s.Error = lipgloss.NewStyle().Foreground(red)
+		Foreground(c(lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"}))
+	s.Error = lipgloss.NewStyle().Foreground(c(red))
 	s.Prompt = lipgloss.NewStyle().MarginRight(1).SetString(">")
-	s.FocusedPrompt = s.Prompt.Copy().Foreground(fuschia)
-	s.Note = lipgloss.NewStyle().Foreground(green)
+	s.FocusedPrompt = s.Prompt.Copy().Foreground(c(fuschia))
+	s.Note = lipgloss.NewStyle().Foreground(c(green))
 	s.NoteDim = lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#ABE5D1", Dark: "#2B4A3F"})
+		Foreground(c(lipgloss.AdaptiveColor{Light: "#ABE5D1", Dark: "#2B4A3F"}))
 	s.Delete = s.Error.Copy()
-	s.DeleteDim = lipgloss.NewStyle().Foreground(faintRed)
-	s.Label = lipgloss.NewStyle().Foreground(fuschia)
-	s.LabelDim = lipgloss.NewStyle().Foreground(indigo)
-	s.ListKey = lipgloss.NewStyle().Foreground(indigo)
-	s.ListDim = lipgloss.NewStyle().Foreground(subtleIndigo)
+	s.DeleteDim = lipgloss.NewStyle().Foreground(c(faintRed))
+	s.Label = lipgloss.NewStyle().Foreground(c(fuschia))
+	s.LabelDim = lipgloss.NewStyle().Foreground(c(indigo))
+	s.ListKey = lipgloss.NewStyle().Foreground(c(indigo))
+	s.ListDim = lipgloss.NewStyle().Foreground(c(subtleIndigo))
 	s.InactivePagination = lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#CACACA", Dark: "#4F4F4F"})
+		Foreground(c(lipgloss.AdaptiveColor{Light: "#CACACA", Dark: "#4F4F4F"}))
 	s.SelectionMarker = lipgloss.NewStyle().
-		Foreground(fuschia).
+		Foreground(c(fuschia)).
 		PaddingRight(1).
 		SetString(">")
 	s.Checkmark = lipgloss.NewStyle().
 		SetString("✔").
-		Foreground(green)
-	s.SelectedMenuItem = lipgloss.NewStyle().Foreground(fuschia)
+		Foreground(c(green))
+	s.SelectedMenuItem = lipgloss.NewStyle().Foreground(c(fuschia))
 	s.Logo = lipgloss.NewStyle().
-		Foreground(cream).
-		Background(lipgloss.Color("#5A56E0")).
+		Foreground(c(cream)).
+		Background(c(lipgloss.Color("#5A56E0"))).
 		Padding(0, 1).
 		SetString("lists.sh")
 	s.App = lipgloss.NewStyle().Margin(1, 0, 1, 2)
 
 	return s
 }
+
+// Gutter is the line drawn beside list items.  Without color the selected
+// and deleting states are spelled out instead.
+func (s Styles) Gutter(state State) string {
+	if !s.NoColor {
+		return VerticalLine(state)
+	}
+	switch state {
+	case StateSelected:
+		return ">"
+	case StateDeleting:
+		return "[DEL]"
+	}
+	return "│"
+}
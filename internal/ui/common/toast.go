@@ -0,0 +1,92 @@
+package common
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toastDuration is how long a notification stays in the footer.
+const toastDuration = 4 * time.Second
+
+// ToastLevel tells notifications that went through from ones that failed.
+type ToastLevel int
+
+// Toast levels.
+const (
+	ToastInfo ToastLevel = iota
+	ToastError
+)
+
+// ToastMsg is a notification for the footer.  Commands can return one
+// directly to report their result.
+type ToastMsg struct {
+	Level ToastLevel
+	Text  string
+}
+
+// ErrorToast wraps err in a notification.
+func ErrorToast(err error) ToastMsg {
+	return ToastMsg{Level: ToastError, Text: err.Error()}
+}
+
+type toastExpiredMsg struct {
+	id int
+}
+
+// Toast shows one notification at a time for a few seconds, newer ones
+// replace whatever is showing.
+type Toast struct {
+	current *ToastMsg
+	id      int // counts notifications so stale timers are ignored
+}
+
+// Push shows msg and returns the command that hides it again.
+func (t *Toast) Push(msg ToastMsg) tea.Cmd {
+	t.id++
+	t.current = &msg
+	id := t.id
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id}
+	})
+}
+
+// Info shows text as a notification.
+func (t *Toast) Info(text string) tea.Cmd {
+	return t.Push(ToastMsg{Level: ToastInfo, Text: text})
+}
+
+// Error shows err as a notification.
+func (t *Toast) Error(err error) tea.Cmd {
+	return t.Push(ErrorToast(err))
+}
+
+// Update shows incoming notifications and hides them when they expire.
+func (t Toast) Update(msg tea.Msg) (Toast, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ToastMsg:
+		cmd := t.Push(msg)
+		return t, cmd
+	case toastExpiredMsg:
+		if msg.id == t.id {
+			t.current = nil
+		}
+	}
+	return t, nil
+}
+
+// Visible reports whether there's a notification showing.
+func (t Toast) Visible() bool {
+	return t.current != nil
+}
+
+// View renders the current notification, or nothing.
+func (t Toast) View(st Styles) string {
+	if t.current == nil {
+		return ""
+	}
+	if t.current.Level == ToastError {
+		return st.Wrap.Render(st.Error.Render("Error: ") + st.Subtle.Render(t.current.Text))
+	}
+	return st.Note.Render(t.current.Text)
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestToast(t *testing.T) {
+	t.Run("newer notifications replace older ones", func(t *testing.T) {
+		is := is.New(t)
+		var toast Toast
+		is.True(toast.Info("Saved") != nil)
+		toast, _ = toast.Update(ErrorToast(errors.New("boom")))
+		is.Equal(toast.View(NewStyles(db.ThemeNoColor)), NewStyles(db.ThemeNoColor).Wrap.Render("Error: boom"))
+	})
+
+	t.Run("only the latest timer hides it", func(t *testing.T) {
+		is := is.New(t)
+		var toast Toast
+		toast.Info("one")
+		toast.Info("two")
+		toast, _ = toast.Update(toastExpiredMsg{1})
+		is.True(toast.Visible())
+		toast, _ = toast.Update(toastExpiredMsg{2})
+		is.True(!toast.Visible())
+	})
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// Meter draws a bar width cells wide, filled in proportion to used of limit.
+func Meter(used, limit, width int) string {
+	filled := width
+	if limit > 0 && used < limit {
+		filled = used * width / limit
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// NearLimit reports whether used is within a tenth of limit, zero being no
+// limit at all.
+func NearLimit(used, limit int) bool {
+	return limit > 0 && used*10 >= limit*9
+}
+
+func megabytes(bytes int) string {
+	return fmt.Sprintf("%.1f", float64(bytes)/(1024*1024))
+}
+
+// UsageSummary is a one line account of usage against the quota, like
+// "12 of 500 posts • 0.1 of 10 MB".
+func (s Styles) UsageSummary(usage *db.Usage, quota config.QuotaConfig) string {
+	posts := fmt.Sprintf("%d posts", usage.Posts)
+	if quota.MaxPosts > 0 {
+		posts = fmt.Sprintf("%d of %d posts", usage.Posts, quota.MaxPosts)
+	}
+	size := megabytes(usage.Bytes) + " MB"
+	if quota.MaxBytes > 0 {
+		size = fmt.Sprintf("%s of %d MB", megabytes(usage.Bytes), quota.MaxBytes/(1024*1024))
+	}
+
+	st := s.Subtle
+	if NearLimit(usage.Posts, quota.MaxPosts) || NearLimit(usage.Bytes, quota.MaxBytes) {
+		st = s.Error
+	}
+	return st.Render(posts + " • " + size)
+}
+
+// UsageView shows a meter for posts and storage each.
+func (s Styles) UsageView(usage *db.Usage, quota config.QuotaConfig) string {
+	const width = 20
+	meter := func(used, limit int, amount, max string) string {
+		if limit == 0 {
+			return amount + s.Subtle.Render(", no limit")
+		}
+		st := s.Label
+		if NearLimit(used, limit) {
+			st = s.Error
+		}
+		return st.Render(Meter(used, limit, width)) + " " + amount + " of " + max
+	}
+
+	return KeyValueView(
+		"Posts", meter(usage.Posts, quota.MaxPosts, fmt.Sprint(usage.Posts), fmt.Sprint(quota.MaxPosts)),
+		"Storage", meter(usage.Bytes, quota.MaxBytes, megabytes(usage.Bytes)+" MB", fmt.Sprintf("%d MB", quota.MaxBytes/(1024*1024))),
+	)
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMeter(t *testing.T) {
+	t.Run("fills in proportion", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Meter(1, 4, 8), "██░░░░░░")
+	})
+
+	t.Run("stops at full", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Meter(9, 4, 4), "████")
+	})
+
+	t.Run("near the limit", func(t *testing.T) {
+		is := is.New(t)
+		is.True(NearLimit(90, 100))
+		is.True(!NearLimit(89, 100))
+		is.True(!NearLimit(1000, 0))
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/neurosnap/lists.sh/internal/i18n"
 )
 
 // State is a general UI state used to help style components.
@@ -47,7 +48,8 @@ var (
 				Padding(0, 3)
 
 	focusedButtonStyle = blurredButtonStyle.Copy().
-				Background(fuschia)
+				Background(fuschia).
+				Bold(true)
 )
 
 // KeyValueView renders key-value pairs.
@@ -116,6 +118,31 @@ var (
 			Foreground(lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"})
 )
 
+// T translates msg into the user's language.
+func (s Styles) T(msg string) string {
+	return i18n.T(s.Locale, msg)
+}
+
+// Tf translates a format string into the user's language and fills it in.
+func (s Styles) Tf(format string, args ...interface{}) string {
+	return i18n.Tf(s.Locale, format, args...)
+}
+
+// HelpView is HelpView with the descriptions, the part after "key: ",
+// translated into the user's language.
+func (s Styles) HelpView(sections ...string) string {
+	translated := make([]string, len(sections))
+	for i, section := range sections {
+		key, desc, ok := strings.Cut(section, ": ")
+		if !ok {
+			translated[i] = s.T(section)
+			continue
+		}
+		translated[i] = key + ": " + s.T(desc)
+	}
+	return HelpView(translated...)
+}
+
 // HelpView renders text intended to display at help text, often at the
 // bottom of a view.
 func HelpView(sections ...string) string {
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+)
+
+func TestStylesHelpView(t *testing.T) {
+	t.Run("translates the descriptions", func(t *testing.T) {
+		is := is.New(t)
+		st := DefaultStyles()
+		st.Locale = i18n.Spanish
+		help := st.HelpView("j/k, ↑/↓: choose", "esc: exit")
+		is.True(strings.Contains(help, "j/k, ↑/↓: elegir"))
+		is.True(strings.Contains(help, "esc: salir"))
+	})
+
+	t.Run("english by default", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(DefaultStyles().HelpView("esc: exit"), HelpView("esc: exit"))
+	})
+}
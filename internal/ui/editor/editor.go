@@ -0,0 +1,280 @@
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/scp"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/textarea"
+)
+
+type state int
+
+const (
+	stateEditing state = iota
+	stateSaving
+)
+
+// index specifies the UI element that's in focus.
+type index int
+
+const (
+	filenameInput index = iota
+	textArea
+)
+
+type (
+	savedMsg struct {
+		post    *db.Post
+		notices string
+	}
+	errMsg struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the post editor.
+type Model struct {
+	Done  bool // true when it's time to exit this view
+	Quit  bool // true when the user wants to quit the whole program
+	Saved bool // true once a save went through, so callers can refresh
+
+	dbpool   db.DB
+	user     *db.User
+	post     *db.Post
+	styles   common.Styles
+	state    state
+	index    index
+	filename input.Model
+	area     textarea.Model
+	dirty    bool
+	discard  bool // esc was pressed once with unsaved changes
+	notices  string
+	err      error
+	spinner  spinner.Model
+}
+
+// Post returns the post being edited, nil for a new post that hasn't been
+// saved yet.
+func (m Model) Post() *db.Post {
+	return m.post
+}
+
+// NewModel returns an editor for post, or for a new post when post is nil.
+func NewModel(dbpool db.DB, user *db.User, post *db.Post, styles common.Styles) Model {
+
+	fi := input.NewModel()
+	fi.CursorStyle = styles.Cursor
+	fi.Placeholder = "hello-world"
+	fi.Prompt = styles.FocusedPrompt.String()
+	fi.CharLimit = 100
+
+	area := textarea.New()
+	area.CursorStyle = styles.Cursor.Copy().Reverse(true)
+	area.PlaceholderStyle = styles.Subtle
+	area.EndOfBufferStyle = styles.Subtle
+
+	m := Model{
+		dbpool:   dbpool,
+		user:     user,
+		post:     post,
+		styles:   styles,
+		state:    stateEditing,
+		filename: fi,
+		area:     area,
+		spinner:  common.NewSpinner(),
+	}
+
+	if post == nil {
+		m.index = filenameInput
+		m.filename.Focus()
+	} else {
+		m.index = textArea
+		m.area.SetValue(post.Text)
+		m.area.Focus()
+	}
+	return m
+}
+
+// InitialCmd returns the initial command.
+func InitialCmd() tea.Cmd {
+	return input.Blink
+}
+
+func (m *Model) updateFocus() {
+	if m.index == filenameInput {
+		m.filename.Focus()
+		m.filename.Prompt = m.styles.FocusedPrompt.String()
+		m.area.Blur()
+	} else {
+		m.filename.Blur()
+		m.filename.Prompt = m.styles.Prompt.String()
+		m.area.Focus()
+	}
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.state == stateSaving {
+			return m, nil
+		}
+
+		if msg.String() != "esc" {
+			m.discard = false
+		}
+
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+			return m, nil
+		case "esc":
+			if m.dirty && !m.discard {
+				m.discard = true
+				return m, nil
+			}
+			m.Done = true
+			return m, nil
+		case "ctrl+s":
+			m.state = stateSaving
+			m.err = nil
+			return m, tea.Batch(save(m), spinner.Tick)
+		case "tab", "shift+tab":
+			// The filename can only be set when creating a post.
+			if m.post == nil {
+				if m.index == filenameInput {
+					m.index = textArea
+				} else {
+					m.index = filenameInput
+				}
+				m.updateFocus()
+			}
+			return m, nil
+		case "enter":
+			if m.index == filenameInput {
+				m.index = textArea
+				m.updateFocus()
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		if m.index == filenameInput {
+			m.filename, cmd = m.filename.Update(msg)
+		} else {
+			before := m.area.Value()
+			m.area, cmd = m.area.Update(msg)
+			if m.area.Value() != before {
+				m.dirty = true
+				m.notices = ""
+			}
+		}
+		return m, cmd
+
+	case savedMsg:
+		m.state = stateEditing
+		m.Saved = true
+		m.dirty = false
+		m.post = msg.post
+		m.notices = msg.notices
+		m.index = textArea
+		m.updateFocus()
+		return m, nil
+
+	case errMsg:
+		m.state = stateEditing
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateSaving {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	if m.index == filenameInput {
+		m.filename, cmd = m.filename.Update(msg)
+	}
+	return m, cmd
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	var s string
+	if m.post == nil {
+		s = "New post\n\n"
+		s += m.filename.View() + m.styles.Subtle.Render(".txt") + "\n\n"
+	} else {
+		title := m.post.Filename
+		if m.dirty {
+			title += " " + m.styles.Subtle.Render("(modified)")
+		}
+		s = "Editing " + m.styles.Label.Render(title) + "\n\n"
+	}
+
+	s += m.area.View() + "\n\n"
+
+	row, col := m.area.Cursor()
+	s += m.styles.Subtle.Render(fmt.Sprintf("line %d/%d, col %d", row+1, m.area.LineCount(), col+1)) + "\n"
+
+	switch {
+	case m.discard:
+		s += "\n" + m.styles.Delete.Render("You have unsaved changes, press esc again to discard them.")
+	case m.state == stateSaving:
+		s += "\n" + m.spinner.View() + " Saving..."
+	case m.err != nil:
+		s += "\n" + m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error()))
+	case m.Saved && !m.dirty:
+		s += "\n" + m.styles.Note.Render("Saved!")
+		if m.notices != "" {
+			s += "\n" + m.styles.Wrap.Render(m.styles.Subtle.Render(strings.TrimSpace(m.notices)))
+		}
+	}
+
+	help := []string{"ctrl+s: save", "esc: exit"}
+	if m.post == nil {
+		help = append([]string{"tab: switch field"}, help...)
+	}
+	return s + "\n\n" + m.styles.HelpView(help...)
+}
+
+func save(m Model) tea.Cmd {
+	return func() tea.Msg {
+		filename := ""
+		if m.post != nil {
+			filename = m.post.Filename
+		} else {
+			filename = strings.TrimSuffix(strings.TrimSpace(m.filename.Value()), ".txt")
+			if filename == "" {
+				return errMsg{errors.New("a post needs a filename")}
+			}
+			if err := internal.ValidateFilename(filename); err != nil {
+				return errMsg{err}
+			}
+			// Saving over a post that was just deleted brings it back.
+			if existing, _ := m.dbpool.FindPostWithFilename(filename, m.user.ID); existing != nil && existing.DeletedAt == nil {
+				return errMsg{fmt.Errorf("%s already exists, edit it from the posts list instead", filename)}
+			}
+		}
+
+		var notices bytes.Buffer
+		logger := internal.CreateLogger()
+		post, err := scp.SavePost(logger, &notices, m.dbpool, m.user, filename+".txt", m.area.Value())
+		if err != nil {
+			return errMsg{err}
+		}
+		return savedMsg{post: post, notices: notices.String()}
+	}
+}
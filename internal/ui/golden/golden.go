@@ -0,0 +1,61 @@
+// Package golden compares what TUI views render with snapshots kept in the
+// testdata directory of the package under test, so a change to the shared
+// styles that moves things around shows up as a failing test.  After
+// checking that a change to a view is intended, rewrite its snapshots with:
+//
+//	LISTS_UPDATE_GOLDEN=1 go test ./internal/ui/...
+//
+// Colors and other escape codes are left out of the snapshots, which are
+// about layout and have to stay readable in a diff.
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// escapes matches the codes lipgloss styles text with.
+var escapes = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// Assert fails the test when got, without escape codes, isn't what
+// testdata/<name>.golden holds, or writes it there when LISTS_UPDATE_GOLDEN
+// is set.
+func Assert(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	got = escapes.ReplaceAllString(got, "")
+
+	if os.Getenv("LISTS_UPDATE_GOLDEN") != "" {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with LISTS_UPDATE_GOLDEN=1 to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s doesn't match what the view rendered, %s\n--- got ---\n%s\n--- want ---\n%s", path, firstDifference(got, string(want)), got, want)
+	}
+}
+
+// firstDifference says where got and want part ways.
+func firstDifference(got string, want string) string {
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+		if gotLines[i] != wantLines[i] {
+			return fmt.Sprintf("line %d is %q instead of %q", i+1, gotLines[i], wantLines[i])
+		}
+	}
+	return fmt.Sprintf("it has %d lines instead of %d", len(gotLines), len(wantLines))
+}
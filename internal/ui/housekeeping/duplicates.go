@@ -0,0 +1,132 @@
+package housekeeping
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// normalizeTitle lowercases a title and removes everything except letters
+// and digits so "Top 10 Books!" and "top-10 books" compare equal.
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// similar reports whether two normalized titles are identical or only a
+// couple of typos apart.
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	shortest := len(ra)
+	if len(rb) < shortest {
+		shortest = len(rb)
+	}
+	// very short titles like "todo" vs "toga" are too noisy to compare
+	if shortest < 5 {
+		return false
+	}
+
+	allowed := 1 + shortest/10
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > allowed {
+		return false
+	}
+
+	return levenshtein(ra, rb) <= allowed
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(cur[j-1]+1, prev[j]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// DuplicateTitles groups posts whose titles are identical or near-identical.
+// Only groups with more than one post are returned.
+func DuplicateTitles(posts []*db.Post) [][]*db.Post {
+	var candidates []*db.Post
+	var titles []string
+	for _, post := range posts {
+		if strings.HasPrefix(post.Filename, "_") {
+			continue
+		}
+		candidates = append(candidates, post)
+		titles = append(titles, normalizeTitle(internal.FilenameToTitle(post.Filename, post.Title)))
+	}
+
+	// union-find so chains of similar titles end up in one group
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			if similar(titles[i], titles[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	var order []int
+	groups := map[int][]*db.Post{}
+	for i, post := range candidates {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], post)
+	}
+
+	var result [][]*db.Post
+	for _, root := range order {
+		if len(groups[root]) > 1 {
+			result = append(result, groups[root])
+		}
+	}
+	return result
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package housekeeping
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestDuplicateTitles(t *testing.T) {
+	is := is.New(t)
+	posts := []*db.Post{
+		{ID: "1", Filename: "top-books", Title: "Top books of 2021"},
+		{ID: "2", Filename: "top-books-2021", Title: "Top Books of 2021!"},
+		{ID: "3", Filename: "top-bookz", Title: "Top bookz of 2021"},
+		{ID: "4", Filename: "groceries", Title: "groceries"},
+		{ID: "5", Filename: "todo", Title: "todo"},
+		{ID: "6", Filename: "toga", Title: "toga"},
+		{ID: "7", Filename: "_readme", Title: "_readme"},
+	}
+
+	groups := DuplicateTitles(posts)
+	is.Equal(len(groups), 1)
+	is.Equal(len(groups[0]), 3)
+	is.Equal(groups[0][0].ID, "1")
+	is.Equal(groups[0][2].ID, "3")
+}
@@ -0,0 +1,301 @@
+package housekeeping
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateRenaming
+	stateMerging
+	stateSaving
+)
+
+type (
+	duplicatesLoadedMsg [][]*db.Post
+	postsChangedMsg     struct{}
+	errMsg              struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// entry is a single row in the housekeeping screen.
+type entry struct {
+	group int
+	post  *db.Post
+}
+
+// Model holds the state of the housekeeping UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	styles  common.Styles
+	state   state
+	groups  [][]*db.Post
+	entries []entry
+	index   int
+	input   input.Model
+	err     error
+	spinner spinner.Model
+}
+
+// NewModel returns a new housekeeping model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+
+	im := input.NewModel()
+	im.CursorStyle = styles.Cursor
+	im.Prompt = styles.FocusedPrompt.String()
+	im.CharLimit = 255
+
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		input:   im,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadDuplicates returns the command that searches for similar titles.
+func LoadDuplicates(m Model) tea.Cmd {
+	return tea.Batch(findDuplicates(m.dbpool, m.user), spinner.Tick)
+}
+
+func (m Model) selected() *entry {
+	if m.index < 0 || m.index >= len(m.entries) {
+		return nil
+	}
+	return &m.entries[m.index]
+}
+
+// mergeTarget is the post the selected post will be merged into.
+func (m Model) mergeTarget() *db.Post {
+	sel := m.selected()
+	if sel == nil {
+		return nil
+	}
+	for _, post := range m.groups[sel.group] {
+		if post.ID != sel.post.ID {
+			return post
+		}
+	}
+	return nil
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.Quit = true
+			return m, nil
+		}
+
+		switch m.state {
+		case stateRenaming:
+			switch msg.Type {
+			case tea.KeyEscape:
+				m.state = stateReady
+				m.input.Blur()
+				return m, nil
+			case tea.KeyEnter:
+				title := strings.TrimSpace(m.input.Value())
+				if title == "" {
+					return m, nil
+				}
+				m.state = stateSaving
+				m.input.Blur()
+				return m, tea.Batch(renamePost(m.dbpool, m.selected().post, title), spinner.Tick)
+			}
+
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+
+		case stateMerging:
+			if msg.String() == "y" {
+				m.state = stateSaving
+				return m, tea.Batch(mergePosts(m.dbpool, m.selected().post, m.mergeTarget()), spinner.Tick)
+			}
+			m.state = stateReady
+			return m, nil
+
+		case stateReady:
+			switch msg.String() {
+			case "q", "esc":
+				m.Done = true
+			case "up", "k":
+				if m.index > 0 {
+					m.index--
+				}
+			case "down", "j":
+				if m.index < len(m.entries)-1 {
+					m.index++
+				}
+			case "r":
+				if sel := m.selected(); sel != nil {
+					m.state = stateRenaming
+					m.input.SetValue(internal.FilenameToTitle(sel.post.Filename, sel.post.Title))
+					m.input.CursorEnd()
+					m.input.Focus()
+					return m, input.Blink
+				}
+			case "m":
+				if m.mergeTarget() != nil {
+					m.state = stateMerging
+				}
+			}
+		}
+		return m, nil
+
+	case duplicatesLoadedMsg:
+		m.state = stateReady
+		m.groups = msg
+		m.entries = nil
+		for i, group := range m.groups {
+			for _, post := range group {
+				m.entries = append(m.entries, entry{group: i, post: post})
+			}
+		}
+		if m.index >= len(m.entries) {
+			m.index = max(0, len(m.entries)-1)
+		}
+		return m, nil
+
+	case postsChangedMsg:
+		m.state = stateLoading
+		return m, LoadDuplicates(m)
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading || m.state == stateSaving {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	if m.state == stateRenaming {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	switch m.state {
+	case stateLoading:
+		return m.spinner.View() + " Looking for similar titles..."
+	case stateSaving:
+		return m.spinner.View() + " Saving..."
+	}
+
+	s := "Housekeeping\n\n"
+	if m.err != nil {
+		s += m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.err.Error()) + "\n\n"
+	}
+
+	if len(m.entries) == 0 {
+		s += m.styles.Note.Render("Your archive is tidy, no posts share a similar title.")
+		return s + "\n\n" + m.styles.HelpView("esc: exit")
+	}
+
+	s += m.styles.Subtle.Render("These posts have identical or near-identical titles.") + "\n\n"
+	for i, e := range m.entries {
+		if i > 0 && m.entries[i-1].group != e.group {
+			s += "\n"
+		}
+
+		gutter := m.styles.Gutter(common.StateNormal)
+		title := internal.FilenameToTitle(e.post.Filename, e.post.Title)
+		if i == m.index {
+			gutter = m.styles.Gutter(common.StateSelected)
+			title = m.styles.Label.Render(title)
+		}
+		s += fmt.Sprintf("%s %s %s\n", gutter, title, m.styles.LabelDim.Render(e.post.Filename))
+	}
+
+	switch m.state {
+	case stateRenaming:
+		s += "\nNew title\n\n" + m.input.View() + "\n\n" + m.styles.HelpView("enter: save", "esc: cancel")
+	case stateMerging:
+		sel := m.selected()
+		prompt := fmt.Sprintf("Merge %s into %s?", sel.post.Filename, m.mergeTarget().Filename)
+		st := m.styles.Delete.Copy().MarginTop(1).MarginRight(1)
+		s += st.Render(prompt) + m.styles.DeleteDim.Render("(y/N)")
+	default:
+		s += "\n" + m.styles.HelpView("j/k, ↑/↓: choose", "r: rename", "m: merge", "esc: exit")
+	}
+
+	return s
+}
+
+func findDuplicates(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		posts, err := dbpool.PostsForUser(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return duplicatesLoadedMsg(DuplicateTitles(posts))
+	}
+}
+
+// renamePost updates the title in the database and in the stored source so
+// the next upload doesn't revert it.
+func renamePost(dbpool db.DB, post *db.Post, title string) tea.Cmd {
+	return func() tea.Msg {
+		text := pkg.SetVariable(post.Text, "title", title)
+		_, err := dbpool.UpdatePost(post.ID, title, text, post.Description, post.PublishAt)
+		if err != nil {
+			return errMsg{err}
+		}
+		return postsChangedMsg{}
+	}
+}
+
+// mergePosts appends the list items of src to dst and removes src.
+func mergePosts(dbpool db.DB, src *db.Post, dst *db.Post) tea.Cmd {
+	return func() tea.Msg {
+		text := strings.TrimRight(dst.Text, "\n") + "\n" + strings.TrimLeft(pkg.StripVariables(src.Text), "\n")
+		_, err := dbpool.UpdatePost(dst.ID, dst.Title, text, dst.Description, dst.PublishAt)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		err = dbpool.RemovePosts([]string{src.ID})
+		if err != nil {
+			return errMsg{err}
+		}
+		return postsChangedMsg{}
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -3,9 +3,8 @@ package info
 // Fetch a user's basic Charm account info
 
 import (
-	"fmt"
-
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
 	"github.com/neurosnap/lists.sh/internal/ui/common"
 )
@@ -28,11 +27,11 @@ type Model struct {
 }
 
 // NewModel returns a new Model in its initial state.
-func NewModel(user *db.User) Model {
+func NewModel(user *db.User, styles common.Styles) Model {
 	return Model{
 		Quit:   false,
 		User:   user,
-		styles: common.DefaultStyles(),
+		styles: styles,
 	}
 }
 
@@ -60,7 +59,7 @@ func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
 // View renders the current view from the model.
 func (m Model) View() string {
 	if m.Err != nil {
-		return "error: " + m.Err.Error()
+		return m.styles.Wrap.Render(m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.Err.Error()))
 	} else if m.User == nil {
 		return " Authenticating..."
 	}
@@ -76,7 +75,7 @@ func (m Model) bioView() string {
 	}
 	return common.KeyValueView(
 		"Username", username,
-		"Blog URL", fmt.Sprintf("https://lists.sh/%s", username),
+		"Blog URL", config.Current().URL(username),
 		"Public key", m.User.PublicKey.Key,
 		"Joined", m.User.CreatedAt.Format("02 Jan 2006"),
 	)
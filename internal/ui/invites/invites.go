@@ -0,0 +1,161 @@
+package invites
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+)
+
+type (
+	invitesLoadedMsg []*db.Invite
+	errMsg           struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the invites UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	limit   int
+	styles  common.Styles
+	state   state
+	invites []*db.Invite
+	err     error
+	spinner spinner.Model
+}
+
+// NewModel returns a new invites model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		limit:   config.Current().Registration.InvitesPerUser,
+		styles:  styles,
+		state:   stateLoading,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadInvites returns the command that fetches the invites the user created.
+func LoadInvites(m Model) tea.Cmd {
+	return tea.Batch(fetchInvites(m.dbpool, m.user), spinner.Tick)
+}
+
+func (m Model) canMint() bool {
+	return m.state == stateReady && len(m.invites) < m.limit
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "n":
+			if m.canMint() {
+				m.state = stateLoading
+				m.err = nil
+				return m, tea.Batch(mintInvite(m.dbpool, m.user), spinner.Tick)
+			}
+		}
+		return m, nil
+
+	case invitesLoadedMsg:
+		m.state = stateReady
+		m.invites = msg
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	if m.state == stateLoading {
+		return m.spinner.View() + " Loading invites..."
+	}
+
+	s := "Invites\n\n"
+	if config.Current().Registration.Mode != config.RegistrationInvite {
+		s += m.styles.Subtle.Render("Registration is not invite only right now, codes will still work if that changes.") + "\n\n"
+	}
+
+	if len(m.invites) == 0 {
+		s += m.styles.Subtle.Render("You haven't created any invite codes yet.") + "\n\n"
+	}
+	for _, invite := range m.invites {
+		status := m.styles.Note.Render("unused")
+		if invite.UsedAt != nil {
+			status = m.styles.LabelDim.Render(fmt.Sprintf("used %s", invite.UsedAt.Format("02 Jan, 2006")))
+		}
+		s += fmt.Sprintf(
+			"%s %s %s\n",
+			common.VerticalLine(common.StateNormal),
+			m.styles.Label.Render(invite.Code),
+			status,
+		)
+	}
+
+	if m.err != nil {
+		s += "\n" + m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.err.Error()) + "\n"
+	}
+
+	s += "\n" + m.styles.Subtle.Render(fmt.Sprintf("%d of %d invites created.", len(m.invites), m.limit))
+
+	help := []string{"esc: exit"}
+	if m.canMint() {
+		help = append([]string{"n: new invite"}, help...)
+	}
+	return s + "\n\n" + m.styles.HelpView(help...)
+}
+
+func fetchInvites(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		invites, err := dbpool.FindInvitesForUser(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return invitesLoadedMsg(invites)
+	}
+}
+
+func mintInvite(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		_, err := dbpool.InsertInvite(user.ID, internal.NewInviteCode())
+		if err != nil {
+			return errMsg{err}
+		}
+		return fetchInvites(dbpool, user)()
+	}
+}
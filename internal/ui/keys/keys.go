@@ -0,0 +1,412 @@
+package keys
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	pager "github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gliderlabs/ssh"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// keysPerPage is how many keys are listed at a time until the window size
+// is known.
+const keysPerPage = 4
+
+// linesPerKey is the height of a single key in the list.
+const linesPerKey = 3
+
+// chromeLines is everything around the list: the heading, the pager, a
+// notice and the help line.
+const chromeLines = 8
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateAdding
+	stateDeleting
+)
+
+type (
+	keysLoadedMsg struct {
+		keys     []*db.PublicKey
+		settings *db.UserSettings
+	}
+	keyAddedMsg   string
+	keyRemovedMsg string
+	errMsg        struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the keys UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	styles  common.Styles
+	state   state
+	keys    []*db.PublicKey
+	index   int
+	pager   pager.Model
+	newKey  input.Model
+	perPage int // the user's preference, db.KeysPerPageAuto to fit the window
+	height  int
+	err     error
+	toast   common.Toast
+	spinner spinner.Model
+}
+
+// NewModel returns a new keys model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	p := pager.NewModel()
+	p.PerPage = keysPerPage
+	p.Type = pager.Dots
+	p.InactiveDot = styles.InactivePagination.Render("•")
+
+	ni := input.New()
+	ni.CursorStyle = styles.Cursor
+	ni.Prompt = styles.FocusedPrompt.String()
+	ni.Placeholder = "ssh-ed25519 AAAA..."
+	ni.CharLimit = 4096
+
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		pager:   p,
+		newKey:  ni,
+		perPage: db.KeysPerPageAuto,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// SetSize fits the list in the room the screen has.
+func (m *Model) SetSize(width, height int) {
+	m.height = height
+	m.fitPage()
+}
+
+// fitPage shows the user's choice of keys per page, or as many as fit, and
+// keeps the selected key in view.
+func (m *Model) fitPage() {
+	fit := keysPerPage
+	if m.height > 0 {
+		fit = max(1, (m.height-chromeLines)/linesPerKey)
+	}
+	perPage := fit
+	if m.perPage != db.KeysPerPageAuto {
+		perPage = min(m.perPage, fit)
+	}
+
+	selected := m.index + m.pager.Page*m.pager.PerPage
+	m.pager.PerPage = perPage
+	m.pager.SetTotalPages(len(m.keys))
+	if len(m.keys) == 0 {
+		m.pager.Page, m.index = 0, 0
+		return
+	}
+	selected = min(selected, len(m.keys)-1)
+	m.pager.Page = selected / perPage
+	m.index = selected % perPage
+}
+
+// LoadKeys returns the command that fetches the user's keys.
+func LoadKeys(m Model) tea.Cmd {
+	return tea.Batch(fetchKeys(m.dbpool, m.user), spinner.Tick)
+}
+
+// selected returns the key under the cursor.
+func (m Model) selected() *db.PublicKey {
+	return m.keys[m.index+m.pager.Page*m.pager.PerPage]
+}
+
+// current reports whether pk is the key this session signed in with.
+func (m Model) current(pk *db.PublicKey) bool {
+	return m.user.PublicKey != nil && m.user.PublicKey.ID == pk.ID
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	var toastCmd tea.Cmd
+	m.toast, toastCmd = m.toast.Update(msg)
+	if _, ok := msg.(common.ToastMsg); ok {
+		return m, toastCmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.state {
+		case stateAdding:
+			return updateAdding(msg, m)
+		case stateDeleting:
+			m.state = stateReady
+			switch msg.String() {
+			case "ctrl+c":
+				m.Quit = true
+			case "y":
+				return m, removeKey(m.dbpool, m.user, m.selected())
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "up", "k":
+			m.index--
+			if m.index < 0 && m.pager.Page > 0 {
+				m.index = m.pager.PerPage - 1
+				m.pager.PrevPage()
+			}
+			m.index = max(0, m.index)
+		case "down", "j":
+			itemsOnPage := m.pager.ItemsOnPage(len(m.keys))
+			m.index++
+			if m.index > itemsOnPage-1 && m.pager.Page < m.pager.TotalPages-1 {
+				m.index = 0
+				m.pager.NextPage()
+			}
+			m.index = max(0, min(itemsOnPage-1, m.index))
+		case "left", "h":
+			m.pager.PrevPage()
+			m.index = min(m.index, m.pager.ItemsOnPage(len(m.keys))-1)
+		case "right", "l":
+			m.pager.NextPage()
+			m.index = min(m.index, m.pager.ItemsOnPage(len(m.keys))-1)
+		case "n":
+			if m.state == stateReady {
+				m.state = stateAdding
+				m.err = nil
+				m.newKey.Reset()
+				return m, m.newKey.Focus()
+			}
+		case "x":
+			if m.state != stateReady || len(m.keys) == 0 {
+				return m, nil
+			}
+			if len(m.keys) == 1 {
+				return m, m.toast.Error(db.ErrLastKey)
+			}
+			m.state = stateDeleting
+		}
+		return m, nil
+
+	case keysLoadedMsg:
+		m.state = stateReady
+		m.keys = msg.keys
+		m.perPage = msg.settings.KeysPerPage
+		m.fitPage()
+		return m, nil
+
+	case keyAddedMsg:
+		return m, tea.Batch(m.toast.Info("Added "+string(msg)), fetchKeys(m.dbpool, m.user))
+
+	case keyRemovedMsg:
+		return m, tea.Batch(m.toast.Info("Removed "+string(msg)), fetchKeys(m.dbpool, m.user))
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	if m.state == stateAdding {
+		m.newKey, cmd = m.newKey.Update(msg)
+	}
+	return m, cmd
+}
+
+func updateAdding(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.Quit = true
+		return m, nil
+	case "esc":
+		m.state = stateReady
+		m.newKey.Blur()
+		m.err = nil
+		return m, nil
+	case "enter":
+		key, err := parseKey(m.newKey.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		for _, pk := range m.keys {
+			if pk.Key == key {
+				m.err = errors.New("that key is already on your account")
+				return m, nil
+			}
+		}
+		m.state = stateReady
+		m.newKey.Blur()
+		m.err = nil
+		return m, addKey(m.dbpool, m.user, key)
+	}
+
+	var cmd tea.Cmd
+	m.newKey, cmd = m.newKey.Update(msg)
+	return m, cmd
+}
+
+// parseKey reads a pasted authorized_keys line and returns the key the same
+// way it's stored when someone signs in with it, without the comment.
+func parseKey(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("paste a public key, like the contents of ~/.ssh/id_ed25519.pub")
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(text))
+	if err != nil {
+		return "", errors.New("that doesn't look like an ssh public key")
+	}
+	return fmt.Sprintf("%s %s", pk.Type(), base64.StdEncoding.EncodeToString(pk.Marshal())), nil
+}
+
+// keyType returns the algorithm of a stored key, like ssh-ed25519.
+func keyType(key string) string {
+	return strings.SplitN(key, " ", 2)[0]
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	if m.state == stateLoading {
+		return m.spinner.View() + " " + m.styles.T("Loading keys...")
+	}
+
+	s := m.styles.T("Keys") + "\n\n"
+	start, end := m.pager.GetSliceBounds(len(m.keys))
+	for i, pk := range m.keys[start:end] {
+		s += keyView(m, pk, i == m.index)
+	}
+	if m.pager.TotalPages > 1 {
+		s += m.pager.View() + "\n\n"
+	}
+
+	switch m.state {
+	case stateAdding:
+		s += m.styles.T("Paste a public key:") + "\n\n" + m.newKey.View() + "\n"
+		if m.err != nil {
+			s += "\n" + m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error())) + "\n"
+		}
+		return s + "\n" + m.styles.HelpView("enter: add", "esc: cancel")
+	case stateDeleting:
+		prompt := m.styles.T("Remove this key?")
+		if m.current(m.selected()) {
+			prompt = m.styles.T("You're signed in with this key, remove it anyway?")
+		}
+		st := m.styles.Delete.Copy().MarginRight(1)
+		return s + st.Render(prompt) + m.styles.DeleteDim.Render("(y/N)")
+	}
+
+	if m.err != nil {
+		s += m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error())) + "\n\n"
+	} else if m.toast.Visible() {
+		s += m.toast.View(m.styles) + "\n\n"
+	}
+
+	help := []string{"j/k, ↑/↓: choose"}
+	if m.pager.TotalPages > 1 {
+		help = append(help, "h/l, ←/→: page")
+	}
+	help = append(help, "n: add key", "x: remove", "esc: exit")
+	return s + m.styles.HelpView(help...)
+}
+
+func keyView(m Model, pk *db.PublicKey, selected bool) string {
+	gutter, keyLabel, usedLabel := " ", "Key:", "Added:"
+	switch {
+	case selected && m.state == stateDeleting:
+		gutter = m.styles.Gutter(common.StateDeleting)
+		keyLabel = m.styles.Delete.Render(keyLabel)
+		usedLabel = m.styles.Delete.Render(usedLabel)
+	case selected:
+		gutter = m.styles.Gutter(common.StateSelected)
+		keyLabel = m.styles.Label.Render(keyLabel)
+		usedLabel = m.styles.Label.Render(usedLabel)
+	}
+
+	name := internal.KeyFingerprint(pk.Key) + " " + m.styles.Subtle.Render(keyType(pk.Key))
+	if m.current(pk) {
+		name += " " + m.styles.Note.Render(m.styles.T("(this session)"))
+	}
+
+	lastUsed := "never used"
+	if pk.LastUsedAt != nil {
+		lastUsed = "last used " + pk.LastUsedAt.Format("02 Jan, 2006 15:04")
+	}
+	dates := m.styles.LabelDim.Render(pk.CreatedAt.Format("02 Jan, 2006")) + " " + m.styles.Subtle.Render(lastUsed)
+
+	return fmt.Sprintf("%s %s %s\n%s %s %s\n\n", gutter, keyLabel, name, gutter, usedLabel, dates)
+}
+
+func fetchKeys(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		keys, err := dbpool.ListKeysForUser(user)
+		if err != nil {
+			return errMsg{err}
+		}
+		settings, err := dbpool.FindUserSettings(user.ID)
+		if err != nil {
+			settings = db.DefaultUserSettings()
+		}
+		return keysLoadedMsg{keys, settings}
+	}
+}
+
+func addKey(dbpool db.DB, user *db.User, key string) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.LinkUserKey(user.ID, key)
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return keyAddedMsg(internal.KeyFingerprint(key))
+	}
+}
+
+func removeKey(dbpool db.DB, user *db.User, pk *db.PublicKey) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.RemoveUserKey(user.ID, pk.ID)
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return keyRemovedMsg(internal.KeyFingerprint(pk.Key))
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
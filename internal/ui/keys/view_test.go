@@ -0,0 +1,56 @@
+package keys
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/golden"
+)
+
+func TestView(t *testing.T) {
+	added := time.Date(2022, 5, 1, 9, 30, 0, 0, time.UTC)
+	used := time.Date(2022, 5, 20, 18, 0, 0, 0, time.UTC)
+	me := &db.User{ID: "me", PublicKey: &db.PublicKey{ID: "a"}}
+	msg := loaded(
+		&db.PublicKey{ID: "a", Key: pasted[:len(pasted)-len(" me@laptop")], CreatedAt: &added, LastUsedAt: &used},
+		&db.PublicKey{ID: "b", Key: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC7", CreatedAt: &added},
+	)
+
+	// Keys are listed the same at any width, only the height matters.
+	t.Run("loading", func(t *testing.T) {
+		m := NewModel(nil, me, common.DefaultStyles())
+		m.SetSize(80, 24)
+		golden.Assert(t, "loading", View(m))
+	})
+
+	t.Run("normal", func(t *testing.T) {
+		m, _ := Update(msg, NewModel(nil, me, common.DefaultStyles()))
+		m.SetSize(80, 24)
+		golden.Assert(t, "normal", View(m))
+	})
+
+	t.Run("deleting", func(t *testing.T) {
+		m, _ := Update(msg, NewModel(nil, me, common.DefaultStyles()))
+		m.SetSize(80, 24)
+		m, _ = Update(key("j"), m)
+		m, _ = Update(key("x"), m)
+		golden.Assert(t, "deleting", View(m))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		m, _ := Update(msg, NewModel(nil, me, common.DefaultStyles()))
+		m.SetSize(80, 24)
+		m, _ = Update(errMsg{errors.New("connection refused")}, m)
+		golden.Assert(t, "error", View(m))
+	})
+
+	t.Run("adding", func(t *testing.T) {
+		m, _ := Update(msg, NewModel(nil, me, common.DefaultStyles()))
+		m.SetSize(80, 24)
+		m, _ = Update(key("n"), m)
+		golden.Assert(t, "adding", View(m))
+	})
+}
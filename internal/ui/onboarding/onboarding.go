@@ -0,0 +1,200 @@
+package onboarding
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/scp"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// The account form is the first step, picking a username.
+const totalSteps = 4
+
+// sampleFilename is what the sample post is saved as.
+const sampleFilename = "hello-world.txt"
+
+const sampleText = `=: title Hello world
+=: description My first list
+# Things to try
+write lists in plain text files, one item per line
+send them with scp
+[ ] delete this post once you've got the hang of it
+> lines starting with > are quotes
+=> %s the help page has everything else
+`
+
+type step int
+
+const (
+	stepURL step = iota
+	stepUpload
+	stepSample
+	stepFinished
+)
+
+type (
+	sampleSavedMsg *db.Post
+	errMsg         struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the onboarding wizard new users go through after
+// picking a username.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	styles  common.Styles
+	step    step
+	saving  bool
+	sample  *db.Post
+	err     error
+	spinner spinner.Model
+}
+
+// NewModel returns a new onboarding model on its first step.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		step:    stepURL,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.saving {
+			if msg.String() == "ctrl+c" {
+				m.Quit = true
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "esc":
+			m.Done = true
+		case "left", "h":
+			if m.step > stepURL && m.step < stepFinished {
+				m.step--
+			}
+		case "right", "l", "enter":
+			if m.step == stepFinished {
+				m.Done = true
+			} else if m.step < stepSample {
+				m.step++
+			}
+		case "y":
+			if m.step == stepSample {
+				m.saving = true
+				m.err = nil
+				return m, tea.Batch(saveSample(m.dbpool, m.user), spinner.Tick)
+			}
+		case "n":
+			if m.step == stepSample {
+				m.step = stepFinished
+			}
+		}
+		return m, nil
+
+	case sampleSavedMsg:
+		m.saving = false
+		m.sample = msg
+		m.step = stepFinished
+		return m, nil
+
+	case errMsg:
+		m.saving = false
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.saving {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// scpCommand is the upload command for the user.
+func scpCommand(name string, domain string) string {
+	return fmt.Sprintf("scp ~/blog/*.txt %s@%s:/", name, domain)
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	cfg := config.Current()
+	blogURL := cfg.URL(m.user.Name)
+
+	var s string
+	switch m.step {
+	case stepURL:
+		s = heading(m, "Your blog")
+		s += "You're all set, " + m.styles.Label.Render(m.user.Name) + "! Your blog lives at\n\n"
+		s += "  " + m.styles.Label.Render(blogURL) + "\n\n"
+		s += m.styles.Subtle.Render("Everything you publish shows up there, in your feed and on the discovery page.")
+		return s + "\n\n" + m.styles.HelpView("enter: next", "esc: skip")
+
+	case stepUpload:
+		s = heading(m, "Publishing")
+		s += "Write your lists in plain text files, one item per line, in a folder\n"
+		s += "like ~/blog. Then send them over:\n\n"
+		s += "  " + m.styles.Label.Render(scpCommand(m.user.Name, cfg.Domain)) + "\n\n"
+		s += m.styles.Subtle.Render("Each file becomes a post named after it, hello-world.txt is published at " + cfg.URL(m.user.Name, "hello-world") + ". Send a file again to update it.")
+		return s + "\n\n" + m.styles.HelpView("enter: next", "h: back", "esc: skip")
+
+	case stepSample:
+		s = heading(m, "A first post")
+		s += "Want a sample post to see how a list looks? You can edit or delete\n"
+		s += "it from Manage posts any time."
+		if m.saving {
+			return s + "\n\n" + m.spinner.View() + " Publishing..."
+		}
+		if m.err != nil {
+			s += "\n\n" + m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error()))
+		}
+		return s + "\n\n" + m.styles.HelpView("y: publish a sample", "n: no thanks", "h: back")
+	}
+
+	s = m.styles.Label.Render("That's it!") + "\n\n"
+	if m.sample != nil {
+		s += "Your sample post is up at " + m.styles.Label.Render(cfg.URL(m.user.Name, m.sample.Filename)) + "\n\n"
+	}
+	s += "Manage posts lists everything you've published, and Read shows what\n"
+	s += "everyone else is writing."
+	return s + "\n\n" + m.styles.HelpView("enter: go to the menu")
+}
+
+func heading(m Model, title string) string {
+	step := fmt.Sprintf("Step %d of %d", int(m.step)+2, totalSteps)
+	return m.styles.Subtle.Render(step) + "  " + title + "\n\n"
+}
+
+func saveSample(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		var notices bytes.Buffer
+		logger := internal.CreateLogger()
+		post, err := scp.SavePost(logger, &notices, dbpool, user, sampleFilename, fmt.Sprintf(sampleText, config.Current().URL("help")))
+		if err != nil {
+			return errMsg{err}
+		}
+		return sampleSavedMsg(post)
+	}
+}
@@ -0,0 +1,56 @@
+package onboarding
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestScpCommand(t *testing.T) {
+	is := is.New(t)
+	is.Equal(scpCommand("erock", "lists.sh"), "scp ~/blog/*.txt erock@lists.sh:/")
+}
+
+func TestSteps(t *testing.T) {
+	user := &db.User{ID: "1", Name: "erock"}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	t.Run("skipping the sample finishes the wizard", func(t *testing.T) {
+		is := is.New(t)
+		m := NewModel(nil, user, common.DefaultStyles())
+		m, _ = Update(enter, m)
+		m, _ = Update(key("h"), m)
+		is.Equal(m.step, stepURL)
+
+		m, _ = Update(enter, m)
+		m, _ = Update(enter, m)
+		is.Equal(m.step, stepSample)
+		m, _ = Update(enter, m)
+		is.Equal(m.step, stepSample) // the sample needs a yes or no
+
+		m, _ = Update(key("n"), m)
+		is.Equal(m.step, stepFinished)
+		m, _ = Update(enter, m)
+		is.True(m.Done)
+	})
+
+	t.Run("publishing the sample", func(t *testing.T) {
+		is := is.New(t)
+		m := NewModel(nil, user, common.DefaultStyles())
+		m.step = stepSample
+		m, cmd := Update(key("y"), m)
+		is.True(m.saving)
+		is.True(cmd != nil)
+
+		m, _ = Update(sampleSavedMsg(&db.Post{Filename: "hello-world"}), m)
+		is.Equal(m.step, stepFinished)
+		is.Equal(m.sample.Filename, "hello-world")
+	})
+}
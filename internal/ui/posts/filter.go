@@ -0,0 +1,110 @@
+package posts
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// searchThreshold is the number of posts past which the filter asks the
+// database instead of scanning the loaded list on every key press.
+const searchThreshold = 200
+
+type filteredMsg struct {
+	query string
+	posts []*db.Post
+}
+
+func newFilterInput(st common.Styles) input.Model {
+	fi := input.New()
+	fi.CursorStyle = st.Cursor
+	fi.Prompt = st.FocusedPrompt.Copy().SetString("/").String()
+	fi.Placeholder = "filter by title or filename"
+	fi.CharLimit = 100
+	return fi
+}
+
+// matchPost reports whether the post's title or filename contains query,
+// ignoring case.
+func matchPost(post *db.Post, query string) bool {
+	query = strings.ToLower(query)
+	return strings.Contains(strings.ToLower(post.Title), query) ||
+		strings.Contains(strings.ToLower(post.Filename), query)
+}
+
+func filterPosts(posts []*db.Post, query string) []*db.Post {
+	filtered := []*db.Post{}
+	for _, post := range posts {
+		if matchPost(post, query) {
+			filtered = append(filtered, post)
+		}
+	}
+	return filtered
+}
+
+// filterQuery returns the current filter, or "" when there isn't one.
+func (m Model) filterQuery() string {
+	return strings.TrimSpace(m.filter.Value())
+}
+
+// applyFilter narrows the visible posts to the current filter and moves the
+// cursor back to the top. Large accounts are searched in the database.
+func (m *Model) applyFilter() tea.Cmd {
+	query := m.filterQuery()
+	m.index = 0
+	m.pager.Page = 0
+
+	// The database search skips the trash, which is never that big.
+	if query != "" && len(m.all) > searchThreshold && !m.inTrash() {
+		return m.busy.Run("Searching for "+strconv.Quote(query), searchPosts(m.dbpool, m.user.ID, query))
+	}
+
+	if query == "" {
+		m.posts = m.all
+	} else {
+		m.posts = filterPosts(m.all, query)
+	}
+	m.pager.SetTotalPages(len(m.posts))
+	return nil
+}
+
+// updateFilter sends keys to the filter input while it's focused.
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		m.Exit = true
+		return m, nil
+	case key.Matches(msg, m.keys.Back):
+		m.filter.Reset()
+		m.filter.Blur()
+		m.state = stateNormal
+		return m, m.applyFilter()
+	case msg.Type == tea.KeyEnter, msg.Type == tea.KeyTab, msg.Type == tea.KeyDown:
+		m.filter.Blur()
+		m.state = stateNormal
+		return m, nil
+	}
+
+	before := m.filter.Value()
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != before {
+		return m, tea.Batch(cmd, m.applyFilter())
+	}
+	return m, cmd
+}
+
+func searchPosts(dbpool db.DB, userID string, query string) tea.Cmd {
+	return func() tea.Msg {
+		posts, err := dbpool.SearchPostsForUser(userID, query)
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return filteredMsg{query: query, posts: posts}
+	}
+}
@@ -0,0 +1,35 @@
+package posts
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestFilterPosts(t *testing.T) {
+	posts := []*db.Post{
+		{ID: "1", Title: "Groceries", Filename: "shopping"},
+		{ID: "2", Title: "Reading list", Filename: "books"},
+		{ID: "3", Title: "Weekend", Filename: "todo"},
+	}
+
+	t.Run("matches titles ignoring case", func(t *testing.T) {
+		is := is.New(t)
+		got := filterPosts(posts, "READ")
+		is.Equal(len(got), 1)
+		is.Equal(got[0].ID, "2")
+	})
+
+	t.Run("matches filenames", func(t *testing.T) {
+		is := is.New(t)
+		got := filterPosts(posts, "do")
+		is.Equal(len(got), 1)
+		is.Equal(got[0].ID, "3")
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(len(filterPosts(posts, "zzz")), 0)
+	})
+}
@@ -0,0 +1,138 @@
+package posts
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// KeyMap holds the key bindings for the posts list.
+type KeyMap struct {
+	Up         key.Binding
+	Down       key.Binding
+	PrevPage   key.Binding
+	NextPage   key.Binding
+	PrevTab    key.Binding
+	NextTab    key.Binding
+	Mark       key.Binding
+	Filter     key.Binding
+	Sort       key.Binding
+	New        key.Binding
+	View       key.Binding
+	Copy       key.Binding
+	Share      key.Binding
+	QR         key.Binding
+	Edit       key.Binding
+	Rename     key.Binding
+	RemoteEdit key.Binding
+	Delete     key.Binding
+	Publish    key.Binding
+	Undo       key.Binding
+	Restore    key.Binding
+	Confirm    key.Binding
+	Help       key.Binding
+	Back       key.Binding
+	Quit       key.Binding
+}
+
+// DefaultKeyMap returns the vim flavored bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:         key.NewBinding(key.WithKeys("k", "up"), key.WithHelp("k/↑", "up")),
+		Down:       key.NewBinding(key.WithKeys("j", "down"), key.WithHelp("j/↓", "down")),
+		PrevPage:   key.NewBinding(key.WithKeys("h", "left", "pgup"), key.WithHelp("h/←", "prev page")),
+		NextPage:   key.NewBinding(key.WithKeys("l", "right", "pgdown"), key.WithHelp("l/→", "next page")),
+		PrevTab:    key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "prev tab")),
+		NextTab:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next tab")),
+		Mark:       key.NewBinding(key.WithKeys(" ", "space"), key.WithHelp("space", "mark")),
+		Filter:     key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+		Sort:       key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+		New:        key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new")),
+		View:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "view")),
+		Copy:       key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy url")),
+		Share:      key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "copy share link")),
+		QR:         key.NewBinding(key.WithKeys("Q"), key.WithHelp("Q", "qr code")),
+		Edit:       key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "edit")),
+		Rename:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rename")),
+		RemoteEdit: key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "$EDITOR")),
+		Delete:     key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete")),
+		Publish:    key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "publish/unpublish")),
+		Undo:       key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "undo")),
+		Restore:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "restore")),
+		Confirm:    key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm")),
+		Help:       key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Back:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+		Quit:       key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "exit")),
+	}
+}
+
+// EmacsKeyMap moves around with the emacs control keys instead of hjkl.
+func EmacsKeyMap() KeyMap {
+	km := DefaultKeyMap()
+	km.Up = key.NewBinding(key.WithKeys("ctrl+p", "up"), key.WithHelp("C-p/↑", "up"))
+	km.Down = key.NewBinding(key.WithKeys("ctrl+n", "down"), key.WithHelp("C-n/↓", "down"))
+	km.PrevPage = key.NewBinding(key.WithKeys("alt+v", "left", "pgup"), key.WithHelp("M-v/←", "prev page"))
+	km.NextPage = key.NewBinding(key.WithKeys("ctrl+v", "right", "pgdown"), key.WithHelp("C-v/→", "next page"))
+	km.Back = key.NewBinding(key.WithKeys("ctrl+g", "esc"), key.WithHelp("C-g", "back"))
+	return km
+}
+
+// keyMapFor returns the bindings the user picked in their settings.
+func keyMapFor(name string) KeyMap {
+	if name == db.KeyMapEmacs {
+		return EmacsKeyMap()
+	}
+	return DefaultKeyMap()
+}
+
+// translated returns the bindings with their help in the user's language.
+func (k KeyMap) translated(st common.Styles) KeyMap {
+	for _, b := range []*key.Binding{
+		&k.Up, &k.Down, &k.PrevPage, &k.NextPage, &k.PrevTab, &k.NextTab,
+		&k.Mark, &k.Filter, &k.Sort, &k.New, &k.View, &k.Copy, &k.Share, &k.QR,
+		&k.Edit, &k.Rename, &k.RemoteEdit, &k.Delete, &k.Publish, &k.Undo, &k.Restore,
+		&k.Confirm, &k.Help, &k.Back, &k.Quit,
+	} {
+		b.SetHelp(b.Help().Key, st.T(b.Help().Desc))
+	}
+	return k
+}
+
+// ShortHelp satisfies help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Quit}
+}
+
+// FullHelp satisfies help.KeyMap, it's what the "?" overlay shows.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PrevPage, k.NextPage, k.PrevTab, k.NextTab},
+		{k.New, k.View, k.Copy, k.Share, k.QR, k.Edit, k.Rename, k.RemoteEdit, k.Filter, k.Sort},
+		{k.Mark, k.Delete, k.Publish, k.Undo, k.Restore},
+		{k.Help, k.Back, k.Quit},
+	}
+}
+
+// newHelp styles the full help overlay to match the rest of the UI.
+func newHelp(st common.Styles) help.Model {
+	h := help.New()
+	h.Styles.FullKey = st.Label
+	h.Styles.FullDesc = st.Subtle
+	h.Styles.FullSeparator = st.Subtle
+	return h
+}
+
+// helpItem renders a binding for the help line, desc replaces the binding's
+// own description when it isn't empty.
+func helpItem(b key.Binding, desc string) string {
+	if desc == "" {
+		desc = b.Help().Desc
+	}
+	return b.Help().Key + ": " + desc
+}
+
+// helpPair renders two bindings that share a description, like up and down.
+func helpPair(a, b key.Binding, desc string) string {
+	return a.Help().Key + ", " + b.Help().Key + ": " + desc
+}
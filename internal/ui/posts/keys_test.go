@@ -0,0 +1,35 @@
+package posts
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestKeyMapFor(t *testing.T) {
+	ctrlN := tea.KeyMsg{Type: tea.KeyCtrlN}
+	j := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+
+	t.Run("default moves with hjkl", func(t *testing.T) {
+		is := is.New(t)
+		km := keyMapFor(db.KeyMapDefault)
+		is.True(key.Matches(j, km.Down))
+		is.True(!key.Matches(ctrlN, km.Down))
+	})
+
+	t.Run("emacs moves with control keys", func(t *testing.T) {
+		is := is.New(t)
+		km := keyMapFor(db.KeyMapEmacs)
+		is.True(key.Matches(ctrlN, km.Down))
+		is.True(!key.Matches(j, km.Down))
+		is.Equal(helpPair(km.Up, km.Down, "choose"), "C-p/↑, C-n/↓: choose")
+	})
+
+	t.Run("unknown names fall back to the default", func(t *testing.T) {
+		is := is.New(t)
+		is.True(key.Matches(j, keyMapFor("vscode").Down))
+	})
+}
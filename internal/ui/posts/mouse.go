@@ -0,0 +1,95 @@
+package posts
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// updateMouse handles the wheel and clicks on the list. Positions are
+// relative to the top left corner of the posts view.
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.state != stateNormal || m.showHelp {
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.MouseWheelUp:
+		m.moveUp()
+	case tea.MouseWheelDown:
+		m.moveDown()
+	case tea.MouseLeft:
+		if msg.Y == 0 {
+			if tab := tabAt(m, msg.X); tab >= 0 && tab != m.tab {
+				return m, m.switchTab(tab - m.tab)
+			}
+			return m, nil
+		}
+
+		if i, ok := postAt(m, msg.Y); ok {
+			if i == m.index {
+				// Clicking the selected post opens it, like enter.
+				m.state = stateViewingPost
+				m.detail = common.NewDetailViewport(m.styles, m.posts[m.getSelectedIndex()].Text)
+				return m, nil
+			}
+			m.index = i
+			return m, nil
+		}
+
+		if page, ok := pageAt(m, msg.X, msg.Y); ok {
+			m.pager.Page = page
+			m.UpdatePaging()
+		}
+	}
+	return m, nil
+}
+
+// listTop is the line the first post is drawn on, under the tabs and the
+// filter.
+func listTop(m Model) int {
+	if m.state == stateFiltering || m.filterQuery() != "" {
+		return 4
+	}
+	return 2
+}
+
+// postAt returns the index on the current page of the post drawn on line y.
+func postAt(m Model, y int) (int, bool) {
+	y -= listTop(m)
+	if y < 0 {
+		return 0, false
+	}
+	i := y / linesPerPost
+	if i >= m.pager.ItemsOnPage(len(m.posts)) {
+		return 0, false
+	}
+	return i, true
+}
+
+// pageAt returns the page whose dot is drawn at x on line y.
+func pageAt(m Model, x, y int) (int, bool) {
+	if m.pager.TotalPages < 2 || y != listTop(m)+m.pager.PerPage*linesPerPost {
+		return 0, false
+	}
+	if x < 0 || x >= m.pager.TotalPages {
+		return 0, false
+	}
+	return x, true
+}
+
+// tabAt returns the tab whose label is drawn at x, or -1.
+func tabAt(m Model, x int) int {
+	left := 0
+	for i, t := range tabs {
+		width := lipgloss.Width(t.label)
+		if m.styles.NoColor && i == m.tab {
+			width += 2 // the brackets
+		}
+		if x >= left && x < left+width {
+			return i
+		}
+		left += width + 2
+	}
+	return -1
+}
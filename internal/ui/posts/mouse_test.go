@@ -0,0 +1,88 @@
+package posts
+
+import (
+	"testing"
+
+	pager "github.com/charmbracelet/bubbles/paginator"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+func TestUpdateMouse(t *testing.T) {
+	newModel := func() Model {
+		m := Model{
+			pager:  pager.NewModel(),
+			styles: common.NewStyles(db.ThemeNoColor),
+			state:  stateNormal,
+			marked: map[string]bool{},
+		}
+		m.pager.PerPage = 2
+		m.all = []*db.Post{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}}
+		m.posts = m.all
+		m.pager.SetTotalPages(len(m.posts))
+		return m
+	}
+	click := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{Type: tea.MouseLeft, X: x, Y: y}
+	}
+
+	t.Run("the wheel moves the selection across pages", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		for i := 0; i < 2; i++ {
+			next, _ := m.Update(tea.MouseMsg{Type: tea.MouseWheelDown})
+			m = next.(Model)
+		}
+		is.Equal(m.pager.Page, 1)
+		is.Equal(m.getSelectedIndex(), 2)
+
+		next, _ := m.Update(tea.MouseMsg{Type: tea.MouseWheelUp})
+		m = next.(Model)
+		is.Equal(m.pager.Page, 0)
+		is.Equal(m.getSelectedIndex(), 1)
+	})
+
+	t.Run("clicking a post selects it and clicking again opens it", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		second := listTop(m) + linesPerPost
+		next, _ := m.Update(click(4, second))
+		m = next.(Model)
+		is.Equal(m.index, 1)
+		is.Equal(m.state, stateNormal)
+
+		next, _ = m.Update(click(4, second+1))
+		m = next.(Model)
+		is.Equal(m.state, stateViewingPost)
+	})
+
+	t.Run("clicking a dot goes to its page", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		dots := listTop(m) + m.pager.PerPage*linesPerPost
+		next, _ := m.Update(click(2, dots))
+		m = next.(Model)
+		is.Equal(m.pager.Page, 2)
+		is.Equal(m.index, 0)
+	})
+
+	t.Run("clicks past the list do nothing", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		next, _ := m.Update(click(0, 40))
+		m = next.(Model)
+		is.Equal(m.index, 0)
+		is.Equal(m.pager.Page, 0)
+	})
+}
+
+func TestTabAt(t *testing.T) {
+	is := is.New(t)
+	m := Model{styles: common.NewStyles(db.ThemeNoColor)}
+	is.Equal(tabAt(m, 0), 0)
+	is.Equal(tabAt(m, len(tabs[0].label)+2), -1) // the gap after "[Published]"
+	is.Equal(tabAt(m, len(tabs[0].label)+2+2), 1)
+	is.Equal(tabAt(m, 200), -1)
+}
@@ -0,0 +1,84 @@
+package posts
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/qr"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+func detailView(m Model, post *db.Post) string {
+	s := m.styles.Label.Render(post.Title) + "\n\n"
+
+	status := "published"
+	if post.HiddenAt != nil {
+		status = "hidden: " + post.HiddenReason
+	} else if post.FlaggedReason != "" {
+		status = "held for review: " + post.FlaggedReason
+	}
+
+	shortURL, clicks := "…", "…"
+	if m.shortLink != nil && m.shortLink.PostID == post.ID {
+		shortURL = config.Current().URL(internal.ShortLinkPath, m.shortLink.Slug)
+		clicks = fmt.Sprintf("%d", m.shortLink.Clicks)
+	}
+
+	s += common.KeyValueView(
+		"URL", config.Current().URL(post.Username, post.Filename),
+		"Short URL", shortURL,
+		"Published", post.PublishAt.In(m.loc).Format("Mon January 2, 2006"),
+		"Views", fmt.Sprintf("%d", post.Views),
+		"Clicks", clicks,
+		"Status", status,
+	)
+	s += "\n\n" + m.detail.View() + "\n\n"
+
+	help := []string{"esc: back"}
+	if scroll := common.DetailScrollHelp(m.detail); scroll != "" {
+		help = append([]string{scroll}, help...)
+	}
+	return s + m.styles.HelpView(help...)
+}
+
+// qrView draws a QR code of the post's short link, for holding a phone up to
+// the screen.
+func qrView(m Model, post *db.Post) string {
+	s := m.styles.Label.Render(post.Title) + "\n\n"
+	if m.shortLink == nil || m.shortLink.PostID != post.ID {
+		return s + m.spinner.View() + " Loading...\n\n" + m.styles.HelpView("esc: back")
+	}
+
+	link := config.Current().URL(internal.ShortLinkPath, m.shortLink.Slug)
+	code, err := qr.Encode(link)
+	if err != nil {
+		s += m.styles.Error.Render(err.Error())
+	} else {
+		s += code.Terminal()
+	}
+	s += "\n\n" + common.KeyValueView(
+		"URL", link,
+		"Image", config.Current().URL(post.Username, post.Filename, "qr.png"),
+	)
+	return s + "\n\n" + m.styles.HelpView("esc: back")
+}
+
+// shortLinkMsg carries the short link of the post being viewed.
+type shortLinkMsg struct {
+	link *db.ShortLink
+}
+
+// loadShortLink fetches the post's short link, making it if it's new.  A
+// failure just leaves it out of the details.
+func loadShortLink(dbpool db.DB, post *db.Post) tea.Cmd {
+	return func() tea.Msg {
+		link, err := dbpool.ShortLinkForPost(post.ID)
+		if err != nil {
+			return shortLinkMsg{}
+		}
+		return shortLinkMsg{link: link}
+	}
+}
@@ -3,6 +3,7 @@ package posts
 import (
 	"fmt"
 
+	"github.com/muesli/reflow/truncate"
 	"github.com/neurosnap/lists.sh/internal/db"
 	"github.com/neurosnap/lists.sh/internal/ui/common"
 )
@@ -12,37 +13,54 @@ type styledKey struct {
 	date      string
 	gutter    string
 	postLabel string
+	dateName  string
 	dateLabel string
 	dateVal   string
 	title     string
+	maxTitle  int // titles are cut short past this width when it's set
 }
 
-func (m Model) newStyledKey(styles common.Styles, post *db.Post) styledKey {
-	publishAt := post.PublishAt
+func (m Model) newStyledKey(styles common.Styles, post *db.Post, marked bool) styledKey {
+	dateName, date := "Added:", post.PublishAt
+	switch {
+	case post.DeletedAt != nil:
+		dateName, date = "Deleted:", post.DeletedAt
+	case post.IsScheduled():
+		dateName = "Publishes:"
+	}
+	when := date.In(m.loc)
+	title := post.Title
+	if marked {
+		title = styles.Checkmark.String() + " " + title
+	}
+	if post.Draft {
+		title += styles.Subtle.Render(" (draft)")
+	}
 	// Default state
 	return styledKey{
 		styles:    styles,
 		gutter:    " ",
 		postLabel: "Post:",
-		date:      publishAt.String(),
-		dateLabel: "Added:",
-		dateVal:   styles.LabelDim.Render(publishAt.String()),
-		title:     post.Title,
+		date:      when.String(),
+		dateName:  dateName,
+		dateLabel: dateName,
+		dateVal:   styles.LabelDim.Render(when.String()),
+		title:     title,
 	}
 }
 
 // Selected state
 func (k *styledKey) selected() {
-	k.gutter = common.VerticalLine(common.StateSelected)
+	k.gutter = k.styles.Gutter(common.StateSelected)
 	k.postLabel = k.styles.Label.Render("Post:")
-	k.dateLabel = k.styles.Label.Render("Added:")
+	k.dateLabel = k.styles.Label.Render(k.dateName)
 }
 
 // Deleting state
 func (k *styledKey) deleting() {
-	k.gutter = common.VerticalLine(common.StateDeleting)
+	k.gutter = k.styles.Gutter(common.StateDeleting)
 	k.postLabel = k.styles.Delete.Render("Post:")
-	k.dateLabel = k.styles.Delete.Render("Added:")
+	k.dateLabel = k.styles.Delete.Render(k.dateName)
 	k.dateVal = k.styles.DeleteDim.Render(k.date)
 }
 
@@ -53,6 +71,9 @@ func (k styledKey) render(state postState) string {
 	case postDeleting:
 		k.deleting()
 	}
+	if k.maxTitle > 0 {
+		k.title = truncate.StringWithTail(k.title, uint(max(1, k.maxTitle)), "…")
+	}
 	return fmt.Sprintf(
 		"%s %s %s\n%s %s %s\n\n",
 		k.gutter, k.postLabel, k.title,
@@ -3,19 +3,40 @@ package posts
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	pager "github.com/charmbracelet/bubbles/paginator"
 	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/muesli/reflow/indent"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/config"
 	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/flags"
+	"github.com/neurosnap/lists.sh/internal/share"
 	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/editor"
 	"go.uber.org/zap"
 )
 
 const keysPerPage = 4
 
+// linesPerPost is the height of a single post in the list.
+const linesPerPost = 3
+
+// chromeLines is everything around the list: the heading, the filter, the
+// pager and the footer.
+const chromeLines = 10
+
+// undoWindow is how long a deletion can be taken back with a single key,
+// after that the post waits in the trash.
+const undoWindow = 5 * time.Second
+
 type state int
 
 const (
@@ -25,7 +46,14 @@ const (
 	stateDeletingPost
 	stateDeletingActivePost
 	stateDeletingAccount
-	stateQuitting
+	stateEditing
+	stateRemoteEditing
+	stateViewingPost
+	stateShowingQR
+	stateBulkDeleting
+	stateBulkToggling
+	stateFiltering
+	stateRenaming
 )
 
 type postState int
@@ -36,40 +64,69 @@ const (
 	postDeleting
 )
 
-// NewProgram creates a new Tea program.
-func NewProgram(dbpool db.DB, user *db.User) *tea.Program {
-	m := NewModel(dbpool, user)
-	m.standalone = true
-	return tea.NewProgram(m)
-}
-
 type PostLoader struct {
-	Posts []*db.Post
+	Posts    []*db.Post
+	Settings *db.UserSettings
+	Usage    *db.Usage
 }
 
 type (
-	postsLoadedMsg PostLoader
-	removePostMsg  int
-	errMsg         struct {
-		err error
+	postsLoadedMsg  PostLoader
+	postsRemovedMsg struct {
+		posts []*db.Post
+	}
+	postsRestoredMsg struct {
+		posts []*db.Post
+	}
+	postsDestroyedMsg struct {
+		posts []*db.Post
+	}
+	undoExpiredMsg struct {
+		id int
+	}
+	visibilityMsg struct {
+		posts []*db.Post
+		draft bool
 	}
 )
 
 // Model is the Tea state model for this user interface.
 type Model struct {
-	dbpool     db.DB
-	user       *db.User
-	posts      []*db.Post
-	styles     common.Styles
-	pager      pager.Model
-	state      state
-	err        error
-	standalone bool
-	index      int // index of selected key in relation to the current page
-	Exit       bool
-	Quit       bool
-	spinner    spinner.Model
-	logger     *zap.SugaredLogger
+	dbpool    db.DB
+	user      *db.User
+	all       []*db.Post // every post, posts is what the filter lets through
+	posts     []*db.Post
+	styles    common.Styles
+	pager     pager.Model
+	state     state
+	index     int // index of selected key in relation to the current page
+	Exit      bool
+	Quit      bool
+	spinner   spinner.Model
+	editor    editor.Model
+	clipboard *common.Clipboard
+	toast     common.Toast // results of async commands, shown in the footer
+	busy      common.Busy  // the database command being waited on
+	detail    viewport.Model
+	shortLink *db.ShortLink   // the viewed post's, nil until it's loaded
+	marked    map[string]bool // post ids picked for a bulk action
+	filter    input.Model
+	title     input.Model // renaming the selected post
+	slug      input.Model
+	sort      string
+	tab       int            // index into tabs
+	loc       *time.Location // the user's timezone for dates
+	perPage   int            // the user's preferred page size
+	usage     *db.Usage      // shown next to the tabs, nil until it's loaded
+	width     int
+	height    int
+	undo      *pendingDelete // the most recent deletion, until it's final
+	undos     int            // counts deletions so stale timers are ignored
+	keys      KeyMap
+	help      help.Model
+	showHelp  bool // the full list of keys is open
+	canShare  bool // share links are set up and rolled out to the user
+	logger    *zap.SugaredLogger
 }
 
 // getSelectedIndex returns the index of the cursor in relation to the total
@@ -78,41 +135,114 @@ func (m *Model) getSelectedIndex() int {
 	return m.index + m.pager.Page*m.pager.PerPage
 }
 
-// UpdatePaging runs an update against the underlying pagination model as well
-// as performing some related tasks on this model.
-func (m *Model) UpdatePaging(msg tea.Msg) {
-	// Handle paging
+// moveUp selects the previous post, going back a page from the top of one.
+func (m *Model) moveUp() {
+	m.index--
+	if m.index < 0 && m.pager.Page > 0 {
+		m.index = m.pager.PerPage - 1
+		m.pager.PrevPage()
+	}
+	m.index = max(0, m.index)
+}
+
+// moveDown selects the next post, going on a page from the bottom of one.
+func (m *Model) moveDown() {
+	itemsOnPage := m.pager.ItemsOnPage(len(m.posts))
+	m.index++
+	if m.index > itemsOnPage-1 && m.pager.Page < m.pager.TotalPages-1 {
+		m.index = 0
+		m.pager.NextPage()
+	}
+	m.index = min(itemsOnPage-1, m.index)
+}
+
+// UpdatePaging keeps the pager in step with the posts after a key press.
+// Paging itself goes through the keymap rather than the pager's own keys so
+// it can be rebound.
+func (m *Model) UpdatePaging() {
 	m.pager.SetTotalPages(len(m.posts))
-	m.pager, _ = m.pager.Update(msg)
 
 	// If selected item is out of bounds, put it in bounds
 	numItems := m.pager.ItemsOnPage(len(m.posts))
 	m.index = min(m.index, numItems-1)
 }
 
+// dropPosts takes posts out of the list, like after they were deleted, and
+// keeps the cursor in bounds.
+func (m *Model) dropPosts(ids ...string) {
+	m.all = withoutPosts(m.all, ids...)
+	m.posts = withoutPosts(m.posts, ids...)
+	m.marked = map[string]bool{}
+
+	m.pager.SetTotalPages(len(m.posts))
+	m.pager.Page = max(0, min(m.pager.Page, m.pager.TotalPages-1))
+	m.index = max(0, min(m.index, m.pager.ItemsOnPage(len(m.posts))-1))
+}
+
+// SetSize tells the model how much room it has, so the page size and titles
+// can adapt to the terminal.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.fitPage()
+}
+
+// fitPage shows as many posts per page as fit, up to the user's preference,
+// and keeps the selected post in view.
+func (m *Model) fitPage() {
+	perPage := m.perPage
+	if m.height > 0 {
+		perPage = max(1, min(perPage, (m.height-chromeLines)/linesPerPost))
+	}
+
+	selected := m.getSelectedIndex()
+	m.pager.PerPage = perPage
+	m.pager.SetTotalPages(len(m.posts))
+	if len(m.posts) == 0 {
+		m.pager.Page, m.index = 0, 0
+		return
+	}
+	selected = min(selected, len(m.posts)-1)
+	m.pager.Page = selected / perPage
+	m.index = selected % perPage
+}
+
 // NewModel creates a new model with defaults.
-func NewModel(dbpool db.DB, user *db.User) Model {
-	logger := internal.CreateLogger()
-	st := common.DefaultStyles()
+func NewModel(dbpool db.DB, user *db.User, clipboard *common.Clipboard, styles common.Styles) Model {
+	cfg := config.Current()
+	canShare := share.Enabled(cfg.Web) && user != nil && flags.Enabled(cfg.Flags, dbpool, user.ID, flags.Share)
+	return newModel(dbpool, user, clipboard, styles, internal.CreateLogger(), canShare)
+}
 
+// newModel is NewModel without reading the configuration, for tests.
+func newModel(dbpool db.DB, user *db.User, clipboard *common.Clipboard, styles common.Styles, logger *zap.SugaredLogger, canShare bool) Model {
 	p := pager.NewModel()
 	p.PerPage = keysPerPage
 	p.Type = pager.Dots
-	p.InactiveDot = st.InactivePagination.Render("•")
+	p.InactiveDot = styles.InactivePagination.Render("•")
 
 	return Model{
-		dbpool:  dbpool,
-		user:    user,
-		styles:  st,
-		pager:   p,
-		state:   stateLoading,
-		err:     nil,
-		posts:   []*db.Post{},
-		index:   0,
-		spinner: common.NewSpinner(),
-		Exit:    false,
-		Quit:    false,
-		logger:  logger,
+		dbpool:    dbpool,
+		user:      user,
+		styles:    styles,
+		pager:     p,
+		state:     stateLoading,
+		all:       []*db.Post{},
+		posts:     []*db.Post{},
+		index:     0,
+		spinner:   common.NewSpinner(),
+		clipboard: clipboard,
+		marked:    map[string]bool{},
+		filter:    newFilterInput(styles),
+		sort:      db.PostSortDate,
+		loc:       time.UTC,
+		perPage:   keysPerPage,
+		keys:      DefaultKeyMap().translated(styles),
+		help:      newHelp(styles),
+		Exit:      false,
+		Quit:      false,
+		logger:    logger,
+		canShare:  canShare,
 	}
 }
 
@@ -125,116 +255,467 @@ func (m Model) Init() tea.Cmd {
 
 // Update is the tea update function which handles incoming messages.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if done, ok := msg.(common.BusyDoneMsg); ok {
+		msg = m.busy.Finish(done)
+		if msg == nil {
+			return m, nil
+		}
+	}
+
+	// Notifications come and go whatever screen is showing.
+	var toastCmd tea.Cmd
+	m.toast, toastCmd = m.toast.Update(msg)
+	if _, ok := msg.(common.ToastMsg); ok {
+		// A failed load still leaves the list usable.
+		if m.state == stateLoading {
+			m.state = stateNormal
+		}
+		return m, toastCmd
+	}
+
+	if msg, ok := msg.(shortLinkMsg); ok {
+		m.shortLink = msg.link
+		return m, nil
+	}
+
+	if m.state == stateEditing {
+		return m.updateEditor(msg)
+	}
+	if m.state == stateShowingQR {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case k.String() == "ctrl+c":
+				m.Quit = true
+			case key.Matches(k, m.keys.Back, m.keys.Quit):
+				m.state = stateNormal
+			}
+		}
+		return m, nil
+	}
+	if m.state == stateViewingPost {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case k.String() == "ctrl+c":
+				m.Quit = true
+				return m, nil
+			case key.Matches(k, m.keys.Back, m.keys.Quit):
+				m.state = stateNormal
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.detail, cmd = m.detail.Update(msg)
+		return m, cmd
+	}
+	if m.state == stateFiltering {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			return m.updateFilter(k)
+		}
+		// Let the input blink, everything else is handled below.
+		var cmd tea.Cmd
+		m.filter, cmd = m.filter.Update(msg)
+		if cmd != nil {
+			return m, cmd
+		}
+	}
+	if m.showHelp {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			if k.String() == "ctrl+c" {
+				m.Quit = true
+			}
+			m.showHelp = false
+			return m, nil
+		}
+	}
+	if m.state == stateRenaming {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			return m.updateRename(k)
+		}
+		var titleCmd, slugCmd tea.Cmd
+		m.title, titleCmd = m.title.Update(msg)
+		m.slug, slugCmd = m.slug.Update(msg)
+		if titleCmd != nil || slugCmd != nil {
+			return m, tea.Batch(titleCmd, slugCmd)
+		}
+	}
+	if m.state == stateRemoteEditing {
+		if k, ok := msg.(tea.KeyMsg); ok {
+			if k.String() == "ctrl+c" {
+				m.Quit = true
+			}
+			m.state = stateNormal
+		}
+		return m, nil
+	}
+
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q", "esc":
-			if m.standalone {
-				m.state = stateQuitting
-				return m, tea.Quit
+		switch {
+		case msg.String() == "ctrl+c":
+			m.Exit = true
+			return m, nil
+		case key.Matches(msg, m.keys.Back):
+			if len(m.marked) > 0 {
+				m.marked = map[string]bool{}
+				return m, nil
+			}
+			if m.filterQuery() != "" {
+				m.filter.Reset()
+				return m, m.applyFilter()
 			}
 			m.Exit = true
 			return m, nil
+		case key.Matches(msg, m.keys.Quit):
+			m.Exit = true
+			return m, nil
+
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+			return m, nil
 
 		// Select individual items
-		case "up", "k":
-			// Move up
-			m.index--
-			if m.index < 0 && m.pager.Page > 0 {
-				m.index = m.pager.PerPage - 1
-				m.pager.PrevPage()
+		case key.Matches(msg, m.keys.Up):
+			m.moveUp()
+		case key.Matches(msg, m.keys.Down):
+			m.moveDown()
+		case key.Matches(msg, m.keys.PrevPage):
+			m.pager.PrevPage()
+		case key.Matches(msg, m.keys.NextPage):
+			m.pager.NextPage()
+		case key.Matches(msg, m.keys.PrevTab):
+			return m, m.switchTab(-1)
+		case key.Matches(msg, m.keys.NextTab):
+			return m, m.switchTab(1)
+
+		case key.Matches(msg, m.keys.Undo):
+			if m.undo != nil {
+				posts := m.undo.posts
+				m.undo = nil
+				return m, m.busy.Run("Restoring "+describePosts(posts), undeletePosts(m.dbpool, posts))
 			}
-			m.index = max(0, m.index)
-		case "down", "j":
-			// Move down
-			itemsOnPage := m.pager.ItemsOnPage(len(m.posts))
-			m.index++
-			if m.index > itemsOnPage-1 && m.pager.Page < m.pager.TotalPages-1 {
+			return m, nil
+
+		case key.Matches(msg, m.keys.Sort):
+			if len(m.all) > 1 {
+				m.sort = nextSort(m.sort)
+				sortPosts(m.all, m.sort)
+				sortPosts(m.posts, m.sort)
 				m.index = 0
-				m.pager.NextPage()
+				m.pager.Page = 0
+				return m, tea.Batch(
+					m.toast.Info("Sorted by "+sortLabel(m.sort)),
+					saveSort(m.dbpool, m.user.ID, m.sort),
+				)
 			}
-			m.index = min(itemsOnPage-1, m.index)
+			return m, nil
 
-		// Delete
-		case "x":
+		case key.Matches(msg, m.keys.Filter):
+			m.state = stateFiltering
+			return m, m.filter.Focus()
+
+		// Mark posts for bulk actions
+		case key.Matches(msg, m.keys.Mark):
+			if len(m.posts) > 0 {
+				id := m.posts[m.getSelectedIndex()].ID
+				if m.marked[id] {
+					delete(m.marked, id)
+				} else {
+					m.marked[id] = true
+				}
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Publish):
+			if m.inTrash() {
+				return m, nil
+			}
+			if len(m.marked) > 0 {
+				m.state = stateBulkToggling
+			} else if len(m.posts) > 0 {
+				// A single post doesn't need confirming, the toast says where it went.
+				posts := []*db.Post{m.posts[m.getSelectedIndex()]}
+				return m, m.busy.Run(toggleLabel(posts), togglePosts(m.dbpool, posts))
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.View):
 			if len(m.posts) > 0 {
+				post := m.posts[m.getSelectedIndex()]
+				m.state = stateViewingPost
+				m.detail = common.NewDetailViewport(m.styles, post.Text)
+				m.shortLink = nil
+				return m, loadShortLink(m.dbpool, post)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Copy):
+			if len(m.posts) > 0 && m.clipboard != nil && !m.inTrash() {
+				post := m.posts[m.getSelectedIndex()]
+				return m, m.clipboard.Copy(config.Current().URL(post.Username, post.Filename))
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Share):
+			if len(m.posts) > 0 && m.clipboard != nil && !m.inTrash() && m.canShare {
+				post := m.posts[m.getSelectedIndex()]
+				if post.HiddenAt != nil {
+					return m, m.toast.Error(errors.New("posts taken down can't be shared"))
+				}
+				return m, m.clipboard.Copy(share.URL(config.Current(), post.Username, post, time.Now().Add(share.DefaultTTL)))
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.QR):
+			if len(m.posts) > 0 && !m.inTrash() {
+				post := m.posts[m.getSelectedIndex()]
+				if !post.IsPublic() {
+					return m, m.toast.Error(errors.New("only published posts get a qr code"))
+				}
+				m.state = stateShowingQR
+				m.shortLink = nil
+				return m, loadShortLink(m.dbpool, post)
+			}
+			return m, nil
+
+		// Editor
+		case key.Matches(msg, m.keys.New):
+			m.state = stateEditing
+			m.editor = editor.NewModel(m.dbpool, m.user, nil, m.styles)
+			return m, editor.InitialCmd()
+		case key.Matches(msg, m.keys.Edit):
+			if len(m.posts) > 0 && !m.inTrash() {
+				m.state = stateEditing
+				m.editor = editor.NewModel(m.dbpool, m.user, m.posts[m.getSelectedIndex()], m.styles)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.RemoteEdit):
+			if len(m.posts) > 0 && !m.inTrash() {
+				m.state = stateRemoteEditing
+			}
+			return m, nil
+
+		// Delete
+		case key.Matches(msg, m.keys.Delete):
+			if len(m.marked) > 0 {
+				m.state = stateBulkDeleting
+			} else if len(m.posts) > 0 {
 				m.state = stateDeletingPost
-				m.UpdatePaging(msg)
+				m.UpdatePaging()
 			}
 
 			return m, nil
 
+		case key.Matches(msg, m.keys.Rename) && !m.inTrash():
+			if len(m.posts) > 0 {
+				return m, m.startRename()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Restore):
+			if !m.inTrash() || len(m.posts) == 0 {
+				return m, nil
+			}
+			posts := []*db.Post{m.posts[m.getSelectedIndex()]}
+			if len(m.marked) > 0 {
+				posts = m.markedPosts()
+			}
+			return m, m.busy.Run("Restoring "+describePosts(posts), undeletePosts(m.dbpool, posts))
+
 		// Confirm Delete
-		case "y":
+		case key.Matches(msg, m.keys.Confirm):
+			deleted, label := removePosts, "Moving %s to the trash"
+			if m.inTrash() {
+				deleted, label = destroyPosts, "Deleting %s for good"
+			}
 			switch m.state {
-			case stateDeletingPost:
+			case stateDeletingPost, stateBulkDeleting:
+				posts := []*db.Post{m.posts[m.getSelectedIndex()]}
+				if m.state == stateBulkDeleting {
+					posts = m.markedPosts()
+				}
 				m.state = stateNormal
-				return m, removePost(m)
+				return m, m.busy.Run(fmt.Sprintf(label, describePosts(posts)), deleted(m.dbpool, posts))
+			case stateBulkToggling:
+				m.state = stateNormal
+				posts := m.markedPosts()
+				return m, m.busy.Run(toggleLabel(posts), togglePosts(m.dbpool, posts))
 			}
 		}
 
-	case errMsg:
-		m.err = msg.err
-		return m, nil
+	case common.CopiedMsg:
+		return m, m.toast.Info("Copied " + string(msg))
 
 	case postsLoadedMsg:
 		m.state = stateNormal
-		m.index = 0
-		m.posts = msg.Posts
+		if msg.Settings != nil {
+			m.sort = msg.Settings.PostSort
+			m.perPage = max(db.MinPerPage, min(msg.Settings.PerPage, db.MaxPerPage))
+			m.loc = msg.Settings.Location()
+			m.keys = keyMapFor(msg.Settings.KeyMap).translated(m.styles)
+		}
+		m.usage = msg.Usage
+		m.all = msg.Posts
+		sortPosts(m.all, m.sort)
+		cmd := m.applyFilter()
+		m.fitPage()
+		return m, cmd
 
-	case removePostMsg:
-		if m.state == stateQuitting {
-			return m, tea.Quit
+	case filteredMsg:
+		// Drop results for a query that has since been typed over.
+		if msg.query != m.filterQuery() {
+			return m, nil
 		}
-		i := m.getSelectedIndex()
+		m.index = 0
+		// The search covers every tab.
+		m.posts = m.withStatus(msg.posts)
+		sortPosts(m.posts, m.sort)
+		m.pager.SetTotalPages(len(m.posts))
+		return m, nil
 
-		// Remove key from array
-		m.posts = append(m.posts[:i], m.posts[i+1:]...)
+	case postsRemovedMsg:
+		m.dropPosts(postIDs(msg.posts)...)
+		m.undos++
+		m.undo = &pendingDelete{id: m.undos, posts: msg.posts}
+		id := m.undos
+		return m, tea.Tick(undoWindow, func(time.Time) tea.Msg {
+			return undoExpiredMsg{id}
+		})
 
-		// Update pagination
-		m.pager.SetTotalPages(len(m.posts))
-		m.pager.Page = min(m.pager.Page, m.pager.TotalPages-1)
+	case postsRestoredMsg:
+		toast := m.toast.Info("Restored " + describePosts(msg.posts) + m.busy.Took())
+		for _, post := range msg.posts {
+			post.DeletedAt = nil
+		}
+		if m.inTrash() {
+			m.dropPosts(postIDs(msg.posts)...)
+			return m, toast
+		}
+		m.all = append(m.all, m.withStatus(msg.posts)...)
+		sortPosts(m.all, m.sort)
+		return m, tea.Batch(toast, m.applyFilter())
 
-		// Update cursor
-		m.index = min(m.index, m.pager.ItemsOnPage(len(m.posts)-1))
+	case postRenamedMsg:
+		// Search results are separate copies of the loaded posts.
+		for _, posts := range [][]*db.Post{m.all, m.posts} {
+			for _, post := range posts {
+				if post.ID == msg.id {
+					post.Title, post.Filename, post.Text = msg.title, msg.filename, msg.text
+				}
+			}
+		}
+		return m, m.toast.Info(fmt.Sprintf("Renamed to %q", msg.title) + m.busy.Took())
 
+	case postsDestroyedMsg:
+		m.dropPosts(postIDs(msg.posts)...)
+		if m.usage != nil {
+			for _, post := range msg.posts {
+				m.usage.Posts--
+				m.usage.Bytes -= len(post.Text)
+			}
+		}
+		return m, m.toast.Info("Deleted " + describePosts(msg.posts) + " for good" + m.busy.Took())
+
+	case undoExpiredMsg:
+		if m.undo != nil && m.undo.id == msg.id {
+			m.undo = nil
+		}
 		return m, nil
 
+	case visibilityMsg:
+		changed := map[string]bool{}
+		for _, post := range msg.posts {
+			changed[post.ID] = true
+		}
+		// Search results are separate copies of the loaded posts.
+		for _, posts := range [][]*db.Post{m.all, m.posts} {
+			for _, post := range posts {
+				if changed[post.ID] {
+					post.Draft = msg.draft
+				}
+			}
+		}
+		// Posts that changed status belong in another tab now.
+		var moved []string
+		for _, post := range m.all {
+			if changed[post.ID] && post.Status() != m.status() {
+				moved = append(moved, post.ID)
+			}
+		}
+		m.dropPosts(moved...)
+		if msg.draft {
+			return m, m.toast.Info("Unpublished " + describePosts(msg.posts) + m.busy.Took() + ", see Drafts")
+		}
+		return m, m.toast.Info("Published " + describePosts(msg.posts) + m.busy.Took())
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
-		if m.state < stateNormal {
+		if m.state < stateNormal || m.busy.Active() {
 			m.spinner, cmd = m.spinner.Update(msg)
 		}
 		return m, cmd
 	}
 
-	m.UpdatePaging(msg)
+	m.UpdatePaging()
 
 	// If an item is being confirmed for delete, any key (other than the key
 	// used for confirmation above) cancels the deletion
 	k, ok := msg.(tea.KeyMsg)
-	if ok && k.String() != "x" {
+	if ok && !key.Matches(k, m.keys.Delete) {
 		m.state = stateNormal
 	}
 
 	return m, nil
 }
 
-// View renders the current UI into a string.
-func (m Model) View() string {
-	if m.err != nil {
-		return m.err.Error()
+// updateEditor hands messages to the editor until it's closed, then reloads
+// the posts if anything was saved.
+func (m Model) updateEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.editor, cmd = editor.Update(msg, m.editor)
+	if m.editor.Quit {
+		m.Quit = true
+		return m, nil
 	}
+	if m.editor.Done {
+		if m.editor.Saved {
+			m.state = stateLoading
+			return m, tea.Batch(LoadPosts(m), m.toast.Info("Saved "+m.editor.Post().Filename))
+		}
+		m.state = stateNormal
+		return m, nil
+	}
+	return m, cmd
+}
 
+// View renders the current UI into a string.
+func (m Model) View() string {
 	var s string
 
 	switch m.state {
+	case stateEditing:
+		s = editor.View(m.editor)
+	case stateRemoteEditing:
+		s = remoteEditView(m, m.posts[m.getSelectedIndex()])
+	case stateViewingPost:
+		s = detailView(m, m.posts[m.getSelectedIndex()])
+	case stateShowingQR:
+		s = qrView(m, m.posts[m.getSelectedIndex()])
 	case stateLoading:
-		s = m.spinner.View() + " Loading...\n\n"
-	case stateQuitting:
-		s = "Thanks for using lists.sh!\n"
+		if m.busy.Active() {
+			s = m.busy.View(m.spinner) + "\n\n"
+		} else {
+			s = m.spinner.View() + " Loading...\n\n"
+		}
 	default:
-		s = "Here are the posts linked to your account.\n\n"
+		if m.showHelp {
+			return fullHelpView(m)
+		}
+
+		s = tabsView(m) + "\n\n"
+		if m.state == stateFiltering || m.filterQuery() != "" {
+			s += m.filter.View() + "  " +
+				m.styles.Subtle.Render(fmt.Sprintf("%d of %d", len(m.posts), len(m.all))) + "\n\n"
+		}
 
 		s += postsView(m)
 		if m.pager.TotalPages > 1 {
@@ -242,17 +723,38 @@ func (m Model) View() string {
 		}
 
 		// Footer
+		var footer string
 		switch m.state {
+		case stateRenaming:
+			footer = renameView(m)
 		case stateDeletingPost:
-			s += m.promptView("Delete this post?")
+			footer = m.promptView("Delete this post" + m.forGood() + "?")
+		case stateBulkDeleting:
+			footer = m.bulkPromptView(fmt.Sprintf("Delete these %d posts%s?", len(m.marked), m.forGood()))
+		case stateBulkToggling:
+			verb := "Unpublish"
+			if allDrafts(m.markedPosts()) {
+				verb = "Publish"
+			}
+			footer = m.bulkPromptView(fmt.Sprintf("%s these %d posts?", verb, len(m.marked)))
 		default:
-			s += "\n\n" + helpView(m)
+			if m.busy.Active() {
+				footer = "\n\n" + m.busy.View(m.spinner)
+			} else if m.undo != nil {
+				footer = "\n\n" + m.styles.Note.Render("Moved "+describePosts(m.undo.posts)+" to the trash, press u to undo")
+			} else if m.toast.Visible() {
+				footer = "\n\n" + m.toast.View(m.styles)
+			}
+			footer += "\n\n" + helpView(m)
 		}
-	}
 
-	if m.standalone {
-		return indent.String(fmt.Sprintf("\n%s\n", s), 2)
+		// Keep the footer at the bottom of the screen as the list changes.
+		if pad := m.height - lipgloss.Height(s) - lipgloss.Height(footer) + 1; pad > 0 {
+			s += strings.Repeat("\n", pad)
+		}
+		s += footer
 	}
+
 	return s
 }
 
@@ -267,8 +769,10 @@ func postsView(m Model) string {
 	destructiveState := m.state == stateDeletingPost
 
 	if len(m.posts) == 0 {
-		s += "You don't have any posts yet."
-		return s
+		if m.filterQuery() != "" {
+			return s + "No posts match your filter."
+		}
+		return s + tabs[m.tab].empty
 	}
 
 	// Render key info
@@ -280,13 +784,17 @@ func postsView(m Model) string {
 		} else {
 			state = postNormal
 		}
-		s += m.newStyledKey(m.styles, post).render(state)
+		key := m.newStyledKey(m.styles, post, m.marked[post.ID])
+		if m.width > 0 {
+			key.maxTitle = m.width - lipgloss.Width(key.gutter+" Post: ")
+		}
+		s += key.render(state)
 	}
 
 	// If there aren't enough keys to fill the view, fill the missing parts
 	// with whitespace
 	if len(slice) < m.pager.PerPage {
-		for i := len(slice); i < keysPerPage; i++ {
+		for i := len(slice); i < m.pager.PerPage; i++ {
 			s += "\n\n\n"
 		}
 	}
@@ -295,18 +803,125 @@ func postsView(m Model) string {
 }
 
 func helpView(m Model) string {
+	k := m.keys
+	if m.state == stateFiltering {
+		return m.styles.HelpView("enter: done", helpItem(k.Back, "clear filter"))
+	}
+
 	var items []string
 	if len(m.posts) > 1 {
-		items = append(items, "j/k, ↑/↓: choose")
+		items = append(items, helpPair(k.Up, k.Down, "choose"))
 	}
 	if m.pager.TotalPages > 1 {
-		items = append(items, "h/l, ←/→: page")
+		items = append(items, helpPair(k.PrevPage, k.NextPage, "page"))
+	}
+	items = append(items, helpPair(k.PrevTab, k.NextTab, "tabs"))
+	if len(m.marked) > 0 && m.inTrash() {
+		items = append(items,
+			helpItem(k.Mark, ""),
+			helpItem(k.Restore, "restore marked"),
+			helpItem(k.Delete, "delete marked for good"),
+			helpItem(k.Back, "clear marks"),
+		)
+		return m.styles.HelpView(items...)
 	}
-	if len(m.posts) > 0 {
-		items = append(items, "x: delete")
+	if len(m.marked) > 0 {
+		items = append(items,
+			helpItem(k.Mark, ""),
+			helpItem(k.Delete, "delete marked"),
+			helpItem(k.Publish, "publish/unpublish marked"),
+			helpItem(k.Back, "clear marks"),
+		)
+		return m.styles.HelpView(items...)
 	}
-	items = append(items, "esc: exit")
-	return common.HelpView(items...)
+	if m.undo != nil {
+		items = append(items, helpItem(k.Undo, ""))
+	}
+	items = append(items, helpItem(k.New, ""))
+	if len(m.posts) > 0 && m.inTrash() {
+		items = append(items,
+			helpItem(k.Mark, ""),
+			helpItem(k.View, ""),
+			helpItem(k.Restore, ""),
+			helpItem(k.Delete, "delete for good"),
+		)
+	} else if len(m.posts) > 0 {
+		publish := "unpublish"
+		if m.posts[m.getSelectedIndex()].Draft {
+			publish = "publish"
+		}
+		items = append(items,
+			helpItem(k.Mark, ""),
+			helpItem(k.View, ""),
+			helpItem(k.Copy, ""),
+		)
+		if m.canShare {
+			items = append(items, helpItem(k.Share, ""))
+		}
+		items = append(items,
+			helpItem(k.QR, ""),
+			helpItem(k.Edit, ""),
+			helpItem(k.Rename, ""),
+			helpItem(k.RemoteEdit, ""),
+			helpItem(k.Publish, publish),
+			helpItem(k.Delete, ""),
+		)
+	}
+	if len(m.all) > 1 {
+		items = append(items, helpItem(k.Filter, ""), helpItem(k.Sort, "sort by "+sortLabel(nextSort(m.sort))))
+	}
+	if m.filterQuery() != "" {
+		items = append(items, helpItem(k.Back, "clear filter"))
+	} else {
+		items = append(items, helpItem(k.Back, "exit"))
+	}
+	items = append(items, helpItem(k.Help, "all keys"))
+	return m.styles.HelpView(items...)
+}
+
+// fullHelpView lists every binding in the keymap.
+func fullHelpView(m Model) string {
+	s := "Keys\n\n"
+	s += m.help.FullHelpView(m.keys.FullHelp())
+	return s + "\n\n" + m.styles.HelpView("any key: back")
+}
+
+// remoteEditView explains how to edit the post with a local editor, since the
+// server can't reach the editor on the other end of the connection.
+func remoteEditView(m Model, post *db.Post) string {
+	host := config.Current().Domain
+	s := "Edit " + m.styles.Label.Render(post.Filename) + " in your own editor\n\n"
+	s += "Run this in a local terminal, the post is saved when your editor exits:\n\n"
+	s += m.styles.Code.Render(fmt.Sprintf(
+		"f=$(mktemp) && ssh %s cat %s > $f && ${EDITOR:-vi} $f && ssh %s put %s < $f",
+		host, post.Filename, host, post.Filename,
+	))
+	s += "\n\n" + m.styles.Subtle.Render(fmt.Sprintf("ssh %s cat <post> prints a post and ssh %s put <post> saves stdin.", host, host))
+	return s + "\n\n" + m.styles.HelpView("any key: back")
+}
+
+// bulkPromptView asks once for every marked post, listing what's affected.
+func (m Model) bulkPromptView(prompt string) string {
+	const maxListed = 8
+	posts := m.markedPosts()
+
+	s := "\n"
+	for i, post := range posts {
+		if i == maxListed {
+			s += m.styles.Subtle.Render(fmt.Sprintf("  and %d more", len(posts)-maxListed)) + "\n"
+			break
+		}
+		s += "  - " + post.Title + "\n"
+	}
+	return s + m.promptView(prompt)
+}
+
+// forGood warns that deleting from the trash can't be undone.
+func (m Model) forGood() string {
+	if m.inTrash() {
+		return " for good"
+	}
+	return ""
 }
 
 func (m Model) promptView(prompt string) string {
@@ -320,35 +935,141 @@ func LoadPosts(m Model) tea.Cmd {
 		m.logger.Info("user not found!")
 		err := errors.New("user not found")
 		return func() tea.Msg {
-			return errMsg{err}
+			return common.ErrorToast(err)
 		}
 	}
-	if m.standalone {
-		return fetchPosts(m.dbpool, m.user.ID)
-	}
 	return tea.Batch(
-		fetchPosts(m.dbpool, m.user.ID),
+		fetchPosts(m.dbpool, m.user.ID, m.status()),
 		spinner.Tick,
 	)
 }
 
-func fetchPosts(dbpool db.DB, userID string) tea.Cmd {
+func fetchPosts(dbpool db.DB, userID string, status string) tea.Cmd {
 	return func() tea.Msg {
-		posts, _ := dbpool.PostsForUser(userID)
+		posts, _ := dbpool.PostsForUserWithStatus(userID, status)
 		loader := PostLoader{
-			Posts: posts,
+			Posts:    posts,
+			Settings: db.DefaultUserSettings(),
+		}
+		if settings, err := dbpool.FindUserSettings(userID); err == nil {
+			loader.Settings = settings
+		}
+		if usage, err := dbpool.FindUserUsage(userID); err == nil {
+			loader.Usage = usage
 		}
 		return postsLoadedMsg(loader)
 	}
 }
 
-func removePost(m Model) tea.Cmd {
+// markedPosts returns the marked posts in list order, including any the
+// filter is hiding.
+func (m Model) markedPosts() []*db.Post {
+	var posts []*db.Post
+	for _, post := range m.all {
+		if m.marked[post.ID] {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+func allDrafts(posts []*db.Post) bool {
+	for _, post := range posts {
+		if !post.Draft {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutPosts returns a copy of posts without the ones in ids.
+func withoutPosts(posts []*db.Post, ids ...string) []*db.Post {
+	drop := map[string]bool{}
+	for _, id := range ids {
+		drop[id] = true
+	}
+	kept := make([]*db.Post, 0, len(posts))
+	for _, post := range posts {
+		if !drop[post.ID] {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+func postIDs(posts []*db.Post) []string {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+// pendingDelete is a deletion that can still be undone.
+type pendingDelete struct {
+	id    int
+	posts []*db.Post
+}
+
+// describePosts names a single post by title and counts anything more.
+func describePosts(posts []*db.Post) string {
+	if len(posts) == 1 {
+		return fmt.Sprintf("%q", posts[0].Title)
+	}
+	return fmt.Sprintf("%d posts", len(posts))
+}
+
+// removePosts moves the posts to the trash.
+func removePosts(dbpool db.DB, posts []*db.Post) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.SoftDeletePosts(postIDs(posts))
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return postsRemovedMsg{posts}
+	}
+}
+
+// destroyPosts deletes posts in the trash for good.
+func destroyPosts(dbpool db.DB, posts []*db.Post) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.RemovePosts(postIDs(posts))
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return postsDestroyedMsg{posts}
+	}
+}
+
+func undeletePosts(dbpool db.DB, posts []*db.Post) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.UndeletePosts(postIDs(posts))
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return postsRestoredMsg{posts}
+	}
+}
+
+// toggleLabel says what togglePosts is about to do to the posts.
+func toggleLabel(posts []*db.Post) string {
+	if allDrafts(posts) {
+		return "Publishing " + describePosts(posts)
+	}
+	return "Unpublishing " + describePosts(posts)
+}
+
+// togglePosts publishes the posts when they're all drafts and unpublishes
+// them otherwise.
+func togglePosts(dbpool db.DB, posts []*db.Post) tea.Cmd {
 	return func() tea.Msg {
-		err := m.dbpool.RemovePosts([]string{m.posts[m.getSelectedIndex()].ID})
+		ids := postIDs(posts)
+		draft := !allDrafts(posts)
+		err := dbpool.UpdatePostVisibility(ids, draft)
 		if err != nil {
-			return errMsg{err}
+			return common.ErrorToast(err)
 		}
-		return removePostMsg(m.index)
+		return visibilityMsg{posts: posts, draft: draft}
 	}
 }
 
@@ -0,0 +1,115 @@
+package posts
+
+import (
+	"fmt"
+	"testing"
+
+	pager "github.com/charmbracelet/bubbles/paginator"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestFitPage(t *testing.T) {
+	newModel := func() Model {
+		m := Model{pager: pager.NewModel(), perPage: 4}
+		for i := 0; i < 10; i++ {
+			m.posts = append(m.posts, &db.Post{ID: fmt.Sprint(i)})
+		}
+		m.fitPage()
+		return m
+	}
+
+	t.Run("small terminals show fewer posts", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		m.SetSize(80, chromeLines+2*linesPerPost)
+		is.Equal(m.pager.PerPage, 2)
+	})
+
+	t.Run("tall terminals stop at the preference", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		m.SetSize(80, 200)
+		is.Equal(m.pager.PerPage, 4)
+	})
+
+	t.Run("the selected post stays in view", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel()
+		m.pager.Page, m.index = 1, 2 // post 6
+		m.SetSize(80, chromeLines+3*linesPerPost)
+		is.Equal(m.getSelectedIndex(), 6)
+		is.Equal(m.pager.Page, 2)
+	})
+}
+
+func TestVisibilityMsg(t *testing.T) {
+	is := is.New(t)
+	m := Model{pager: pager.NewModel(), marked: map[string]bool{}}
+	m.all = []*db.Post{{ID: "a"}, {ID: "b"}}
+	m.posts = m.all
+
+	model, cmd := m.Update(visibilityMsg{posts: m.all[:1], draft: true})
+	m = model.(Model)
+	is.True(cmd != nil) // the toast
+	is.Equal(len(m.posts), 1)
+	is.Equal(m.posts[0].ID, "b")
+}
+
+func TestToggleLabel(t *testing.T) {
+	is := is.New(t)
+	draft := &db.Post{Title: "Groceries", Draft: true}
+	published := &db.Post{Title: "Books"}
+	is.Equal(toggleLabel([]*db.Post{draft}), "Publishing "+describePosts([]*db.Post{draft}))
+	is.Equal(toggleLabel([]*db.Post{published}), "Unpublishing "+describePosts([]*db.Post{published}))
+}
+
+// shortLinkDB has a short link for every post.
+type shortLinkDB struct {
+	db.DB
+}
+
+func (d *shortLinkDB) ShortLinkForPost(postID string) (*db.ShortLink, error) {
+	return &db.ShortLink{Slug: "Ab3xZ", PostID: postID, Clicks: 7}, nil
+}
+
+func TestShortLink(t *testing.T) {
+	is := is.New(t)
+	m := viewModel(t, samplePosts(), 80, 40)
+	m.dbpool = &shortLinkDB{}
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	is.Equal(m.state, stateViewingPost)
+	is.Equal(m.shortLink, nil) // loading
+
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	is.Equal(m.state, stateViewingPost)
+	is.Equal(m.shortLink.PostID, m.posts[0].ID)
+	is.Equal(m.shortLink.Clicks, 7)
+}
+
+func TestQR(t *testing.T) {
+	is := is.New(t)
+	m := viewModel(t, samplePosts(), 80, 40)
+	m.dbpool = &shortLinkDB{}
+	q := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Q")}
+
+	model, cmd := m.Update(q)
+	m = model.(Model)
+	is.Equal(m.state, stateShowingQR)
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	is.Equal(m.shortLink.PostID, m.posts[0].ID)
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	is.Equal(m.state, stateNormal)
+
+	m.index = 2 // a draft
+	model, _ = m.Update(q)
+	m = model.(Model)
+	is.Equal(m.state, stateNormal) // drafts don't get one
+}
@@ -0,0 +1,137 @@
+package posts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+type postRenamedMsg struct {
+	id       string
+	title    string
+	filename string
+	text     string
+}
+
+func newRenameInput(st common.Styles, placeholder string) input.Model {
+	ri := input.New()
+	ri.CursorStyle = st.Cursor
+	ri.Prompt = st.FocusedPrompt.String()
+	ri.Placeholder = placeholder
+	ri.CharLimit = 255
+	return ri
+}
+
+// startRename fills the rename inputs from the selected post.
+func (m *Model) startRename() tea.Cmd {
+	post := m.posts[m.getSelectedIndex()]
+	m.state = stateRenaming
+	m.title = newRenameInput(m.styles, "title")
+	m.title.SetValue(internal.FilenameToTitle(post.Filename, post.Title))
+	m.title.CursorEnd()
+	m.slug = newRenameInput(m.styles, "slug")
+	m.slug.SetValue(post.Filename)
+	m.slug.CursorEnd()
+	m.slug.Blur()
+	return m.title.Focus()
+}
+
+// updateRename sends keys to the focused rename input, enter saves both.
+func (m Model) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		m.Exit = true
+		return m, nil
+	case key.Matches(msg, m.keys.Back):
+		m.state = stateNormal
+		return m, nil
+	case msg.Type == tea.KeyTab, msg.Type == tea.KeyShiftTab, msg.Type == tea.KeyUp, msg.Type == tea.KeyDown:
+		if m.title.Focused() {
+			m.title.Blur()
+			return m, m.slug.Focus()
+		}
+		m.slug.Blur()
+		return m, m.title.Focus()
+	case msg.Type == tea.KeyEnter:
+		post := m.posts[m.getSelectedIndex()]
+		title := strings.TrimSpace(m.title.Value())
+		filename, err := validSlug(post, m.slug.Value())
+		if err != nil {
+			return m, m.toast.Error(err)
+		}
+		if title == "" {
+			return m, m.toast.Error(errors.New("a post needs a title"))
+		}
+		m.state = stateNormal
+		return m, m.busy.Run("Renaming "+describePosts([]*db.Post{post}), renamePost(m.dbpool, post, title, filename))
+	}
+
+	var cmd tea.Cmd
+	if m.title.Focused() {
+		m.title, cmd = m.title.Update(msg)
+	} else {
+		m.slug, cmd = m.slug.Update(msg)
+	}
+	return m, cmd
+}
+
+// validSlug cleans up the filename typed in for post.
+func validSlug(post *db.Post, slug string) (string, error) {
+	slug = strings.TrimSuffix(strings.TrimSpace(slug), ".txt")
+	switch {
+	case slug == post.Filename:
+		return slug, nil
+	case slug == "":
+		return "", errors.New("a post needs a slug")
+	case strings.HasPrefix(post.Filename, "_"), strings.HasPrefix(slug, "_"):
+		return "", errors.New("special files like _readme keep their names")
+	}
+	if err := internal.ValidateFilename(slug); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+func renameView(m Model) string {
+	s := "\n\n" + m.title.View() + "\n" + m.slug.View()
+	if m.slug.Value() != m.posts[m.getSelectedIndex()].Filename {
+		s += "\n" + m.styles.Subtle.Render("The old URL will redirect to the new one.")
+	}
+	return s + "\n\n" + m.styles.HelpView("enter: save", "tab: title/slug", helpItem(m.keys.Back, "cancel"))
+}
+
+// renamePost saves the new title into the post's source, so the next upload
+// doesn't undo it, and moves the post to its new filename.
+func renamePost(dbpool db.DB, post *db.Post, title string, filename string) tea.Cmd {
+	return func() tea.Msg {
+		if filename != post.Filename {
+			if existing, _ := dbpool.FindPostWithFilename(filename, post.UserID); existing != nil {
+				return common.ErrorToast(fmt.Errorf("%s already exists", filename))
+			}
+		}
+
+		text := post.Text
+		if title != internal.FilenameToTitle(post.Filename, post.Title) {
+			text = pkg.SetVariable(post.Text, "title", title)
+			_, err := dbpool.UpdatePost(post.ID, title, text, post.Description, post.PublishAt)
+			if err != nil {
+				return common.ErrorToast(err)
+			}
+		}
+		if filename != post.Filename {
+			err := dbpool.RenamePost(post, filename)
+			if err != nil {
+				return common.ErrorToast(err)
+			}
+		}
+		return postRenamedMsg{id: post.ID, title: title, filename: filename, text: text}
+	}
+}
@@ -0,0 +1,40 @@
+package posts
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestValidSlug(t *testing.T) {
+	post := &db.Post{Filename: "groceries"}
+
+	t.Run("cleans up the extension", func(t *testing.T) {
+		is := is.New(t)
+		slug, err := validSlug(post, " shopping.txt ")
+		is.NoErr(err)
+		is.Equal(slug, "shopping")
+	})
+
+	t.Run("rejects paths", func(t *testing.T) {
+		is := is.New(t)
+		_, err := validSlug(post, "lists/shopping")
+		is.True(err != nil)
+	})
+
+	t.Run("rejects names taken by routes", func(t *testing.T) {
+		is := is.New(t)
+		_, err := validSlug(post, "rss")
+		is.True(err != nil)
+	})
+
+	t.Run("special files keep their names", func(t *testing.T) {
+		is := is.New(t)
+		_, err := validSlug(&db.Post{Filename: "_readme"}, "about")
+		is.True(err != nil)
+		slug, err := validSlug(&db.Post{Filename: "_readme"}, "_readme")
+		is.NoErr(err)
+		is.Equal(slug, "_readme")
+	})
+}
@@ -0,0 +1,83 @@
+package posts
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+type sortSavedMsg struct{}
+
+// sortOrders is the cycle the "s" key walks through.
+var sortOrders = []string{
+	db.PostSortDate,
+	db.PostSortUpdated,
+	db.PostSortTitle,
+	db.PostSortViews,
+}
+
+// nextSort returns the order after current, starting over at the end.
+func nextSort(current string) string {
+	for i, order := range sortOrders {
+		if order == current {
+			return sortOrders[(i+1)%len(sortOrders)]
+		}
+	}
+	return sortOrders[0]
+}
+
+func sortLabel(order string) string {
+	switch order {
+	case db.PostSortUpdated:
+		return "last edited"
+	case db.PostSortTitle:
+		return "title"
+	case db.PostSortViews:
+		return "views"
+	}
+	return "publish date"
+}
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// sortPosts orders posts in place. Dates and views are newest/highest first,
+// titles are alphabetical.
+func sortPosts(posts []*db.Post, order string) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		switch order {
+		case db.PostSortUpdated:
+			return timeOf(a.UpdatedAt).After(timeOf(b.UpdatedAt))
+		case db.PostSortTitle:
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		case db.PostSortViews:
+			return a.Views > b.Views
+		}
+		return timeOf(a.PublishAt).After(timeOf(b.PublishAt))
+	})
+}
+
+// saveSort remembers the order for the next session.
+func saveSort(dbpool db.DB, userID string, order string) tea.Cmd {
+	return func() tea.Msg {
+		settings, err := dbpool.FindUserSettings(userID)
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		settings.PostSort = order
+		err = dbpool.UpdateUserSettings(userID, settings)
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return sortSavedMsg{}
+	}
+}
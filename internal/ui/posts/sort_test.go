@@ -0,0 +1,52 @@
+package posts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestSortPosts(t *testing.T) {
+	day := func(n int) *time.Time {
+		d := time.Date(2022, 5, n, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	newPosts := func() []*db.Post {
+		return []*db.Post{
+			{ID: "a", Title: "beta", PublishAt: day(1), UpdatedAt: day(9), Views: 5},
+			{ID: "b", Title: "Alpha", PublishAt: day(3), UpdatedAt: day(3), Views: 1},
+			{ID: "c", Title: "gamma", PublishAt: day(2), UpdatedAt: day(4), Views: 9},
+		}
+	}
+	ids := func(posts []*db.Post) string {
+		s := ""
+		for _, post := range posts {
+			s += post.ID
+		}
+		return s
+	}
+
+	cases := map[string]string{
+		db.PostSortDate:    "bca",
+		db.PostSortUpdated: "acb",
+		db.PostSortTitle:   "bac",
+		db.PostSortViews:   "cab",
+	}
+	for order, want := range cases {
+		order, want := order, want
+		t.Run(order, func(t *testing.T) {
+			is := is.New(t)
+			posts := newPosts()
+			sortPosts(posts, order)
+			is.Equal(ids(posts), want)
+		})
+	}
+
+	t.Run("cycle wraps around", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(nextSort(db.PostSortViews), db.PostSortDate)
+		is.Equal(nextSort("bogus"), db.PostSortDate)
+	})
+}
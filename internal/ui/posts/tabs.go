@@ -0,0 +1,74 @@
+package posts
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+// tab lists the posts in one status.
+type tab struct {
+	label  string
+	status string
+	empty  string // shown when there's nothing in the tab
+}
+
+var tabs = []tab{
+	{"Published", db.PostStatusPublished, "You haven't published any posts yet."},
+	{"Drafts", db.PostStatusDraft, "You don't have any drafts."},
+	{"Scheduled", db.PostStatusScheduled, "Nothing is scheduled, posts with a future publish date wait here."},
+	{"Trash", db.PostStatusDeleted, "The trash is empty, deleted posts are kept here for 30 days."},
+}
+
+func (m Model) status() string {
+	return tabs[m.tab].status
+}
+
+func (m Model) inTrash() bool {
+	return m.status() == db.PostStatusDeleted
+}
+
+// switchTab moves step tabs over, wrapping around at either end, and loads
+// the posts for it.
+func (m *Model) switchTab(step int) tea.Cmd {
+	m.tab = (m.tab + step + len(tabs)) % len(tabs)
+	m.state = stateLoading
+	m.marked = map[string]bool{}
+	m.filter.Reset()
+	m.index = 0
+	m.pager.Page = 0
+	return m.busy.Run("Loading "+strings.ToLower(tabs[m.tab].label), fetchPosts(m.dbpool, m.user.ID, m.status()))
+}
+
+// withStatus keeps the posts that belong in the current tab, for when a post
+// changed status under the list.
+func (m Model) withStatus(posts []*db.Post) []*db.Post {
+	kept := make([]*db.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.Status() == m.status() {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+func tabsView(m Model) string {
+	labels := make([]string, len(tabs))
+	for i, t := range tabs {
+		switch {
+		case i != m.tab:
+			labels[i] = m.styles.Subtle.Render(t.label)
+		case m.styles.NoColor:
+			labels[i] = "[" + t.label + "]"
+		default:
+			labels[i] = m.styles.SelectedMenuItem.Copy().Underline(true).Render(t.label)
+		}
+	}
+	s := strings.Join(labels, "  ")
+	if m.usage != nil {
+		s += "    " + m.styles.UsageSummary(m.usage, config.Current().Quota)
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package posts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestWithStatus(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	posts := []*db.Post{
+		{ID: "published", PublishAt: &past},
+		{ID: "draft", PublishAt: &future, Draft: true},
+		{ID: "scheduled", PublishAt: &future},
+		{ID: "deleted", PublishAt: &past, Draft: true, DeletedAt: &past},
+	}
+
+	for i, tab := range tabs {
+		tab := tab
+		m := Model{tab: i}
+		t.Run(tab.label, func(t *testing.T) {
+			is := is.New(t)
+			kept := m.withStatus(posts)
+			is.Equal(len(kept), 1)
+			is.Equal(kept[0].ID, tab.status)
+		})
+	}
+
+	t.Run("scheduled posts aren't public yet", func(t *testing.T) {
+		is := is.New(t)
+		is.True(!posts[2].IsPublic())
+		is.True(posts[0].IsPublic())
+	})
+}
@@ -0,0 +1,80 @@
+package posts
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/golden"
+	"go.uber.org/zap"
+)
+
+// viewModel is the posts screen after loading posts, at a terminal size.
+func viewModel(t *testing.T, posts []*db.Post, width, height int) Model {
+	m := newModel(nil, &db.User{ID: "u1", Name: "erock"}, nil, common.DefaultStyles(), zap.NewNop().Sugar(), true)
+	model, _ := m.Update(postsLoadedMsg{Posts: posts, Settings: db.DefaultUserSettings()})
+	m = model.(Model)
+	m.SetSize(width, height)
+	return m
+}
+
+func samplePosts() []*db.Post {
+	var posts []*db.Post
+	titles := []string{"Groceries", "Books I want to read before the end of the year, in no particular order", "Movies"}
+	for i, title := range titles {
+		at := time.Date(2022, 5, 10-i, 12, 0, 0, 0, time.UTC)
+		posts = append(posts, &db.Post{ID: fmt.Sprint(i), Title: title, Filename: fmt.Sprint("post-", i), PublishAt: &at})
+	}
+	posts[2].Draft = true
+	return posts
+}
+
+func TestView(t *testing.T) {
+	for _, width := range []int{80, 40} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			t.Run("loading", func(t *testing.T) {
+				m := newModel(nil, &db.User{ID: "u1"}, nil, common.DefaultStyles(), zap.NewNop().Sugar(), true)
+				m.SetSize(width, 24)
+				golden.Assert(t, fmt.Sprintf("loading-%d", width), m.View())
+			})
+
+			t.Run("normal", func(t *testing.T) {
+				m := viewModel(t, samplePosts(), width, 24)
+				golden.Assert(t, fmt.Sprintf("normal-%d", width), m.View())
+			})
+
+			t.Run("deleting", func(t *testing.T) {
+				m := viewModel(t, samplePosts(), width, 24)
+				m.index = 1
+				m.state = stateDeletingPost
+				golden.Assert(t, fmt.Sprintf("deleting-%d", width), m.View())
+			})
+
+			t.Run("error", func(t *testing.T) {
+				m := viewModel(t, samplePosts(), width, 24)
+				model, _ := m.Update(common.ErrorToast(errors.New("connection refused")))
+				golden.Assert(t, fmt.Sprintf("error-%d", width), model.View())
+			})
+
+			t.Run("empty", func(t *testing.T) {
+				m := viewModel(t, nil, width, 24)
+				golden.Assert(t, fmt.Sprintf("empty-%d", width), m.View())
+			})
+
+			t.Run("paged", func(t *testing.T) {
+				var posts []*db.Post
+				for i := 0; i < 4; i++ {
+					posts = append(posts, samplePosts()...)
+				}
+				for i, post := range posts {
+					post.ID = fmt.Sprint(i)
+				}
+				m := viewModel(t, posts, width, 24)
+				golden.Assert(t, fmt.Sprintf("paged-%d", width), m.View())
+			})
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package privacy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/export"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateConfirming
+	stateErasing
+	stateErased
+)
+
+// confirmWord is typed to confirm erasure by users without a username.
+const confirmWord = "delete"
+
+type (
+	dataLoadedMsg *export.UserData
+	erasedMsg     struct{}
+	errMsg        struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the "your data" UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	styles  common.Styles
+	state   state
+	data    *export.UserData
+	err     error
+	input   input.Model
+	spinner spinner.Model
+}
+
+// NewModel returns a new data model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+
+	im := input.NewModel()
+	im.CursorStyle = styles.Cursor
+	im.Prompt = styles.FocusedPrompt.String()
+	im.CharLimit = 50
+
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		input:   im,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadData returns the command that gathers the account summary.
+func LoadData(m Model) tea.Cmd {
+	return tea.Batch(collect(m.dbpool, m.user), spinner.Tick)
+}
+
+func (m Model) confirmation() string {
+	if m.user.Name != "" {
+		return m.user.Name
+	}
+	return confirmWord
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.Quit = true
+			return m, nil
+		}
+
+		switch m.state {
+		case stateErased:
+			// The account is gone, there's nothing left to do here.
+			m.Quit = true
+			return m, nil
+		case stateConfirming:
+			switch msg.String() {
+			case "esc":
+				m.state = stateReady
+				m.input.Blur()
+				m.input.Reset()
+				return m, nil
+			case "enter":
+				if strings.TrimSpace(m.input.Value()) != m.confirmation() {
+					m.err = fmt.Errorf("that doesn't match, type %q to confirm", m.confirmation())
+					return m, nil
+				}
+				m.state = stateErasing
+				m.err = nil
+				return m, tea.Batch(erase(m.dbpool, m.user), spinner.Tick)
+			}
+
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		case stateReady:
+			switch msg.String() {
+			case "q", "esc":
+				m.Done = true
+			case "d":
+				m.state = stateConfirming
+				m.err = nil
+				m.input.Placeholder = m.confirmation()
+				m.input.Focus()
+				return m, input.Blink
+			}
+		}
+		return m, nil
+
+	case dataLoadedMsg:
+		m.state = stateReady
+		m.data = msg
+		return m, nil
+
+	case erasedMsg:
+		m.state = stateErased
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading || m.state == stateErasing {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	if m.state == stateConfirming {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	switch m.state {
+	case stateLoading:
+		return m.spinner.View() + " Loading your data..."
+	case stateErasing:
+		return m.spinner.View() + " Erasing your account..."
+	case stateErased:
+		return "Your account, posts, keys and invites have been erased.\n\n" +
+			m.styles.Subtle.Render("Database backups age out within the backup retention period.") +
+			"\n\n" + m.styles.HelpView("any key: quit")
+	}
+
+	s := "Your data\n\n"
+	if m.data != nil {
+		stats := m.data.Stats()
+		s += common.KeyValueView(
+			"Posts", fmt.Sprintf("%d (%d bytes)", stats.Posts, stats.Bytes),
+			"Hidden posts", fmt.Sprintf("%d", stats.HiddenPosts),
+			"Public keys", fmt.Sprintf("%d", stats.Keys),
+			"Invites", fmt.Sprintf("%d created, %d used", stats.InvitesCreated, stats.InvitesUsed),
+		)
+		s += "\n\n"
+	}
+
+	s += "Download everything we store about you:\n\n"
+	s += m.styles.Code.Render(fmt.Sprintf("ssh %s export data > lists-data.tar.gz", config.Current().Domain))
+	s += "\n\n"
+
+	if m.state == stateConfirming {
+		s += m.styles.Delete.Render("Erasing your account can't be undone.") + "\n"
+		s += fmt.Sprintf("Type %s to delete your account and every post.\n\n", m.styles.Keyword.Render(m.confirmation()))
+		s += m.input.View() + "\n"
+	}
+
+	if m.err != nil {
+		s += "\n" + m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.err.Error()) + "\n"
+	}
+
+	if m.state == stateConfirming {
+		return s + "\n" + m.styles.HelpView("enter: erase account", "esc: cancel")
+	}
+	return s + "\n" + m.styles.HelpView("d: delete account", "esc: exit")
+}
+
+func collect(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		data, err := export.CollectUserData(dbpool, user)
+		if err != nil {
+			return errMsg{err}
+		}
+		return dataLoadedMsg(data)
+	}
+}
+
+func erase(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.EraseUser(user.ID, user.Name)
+		if err != nil {
+			return errMsg{err}
+		}
+		return erasedMsg{}
+	}
+}
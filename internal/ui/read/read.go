@@ -0,0 +1,485 @@
+package read
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/truncate"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// pageSize matches the page size the discovery query counts its pages with.
+const pageSize = 15
+
+// maxTitle is where long titles are cut short in the list.
+const maxTitle = 48
+
+// feed is one of the tabs at the top of the screen.
+type feed int
+
+const (
+	feedEveryone feed = iota
+	feedFollowing
+)
+
+var feedNames = [...]string{"Everyone", "Following"}
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateViewingPost
+)
+
+type (
+	pageLoadedMsg struct {
+		feed      feed
+		page      int
+		posts     []*db.Post
+		unread    map[string]bool
+		total     int
+		following []string
+		starred   []string
+	}
+	followedMsg struct {
+		post      *db.Post
+		following bool
+	}
+	starredMsg struct {
+		post    *db.Post
+		starred bool
+	}
+	errMsg struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the discovery reader.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool    db.DB
+	user      *db.User
+	styles    common.Styles
+	state     state
+	feed      feed
+	page      int
+	total     int // number of pages in the feed
+	posts     []*db.Post
+	unread    map[string]bool // post IDs the user hasn't opened yet
+	following map[string]bool // author IDs the user follows
+	starred   map[string]bool // post IDs the user starred
+	index     int
+	detail    viewport.Model
+	err       error
+	toast     common.Toast
+	spinner   spinner.Model
+}
+
+// NewModel returns a new reader model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadFeed returns the command that fetches the first page of the feed.
+func LoadFeed(m Model) tea.Cmd {
+	return tea.Batch(fetchPage(m.dbpool, m.user, feedEveryone, 0), spinner.Tick)
+}
+
+// load starts loading a page of one of the feeds.
+func (m Model) load(f feed, page int) (Model, tea.Cmd) {
+	m.state = stateLoading
+	m.err = nil
+	return m, tea.Batch(fetchPage(m.dbpool, m.user, f, page), spinner.Tick)
+}
+
+// goToPage starts loading another page of the current feed.
+func (m Model) goToPage(page int) (Model, tea.Cmd) {
+	if m.state != stateReady || page < 0 || page >= m.total || page == m.page {
+		return m, nil
+	}
+	return m.load(m.feed, page)
+}
+
+// switchFeed moves to the next or previous tab.
+func (m Model) switchFeed(step int) (Model, tea.Cmd) {
+	if m.state != stateReady {
+		return m, nil
+	}
+	f := feed((int(m.feed) + step + len(feedNames)) % len(feedNames))
+	return m.load(f, 0)
+}
+
+// toggleFollow follows or unfollows the author of the selected post.
+func (m Model) toggleFollow() (Model, tea.Cmd) {
+	if len(m.posts) == 0 {
+		return m, nil
+	}
+	post := m.posts[m.index]
+	if post.UserID == m.user.ID {
+		return m, m.toast.Info("That's you, your own posts are under Manage posts")
+	}
+	return m, setFollowing(m.dbpool, m.user, post, !m.following[post.UserID])
+}
+
+// toggleStar stars or unstars the selected post.
+func (m Model) toggleStar() (Model, tea.Cmd) {
+	if len(m.posts) == 0 {
+		return m, nil
+	}
+	post := m.posts[m.index]
+	if post.UserID == m.user.ID {
+		return m, m.toast.Info("That's your own post, stars are for other people's")
+	}
+	return m, setStarred(m.dbpool, m.user, post, !m.starred[post.ID])
+}
+
+// open shows the selected post and clears its unread marker.
+func (m Model) open() (Model, tea.Cmd) {
+	post := m.posts[m.index]
+	m.state = stateViewingPost
+	m.detail = common.NewDetailViewport(m.styles, post.Text)
+	if !m.unread[post.ID] {
+		return m, nil
+	}
+	delete(m.unread, post.ID)
+	return m, markRead(m.dbpool, m.user, post)
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	var toastCmd tea.Cmd
+	m.toast, toastCmd = m.toast.Update(msg)
+	if _, ok := msg.(common.ToastMsg); ok {
+		return m, toastCmd
+	}
+
+	if f, ok := msg.(followedMsg); ok {
+		return m.followed(f)
+	}
+	if s, ok := msg.(starredMsg); ok {
+		m.starred[s.post.ID] = s.starred
+		if s.starred {
+			return m, m.toast.Info("Starred " + s.post.Title)
+		}
+		return m, m.toast.Info("Unstarred " + s.post.Title)
+	}
+	if m.state == stateViewingPost {
+		return updateDetail(msg, m)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "up", "k":
+			if m.index > 0 {
+				m.index--
+			}
+		case "down", "j":
+			if m.index < len(m.posts)-1 {
+				m.index++
+			}
+		case "tab":
+			return m.switchFeed(1)
+		case "shift+tab":
+			return m.switchFeed(-1)
+		case "f":
+			if m.state == stateReady {
+				return m.toggleFollow()
+			}
+		case "s":
+			if m.state == stateReady {
+				return m.toggleStar()
+			}
+		case "left", "h", "pgup":
+			return m.goToPage(m.page - 1)
+		case "right", "l", "pgdown":
+			return m.goToPage(m.page + 1)
+		case "enter":
+			if m.state == stateReady && len(m.posts) > 0 {
+				return m.open()
+			}
+		case "r":
+			if m.state == stateReady {
+				return m.load(m.feed, m.page)
+			}
+		}
+		return m, nil
+
+	case pageLoadedMsg:
+		m.state = stateReady
+		m.feed = msg.feed
+		m.page = msg.page
+		m.posts = msg.posts
+		m.unread = msg.unread
+		m.total = msg.total
+		m.index = 0
+		m.following = map[string]bool{}
+		for _, id := range msg.following {
+			m.following[id] = true
+		}
+		m.starred = map[string]bool{}
+		for _, id := range msg.starred {
+			m.starred[id] = true
+		}
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// followed records a follow or unfollow. The following feed is reloaded
+// since the author's posts come or go with it.
+func (m Model) followed(msg followedMsg) (Model, tea.Cmd) {
+	m.following[msg.post.UserID] = msg.following
+	toast := m.toast.Info("Unfollowed ~" + msg.post.Username)
+	if msg.following {
+		toast = m.toast.Info("Following ~" + msg.post.Username)
+	}
+	if m.feed == feedFollowing && m.state == stateReady {
+		var cmd tea.Cmd
+		m, cmd = m.load(feedFollowing, 0)
+		return m, tea.Batch(toast, cmd)
+	}
+	return m, toast
+}
+
+func updateDetail(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch k.String() {
+		case "ctrl+c":
+			m.Quit = true
+			return m, nil
+		case "q", "esc":
+			m.state = stateReady
+			return m, nil
+		case "f":
+			return m.toggleFollow()
+		case "s":
+			return m.toggleStar()
+		}
+	}
+	var cmd tea.Cmd
+	m.detail, cmd = m.detail.Update(msg)
+	return m, cmd
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	switch m.state {
+	case stateLoading:
+		return m.spinner.View() + " Loading posts..."
+	case stateViewingPost:
+		return detailView(m, m.posts[m.index])
+	}
+
+	s := "Read  " + tabsView(m) + "\n\n"
+	if m.err != nil {
+		s += m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error())) + "\n\n"
+		return s + m.styles.HelpView("r: retry", "esc: exit")
+	}
+
+	if len(m.posts) == 0 {
+		s += m.styles.Subtle.Render(emptyText(m)) + "\n\n"
+		return s + footerView(m, "tab: switch feed", "esc: exit")
+	}
+
+	for i, post := range m.posts {
+		gutter, author := " ", m.styles.LabelDim.Render("~"+post.Username)
+		if m.unread[post.ID] {
+			author += " " + m.styles.Note.Render("new")
+		}
+		if m.starred[post.ID] {
+			author += " " + m.styles.Label.Render("★")
+		}
+		title := truncate.StringWithTail(post.Title, maxTitle, "…")
+		if i == m.index {
+			gutter = m.styles.Gutter(common.StateSelected)
+			title = m.styles.Label.Render(title)
+		}
+		s += fmt.Sprintf("%s %s %s\n", gutter, title, author)
+		s += fmt.Sprintf("%s %s\n", gutter, m.styles.Subtle.Render(post.PublishAt.Format("Jan 2, 2006")))
+	}
+
+	if m.total > 1 {
+		s += "\n" + m.styles.Subtle.Render(fmt.Sprintf("Page %d of %d", m.page+1, m.total))
+	}
+
+	help := []string{"j/k, ↑/↓: choose", "enter: read", followHelp(m), starHelp(m)}
+	if m.total > 1 {
+		help = append(help, "h/l, ←/→: page")
+	}
+	help = append(help, "tab: switch feed", "r: refresh", "esc: exit")
+	return s + "\n" + footerView(m, help...)
+}
+
+// footerView shows the latest notification above the help.
+func footerView(m Model, help ...string) string {
+	s := "\n"
+	if m.toast.Visible() {
+		s += m.toast.View(m.styles) + "\n\n"
+	}
+	return s + m.styles.HelpView(help...)
+}
+
+func tabsView(m Model) string {
+	tabs := make([]string, len(feedNames))
+	for i, name := range feedNames {
+		if feed(i) == m.feed {
+			tabs[i] = m.styles.SelectedMenuItem.Render("[" + name + "]")
+		} else {
+			tabs[i] = m.styles.Subtle.Render(" " + name + " ")
+		}
+	}
+	return strings.Join(tabs, " ")
+}
+
+func emptyText(m Model) string {
+	switch {
+	case m.feed == feedEveryone:
+		return "Nobody has published anything yet."
+	case len(m.following) == 0:
+		return "You don't follow anyone yet, press f on a post under Everyone to follow its author."
+	}
+	return "Nobody you follow has published anything yet."
+}
+
+// followHelp describes what f does for the selected post.
+func followHelp(m Model) string {
+	if len(m.posts) > 0 && m.following[m.posts[m.index].UserID] {
+		return "f: unfollow"
+	}
+	return "f: follow"
+}
+
+// starHelp describes what s does for the selected post.
+func starHelp(m Model) string {
+	if len(m.posts) > 0 && m.starred[m.posts[m.index].ID] {
+		return "s: unstar"
+	}
+	return "s: star"
+}
+
+func detailView(m Model, post *db.Post) string {
+	s := m.styles.Label.Render(post.Title) + "\n\n"
+	s += common.KeyValueView(
+		"Author", post.Username,
+		"URL", config.Current().URL(post.Username, post.Filename),
+		"Published", post.PublishAt.Format("Mon January 2, 2006"),
+	)
+	s += "\n\n" + m.detail.View() + "\n\n"
+
+	help := []string{followHelp(m), starHelp(m), "esc: back"}
+	if scroll := common.DetailScrollHelp(m.detail); scroll != "" {
+		help = append([]string{scroll}, help...)
+	}
+	if m.toast.Visible() {
+		s += m.toast.View(m.styles) + "\n\n"
+	}
+	return s + m.styles.HelpView(help...)
+}
+
+func fetchPage(dbpool db.DB, user *db.User, f feed, page int) tea.Cmd {
+	return func() tea.Msg {
+		following, err := dbpool.FindFollowing(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		starred, err := dbpool.FindStarred(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		msg := pageLoadedMsg{feed: f, page: page, following: following, starred: starred, unread: map[string]bool{}}
+		pager := &db.Pager{Limit: pageSize, Offset: page}
+
+		if f == feedEveryone {
+			posts, err := dbpool.FindAllPosts(pager)
+			if err != nil {
+				return errMsg{err}
+			}
+			msg.posts, msg.total = posts.Data, posts.Total
+			return msg
+		}
+
+		posts, err := dbpool.FindFollowingPosts(user.ID, pager)
+		if err != nil {
+			return errMsg{err}
+		}
+		for _, item := range posts.Data {
+			msg.posts = append(msg.posts, item.Post)
+			if item.Unread {
+				msg.unread[item.Post.ID] = true
+			}
+		}
+		msg.total = posts.Total
+		return msg
+	}
+}
+
+func setFollowing(dbpool db.DB, user *db.User, post *db.Post, following bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if following {
+			err = dbpool.FollowUser(user.ID, post.UserID)
+		} else {
+			err = dbpool.UnfollowUser(user.ID, post.UserID)
+		}
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return followedMsg{post: post, following: following}
+	}
+}
+
+func setStarred(dbpool db.DB, user *db.User, post *db.Post, starred bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if starred {
+			err = dbpool.StarPost(user.ID, post.ID)
+		} else {
+			err = dbpool.UnstarPost(user.ID, post.ID)
+		}
+		if err != nil {
+			return common.ErrorToast(err)
+		}
+		return starredMsg{post: post, starred: starred}
+	}
+}
+
+func markRead(dbpool db.DB, user *db.User, post *db.Post) tea.Cmd {
+	return func() tea.Msg {
+		if err := dbpool.MarkPostRead(user.ID, post.ID); err != nil {
+			return common.ErrorToast(err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package read
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+var me = &db.User{ID: "me"}
+
+func TestUpdate(t *testing.T) {
+	loaded := pageLoadedMsg{
+		page:  1,
+		posts: []*db.Post{{Title: "one", Text: "- a"}, {Title: "two", Text: "- b"}},
+		total: 2,
+	}
+
+	t.Run("pages stay inside the feed", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		is.Equal(m.state, stateReady)
+
+		m, cmd := Update(key("l"), m)
+		is.True(cmd == nil) // already on the last page
+		is.Equal(m.state, stateReady)
+
+		m, cmd = Update(key("h"), m)
+		is.True(cmd != nil)
+		is.Equal(m.state, stateLoading)
+	})
+
+	t.Run("enter opens the selected post and esc goes back", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		m, _ = Update(key("j"), m)
+		m, _ = Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
+		is.Equal(m.state, stateViewingPost)
+		is.Equal(m.index, 1)
+
+		m, _ = Update(tea.KeyMsg{Type: tea.KeyEsc}, m)
+		is.Equal(m.state, stateReady)
+		is.True(!m.Done)
+	})
+}
+
+func TestFollowing(t *testing.T) {
+	loaded := pageLoadedMsg{
+		feed:      feedFollowing,
+		posts:     []*db.Post{{ID: "1", UserID: "ann", Username: "ann"}, {ID: "2", UserID: "me"}},
+		unread:    map[string]bool{"1": true},
+		total:     1,
+		following: []string{"ann"},
+	}
+
+	t.Run("opening an unread post clears its marker", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		is.True(m.unread["1"])
+
+		m, cmd := Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
+		is.True(cmd != nil) // saves the read marker
+		is.True(!m.unread["1"])
+	})
+
+	t.Run("f offers to unfollow authors already followed", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		is.Equal(followHelp(m), "f: unfollow")
+
+		m, _ = Update(followedMsg{post: loaded.posts[0], following: false}, m)
+		is.Equal(followHelp(m), "f: follow")
+		is.Equal(m.state, stateLoading) // the following feed reloads without them
+	})
+
+	t.Run("your own posts can't be followed", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		m, _ = Update(key("j"), m)
+		m, _ = Update(key("f"), m)
+		is.True(m.toast.Visible())
+	})
+}
+
+func TestStarring(t *testing.T) {
+	loaded := pageLoadedMsg{
+		posts:   []*db.Post{{ID: "1", UserID: "ann", Title: "tacos"}, {ID: "2", UserID: "me"}},
+		total:   1,
+		starred: []string{"1"},
+	}
+
+	t.Run("s offers to unstar posts already starred", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		is.Equal(starHelp(m), "s: unstar")
+
+		m, cmd := Update(starredMsg{post: loaded.posts[0], starred: false}, m)
+		is.True(cmd != nil) // the toast
+		is.Equal(starHelp(m), "s: star")
+		is.Equal(m.state, stateReady)
+	})
+
+	t.Run("your own posts can't be starred", func(t *testing.T) {
+		is := is.New(t)
+		m, _ := Update(loaded, NewModel(nil, me, common.DefaultStyles()))
+		m, _ = Update(key("j"), m)
+		m, _ = Update(key("s"), m)
+		is.True(m.toast.Visible())
+	})
+}
@@ -0,0 +1,547 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/truncate"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/i18n"
+	"github.com/neurosnap/lists.sh/internal/relme"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/internal/ui/username"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateUsername
+	stateEditing // typing a new display name, bio, timezone, reply email or links
+)
+
+// row is a setting in the list.
+type row int
+
+const (
+	usernameRow row = iota
+	displayNameRow
+	bioRow
+	layoutRow
+	timezoneRow
+	perPageRow
+	keysPerPageRow
+	themeRow
+	keyMapRow
+	duplicatesRow
+	replyEmailRow
+	profileLinksRow
+	discoverableRow
+	languageRow
+)
+
+var (
+	themes     = []string{db.ThemeAuto, db.ThemeDark, db.ThemeLight, db.ThemeNoColor}
+	keyMaps    = []string{db.KeyMapDefault, db.KeyMapEmacs}
+	layouts    = []string{db.LayoutList, db.LayoutDigest, db.LayoutTags}
+	duplicates = []string{db.DuplicatesWarn, db.DuplicatesSkip}
+)
+
+type (
+	settingsLoadedMsg struct {
+		settings *db.UserSettings
+		usage    *db.Usage
+		links    []*db.ProfileLink
+	}
+	profileSavedMsg struct {
+		displayName string
+		bio         string
+	}
+	linksSavedMsg []*db.ProfileLink
+	errMsg        struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// SavedMsg is sent when the settings have been saved, so the rest of the UI
+// can pick up changes like the theme.
+type SavedMsg *db.UserSettings
+
+// Model holds the state of the settings UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool   db.DB
+	user     *db.User
+	settings *db.UserSettings
+	usage    *db.Usage
+	links    []*db.ProfileLink
+	styles   common.Styles
+	state    state
+	row      row
+	username username.Model
+	field    input.Model // the setting being edited
+	notice   string
+	err      error
+	spinner  spinner.Model
+}
+
+// NewModel returns a new settings model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	field := input.New()
+	field.CursorStyle = styles.Cursor
+	field.Prompt = styles.FocusedPrompt.String()
+
+	return Model{
+		dbpool:   dbpool,
+		user:     user,
+		settings: db.DefaultUserSettings(),
+		styles:   styles,
+		state:    stateLoading,
+		field:    field,
+		spinner:  common.NewSpinner(),
+	}
+}
+
+// LoadSettings returns the command that fetches the user's settings.
+func LoadSettings(m Model) tea.Cmd {
+	return tea.Batch(fetchSettings(m.dbpool, m.user), spinner.Tick)
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch m.state {
+	case stateUsername:
+		return updateUsername(msg, m)
+	case stateEditing:
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return updateField(msg, m)
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.notice = ""
+		m.err = nil
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "up", "k":
+			if m.row > usernameRow {
+				m.row--
+			}
+		case "down", "j":
+			if m.row < languageRow {
+				m.row++
+			}
+		case "left", "h":
+			return m.adjust(-1)
+		case "right", "l":
+			return m.adjust(1)
+		case "enter":
+			switch m.row {
+			case usernameRow:
+				m.state = stateUsername
+				m.username = username.NewModel(m.dbpool, m.user, m.styles)
+				return m, username.InitialCmd()
+			case displayNameRow:
+				return m.edit(m.user.DisplayName, m.user.Name+"'s blog", db.MaxDisplayNameLength)
+			case bioRow:
+				return m.edit(m.user.Bio, "a line about you or your lists", db.MaxBioLength)
+			case timezoneRow:
+				return m.edit(m.settings.Timezone, "Europe/Berlin", 64)
+			case replyEmailRow:
+				return m.edit(m.settings.ReplyEmail, "you@example.com", db.MaxReplyEmailLength)
+			case profileLinksRow:
+				urls := make([]string, 0, len(m.links))
+				for _, link := range m.links {
+					urls = append(urls, link.URL)
+				}
+				return m.edit(strings.Join(urls, " "), "https://mastodon.social/@you", db.MaxProfileLinks*(db.MaxProfileLinkLength+1))
+			default:
+				return m.adjust(1)
+			}
+		}
+		return m, nil
+
+	case settingsLoadedMsg:
+		m.state = stateReady
+		m.settings = msg.settings
+		m.usage = msg.usage
+		m.links = msg.links
+		return m, nil
+
+	case profileSavedMsg:
+		m.user.DisplayName = msg.displayName
+		m.user.Bio = msg.bio
+		m.notice = m.styles.T("Your blog header is updated")
+		return m, nil
+
+	case linksSavedMsg:
+		m.links = msg
+		if len(msg) > 0 {
+			m.notice = m.styles.T("Checking that your links point back to your blog")
+		}
+		return m, nil
+
+	case SavedMsg:
+		m.settings = msg
+		m.styles = common.NewStyles(msg.Theme)
+		m.styles.Locale = msg.Locale
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	if m.state == stateEditing {
+		m.field, cmd = m.field.Update(msg)
+	}
+	return m, cmd
+}
+
+// edit focuses the input on the current row's value.
+func (m Model) edit(value string, placeholder string, limit int) (Model, tea.Cmd) {
+	m.state = stateEditing
+	m.field.Placeholder = placeholder
+	m.field.CharLimit = limit
+	m.field.SetValue(value)
+	m.field.CursorEnd()
+	return m, m.field.Focus()
+}
+
+// adjust steps the blog layout, the per page counts, the theme, the key
+// bindings, what to do with duplicate uploads, discovery or the language and
+// saves the result.
+func (m Model) adjust(step int) (Model, tea.Cmd) {
+	if m.state != stateReady {
+		return m, nil
+	}
+
+	settings := *m.settings
+	switch m.row {
+	case layoutRow:
+		settings.Layout = cycle(layouts, settings.Layout, step)
+	case perPageRow:
+		settings.PerPage += step
+		if settings.PerPage < db.MinPerPage || settings.PerPage > db.MaxPerPage {
+			return m, nil
+		}
+	case keysPerPageRow:
+		n, ok := stepKeysPerPage(settings.KeysPerPage, step)
+		if !ok {
+			return m, nil
+		}
+		settings.KeysPerPage = n
+	case themeRow:
+		settings.Theme = cycle(themes, settings.Theme, step)
+	case keyMapRow:
+		settings.KeyMap = cycle(keyMaps, settings.KeyMap, step)
+	case duplicatesRow:
+		settings.Duplicates = cycle(duplicates, settings.Duplicates, step)
+	case discoverableRow:
+		settings.Discoverable = !settings.Discoverable
+	case languageRow:
+		settings.Locale = cycle(i18n.Locales, settings.Locale, step)
+	default:
+		return m, nil
+	}
+
+	m.settings = &settings
+	return m, saveSettings(m.dbpool, m.user, &settings)
+}
+
+// stepKeysPerPage steps from auto up through the allowed counts, reporting
+// false at either end.
+func stepKeysPerPage(n int, step int) (int, bool) {
+	switch {
+	case n == db.KeysPerPageAuto && step > 0:
+		return db.MinPerPage, true
+	case n == db.KeysPerPageAuto:
+		return n, false
+	case n+step < db.MinPerPage:
+		return db.KeysPerPageAuto, true
+	case n+step > db.MaxPerPage:
+		return n, false
+	}
+	return n + step, true
+}
+
+// cycle steps through options from current, wrapping at either end.
+func cycle(options []string, current string, step int) string {
+	i := 0
+	for j, option := range options {
+		if option == current {
+			i = j
+		}
+	}
+	return options[(i+step+len(options))%len(options)]
+}
+
+func updateUsername(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	if name, ok := msg.(username.NameSetMsg); ok {
+		m.state = stateReady
+		m.notice = m.styles.Tf("Your username is now %s", string(name))
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.username, cmd = username.Update(msg, m.username)
+	if m.username.Quit {
+		m.Quit = true
+	} else if m.username.Done {
+		m.state = stateReady
+	}
+	return m, cmd
+}
+
+func updateField(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.Quit = true
+		return m, nil
+	case "esc":
+		m.state = stateReady
+		m.field.Blur()
+		m.err = nil
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.field.Value())
+		var cmd tea.Cmd
+		switch m.row {
+		case displayNameRow, bioRow:
+			displayName, bio := m.user.DisplayName, m.user.Bio
+			if m.row == displayNameRow {
+				displayName = value
+			} else {
+				bio = value
+			}
+			if err := db.ValidateProfile(displayName, bio); err != nil {
+				m.err = err
+				return m, nil
+			}
+			cmd = saveProfile(m.dbpool, m.user, displayName, bio)
+		case timezoneRow:
+			if _, err := time.LoadLocation(value); err != nil || value == "" {
+				m.err = fmt.Errorf("%q is not a timezone, try a name like America/New_York", value)
+				return m, nil
+			}
+			settings := *m.settings
+			settings.Timezone = value
+			m.settings = &settings
+			cmd = saveSettings(m.dbpool, m.user, &settings)
+		case replyEmailRow:
+			if err := db.ValidateReplyEmail(value); err != nil {
+				m.err = err
+				return m, nil
+			}
+			settings := *m.settings
+			settings.ReplyEmail = value
+			m.settings = &settings
+			cmd = saveSettings(m.dbpool, m.user, &settings)
+		case profileLinksRow:
+			urls := strings.Fields(value)
+			if err := db.ValidateProfileLinks(urls); err != nil {
+				m.err = err
+				return m, nil
+			}
+			cmd = saveLinks(m.dbpool, m.user, urls)
+		}
+		m.state = stateReady
+		m.field.Blur()
+		m.err = nil
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.field, cmd = m.field.Update(msg)
+	return m, cmd
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	switch m.state {
+	case stateLoading:
+		return m.spinner.View() + " " + m.styles.T("Loading settings...")
+	case stateUsername:
+		return username.View(m.username)
+	}
+
+	values := []string{
+		orNone(m, m.user.Name),
+		orNone(m, m.user.DisplayName),
+		orNone(m, truncate.StringWithTail(m.user.Bio, 40, "…")),
+		m.settings.Layout,
+		m.settings.Timezone + " " + m.styles.Subtle.Render(time.Now().In(m.settings.Location()).Format("15:04")),
+		fmt.Sprintf("%d", m.settings.PerPage),
+		keysPerPageView(m),
+		m.settings.Theme,
+		m.settings.KeyMap,
+		m.settings.Duplicates + " " + m.styles.Subtle.Render(m.styles.T("(same text as another post)")),
+		orNone(m, m.settings.ReplyEmail),
+		linksView(m),
+		discoverableView(m),
+		i18n.Name(m.settings.Locale),
+	}
+	labels := []string{"Username", "Display name", "Bio", "Blog layout", "Timezone", "Posts per page", "Keys per page", "Theme", "Key bindings", "Duplicate uploads", "Reply by email", "Profile links", "Show in discovery", "Language"}
+
+	s := m.styles.T("Settings") + "\n\n"
+	for i, label := range labels {
+		label = m.styles.T(label)
+		line := "  "
+		if row(i) == m.row {
+			line = m.styles.SelectionMarker.String()
+			label = m.styles.SelectedMenuItem.Render(label)
+		}
+		if row(i) == m.row && m.state == stateEditing {
+			values[i] = m.field.View()
+		}
+		s += fmt.Sprintf("%s%s: %s\n", line, label, values[i])
+	}
+
+	if m.usage != nil {
+		s += "\n" + m.styles.T("Usage") + "\n\n" + m.styles.UsageView(m.usage, config.Current().Quota) + "\n"
+	}
+
+	if m.err != nil {
+		s += "\n" + m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error())) + "\n"
+	} else if m.notice != "" {
+		s += "\n" + m.styles.Note.Render(m.notice) + "\n"
+	}
+
+	return s + "\n" + m.styles.HelpView(helpItems(m)...)
+}
+
+func keysPerPageView(m Model) string {
+	if m.settings.KeysPerPage == db.KeysPerPageAuto {
+		return m.styles.T("auto") + " " + m.styles.Subtle.Render(m.styles.T("(fits the window)"))
+	}
+	return fmt.Sprintf("%d", m.settings.KeysPerPage)
+}
+
+// linksView lists the profile links, marking the ones that link back.
+func linksView(m Model) string {
+	if len(m.links) == 0 {
+		return orNone(m, "")
+	}
+	labels := make([]string, 0, len(m.links))
+	verified := false
+	for _, link := range m.links {
+		label := relme.Label(link.URL)
+		if link.VerifiedAt != nil {
+			label += " ✓"
+			verified = true
+		}
+		labels = append(labels, label)
+	}
+	s := truncate.StringWithTail(strings.Join(labels, ", "), 50, "…")
+	if verified {
+		s += " " + m.styles.Subtle.Render(m.styles.T("(✓ links back)"))
+	}
+	return s
+}
+
+func discoverableView(m Model) string {
+	if m.settings.Discoverable {
+		return m.styles.T("yes") + " " + m.styles.Subtle.Render(m.styles.T("(listed on /read)"))
+	}
+	return m.styles.T("no") + " " + m.styles.Subtle.Render(m.styles.T("(only at your URL)"))
+}
+
+// orNone shows a placeholder for settings that haven't been set.
+func orNone(m Model, value string) string {
+	if value == "" {
+		return m.styles.Subtle.Render(m.styles.T("(none set)"))
+	}
+	return value
+}
+
+func helpItems(m Model) []string {
+	if m.state == stateEditing {
+		return []string{"enter: save", "esc: cancel"}
+	}
+
+	items := []string{"j/k, ↑/↓: choose"}
+	switch m.row {
+	case usernameRow, displayNameRow, bioRow, timezoneRow, replyEmailRow, profileLinksRow:
+		items = append(items, "enter: change")
+	case perPageRow, keysPerPageRow:
+		items = append(items, "h/l, ←/→: fewer/more")
+	case layoutRow, themeRow, keyMapRow, duplicatesRow, discoverableRow, languageRow:
+		items = append(items, "h/l, ←/→: switch")
+	}
+	return append(items, "esc: exit")
+}
+
+func fetchSettings(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		settings, err := dbpool.FindUserSettings(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		usage, err := dbpool.FindUserUsage(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		links, err := dbpool.FindProfileLinks(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return settingsLoadedMsg{settings, usage, links}
+	}
+}
+
+func saveProfile(dbpool db.DB, user *db.User, displayName string, bio string) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.SetUserProfile(user.ID, displayName, bio)
+		if err != nil {
+			return errMsg{err}
+		}
+		return profileSavedMsg{displayName, bio}
+	}
+}
+
+// saveLinks replaces the user's profile links and queues a check of each
+// for a link back, including the ones that were verified before in case
+// the user renamed themselves since.
+func saveLinks(dbpool db.DB, user *db.User, urls []string) tea.Cmd {
+	return func() tea.Msg {
+		links, err := dbpool.SetProfileLinks(user.ID, urls)
+		if err != nil {
+			return errMsg{err}
+		}
+		if err := relme.Enqueue(dbpool, links); err != nil {
+			return errMsg{err}
+		}
+		return linksSavedMsg(links)
+	}
+}
+
+func saveSettings(dbpool db.DB, user *db.User, settings *db.UserSettings) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.UpdateUserSettings(user.ID, settings)
+		if err != nil {
+			return errMsg{err}
+		}
+		return SavedMsg(settings)
+	}
+}
@@ -0,0 +1,152 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+func TestAdjust(t *testing.T) {
+	newModel := func(r row) Model {
+		m := NewModel(nil, &db.User{ID: "1"}, common.DefaultStyles())
+		m.state = stateReady
+		m.row = r
+		return m
+	}
+
+	t.Run("theme wraps around", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(themeRow).adjust(-1)
+		is.True(cmd != nil)
+		is.Equal(m.settings.Theme, db.ThemeNoColor)
+	})
+
+	t.Run("per page stays in bounds", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel(perPageRow)
+		m.settings.PerPage = db.MaxPerPage
+		m, cmd := m.adjust(1)
+		is.Equal(cmd, nil)
+		is.Equal(m.settings.PerPage, db.MaxPerPage)
+	})
+
+	t.Run("keys per page steps up from auto", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(keysPerPageRow).adjust(1)
+		is.True(cmd != nil)
+		is.Equal(m.settings.KeysPerPage, db.MinPerPage)
+		m, _ = m.adjust(-1)
+		is.Equal(m.settings.KeysPerPage, db.KeysPerPageAuto)
+		m, cmd = m.adjust(-1)
+		is.Equal(cmd, nil)
+		is.Equal(m.settings.KeysPerPage, db.KeysPerPageAuto)
+	})
+
+	t.Run("blog layout cycles", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(layoutRow).adjust(1)
+		is.True(cmd != nil)
+		is.Equal(m.settings.Layout, db.LayoutDigest)
+	})
+
+	t.Run("key bindings switch to emacs", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(keyMapRow).adjust(1)
+		is.True(cmd != nil)
+		is.Equal(m.settings.KeyMap, db.KeyMapEmacs)
+	})
+
+	t.Run("duplicate uploads can be skipped", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(duplicatesRow).adjust(1)
+		is.True(cmd != nil)
+		is.Equal(m.settings.Duplicates, db.DuplicatesSkip)
+	})
+
+	t.Run("blogs can leave discovery and come back", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := newModel(discoverableRow).adjust(1)
+		is.True(cmd != nil)
+		is.True(!m.settings.Discoverable)
+		m, _ = m.adjust(-1)
+		is.True(m.settings.Discoverable)
+	})
+}
+
+func TestUpdateField(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	newModel := func(r row, value string) Model {
+		m := NewModel(nil, &db.User{ID: "1", Name: "erock"}, common.DefaultStyles())
+		m.state = stateReady
+		m.row = r
+		m, _ = m.edit("", "", 1000)
+		m.field.SetValue(value)
+		return m
+	}
+
+	t.Run("display name is saved", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := updateField(enter, newModel(displayNameRow, "  Eric's lists "))
+		is.True(cmd != nil)
+		is.Equal(m.state, stateReady)
+		is.Equal(m.err, nil)
+	})
+
+	t.Run("bio over the limit is refused", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := updateField(enter, newModel(bioRow, strings.Repeat("x", db.MaxBioLength+1)))
+		is.Equal(cmd, nil)
+		is.Equal(m.state, stateEditing)
+		is.True(m.err != nil)
+	})
+
+	t.Run("timezone has to exist", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := updateField(enter, newModel(timezoneRow, "Mars/Olympus"))
+		is.Equal(cmd, nil)
+		is.True(m.err != nil)
+	})
+
+	t.Run("reply email has to be an address", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := updateField(enter, newModel(replyEmailRow, "erock at example"))
+		is.Equal(cmd, nil)
+		is.True(m.err != nil)
+
+		m, cmd = updateField(enter, newModel(replyEmailRow, "Eric <erock@example.com>"))
+		is.Equal(cmd, nil)
+		is.True(m.err != nil)
+
+		m, cmd = updateField(enter, newModel(replyEmailRow, " erock@example.com "))
+		is.True(cmd != nil)
+		is.Equal(m.settings.ReplyEmail, "erock@example.com")
+	})
+
+	t.Run("clearing the reply email turns replies off", func(t *testing.T) {
+		is := is.New(t)
+		m := newModel(replyEmailRow, "")
+		m.settings.ReplyEmail = "erock@example.com"
+		m, cmd := updateField(enter, m)
+		is.True(cmd != nil)
+		is.Equal(m.settings.ReplyEmail, "")
+	})
+
+	t.Run("profile links have to be links", func(t *testing.T) {
+		is := is.New(t)
+		m, cmd := updateField(enter, newModel(profileLinksRow, "https://mastodon.social/@erock mastodon.social/@erock"))
+		is.Equal(cmd, nil)
+		is.True(m.err != nil)
+
+		m, cmd = updateField(enter, newModel(profileLinksRow, "https://a.example https://a.example"))
+		is.Equal(cmd, nil)
+		is.True(m.err != nil)
+
+		m, cmd = updateField(enter, newModel(profileLinksRow, " https://mastodon.social/@erock  https://github.com/neurosnap "))
+		is.True(cmd != nil)
+		is.Equal(m.err, nil)
+	})
+}
@@ -0,0 +1,209 @@
+package signins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/config"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/indieauth"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+	stateSaving
+)
+
+type (
+	requestsLoadedMsg []*db.AuthRequest
+	answeredMsg       struct{ approved bool }
+	errMsg            struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the sign in requests UI, where IndieAuth sign ins
+// started in a browser are approved or denied.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool   db.DB
+	user     *db.User
+	styles   common.Styles
+	state    state
+	requests []*db.AuthRequest
+	index    int
+	message  string
+	err      error
+	spinner  spinner.Model
+}
+
+// NewModel returns a new sign in requests model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadRequests returns the command that fetches the sign ins waiting on the
+// user.
+func LoadRequests(m Model) tea.Cmd {
+	return tea.Batch(fetchRequests(m.dbpool, m.user), spinner.Tick)
+}
+
+func (m Model) selected() *db.AuthRequest {
+	if m.state != stateReady || m.index < 0 || m.index >= len(m.requests) {
+		return nil
+	}
+	return m.requests[m.index]
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "k", "up":
+			if m.index > 0 {
+				m.index--
+			}
+		case "j", "down":
+			if m.index < len(m.requests)-1 {
+				m.index++
+			}
+		case "a", "d":
+			if req := m.selected(); req != nil {
+				m.state = stateSaving
+				m.err = nil
+				return m, tea.Batch(answer(m.dbpool, m.user, req, msg.String() == "a"), spinner.Tick)
+			}
+		case "r":
+			if m.state == stateReady {
+				m.state = stateLoading
+				m.err = nil
+				m.message = ""
+				return m, LoadRequests(m)
+			}
+		}
+		return m, nil
+
+	case requestsLoadedMsg:
+		m.state = stateReady
+		m.requests = msg
+		if m.index >= len(m.requests) {
+			m.index = len(m.requests) - 1
+		}
+		if m.index < 0 {
+			m.index = 0
+		}
+		return m, nil
+
+	case answeredMsg:
+		m.message = "Denied, the site is told you said no."
+		if msg.approved {
+			m.message = "Approved, your browser carries on signing you in."
+		}
+		return m, fetchRequests(m.dbpool, m.user)
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading || m.state == stateSaving {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	switch m.state {
+	case stateLoading:
+		return m.spinner.View() + " Loading sign in requests..."
+	case stateSaving:
+		return m.spinner.View() + " Saving..."
+	}
+
+	s := "Sign in requests\n\n"
+	s += m.styles.Subtle.Render(fmt.Sprintf(
+		"Sites that sign you in with %s wait here for your go ahead. Only approve a request showing the same code as your browser.",
+		config.Current().URL(m.user.Name),
+	)) + "\n\n"
+
+	if len(m.requests) == 0 {
+		s += m.styles.Note.Render("Nothing is waiting, press r once your browser shows a code.") + "\n"
+	}
+	for i, req := range m.requests {
+		gutter := m.styles.Gutter(common.StateNormal)
+		client := indieauth.ClientName(req.ClientID)
+		if i == m.index {
+			gutter = m.styles.Gutter(common.StateSelected)
+			client = m.styles.Label.Render(client)
+		}
+		detail := fmt.Sprintf("expires in %d min", int(time.Until(req.ExpiresAt).Minutes())+1)
+		if req.Scope == indieauth.ScopeProfile {
+			detail = "with your name and picture, " + detail
+		}
+		s += fmt.Sprintf(
+			"%s %s %s %s\n",
+			gutter,
+			m.styles.Note.Render(req.Challenge),
+			client,
+			m.styles.LabelDim.Render(detail),
+		)
+	}
+
+	if m.message != "" {
+		s += "\n" + m.styles.Note.Render(m.message) + "\n"
+	}
+	if m.err != nil {
+		s += "\n" + m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.err.Error()) + "\n"
+	}
+
+	help := []string{"r: refresh", "esc: exit"}
+	if len(m.requests) > 0 {
+		help = append([]string{"j/k, ↑/↓: choose", "a: approve", "d: deny"}, help...)
+	}
+	return s + "\n" + m.styles.HelpView(help...)
+}
+
+func fetchRequests(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		reqs, err := dbpool.FindPendingAuthRequests(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return requestsLoadedMsg(reqs)
+	}
+}
+
+func answer(dbpool db.DB, user *db.User, req *db.AuthRequest, approved bool) tea.Cmd {
+	return func() tea.Msg {
+		err := dbpool.AnswerAuthRequest(req.ID, user.ID, approved)
+		if err != nil {
+			return errMsg{err}
+		}
+		return answeredMsg{approved: approved}
+	}
+}
@@ -0,0 +1,66 @@
+package signins
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// requestsDB holds the sign ins waiting on a user.
+type requestsDB struct {
+	db.DB
+	pending  []*db.AuthRequest
+	answered map[string]bool
+}
+
+func (d *requestsDB) FindPendingAuthRequests(userID string) ([]*db.AuthRequest, error) {
+	var reqs []*db.AuthRequest
+	for _, req := range d.pending {
+		if _, ok := d.answered[req.ID]; !ok && req.UserID == userID {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs, nil
+}
+
+func (d *requestsDB) AnswerAuthRequest(requestID string, userID string, approved bool) error {
+	d.answered[requestID] = approved
+	return nil
+}
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestAnswer(t *testing.T) {
+	is := is.New(t)
+	expires := time.Now().Add(5 * time.Minute)
+	dbpool := &requestsDB{
+		pending: []*db.AuthRequest{
+			{ID: "r1", UserID: "1", Challenge: "AAAA-BBBB", ClientID: "https://one.example/", ExpiresAt: expires},
+			{ID: "r2", UserID: "1", Challenge: "CCCC-DDDD", ClientID: "https://two.example/", ExpiresAt: expires},
+		},
+		answered: map[string]bool{},
+	}
+	user := &db.User{ID: "1", Name: "erock"}
+	m := NewModel(dbpool, user, common.DefaultStyles())
+	m, _ = Update(fetchRequests(dbpool, user)(), m)
+	is.Equal(len(m.requests), 2)
+
+	m, _ = Update(key("j"), m)
+	m, cmd := Update(key("a"), m)
+	is.True(cmd != nil)
+	is.Equal(m.state, stateSaving)
+	is.Equal(m.selected(), nil) // no answering twice while saving
+
+	m, cmd = Update(answer(dbpool, user, dbpool.pending[1], true)(), m)
+	m, _ = Update(cmd(), m)
+	is.Equal(dbpool.answered, map[string]bool{"r2": true})
+	is.Equal(len(m.requests), 1)
+	is.Equal(m.index, 0)
+	is.Equal(m.selected().ID, "r1")
+}
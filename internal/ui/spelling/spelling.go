@@ -0,0 +1,162 @@
+package spelling
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/spellcheck"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+	"github.com/neurosnap/lists.sh/pkg"
+)
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+)
+
+// PostReport pairs a post with its spellcheck results.
+type PostReport struct {
+	Post   *db.Post
+	Report *spellcheck.Report
+}
+
+type (
+	reportLoadedMsg []*PostReport
+	errMsg          struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the spellcheck report UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool  db.DB
+	user    *db.User
+	styles  common.Styles
+	state   state
+	reports []*PostReport
+	err     error
+	spinner spinner.Model
+}
+
+// NewModel returns a new spellcheck report model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadReport returns the command that checks every post for the user.
+func LoadReport(m Model) tea.Cmd {
+	return tea.Batch(checkPosts(m.dbpool, m.user), spinner.Tick)
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		}
+		return m, nil
+
+	case reportLoadedMsg:
+		m.state = stateReady
+		m.reports = msg
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	if m.state == stateLoading {
+		return m.spinner.View() + " Checking posts..."
+	}
+
+	s := "Spellcheck report\n\n"
+	if m.err != nil {
+		s += m.styles.Error.Render("Error: ") + m.styles.Subtle.Render(m.err.Error())
+	} else if spellcheck.Default() == nil {
+		s += m.styles.Subtle.Render("Spellchecking is not enabled on this server.")
+	} else if len(m.reports) == 0 {
+		s += m.styles.Note.Render("No likely misspellings found.")
+	} else {
+		for _, r := range m.reports {
+			s += fmt.Sprintf(
+				"%s %s %s\n%s %s\n\n",
+				common.VerticalLine(common.StateNormal),
+				m.styles.Label.Render(r.Post.Title),
+				m.styles.LabelDim.Render(fmt.Sprintf("(%s)", r.Report.Lang)),
+				common.VerticalLine(common.StateNormal),
+				m.styles.Subtle.Render(strings.Join(r.Report.Misspelled, ", ")),
+			)
+		}
+		s += m.styles.Subtle.Render(
+			fmt.Sprintf("Add words to %s.txt to teach the checker.", spellcheck.DictionaryFilename),
+		)
+	}
+
+	return s + "\n\n" + m.styles.HelpView("esc: exit")
+}
+
+func checkPosts(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		checker := spellcheck.Default()
+		if checker == nil {
+			return reportLoadedMsg(nil)
+		}
+
+		posts, err := dbpool.PostsForUser(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		var userWords []string
+		for _, post := range posts {
+			if post.Filename == spellcheck.DictionaryFilename {
+				userWords = spellcheck.UserWords(post.Text)
+			}
+		}
+
+		var reports []*PostReport
+		for _, post := range posts {
+			if strings.HasPrefix(post.Filename, "_") {
+				continue
+			}
+			report := checker.Check(pkg.ParseText(post.Text).Items, userWords)
+			if len(report.Misspelled) > 0 {
+				reports = append(reports, &PostReport{Post: post, Report: report})
+			}
+		}
+
+		return reportLoadedMsg(reports)
+	}
+}
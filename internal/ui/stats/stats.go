@@ -0,0 +1,218 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/neurosnap/lists.sh/internal/db"
+	"github.com/neurosnap/lists.sh/internal/ui/common"
+)
+
+// maxTopPosts is how many posts are listed by views.
+const maxTopPosts = 8
+
+type state int
+
+const (
+	stateLoading state = iota
+	stateReady
+)
+
+type (
+	statsLoadedMsg struct {
+		posts     []*db.Post
+		analytics *db.UserAnalytics
+		stars     int
+		likes     int
+	}
+	errMsg struct{ err error }
+)
+
+func (e errMsg) Error() string { return e.err.Error() }
+
+// Model holds the state of the stats UI.
+type Model struct {
+	Done bool // true when it's time to exit this view
+	Quit bool // true when the user wants to quit the whole program
+
+	dbpool    db.DB
+	user      *db.User
+	styles    common.Styles
+	state     state
+	posts     []*db.Post
+	analytics *db.UserAnalytics
+	stars     int // across all of the user's posts
+	likes     int
+	err       error
+	spinner   spinner.Model
+}
+
+// NewModel returns a new stats model in its initial state.
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
+	return Model{
+		dbpool:  dbpool,
+		user:    user,
+		styles:  styles,
+		state:   stateLoading,
+		spinner: common.NewSpinner(),
+	}
+}
+
+// LoadStats returns the command that fetches the user's analytics.
+func LoadStats(m Model) tea.Cmd {
+	return tea.Batch(fetchStats(m.dbpool, m.user), spinner.Tick)
+}
+
+// Update is the Bubble Tea update loop.
+func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.Quit = true
+		case "q", "esc":
+			m.Done = true
+		case "r":
+			if m.state == stateReady {
+				m.state = stateLoading
+				m.err = nil
+				return m, LoadStats(m)
+			}
+		}
+		return m, nil
+
+	case statsLoadedMsg:
+		m.state = stateReady
+		m.posts = msg.posts
+		m.analytics = msg.analytics
+		m.stars = msg.stars
+		m.likes = msg.likes
+		return m, nil
+
+	case errMsg:
+		m.state = stateReady
+		m.err = msg
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		if m.state == stateLoading {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// dailySeries lays the views out over the last n days ending today, filling
+// in the days nobody visited.
+func dailySeries(daily []*db.DailyViews, n int, now time.Time) []int {
+	byDay := map[string]int{}
+	for _, d := range daily {
+		byDay[d.Day.Format("2006-01-02")] += d.Views
+	}
+
+	series := make([]int, n)
+	for i := range series {
+		day := now.UTC().AddDate(0, 0, i-n+1)
+		series[i] = byDay[day.Format("2006-01-02")]
+	}
+	return series
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// engagement shows a post's stars and likes, empty when it has neither.
+func engagement(post *db.Post) string {
+	var parts []string
+	if post.Stars > 0 {
+		parts = append(parts, fmt.Sprintf("★%d", post.Stars))
+	}
+	if post.Likes > 0 {
+		parts = append(parts, fmt.Sprintf("♥%d", post.Likes))
+	}
+	return strings.Join(parts, " ")
+}
+
+// View renders current view from the model.
+func View(m Model) string {
+	if m.state == stateLoading {
+		return m.spinner.View() + " Loading stats..."
+	}
+
+	s := "Stats\n\n"
+	if m.err != nil {
+		s += m.styles.Wrap.Render(m.styles.Error.Render("Error: ")+m.styles.Subtle.Render(m.err.Error())) + "\n\n"
+		return s + m.styles.HelpView("r: retry", "esc: exit")
+	}
+
+	month := dailySeries(m.analytics.Daily, 30, time.Now())
+	week := month[len(month)-7:]
+	s += common.KeyValueView(
+		"Last 7 days", fmt.Sprintf("%s %d views", m.styles.Label.Render(common.Sparkline(week)), sum(week)),
+		"Last 30 days", fmt.Sprintf("%s %d views", m.styles.Label.Render(common.Sparkline(month)), sum(month)),
+		"Feed subscribers", fmt.Sprintf("%d", m.analytics.Subscribers),
+		"Stars", fmt.Sprintf("%d", m.stars),
+		"Likes", fmt.Sprintf("%d", m.likes),
+	)
+
+	s += "\n\nTop posts\n"
+	if len(m.posts) == 0 {
+		s += m.styles.Subtle.Render("  No posts yet.") + "\n"
+	}
+	for _, post := range m.posts {
+		line := fmt.Sprintf("  %s %s", m.styles.LabelDim.Render(fmt.Sprintf("%6d", post.Views)), post.Title)
+		if e := engagement(post); e != "" {
+			line += " " + m.styles.Subtle.Render(e)
+		}
+		s += line + "\n"
+	}
+
+	s += "\nTop referrers\n"
+	if len(m.analytics.Referrers) == 0 {
+		s += m.styles.Subtle.Render("  Nobody has linked to your posts yet.") + "\n"
+	}
+	for _, referrer := range m.analytics.Referrers {
+		s += fmt.Sprintf("  %s %s\n", m.styles.LabelDim.Render(fmt.Sprintf("%6d", referrer.Views)), referrer.Host)
+	}
+
+	s += "\n" + m.styles.Subtle.Render("Subscribers are counted from feed readers that report them, like Feedly. Stars come from people reading on lists.sh, likes from the web.")
+	return s + "\n\n" + m.styles.HelpView("r: refresh", "esc: exit")
+}
+
+func fetchStats(dbpool db.DB, user *db.User) tea.Cmd {
+	return func() tea.Msg {
+		posts, err := dbpool.PostsForUser(user.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		var stars, likes int
+		for _, post := range posts {
+			stars += post.Stars
+			likes += post.Likes
+		}
+		sort.SliceStable(posts, func(i, j int) bool {
+			return posts[i].Views > posts[j].Views
+		})
+		if len(posts) > maxTopPosts {
+			posts = posts[:maxTopPosts]
+		}
+
+		analytics, err := dbpool.FindUserAnalytics(user.ID, time.Now().AddDate(0, 0, -30))
+		if err != nil {
+			return errMsg{err}
+		}
+		return statsLoadedMsg{posts: posts, analytics: analytics, stars: stars, likes: likes}
+	}
+}
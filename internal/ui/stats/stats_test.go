@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/neurosnap/lists.sh/internal/db"
+)
+
+func TestDailySeries(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2022, 5, 10, 15, 0, 0, 0, time.UTC)
+	daily := []*db.DailyViews{
+		{Day: time.Date(2022, 5, 8, 0, 0, 0, 0, time.UTC), Views: 3},
+		{Day: time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC), Views: 5},
+		{Day: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), Views: 9},
+	}
+	is.Equal(dailySeries(daily, 4, now), []int{0, 3, 0, 5})
+}
+
+func TestEngagement(t *testing.T) {
+	is := is.New(t)
+	is.Equal(engagement(&db.Post{}), "")
+	is.Equal(engagement(&db.Post{Likes: 4}), "♥4")
+	is.Equal(engagement(&db.Post{Stars: 2, Likes: 4}), "★2 ♥4")
+}
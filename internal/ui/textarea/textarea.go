@@ -0,0 +1,272 @@
+// Package textarea is a small multi-line text input for the TUI, in the
+// spirit of the textinput bubble.
+package textarea
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Model holds the text being edited and the cursor position.
+type Model struct {
+	Width       int
+	Height      int
+	Placeholder string
+
+	CursorStyle      lipgloss.Style
+	PlaceholderStyle lipgloss.Style
+	EndOfBufferStyle lipgloss.Style
+
+	lines  [][]rune
+	row    int
+	col    int
+	offset int // first visible line
+	focus  bool
+}
+
+// New returns a textarea with sensible defaults.
+func New() Model {
+	return Model{
+		Width:            58,
+		Height:           12,
+		CursorStyle:      lipgloss.NewStyle().Reverse(true),
+		PlaceholderStyle: lipgloss.NewStyle().Faint(true),
+		EndOfBufferStyle: lipgloss.NewStyle().Faint(true),
+		lines:            [][]rune{{}},
+	}
+}
+
+// SetValue replaces the text and moves the cursor to the start.
+func (m *Model) SetValue(s string) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	m.lines = nil
+	for _, line := range strings.Split(s, "\n") {
+		m.lines = append(m.lines, []rune(line))
+	}
+	m.row, m.col, m.offset = 0, 0, 0
+}
+
+// Value returns the text.
+func (m Model) Value() string {
+	lines := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Cursor returns the line and column of the cursor.
+func (m Model) Cursor() (int, int) {
+	return m.row, m.col
+}
+
+// LineCount returns the number of lines.
+func (m Model) LineCount() int {
+	return len(m.lines)
+}
+
+func (m *Model) Focus()       { m.focus = true }
+func (m *Model) Blur()        { m.focus = false }
+func (m Model) Focused() bool { return m.focus }
+
+// InsertString types s at the cursor, newlines included.
+func (m *Model) InsertString(s string) {
+	for _, r := range strings.ReplaceAll(s, "\r\n", "\n") {
+		switch r {
+		case '\n', '\r':
+			m.newline()
+		case '\t':
+			m.insertRune(' ')
+			m.insertRune(' ')
+		default:
+			m.insertRune(r)
+		}
+	}
+}
+
+func (m *Model) insertRune(r rune) {
+	line := m.lines[m.row]
+	line = append(line[:m.col], append([]rune{r}, line[m.col:]...)...)
+	m.lines[m.row] = line
+	m.col++
+}
+
+func (m *Model) newline() {
+	line := m.lines[m.row]
+	head := append([]rune{}, line[:m.col]...)
+	tail := append([]rune{}, line[m.col:]...)
+	m.lines[m.row] = head
+	m.lines = append(m.lines[:m.row+1], append([][]rune{tail}, m.lines[m.row+1:]...)...)
+	m.row++
+	m.col = 0
+}
+
+func (m *Model) backspace() {
+	if m.col > 0 {
+		line := m.lines[m.row]
+		m.lines[m.row] = append(line[:m.col-1], line[m.col:]...)
+		m.col--
+		return
+	}
+	if m.row == 0 {
+		return
+	}
+	prev := m.lines[m.row-1]
+	m.col = len(prev)
+	m.lines[m.row-1] = append(prev, m.lines[m.row]...)
+	m.lines = append(m.lines[:m.row], m.lines[m.row+1:]...)
+	m.row--
+}
+
+func (m *Model) deleteForward() {
+	line := m.lines[m.row]
+	if m.col < len(line) {
+		m.lines[m.row] = append(line[:m.col], line[m.col+1:]...)
+		return
+	}
+	if m.row == len(m.lines)-1 {
+		return
+	}
+	m.lines[m.row] = append(line, m.lines[m.row+1]...)
+	m.lines = append(m.lines[:m.row+1], m.lines[m.row+2:]...)
+}
+
+// moveTo puts the cursor on row, keeping the column inside the line.
+func (m *Model) moveTo(row int) {
+	if row < 0 {
+		row = 0
+	}
+	if row > len(m.lines)-1 {
+		row = len(m.lines) - 1
+	}
+	m.row = row
+	if m.col > len(m.lines[m.row]) {
+		m.col = len(m.lines[m.row])
+	}
+}
+
+func (m *Model) left() {
+	if m.col > 0 {
+		m.col--
+	} else if m.row > 0 {
+		m.row--
+		m.col = len(m.lines[m.row])
+	}
+}
+
+func (m *Model) right() {
+	if m.col < len(m.lines[m.row]) {
+		m.col++
+	} else if m.row < len(m.lines)-1 {
+		m.row++
+		m.col = 0
+	}
+}
+
+// scroll keeps the cursor line on screen.
+func (m *Model) scroll() {
+	if m.row < m.offset {
+		m.offset = m.row
+	}
+	if m.row >= m.offset+m.Height {
+		m.offset = m.row - m.Height + 1
+	}
+}
+
+// Update handles key presses while the textarea is focused.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.focus {
+		return m, nil
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.Type {
+	case tea.KeyRunes:
+		m.InsertString(string(key.Runes))
+	case tea.KeySpace:
+		m.insertRune(' ')
+	case tea.KeyEnter:
+		m.newline()
+	case tea.KeyBackspace:
+		m.backspace()
+	case tea.KeyDelete:
+		m.deleteForward()
+	case tea.KeyLeft:
+		m.left()
+	case tea.KeyRight:
+		m.right()
+	case tea.KeyUp:
+		m.moveTo(m.row - 1)
+	case tea.KeyDown:
+		m.moveTo(m.row + 1)
+	case tea.KeyPgUp:
+		m.moveTo(m.row - m.Height)
+	case tea.KeyPgDown:
+		m.moveTo(m.row + m.Height)
+	case tea.KeyHome, tea.KeyCtrlA:
+		m.col = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		m.col = len(m.lines[m.row])
+	case tea.KeyCtrlK:
+		m.lines[m.row] = m.lines[m.row][:m.col]
+	}
+
+	m.scroll()
+	return m, nil
+}
+
+// View renders the visible lines with the cursor.
+func (m Model) View() string {
+	if m.Value() == "" && m.Placeholder != "" && !m.focus {
+		return m.PlaceholderStyle.Render(m.Placeholder) + strings.Repeat("\n", m.Height-1)
+	}
+
+	var b strings.Builder
+	for i := m.offset; i < m.offset+m.Height; i++ {
+		if i > m.offset {
+			b.WriteString("\n")
+		}
+		if i >= len(m.lines) {
+			b.WriteString(m.EndOfBufferStyle.Render("~"))
+			continue
+		}
+		b.WriteString(m.lineView(i))
+	}
+	return b.String()
+}
+
+func (m Model) lineView(i int) string {
+	line := m.lines[i]
+	if i != m.row || !m.focus {
+		if len(line) > m.Width {
+			line = line[:m.Width]
+		}
+		return string(line)
+	}
+
+	// Slide the visible part of the cursor line so the cursor stays on
+	// screen, leaving room for the cursor past the last character.
+	start := 0
+	if m.col >= m.Width {
+		start = m.col - m.Width + 1
+	}
+	end := start + m.Width
+	if end > len(line) {
+		end = len(line)
+	}
+
+	s := string(line[start:m.col])
+	if m.col < len(line) {
+		s += m.CursorStyle.Render(string(line[m.col]))
+		s += string(line[m.col+1 : end])
+	} else {
+		s += m.CursorStyle.Render(" ")
+	}
+	return s
+}
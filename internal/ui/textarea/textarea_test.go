@@ -0,0 +1,86 @@
+package textarea
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+)
+
+func press(m Model, keys ...tea.KeyMsg) Model {
+	for _, k := range keys {
+		m, _ = m.Update(k)
+	}
+	return m
+}
+
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func key(t tea.KeyType) tea.KeyMsg {
+	return tea.KeyMsg{Type: t}
+}
+
+func TestTextarea(t *testing.T) {
+	t.Run("typing and newlines", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m.Focus()
+		m = press(m, typed("- one"), key(tea.KeyEnter), typed("- two"))
+		is.Equal(m.Value(), "- one\n- two")
+		row, col := m.Cursor()
+		is.Equal(row, 1)
+		is.Equal(col, 5)
+	})
+
+	t.Run("backspace joins lines", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m.SetValue("ab\ncd")
+		m.Focus()
+		m = press(m, key(tea.KeyDown), key(tea.KeyBackspace))
+		is.Equal(m.Value(), "abcd")
+		row, col := m.Cursor()
+		is.Equal(row, 0)
+		is.Equal(col, 2)
+	})
+
+	t.Run("delete and kill line", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m.SetValue("hello\nworld")
+		m.Focus()
+		m = press(m, key(tea.KeyEnd), key(tea.KeyDelete))
+		is.Equal(m.Value(), "helloworld")
+		m = press(m, key(tea.KeyHome), key(tea.KeyRight), key(tea.KeyCtrlK))
+		is.Equal(m.Value(), "h")
+	})
+
+	t.Run("pasted text keeps its lines", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m.Focus()
+		m = press(m, typed("a\r\nb\tc"))
+		is.Equal(m.Value(), "a\nb  c")
+	})
+
+	t.Run("scrolls to the cursor", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m.Height = 2
+		m.SetValue("1\n2\n3\n4")
+		m.Focus()
+		m = press(m, key(tea.KeyDown), key(tea.KeyDown), key(tea.KeyDown))
+		is.Equal(m.offset, 2)
+		m = press(m, key(tea.KeyPgUp))
+		is.Equal(m.offset, 1)
+	})
+
+	t.Run("ignores keys when blurred", func(t *testing.T) {
+		is := is.New(t)
+		m := New()
+		m = press(m, typed("nope"))
+		is.Equal(m.Value(), "")
+	})
+}
@@ -89,13 +89,12 @@ func (m *Model) indexBackward() {
 }
 
 // NewModel returns a new username model in its initial state.
-func NewModel(dbpool db.DB, user *db.User) Model {
-	st := common.DefaultStyles()
+func NewModel(dbpool db.DB, user *db.User, styles common.Styles) Model {
 
 	im := input.NewModel()
-	im.CursorStyle = st.Cursor
+	im.CursorStyle = styles.Cursor
 	im.Placeholder = "divagurl2000"
-	im.Prompt = st.FocusedPrompt.String()
+	im.Prompt = styles.FocusedPrompt.String()
 	im.CharLimit = 50
 	im.Focus()
 
@@ -104,7 +103,7 @@ func NewModel(dbpool db.DB, user *db.User) Model {
 		Quit:    false,
 		dbpool:  dbpool,
 		user:    user,
-		styles:  st,
+		styles:  styles,
 		state:   ready,
 		newName: "",
 		index:   textInput,
@@ -115,9 +114,9 @@ func NewModel(dbpool db.DB, user *db.User) Model {
 }
 
 // Init is the Bubble Tea initialization function.
-func Init(dbpool db.DB, user *db.User) func() (Model, tea.Cmd) {
+func Init(dbpool db.DB, user *db.User, styles common.Styles) func() (Model, tea.Cmd) {
 	return func() (Model, tea.Cmd) {
-		m := NewModel(dbpool, user)
+		m := NewModel(dbpool, user, styles)
 		return m, InitialCmd()
 	}
 }
@@ -207,7 +206,7 @@ func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
 	case NameInvalidMsg:
 		m.state = ready
 		head := m.styles.Error.Render("Invalid name. ")
-		body := m.styles.Subtle.Render("Names can only contain plain letters and numbers and must be less than 50 characters. And no emojis, kiddo.")
+		body := m.styles.Subtle.Render("Names can only contain plain letters, numbers, - and _, start with a letter or number and must be less than 50 characters. And no emojis, kiddo.")
 		m.errMsg = m.styles.Wrap.Render(head + body)
 
 		return m, nil
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+var shortcodeRe = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+
+// maxShortcodeLen is longer than any shortcode in the table.
+const maxShortcodeLen = 32
+
+// emoji maps the shortcodes people use most often, the same names GitHub
+// and Slack use.
+var emoji = map[string]string{
+	"+1":                   "👍",
+	"-1":                   "👎",
+	"100":                  "💯",
+	"airplane":             "✈️",
+	"alarm_clock":          "⏰",
+	"apple":                "🍎",
+	"arrow_down":           "⬇️",
+	"arrow_left":           "⬅️",
+	"arrow_right":          "➡️",
+	"arrow_up":             "⬆️",
+	"art":                  "🎨",
+	"baby":                 "👶",
+	"balloon":              "🎈",
+	"beer":                 "🍺",
+	"bell":                 "🔔",
+	"bike":                 "🚲",
+	"black_circle":         "⚫",
+	"blue_heart":           "💙",
+	"book":                 "📖",
+	"books":                "📚",
+	"bookmark":             "🔖",
+	"bulb":                 "💡",
+	"burrito":              "🌯",
+	"bug":                  "🐛",
+	"cake":                 "🍰",
+	"calendar":             "📆",
+	"camera":               "📷",
+	"car":                  "🚗",
+	"cat":                  "🐱",
+	"clap":                 "👏",
+	"clipboard":            "📋",
+	"cherry_blossom":       "🌸",
+	"cloud":                "☁️",
+	"coffee":               "☕",
+	"computer":             "💻",
+	"construction":         "🚧",
+	"cookie":               "🍪",
+	"cry":                  "😢",
+	"dog":                  "🐶",
+	"earth_americas":       "🌎",
+	"email":                "📧",
+	"eyes":                 "👀",
+	"fire":                 "🔥",
+	"flag":                 "🚩",
+	"gift":                 "🎁",
+	"globe_with_meridians": "🌐",
+	"green_heart":          "💚",
+	"grin":                 "😁",
+	"hammer":               "🔨",
+	"heart":                "❤️",
+	"heavy_check_mark":     "✔️",
+	"house":                "🏠",
+	"hourglass":            "⌛",
+	"joy":                  "😂",
+	"key":                  "🔑",
+	"laughing":             "😆",
+	"link":                 "🔗",
+	"lock":                 "🔒",
+	"memo":                 "📝",
+	"moneybag":             "💰",
+	"moon":                 "🌙",
+	"movie_camera":         "🎥",
+	"muscle":               "💪",
+	"musical_note":         "🎵",
+	"no_entry":             "⛔",
+	"ok":                   "🆗",
+	"ok_hand":              "👌",
+	"package":              "📦",
+	"paperclip":            "📎",
+	"partying_face":        "🥳",
+	"pencil":               "📝",
+	"pencil2":              "✏️",
+	"phone":                "☎️",
+	"pizza":                "🍕",
+	"point_right":          "👉",
+	"pray":                 "🙏",
+	"purple_heart":         "💜",
+	"pushpin":              "📌",
+	"question":             "❓",
+	"rainbow":              "🌈",
+	"red_circle":           "🔴",
+	"rocket":               "🚀",
+	"rotating_light":       "🚨",
+	"runner":               "🏃",
+	"sake":                 "🍶",
+	"seedling":             "🌱",
+	"shopping_cart":        "🛒",
+	"skull":                "💀",
+	"smile":                "😄",
+	"smiley":               "😃",
+	"snowflake":            "❄️",
+	"sob":                  "😭",
+	"sparkles":             "✨",
+	"star":                 "⭐",
+	"sunny":                "☀️",
+	"taco":                 "🌮",
+	"tada":                 "🎉",
+	"thinking":             "🤔",
+	"thumbsdown":           "👎",
+	"thumbsup":             "👍",
+	"trophy":               "🏆",
+	"tv":                   "📺",
+	"umbrella":             "☔",
+	"warning":              "⚠️",
+	"wave":                 "👋",
+	"white_check_mark":     "✅",
+	"wine_glass":           "🍷",
+	"wink":                 "😉",
+	"x":                    "❌",
+	"yellow_heart":         "💛",
+	"zap":                  "⚡",
+}
+
+// ReplaceEmoji turns shortcodes like :rocket: into emoji, leaving the ones
+// it doesn't know alone.
+func ReplaceEmoji(text string) string {
+	if !strings.Contains(text, ":") {
+		return text
+	}
+	return shortcodeRe.ReplaceAllStringFunc(text, func(code string) string {
+		if e, ok := emoji[code[1:len(code)-1]]; ok {
+			return e
+		}
+		return code
+	})
+}
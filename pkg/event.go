@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Event is a list item that starts with a date, like
+// "2024-07-01 — conference talk" or "2024-07-01 18:30 - dinner". Events
+// without a time last all day.
+type Event struct {
+	Start   time.Time
+	AllDay  bool
+	Summary string
+	URL     string // the link of a `=>` item
+}
+
+// eventRe matches a date, an optional 24 hour time and the text after them,
+// set apart by a dash, a colon or just a space.
+var eventRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:[ T](\d{1,2}:\d{2}))?(?:\s*[—–:-]\s*|\s+)(\S.*)$`)
+
+// ParseEvent reads the date off the front of an item's text, the second
+// return is false when it doesn't start with one.
+func ParseEvent(text string) (*Event, bool) {
+	m := eventRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return nil, false
+	}
+
+	event := &Event{AllDay: m[2] == "", Summary: spansText(ParseSpans(m[3]))}
+	layout, value := "2006-01-02", m[1]
+	if !event.AllDay {
+		layout, value = "2006-01-02 15:04", m[1]+" "+m[2]
+	}
+	start, err := time.Parse(layout, value)
+	if err != nil || strings.Trim(event.Summary, "—–:- ") == "" {
+		return nil, false
+	}
+	event.Start = start
+	return event, true
+}
+
+// Events collects the dated items of a list in the order they're written.
+// Headers, quotes and images aren't events even when they start with a date.
+func Events(items []*ListItem) []*Event {
+	events := []*Event{}
+	for _, li := range items {
+		text := li.Value
+		switch {
+		case li.IsDefinition:
+			// "2024-07-01: talk" and "2024-07-01 18:30: dinner" split
+			// on the colon.
+			text = li.Term + " " + li.Value
+		case !li.IsText && !li.IsURL:
+			continue
+		}
+		if event, ok := ParseEvent(text); ok {
+			if li.IsURL {
+				event.URL = li.URL
+			}
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func spansText(spans []Span) string {
+	var b strings.Builder
+	for _, span := range spans {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
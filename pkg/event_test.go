@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("date syntax", func(t *testing.T) {
+		is := is.New(t)
+		for _, text := range []string{
+			"2024-07-01 — conference talk",
+			"2024-07-01 - conference talk",
+			"2024-07-01: conference talk",
+			"2024-07-01 conference talk",
+			"2024-07-01—conference talk",
+		} {
+			event, ok := ParseEvent(text)
+			is.True(ok)
+			is.True(event.AllDay)
+			is.Equal(event.Start, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+			is.Equal(event.Summary, "conference talk")
+		}
+
+		event, ok := ParseEvent("2024-07-01 18:30 – dinner ~~at 8~~")
+		is.True(ok)
+		is.True(!event.AllDay)
+		is.Equal(event.Start, time.Date(2024, 7, 1, 18, 30, 0, 0, time.UTC))
+		is.Equal(event.Summary, "dinner at 8")
+
+		for _, text := range []string{"2024-13-01 talk", "2024-07-01", "2024-07-01 — ", "talk on 2024-07-01", "20240701 talk"} {
+			_, ok := ParseEvent(text)
+			is.True(!ok)
+		}
+	})
+
+	t.Run("from list items", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText("# 2024-06-01 schedule\n2024-07-01 — talk\n2024-07-02: workshop\n2024-07-03 18:30: dinner\n[x] 2024-05-01 rsvp\n=> https://example.com 2024-07-04 party\n> 2024-07-05 quoted\nno date\n")
+		events := Events(parsed.Items)
+		summaries := []string{}
+		for _, event := range events {
+			summaries = append(summaries, event.Summary)
+		}
+		is.Equal(summaries, []string{"talk", "workshop", "dinner", "rsvp", "party"})
+		is.True(!events[2].AllDay)
+		is.Equal(events[4].URL, "https://example.com")
+	})
+}
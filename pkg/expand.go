@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth is how deep includes can nest, a post including itself
+// is skipped before that.
+const maxIncludeDepth = 3
+
+// maxIncludes caps the includes tried for one post, found or not, so a few
+// posts that include each other, or something missing, many times can't
+// blow up the page or the lookups behind it.
+const maxIncludes = 20
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([a-z_.]+)(?:\s+([^\s}]+))?\s*\}\}`)
+
+// IncludeFunc returns the text of the author's post with the filename, for
+// `{{include filename}}`.
+type IncludeFunc func(filename string) (string, bool)
+
+// Expand fills in the `{{site.url}}` style placeholders of a post's text
+// from vars and replaces `{{include filename}}` with the other post's
+// items, leaving out its variables. Placeholders it doesn't know are left
+// as written.
+func Expand(text string, vars map[string]string, include IncludeFunc) string {
+	e := &expander{vars: vars, include: include, seen: map[string]bool{}}
+	return e.expand(text, 0)
+}
+
+type expander struct {
+	vars     map[string]string
+	include  IncludeFunc
+	seen     map[string]bool
+	included int
+}
+
+func (e *expander) expand(text string, depth int) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return placeholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		sub := placeholderRe.FindStringSubmatch(match)
+		name, arg := sub[1], sub[2]
+		if name != "include" {
+			if value, ok := e.vars[name]; ok && arg == "" {
+				return value
+			}
+			return match
+		}
+
+		if arg == "" || e.include == nil || e.seen[arg] || depth >= maxIncludeDepth || e.included >= maxIncludes {
+			return match
+		}
+		e.included++
+		included, ok := e.include(arg)
+		if !ok {
+			return match
+		}
+		e.seen[arg] = true
+		defer delete(e.seen, arg)
+		included = strings.TrimRight(StripVariables(included), "\n")
+		return e.expand(included, depth+1)
+	})
+}
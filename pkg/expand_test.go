@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"site.url": "https://lists.sh", "user.name": "erock"}
+	posts := map[string]string{
+		"disclaimer": "=: title Disclaimer\nopinions are my own, {{user.name}}\n",
+		"loop":       "around {{include loop}}",
+		"outer":      "outer\n{{include inner}}",
+		"inner":      "inner",
+	}
+	include := func(filename string) (string, bool) {
+		text, ok := posts[filename]
+		return text, ok
+	}
+
+	t.Run("variables", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Expand("=> {{site.url}}/{{ user.name }} my blog", vars, include), "=> https://lists.sh/erock my blog")
+		is.Equal(Expand("{{user.email}} {{site.url x}}", vars, include), "{{user.email}} {{site.url x}}")
+	})
+
+	t.Run("includes", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(Expand("tacos\n{{include disclaimer}}", vars, include), "tacos\nopinions are my own, erock")
+		is.Equal(Expand("{{include outer}}", vars, include), "outer\ninner")
+		is.Equal(Expand("{{include missing}}", vars, include), "{{include missing}}")
+		is.Equal(Expand("{{include loop}}", vars, include), "around {{include loop}}")
+		is.Equal(Expand("{{include disclaimer}}", vars, nil), "{{include disclaimer}}")
+	})
+
+	t.Run("every attempt counts toward the cap", func(t *testing.T) {
+		is := is.New(t)
+		calls := 0
+		counting := func(filename string) (string, bool) {
+			calls++
+			return include(filename)
+		}
+		text := strings.Repeat("{{include missing}}\n", 30) + "{{include inner}}"
+		out := Expand(text, vars, counting)
+		is.Equal(calls, maxIncludes)
+		is.True(strings.HasSuffix(out, "{{include inner}}")) // past the cap
+	})
+}
@@ -1,15 +1,35 @@
 package pkg
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 type ParsedText struct {
-	Items    []*ListItem
-	MetaData *MetaData
+	Items     []*ListItem
+	MetaData  *MetaData
+	ItemCount int // list items, not counting headers and blank lines
+	WordCount int
+	// Changelog holds the entries of the changelog section, newest first,
+	// when the post is in changelog mode.
+	Changelog []*ChangelogEntry
+}
+
+// ChangelogEntry is a line of the changelog section, like
+// "2022-05-18 added the new taco place". Lines without a date have a nil
+// Date.
+type ChangelogEntry struct {
+	Date *time.Time
+	Text string
 }
 
 type ListItem struct {
@@ -22,6 +42,28 @@ type ListItem struct {
 	IsHeaderOne bool
 	IsHeaderTwo bool
 	IsImg       bool
+	// IsDivider marks a "---" line that splits the list into sections.
+	IsDivider bool
+	// IsTodo and IsDone mark text items written as "[ ] item" and
+	// "[x] item", the checkbox is taken off the value.
+	IsTodo bool
+	IsDone bool
+	// IsNumbered marks text items written as "1. item", Number is the
+	// position in its run of numbered items, counting up from the first.
+	IsNumbered bool
+	Number     int
+	// IsDefinition marks "term: definition" items, Term holds the term and
+	// Value the definition.
+	IsDefinition bool
+	Term         string
+	// Spans is the value split around ~~strikethrough~~ text.
+	Spans []Span
+}
+
+// Span is a run of an item's text, Struck when it was between ~~.
+type Span struct {
+	Text   string
+	Struck bool
 }
 
 type MetaData struct {
@@ -29,14 +71,38 @@ type MetaData struct {
 	Title       string
 	Description string
 	ListType    string // https://developer.mozilla.org/en-US/docs/Web/CSS/list-style-type
+	Tags        []string
+	// Authors are the usernames of the people who wrote the post along
+	// with whoever published it, set with `=: authors erock, mia`.
+	Authors []string
+	// Visibility is who can read the post, set with `=: visibility
+	// followers` or `=: visibility mia, erock` to name the readers, by
+	// username or email.
+	Visibility string
+	Readers    []string
+	// Changelog moves the section under a "Changelog" header out of the
+	// list and into dated update entries.
+	Changelog bool
+	// Emoji turns shortcodes like :rocket: into emoji when the post is
+	// shown, on unless the post sets `=: emoji false`.
+	Emoji bool
 }
 
+// Visibilities a post can have, see MetaData.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityReaders   = "readers"
+)
+
 var urlToken = "=>"
 var blockToken = ">"
 var varToken = "=:"
 var imgToken = "=<"
 var headerOneToken = "#"
 var headerTwoToken = "##"
+var definitionToken = ": "
+var dividerToken = "---"
 
 type SplitToken struct {
 	Key   string
@@ -46,15 +112,9 @@ type SplitToken struct {
 func TextToSplitToken(text string) *SplitToken {
 	txt := strings.Trim(text, " ")
 	token := &SplitToken{}
-	word := ""
-	for i, c := range txt {
-		if c == ' ' {
-			token.Key = strings.Trim(word, " ")
-			token.Value = strings.Trim(txt[i:], " ")
-			break
-		} else {
-			word += string(c)
-		}
+	if i := strings.IndexByte(txt, ' '); i >= 0 {
+		token.Key = txt[:i]
+		token.Value = strings.Trim(txt[i:], " ")
 	}
 
 	if token.Key == "" {
@@ -69,6 +129,18 @@ func SplitByNewline(text string) []string {
 	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
 }
 
+// NormalizeText gives text the same bytes whichever OS it was written on:
+// a leading byte order mark is dropped, line endings become \n and trailing
+// whitespace is stripped from every line.
+func NormalizeText(text string) string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	lines := SplitByNewline(text)
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func PublishAtDate(date string) (*time.Time, error) {
 	e := errors.New("Date must be in this format: YYYY-MM-DD")
 	sp := strings.Split(date, "-")
@@ -97,88 +169,470 @@ func PublishAtDate(date string) (*time.Time, error) {
 }
 
 func ParseText(text string) *ParsedText {
-	textItems := SplitByNewline(text)
-	items := []*ListItem{}
-	meta := &MetaData{
-		ListType: "disc",
+	p := newParser()
+	for _, t := range SplitByNewline(text) {
+		p.parseLine(t)
 	}
+	return p.finish()
+}
+
+// ErrTooLarge is returned by ParseReader when the text is over its limit.
+var ErrTooLarge = errors.New("text is too large")
+
+// poolMax is the largest buffer ParseReader keeps for the next call, the
+// few bigger posts get a buffer of their own that the GC takes back.
+const poolMax = 64 * 1024
 
-	for _, t := range textItems {
-		li := &ListItem{
-			Value: strings.Trim(t, " "),
+// Uploads run ParseReader many times at once, reusing its buffers keeps
+// memory flat instead of growing a new one for every post.
+var (
+	readerPool = sync.Pool{New: func() interface{} { return bufio.NewReader(nil) }}
+	textPool   = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+// ParseReader parses text line by line as it's read, giving up with
+// ErrTooLarge as soon as more than limit bytes come in so a huge upload
+// is never held in memory. It returns the text read along with the parsed
+// list, the same as ParseText would have made of it.
+func ParseReader(r io.Reader, limit int) (*ParsedText, string, error) {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(io.LimitReader(r, int64(limit)+1))
+	text := textPool.Get().(*bytes.Buffer)
+	text.Reset()
+	defer func() {
+		br.Reset(nil)
+		readerPool.Put(br)
+		if text.Cap() <= poolMax {
+			textPool.Put(text)
 		}
+	}()
 
-		if strings.HasPrefix(li.Value, urlToken) {
-			li.IsURL = true
-			split := TextToSplitToken(strings.Replace(li.Value, urlToken, "", 1))
-			li.URL = split.Key
-			if split.Value == "" {
-				li.Value = split.Key
-			} else {
-				li.Value = split.Value
-			}
-		} else if strings.HasPrefix(li.Value, blockToken) {
-			li.IsBlock = true
-			li.Value = strings.Replace(li.Value, blockToken, "", 1)
-		} else if strings.HasPrefix(li.Value, imgToken) {
-			li.IsImg = true
-			split := TextToSplitToken(strings.Replace(li.Value, imgToken, "", 1))
-			li.URL = split.Key
-			if split.Value == "" {
-				li.Value = split.Key
-			} else {
-				li.Value = split.Value
-			}
-		} else if strings.HasPrefix(li.Value, varToken) {
-			split := TextToSplitToken(strings.Replace(li.Value, varToken, "", 1))
-			if split.Key == "publish_at" {
-				publishAt, err := PublishAtDate(split.Value)
-				if err == nil {
-					meta.PublishAt = publishAt
-				}
-			}
+	p := newParser()
+	for {
+		line, err := br.ReadString('\n')
+		text.WriteString(line)
+		if text.Len() > limit {
+			return nil, "", ErrTooLarge
+		}
+		if err != nil && err != io.EOF {
+			return nil, "", err
+		}
 
-			if split.Key == "title" {
-				meta.Title = split.Value
-			}
+		if strings.HasSuffix(line, "\n") {
+			line = strings.TrimSuffix(line[:len(line)-1], "\r")
+		}
+		p.parseLine(line)
+		if err == io.EOF {
+			break
+		}
+	}
+	// String copies the text out of the buffer, which goes back to the pool.
+	return p.finish(), text.String(), nil
+}
 
-			if split.Key == "description" {
-				meta.Description = split.Value
-			}
+// parser builds up the list one line at a time.
+type parser struct {
+	items []*ListItem
+	meta  *MetaData
+}
 
-			if split.Key == "list_type" {
-				meta.ListType = split.Value
-			}
-			continue
-		} else if strings.HasPrefix(li.Value, headerTwoToken) {
-			li.IsHeaderTwo = true
-			li.Value = strings.Replace(li.Value, headerTwoToken, "", 1)
-		} else if strings.HasPrefix(li.Value, headerOneToken) {
-			li.IsHeaderOne = true
-			li.Value = strings.Replace(li.Value, headerOneToken, "", 1)
+func newParser() *parser {
+	return &parser{
+		items: []*ListItem{},
+		meta: &MetaData{
+			ListType:   "disc",
+			Visibility: VisibilityPublic,
+			Emoji:      true,
+		},
+	}
+}
+
+func (p *parser) parseLine(t string) {
+	li := &ListItem{
+		Value: strings.Trim(t, " "),
+	}
+
+	if li.Value == dividerToken {
+		li.IsDivider = true
+		li.Value = ""
+	} else if strings.HasPrefix(li.Value, urlToken) {
+		li.IsURL = true
+		split := TextToSplitToken(strings.Replace(li.Value, urlToken, "", 1))
+		li.URL = split.Key
+		if split.Value == "" {
+			li.Value = split.Key
+		} else {
+			li.Value = split.Value
+		}
+	} else if strings.HasPrefix(li.Value, blockToken) {
+		li.IsBlock = true
+		li.Value = strings.Replace(li.Value, blockToken, "", 1)
+	} else if strings.HasPrefix(li.Value, imgToken) {
+		li.IsImg = true
+		split := TextToSplitToken(strings.Replace(li.Value, imgToken, "", 1))
+		li.URL = split.Key
+		if split.Value == "" {
+			li.Value = split.Key
 		} else {
-			li.IsText = true
+			li.Value = split.Value
+		}
+	} else if strings.HasPrefix(li.Value, varToken) {
+		split := TextToSplitToken(strings.Replace(li.Value, varToken, "", 1))
+		if split.Key == "publish_at" {
+			publishAt, err := PublishAtDate(split.Value)
+			if err == nil {
+				p.meta.PublishAt = publishAt
+			}
+		}
+
+		if split.Key == "title" {
+			p.meta.Title = split.Value
 		}
 
-		if len(items) > 0 {
-			prevItem := items[len(items)-1]
-			if li.Value == "" && prevItem.Value == "" {
-				continue
+		if split.Key == "description" {
+			p.meta.Description = split.Value
+		}
+
+		if split.Key == "list_type" {
+			p.meta.ListType = split.Value
+		}
+
+		if split.Key == "tags" {
+			p.meta.Tags = ParseTags(split.Value)
+		}
+
+		if split.Key == "authors" {
+			p.meta.Authors = ParseAuthors(split.Value)
+		}
+
+		if split.Key == "visibility" {
+			p.meta.Visibility, p.meta.Readers = ParseVisibility(split.Value)
+		}
+
+		if split.Key == "changelog" {
+			p.meta.Changelog = split.Value == "true"
+		}
+
+		if split.Key == "emoji" {
+			p.meta.Emoji = split.Value != "false"
+		}
+		return
+	} else if strings.HasPrefix(li.Value, headerTwoToken) {
+		li.IsHeaderTwo = true
+		li.Value = strings.Replace(li.Value, headerTwoToken, "", 1)
+	} else if strings.HasPrefix(li.Value, headerOneToken) {
+		li.IsHeaderOne = true
+		li.Value = strings.Replace(li.Value, headerOneToken, "", 1)
+	} else {
+		li.IsText = true
+		if n, rest, ok := splitNumber(li.Value); ok {
+			li.IsNumbered = true
+			li.Number = n
+			li.Value = rest
+		}
+		switch {
+		case strings.HasPrefix(li.Value, "[ ] "):
+			li.IsTodo = true
+			li.Value = li.Value[4:]
+		case strings.HasPrefix(li.Value, "[x] "), strings.HasPrefix(li.Value, "[X] "):
+			li.IsDone = true
+			li.Value = li.Value[4:]
+		default:
+			if term, def, ok := splitDefinition(li.Value); ok && !li.IsNumbered {
+				li.IsText = false
+				li.IsDefinition = true
+				li.Term = term
+				li.Value = def
 			}
 		}
+	}
 
-		items = append(items, li)
+	if len(p.items) > 0 {
+		prevItem := p.items[len(p.items)-1]
+		if li.Value == "" && prevItem.Value == "" && !li.IsDivider && !prevItem.IsDivider {
+			return
+		}
 	}
 
+	p.items = append(p.items, li)
+}
+
+// finish tidies up the items once every line is in.
+func (p *parser) finish() *ParsedText {
+	items, meta := p.items, p.meta
+
 	if len(items) > 0 {
 		last := items[len(items)-1]
-		if last.Value == "" {
+		if last.Value == "" && !last.IsDivider {
 			items = items[:len(items)-1]
 		}
 	}
 
-	return &ParsedText{
+	numberItems(items)
+
+	if meta.Emoji {
+		for _, li := range items {
+			// A link without a label shows its URL, which stays as is.
+			if li.Value != li.URL {
+				li.Value = ReplaceEmoji(li.Value)
+			}
+			li.Term = ReplaceEmoji(li.Term)
+		}
+	}
+
+	for _, li := range items {
+		li.Spans = ParseSpans(li.Value)
+	}
+
+	parsed := &ParsedText{
 		Items:    items,
 		MetaData: meta,
 	}
+	if meta.Changelog {
+		parsed.Items, parsed.Changelog = splitChangelog(items)
+	}
+	for _, li := range items {
+		words := len(strings.Fields(li.Value)) + len(strings.Fields(li.Term))
+		parsed.WordCount += words
+		if words > 0 && !li.IsHeaderOne && !li.IsHeaderTwo {
+			parsed.ItemCount++
+		}
+	}
+	return parsed
+}
+
+// splitNumber reads a "1. item" line into its number and the item.
+func splitNumber(text string) (int, string, bool) {
+	i := strings.Index(text, ". ")
+	if i < 1 {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(text[:i])
+	if err != nil || strings.ContainsAny(text[:i], "+-") {
+		return 0, "", false
+	}
+	rest := strings.TrimSpace(text[i+2:])
+	if rest == "" {
+		return 0, "", false
+	}
+	return n, rest, true
+}
+
+// numberItems counts up from the first number of every run of numbered
+// items, like markdown does, so "1." on every line still reads 1, 2, 3.
+func numberItems(items []*ListItem) {
+	for i, li := range items {
+		if li.IsNumbered && i > 0 && items[i-1].IsNumbered {
+			li.Number = items[i-1].Number + 1
+		}
+	}
+}
+
+// splitDefinition reads a "term: definition" line. Both sides need text, so
+// "note:" alone and URLs like https://example.com stay plain text, and the
+// colon closing an emoji shortcode like ":rocket: launch" isn't a separator.
+func splitDefinition(text string) (string, string, bool) {
+	i := -1
+	for from := 0; ; {
+		j := strings.Index(text[from:], definitionToken)
+		if j < 0 {
+			return "", "", false
+		}
+		j += from
+		if !endsWithShortcode(text[:j+1]) {
+			i = j
+			break
+		}
+		from = j + 1
+	}
+	term := strings.TrimSpace(text[:i])
+	def := strings.TrimSpace(text[i+len(definitionToken):])
+	if term == "" || def == "" {
+		return "", "", false
+	}
+	return term, def, true
+}
+
+// endsWithShortcode reports whether text ends with a known emoji shortcode.
+// It only looks at the end of the text so lines full of colons stay cheap.
+func endsWithShortcode(text string) bool {
+	if !strings.HasSuffix(text, ":") {
+		return false
+	}
+	name := text[:len(text)-1]
+	start := strings.LastIndexByte(name, ':')
+	if start < 0 || len(name)-start > maxShortcodeLen {
+		return false
+	}
+	_, ok := emoji[name[start+1:]]
+	return ok
+}
+
+// strikeToken wraps text that's struck through.
+var strikeToken = "~~"
+
+// ParseSpans splits text around ~~strikethrough~~. A ~~ without a partner
+// is left as written.
+func ParseSpans(text string) []Span {
+	spans := []Span{}
+	for {
+		start := strings.Index(text, strikeToken)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start+len(strikeToken):], strikeToken)
+		if end < 0 {
+			break
+		}
+		end += start + len(strikeToken)
+		if start > 0 {
+			spans = append(spans, Span{Text: text[:start]})
+		}
+		if struck := text[start+len(strikeToken) : end]; struck != "" {
+			spans = append(spans, Span{Text: struck, Struck: true})
+		}
+		text = text[end+len(strikeToken):]
+	}
+	if text != "" {
+		spans = append(spans, Span{Text: text})
+	}
+	return spans
+}
+
+// changelogHeader is the header that starts the changelog section.
+const changelogHeader = "changelog"
+
+// splitChangelog takes the items under the changelog header, up to the next
+// header, out of the list.
+func splitChangelog(items []*ListItem) ([]*ListItem, []*ChangelogEntry) {
+	start := -1
+	for i, li := range items {
+		if (li.IsHeaderOne || li.IsHeaderTwo) && strings.EqualFold(strings.TrimSpace(li.Value), changelogHeader) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return items, nil
+	}
+
+	end := len(items)
+	for i := start + 1; i < len(items); i++ {
+		if items[i].IsHeaderOne || items[i].IsHeaderTwo {
+			end = i
+			break
+		}
+	}
+
+	entries := []*ChangelogEntry{}
+	for _, li := range items[start+1 : end] {
+		if li.Value == "" {
+			continue
+		}
+		entry := &ChangelogEntry{Text: li.Value}
+		split := TextToSplitToken(li.Value)
+		if date, err := PublishAtDate(split.Key); err == nil && split.Key != split.Value {
+			entry.Date = date
+			entry.Text = split.Value
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Date, entries[j].Date
+		return a != nil && (b == nil || a.After(*b))
+	})
+
+	rest := append([]*ListItem{}, items[:start]...)
+	rest = append(rest, items[end:]...)
+	if len(rest) > 0 && rest[len(rest)-1].Value == "" {
+		rest = rest[:len(rest)-1]
+	}
+	return rest, entries
+}
+
+// ParseTags reads a comma separated list of tags, lowercased and without
+// duplicates.
+func ParseTags(text string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// ParseAuthors reads a comma separated list of usernames, an @ in front of
+// a name is dropped.
+func ParseAuthors(text string) []string {
+	authors := []string{}
+	for _, name := range ParseTags(text) {
+		name = strings.TrimPrefix(name, "@")
+		if name == "" || contains(authors, name) {
+			continue
+		}
+		authors = append(authors, name)
+	}
+	return authors
+}
+
+// ParseVisibility reads who can see a post: public, followers or a comma
+// separated list of usernames and emails.
+func ParseVisibility(text string) (string, []string) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "", VisibilityPublic:
+		return VisibilityPublic, nil
+	case VisibilityFollowers:
+		return VisibilityFollowers, nil
+	}
+	return VisibilityReaders, ParseAuthors(text)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SetVariable replaces the value of the `=: key` variable in the text or,
+// when the variable is missing, adds it to the top of the text.
+func SetVariable(text string, key string, value string) string {
+	line := fmt.Sprintf("%s %s %s", varToken, key, value)
+	lines := SplitByNewline(text)
+	for i, t := range lines {
+		trimmed := strings.Trim(t, " ")
+		if !strings.HasPrefix(trimmed, varToken) {
+			continue
+		}
+		split := TextToSplitToken(strings.Replace(trimmed, varToken, "", 1))
+		if split.Key == key {
+			lines[i] = line
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	if text == "" {
+		return line
+	}
+	return line + "\n" + text
+}
+
+// StripVariables removes every variable line from the text, leaving only the
+// list items.
+func StripVariables(text string) string {
+	var lines []string
+	for _, t := range SplitByNewline(text) {
+		if strings.HasPrefix(strings.Trim(t, " "), varToken) {
+			continue
+		}
+		lines = append(lines, t)
+	}
+	return strings.Join(lines, "\n")
 }
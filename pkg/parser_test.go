@@ -0,0 +1,251 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEmoji(t *testing.T) {
+	t.Run("shortcodes are shown as emoji", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText(":rocket: launch\n=> https://example.com/:tada: :tada: party\n=> https://example.com/:tada:\nkeep :not_an_emoji:\n")
+		is.Equal(parsed.Items[0].Value, "🚀 launch")
+		is.Equal(parsed.Items[1].Value, "🎉 party")
+		is.Equal(parsed.Items[1].URL, "https://example.com/:tada:")
+		is.Equal(parsed.Items[2].Value, parsed.Items[2].URL) // links without a label show the URL
+		is.Equal(parsed.Items[3].Value, "keep :not_an_emoji:")
+	})
+
+	t.Run("opt out", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText(":rocket: launch\n=: emoji false\n")
+		is.Equal(parsed.Items[0].Value, ":rocket: launch")
+	})
+}
+
+func TestChecklist(t *testing.T) {
+	t.Run("checkboxes", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText("[ ] buy tortillas\n[x] make salsa\n[X] chop onions\n[] not a checkbox\n")
+		is.True(parsed.Items[0].IsTodo)
+		is.Equal(parsed.Items[0].Value, "buy tortillas")
+		is.True(parsed.Items[1].IsDone)
+		is.Equal(parsed.Items[1].Value, "make salsa")
+		is.True(parsed.Items[2].IsDone)
+		is.True(!parsed.Items[3].IsTodo && !parsed.Items[3].IsDone)
+	})
+
+	t.Run("strikethrough", func(t *testing.T) {
+		is := is.New(t)
+		is.Equal(ParseSpans("tacos are ~~overrated~~ perfect"), []Span{
+			{Text: "tacos are "},
+			{Text: "overrated", Struck: true},
+			{Text: " perfect"},
+		})
+		is.Equal(ParseSpans("~~gone~~"), []Span{{Text: "gone", Struck: true}})
+		is.Equal(ParseSpans("half ~~open"), []Span{{Text: "half ~~open"}})
+		is.Equal(ParseSpans(""), []Span{})
+	})
+}
+
+func TestDefinitions(t *testing.T) {
+	is := is.New(t)
+	parsed := ParseText("salsa verde: tomatillos and ~~lime~~ chiles\nnote:\nsee https://lists.sh\n[ ] buy: tortillas\n")
+	is.Equal(len(parsed.Items), 4)
+
+	def := parsed.Items[0]
+	is.True(def.IsDefinition)
+	is.True(!def.IsText)
+	is.Equal(def.Term, "salsa verde")
+	is.Equal(def.Value, "tomatillos and ~~lime~~ chiles")
+	is.Equal(len(def.Spans), 3)
+
+	is.True(parsed.Items[1].IsText) // no definition
+	is.True(parsed.Items[2].IsText) // the colon in a URL isn't a separator
+	is.True(parsed.Items[3].IsTodo) // checkboxes win
+	is.Equal(parsed.Items[3].Value, "buy: tortillas")
+
+	is.Equal(parsed.WordCount, 11)
+
+	parsed = ParseText(":taco: tacos: are great\n")
+	is.Equal(parsed.Items[0].Term, "🌮 tacos")
+	is.Equal(parsed.Items[0].Value, "are great")
+}
+
+func TestNumbered(t *testing.T) {
+	t.Run("counts up from the first number", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText("1. warm\n1. fill\n1. eat\n\n3. third\n7. fourth\n")
+		numbers := []int{}
+		for _, li := range parsed.Items {
+			if li.IsNumbered {
+				numbers = append(numbers, li.Number)
+			}
+		}
+		is.Equal(numbers, []int{1, 2, 3, 3, 4})
+		is.Equal(parsed.Items[0].Value, "warm")
+		is.True(parsed.Items[0].IsText)
+	})
+
+	t.Run("not numbers", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText("2022. a good year\n1.5 liters\n-1. minus\n1.\n")
+		is.True(parsed.Items[0].IsNumbered) // a year is still a number
+		is.True(!parsed.Items[1].IsNumbered)
+		is.True(!parsed.Items[2].IsNumbered)
+		is.True(!parsed.Items[3].IsNumbered)
+	})
+
+	t.Run("with checkboxes and colons", func(t *testing.T) {
+		is := is.New(t)
+		parsed := ParseText("1. [x] tacos: the best\n")
+		li := parsed.Items[0]
+		is.True(li.IsNumbered)
+		is.True(li.IsDone)
+		is.True(!li.IsDefinition)
+		is.Equal(li.Value, "tacos: the best")
+	})
+}
+
+func TestDivider(t *testing.T) {
+	is := is.New(t)
+	parsed := ParseText("tacos\n---\n---\n\nhorchata\n---\n")
+	is.Equal(len(parsed.Items), 6)
+	is.True(parsed.Items[1].IsDivider)
+	is.True(parsed.Items[2].IsDivider)
+	is.True(!parsed.Items[1].IsText)
+	is.Equal(parsed.Items[1].Value, "")
+	is.True(parsed.Items[5].IsDivider) // a trailing divider isn't an empty item
+	is.Equal(parsed.ItemCount, 2)
+	is.Equal(parsed.WordCount, 2)
+
+	is.True(!ParseText("----\n").Items[0].IsDivider)
+}
+
+func TestParseTextLargeInput(t *testing.T) {
+	// Uploads are untrusted, every shape here used to be or could easily
+	// become quadratic.
+	for name, text := range map[string]string{
+		"long first word":      "=: " + strings.Repeat("a", 1<<20) + " b",
+		"long url":             "=> " + strings.Repeat("x", 1<<20),
+		"shortcodes and colon": strings.Repeat(":taco: ", 1<<17),
+		"many colons":          strings.Repeat("a: ", 1<<18),
+		"unmatched strikes":    "~~" + strings.Repeat("a~", 1<<19),
+		"many strikes":         strings.Repeat("~~a", 1<<18),
+		"blank lines":          strings.Repeat("\n", 1<<20),
+		"numbered items":       strings.Repeat("1. a\n", 1<<18),
+		"indentation":          strings.Repeat(" ", 1<<20) + "a",
+		"tabs":                 strings.Repeat("\t", 1<<20) + "a",
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed := ParseText(text)
+			if parsed == nil {
+				t.Fatal("no result")
+			}
+		})
+	}
+}
+
+func FuzzParseText(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"=: title tacos\n=: tags a, b\n# header\n## sub\ntext",
+		"=> https://lists.sh lists\n=< https://lists.sh/a.png alt\n> quote",
+		"[ ] todo\n[x] done\n1. one\n1. two\n---\nterm: definition",
+		"~~struck~~ ~~open\n:rocket: launch :taco: tacos: yes",
+		"=: changelog true\n# Changelog\n2022-05-01 first\nundated",
+		"\xff\xfe invalid \u202e utf-8 \r\n\t\x00",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		parsed := ParseText(text)
+		for _, li := range parsed.Items {
+			n := 0
+			for _, span := range li.Spans {
+				n += len(span.Text)
+			}
+			if n > len(li.Value) {
+				t.Fatalf("spans of %q are longer than the value", li.Value)
+			}
+		}
+		if parsed.WordCount < 0 || parsed.ItemCount < 0 {
+			t.Fatal("negative counts")
+		}
+
+		streamed, read, err := ParseReader(strings.NewReader(text), len(text))
+		if err != nil || read != text || !reflect.DeepEqual(streamed, parsed) {
+			t.Fatalf("streaming %q doesn't match ParseText", text)
+		}
+	})
+}
+
+// endless is a reader that never runs out of tacos.
+type endless struct{}
+
+func (endless) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = "taco\n"[i%5]
+	}
+	return len(b), nil
+}
+
+func TestParseReader(t *testing.T) {
+	t.Run("same as ParseText", func(t *testing.T) {
+		is := is.New(t)
+		for _, text := range []string{
+			"",
+			"tacos",
+			"tacos\n",
+			"tacos\r\nburritos\r\n\r\n",
+			"lone\rreturn\r",
+			"=: title Tacos\n[x] salsa\n\n\n1. one\n---\n",
+		} {
+			parsed, read, err := ParseReader(strings.NewReader(text), 1024)
+			is.NoErr(err)
+			is.Equal(read, text)
+			is.Equal(parsed, ParseText(text))
+		}
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		is := is.New(t)
+		_, _, err := ParseReader(endless{}, 1024*1024)
+		is.True(errors.Is(err, ErrTooLarge))
+
+		_, _, err = ParseReader(strings.NewReader("tacos"), 4)
+		is.True(errors.Is(err, ErrTooLarge))
+
+		_, read, err := ParseReader(strings.NewReader("tacos"), 5)
+		is.NoErr(err)
+		is.Equal(read, "tacos")
+	})
+
+	t.Run("reused buffers don't change what it returned", func(t *testing.T) {
+		is := is.New(t)
+		_, first, err := ParseReader(strings.NewReader("tacos\n"), 1024)
+		is.NoErr(err)
+		long := strings.Repeat("burritos\n", 10000)
+		_, second, err := ParseReader(strings.NewReader(long), len(long))
+		is.NoErr(err)
+		_, _, err = ParseReader(strings.NewReader("salsa\n"), 1024)
+		is.NoErr(err)
+
+		is.Equal(first, "tacos\n")
+		is.Equal(second, long)
+	})
+}
+
+func TestNormalizeText(t *testing.T) {
+	is := is.New(t)
+	is.Equal(NormalizeText("\ufefftacos  \r\nburritos\t\r\n\r\n  > quote \n"), "tacos\nburritos\n\n  > quote\n")
+	is.Equal(NormalizeText("tacos\nburritos"), "tacos\nburritos")
+	is.Equal(NormalizeText(""), "")
+
+	text := "=: title Tacos\r\n[x] salsa   \r\n"
+	is.Equal(ParseText(NormalizeText(text)), ParseText(text))
+}
@@ -0,0 +1,10 @@
+// Package public holds the web server's static assets, built into the
+// binary like the templates.
+package public
+
+import "embed"
+
+// FS is every asset in this directory.
+//
+//go:embed *.css *.png *.ico *.txt
+var FS embed.FS